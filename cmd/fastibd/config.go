@@ -19,6 +19,8 @@ var (
 	defaultDbType   = "ffldb"
 	defaultDAGType  = "phantom"
 	defaultFileName = "blocks.ibd"
+
+	defaultSnapshotFileName = "utxoset.snapshot"
 )
 
 type Config struct {
@@ -37,6 +39,13 @@ type Config struct {
 	ByID       bool
 	AidMode    bool
 	CPUNum     int
+
+	DAGStartOrder uint
+	DAGEndOrder   uint
+	DAGFormat     string
+	DAGFile       string
+
+	VerifyFile string
 }
 
 func (c *Config) load() error {
@@ -112,3 +121,13 @@ func GetIBDFilePath(path string) (string, error) {
 	}
 	return strings.TrimRight(strings.TrimRight(path, "/"), "\\") + "/" + defaultFileName, nil
 }
+
+func GetSnapshotFilePath(path string) (string, error) {
+	if len(path) <= 0 {
+		return "", fmt.Errorf("Path error")
+	}
+	if strings.HasSuffix(path, ".snapshot") {
+		return path, nil
+	}
+	return strings.TrimRight(strings.TrimRight(path, "/"), "\\") + "/" + defaultSnapshotFileName, nil
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"github.com/Qitmeer/qitmeer/common/roughtime"
+	"github.com/Qitmeer/qitmeer/core/blockdag"
 	_ "github.com/Qitmeer/qitmeer/database/ffldb"
 	_ "github.com/Qitmeer/qitmeer/services/common"
 	"github.com/urfave/cli/v2"
@@ -99,6 +100,123 @@ func fastIBD() error {
 					return node.Import()
 				},
 			},
+			&cli.Command{
+				Name:        "exportutxoset",
+				Aliases:     []string{"eu"},
+				Category:    "IBD",
+				Usage:       "Export the utxo set snapshot from database",
+				Description: "Export the utxo set, DAG state and token state of the main chain tip",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:        "path",
+						Aliases:     []string{"p"},
+						Usage:       "Path to output data",
+						Value:       defaultHomeDir,
+						Destination: &cfg.OutputPath,
+					},
+				},
+				Before: func(c *cli.Context) error {
+					return node.init(cfg)
+				},
+				After: func(c *cli.Context) error {
+					return node.exit()
+				},
+				Action: func(c *cli.Context) error {
+					return node.ExportUtxoSet()
+				},
+			},
+			&cli.Command{
+				Name:        "loadutxoset",
+				Aliases:     []string{"lu"},
+				Category:    "IBD",
+				Usage:       "Load the utxo set snapshot to database",
+				Description: "Load the utxo set snapshot which is committed in params, the history is validated by node later",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:        "path",
+						Aliases:     []string{"p"},
+						Usage:       "Path to input data",
+						Value:       defaultHomeDir,
+						Destination: &cfg.InputPath,
+					},
+				},
+				Before: func(c *cli.Context) error {
+					return node.init(cfg)
+				},
+				After: func(c *cli.Context) error {
+					return node.exit()
+				},
+				Action: func(c *cli.Context) error {
+					return node.LoadUtxoSet()
+				},
+			},
+			&cli.Command{
+				Name:        "exportdag",
+				Aliases:     []string{"d"},
+				Category:    "DAG",
+				Usage:       "Export the sub-DAG from database",
+				Description: "Export the sub-DAG between two orders as {dot,graphml,json}",
+				Flags: []cli.Flag{
+					&cli.UintFlag{
+						Name:        "start",
+						Aliases:     []string{"s"},
+						Usage:       "Start order",
+						Destination: &cfg.DAGStartOrder,
+					},
+					&cli.UintFlag{
+						Name:        "end",
+						Aliases:     []string{"e"},
+						Usage:       "End order, the default is the last order",
+						Destination: &cfg.DAGEndOrder,
+					},
+					&cli.StringFlag{
+						Name:        "format",
+						Aliases:     []string{"f"},
+						Usage:       "Output format {dot,graphml,json}",
+						Value:       blockdag.ExportFormatDOT,
+						Destination: &cfg.DAGFormat,
+					},
+					&cli.StringFlag{
+						Name:        "output",
+						Aliases:     []string{"o"},
+						Usage:       "Path to output file, the default is stdout",
+						Destination: &cfg.DAGFile,
+					},
+				},
+				Before: func(c *cli.Context) error {
+					return node.init(cfg)
+				},
+				After: func(c *cli.Context) error {
+					return node.exit()
+				},
+				Action: func(c *cli.Context) error {
+					return node.ExportDAG()
+				},
+			},
+			&cli.Command{
+				Name:        "verifydag",
+				Aliases:     []string{"v"},
+				Category:    "DAG",
+				Usage:       "Verify the consistency of DAG in database",
+				Description: "Rebuild the DAG from stored blocks and report the first divergence as json",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:        "output",
+						Aliases:     []string{"o"},
+						Usage:       "Path to output report file, the default is stdout",
+						Destination: &cfg.VerifyFile,
+					},
+				},
+				Before: func(c *cli.Context) error {
+					return node.init(cfg)
+				},
+				After: func(c *cli.Context) error {
+					return node.exit()
+				},
+				Action: func(c *cli.Context) error {
+					return node.VerifyDAG()
+				},
+			},
 			&cli.Command{
 				Name:        "upgrade",
 				Aliases:     []string{"u"},
@@ -183,7 +301,7 @@ func fastIBD() error {
 			&cli.StringFlag{
 				Name:        "dagtype",
 				Aliases:     []string{"G"},
-				Usage:       "DAG type {phantom,spectre}",
+				Usage:       "DAG type {phantom,ghostdag,spectre}",
 				Value:       defaultDAGType,
 				Destination: &cfg.DAGType,
 			},
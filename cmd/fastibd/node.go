@@ -10,6 +10,7 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/Qitmeer/qitmeer/common/hash"
 	"github.com/Qitmeer/qitmeer/config"
@@ -22,6 +23,7 @@ import (
 	"github.com/Qitmeer/qitmeer/services/common"
 	"github.com/Qitmeer/qitmeer/services/index"
 	"github.com/schollz/progressbar/v3"
+	"io/ioutil"
 	"os"
 	"path"
 	"runtime"
@@ -217,6 +219,130 @@ func (node *Node) Export() error {
 	return nil
 }
 
+// Export the utxo set snapshot of the main chain tip.
+func (node *Node) ExportUtxoSet() error {
+	outFilePath, err := GetSnapshotFilePath(node.cfg.OutputPath)
+	if err != nil {
+		return err
+	}
+	outFile, err := os.OpenFile(outFilePath, os.O_CREATE|os.O_TRUNC|os.O_RDWR, os.ModePerm)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		outFile.Close()
+	}()
+
+	log.Info("Export utxo set...")
+	us, err := node.bc.ExportUtxoSet(outFile)
+	if err != nil {
+		return err
+	}
+	log.Info(fmt.Sprintf("Finish export utxo set: base(%s) order(%d) utxos(%d)    ------>File:%s",
+		us.BaseHash.String(), us.BaseOrder, us.Utxos, outFilePath))
+	log.Info(fmt.Sprintf("Utxo set hash:%s", us.UtxoSetHash.String()))
+	return nil
+}
+
+// Load the utxo set snapshot to the empty database, the node validates the
+// history in background after it is started.
+func (node *Node) LoadUtxoSet() error {
+	inputFilePath, err := GetSnapshotFilePath(node.cfg.InputPath)
+	if err != nil {
+		return err
+	}
+	inputFile, err := os.Open(inputFilePath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		inputFile.Close()
+	}()
+
+	log.Info("Load utxo set...")
+	us, err := node.bc.LoadUtxoSet(inputFile)
+	if err != nil {
+		return err
+	}
+	log.Info(fmt.Sprintf("Finish load utxo set: base(%s) order(%d) utxos(%d)    ------>File:%s",
+		us.BaseHash.String(), us.BaseOrder, us.Utxos, inputFilePath))
+	return nil
+}
+
+// Export the sub-DAG for debugging, the default output is stdout.
+func (node *Node) ExportDAG() error {
+	endOrder := node.cfg.DAGEndOrder
+	if endOrder == 0 {
+		endOrder = node.bc.BlockDAG().GetMainChainTip().GetOrder()
+	}
+	de, err := node.bc.BlockDAG().Export(node.cfg.DAGStartOrder, endOrder)
+	if err != nil {
+		return err
+	}
+	if len(de.Blocks) == blockdag.MaxExportBlocks && de.EndOrder < endOrder {
+		log.Warn(fmt.Sprintf("Export DAG is limited to %d blocks, the rest is from order %d",
+			blockdag.MaxExportBlocks, de.EndOrder+1))
+	}
+	if len(node.cfg.DAGFile) == 0 {
+		err = de.Write(os.Stdout, node.cfg.DAGFormat)
+		fmt.Println()
+		return err
+	}
+	outFile, err := os.OpenFile(node.cfg.DAGFile, os.O_CREATE|os.O_TRUNC|os.O_RDWR, os.ModePerm)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		outFile.Close()
+	}()
+	err = de.Write(outFile, node.cfg.DAGFormat)
+	if err != nil {
+		return err
+	}
+	log.Info(fmt.Sprintf("Finish export DAG: blocks(%d)    ------>File:%s", len(de.Blocks), node.cfg.DAGFile))
+	return nil
+}
+
+// Rebuild the DAG in a scratch database and compare it with the persisted one,
+// the report is written as json.
+func (node *Node) VerifyDAG() error {
+	scratchDir, err := ioutil.TempDir(os.TempDir(), "verifydag")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(scratchDir)
+
+	scratchDB, err := LoadBlockDB(node.cfg.DbType, scratchDir, true)
+	if err != nil {
+		return err
+	}
+	defer scratchDB.Close()
+
+	log.Info("Verify DAG...")
+	report, err := node.bc.BlockDAG().Verify(scratchDB)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if len(node.cfg.VerifyFile) == 0 {
+		fmt.Println(string(data))
+	} else {
+		err = ioutil.WriteFile(node.cfg.VerifyFile, data, 0644)
+		if err != nil {
+			return err
+		}
+	}
+	if !report.Consistent {
+		return fmt.Errorf("DAG divergence: %s %s (persisted:%s rebuilt:%s)", report.Divergence.Kind,
+			report.Divergence.Field, report.Divergence.Persisted, report.Divergence.Rebuilt)
+	}
+	log.Info(fmt.Sprintf("Finish verify DAG: blocks(%d) skipped(%d)", report.Rebuilt, report.Skipped))
+	return nil
+}
+
 func (node *Node) Import() error {
 	mainTip := node.bc.BlockDAG().GetMainChainTip()
 	if mainTip.GetOrder() > 0 {
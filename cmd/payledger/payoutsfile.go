@@ -85,6 +85,8 @@ func processLockingPayouts(genesisLedger ledger.PayoutList2, lockNum int64) stri
 
 		for v.Payout.Amount.Value > 0 {
 			needLockNum := lockNum - curLockedNum
+			// Every lockNum is released at the next main height.
+			releaseHeight := curMHeight + 1
 
 			amount := int64(0)
 			if v.Payout.Amount.Value >= needLockNum {
@@ -97,7 +99,7 @@ func processLockingPayouts(genesisLedger ledger.PayoutList2, lockNum int64) stri
 				curLockedNum += amount
 				v.Payout.Amount.Value = 0
 			}
-			script, err := PayToCltvAddrScriptWithMainHeight(v.Payout.Address, curMHeight)
+			script, err := PayToGenesisLockAddrScript(v.Payout.Address, releaseHeight)
 			if err != nil {
 				return err.Error()
 			}
@@ -108,10 +110,12 @@ func processLockingPayouts(genesisLedger ledger.PayoutList2, lockNum int64) stri
 	return fileContent
 }
 
-func PayToCltvAddrScriptWithMainHeight(addrStr string, mainHeight int64) ([]byte, error) {
+// PayToGenesisLockAddrScript returns the locked genesis output script which
+// pays to the address after the DAG main height releaseHeight.
+func PayToGenesisLockAddrScript(addrStr string, releaseHeight int64) ([]byte, error) {
 	addr, err := address.DecodeAddress(addrStr)
 	if err != nil {
 		return nil, err
 	}
-	return txscript.PayToCLTVPubKeyHashScript(addr.Script(), mainHeight)
+	return txscript.PayToGenesisLockScript(addr.Script(), releaseHeight)
 }
@@ -93,6 +93,14 @@ func qitmeerdMain(nodeChan chan<- *node.Node) error {
 
 		return nil
 	}
+	if cfg.DropInclusionIndex {
+		if err := index.DropTxInclusionIndex(db, interrupt); err != nil {
+			log.Error(fmt.Sprintf("%v", err))
+			return err
+		}
+
+		return nil
+	}
 	if cfg.DropTxIndex {
 		if err := index.DropTxIndex(db, interrupt); err != nil {
 			log.Error(fmt.Sprintf("%v", err))
@@ -0,0 +1,127 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+
+// Package muhash implements MuHash3072, a rolling hash of a multiset.
+//
+// Every element is mapped to a number modulo the 3072 bits prime
+// 2^3072 - 1103717, the multiset is the product of all its elements.
+// Adding and removing elements are multiplications, so the result doesn't
+// depend on the order of the operations.
+package muhash
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"golang.org/x/crypto/sha3"
+	"math/big"
+)
+
+const (
+	// ElementSize is the size of the number which an element is mapped to.
+	ElementSize = 384
+
+	// SerializedSize is the size of the serialized MuHash.
+	SerializedSize = ElementSize * 2
+)
+
+var prime = func() *big.Int {
+	p := new(big.Int).Lsh(big.NewInt(1), ElementSize*8)
+	return p.Sub(p, big.NewInt(1103717))
+}()
+
+// MuHash is the state of the multiset, the elements which are added are
+// multiplied to the numerator and the removed ones to the denominator.
+type MuHash struct {
+	numerator   *big.Int
+	denominator *big.Int
+}
+
+// New returns the MuHash of the empty set.
+func New() *MuHash {
+	return &MuHash{
+		numerator:   big.NewInt(1),
+		denominator: big.NewInt(1),
+	}
+}
+
+// elementToNumber expands data to 3072 bits by SHAKE256.
+func elementToNumber(data []byte) *big.Int {
+	var buf [ElementSize]byte
+	sha3.ShakeSum256(buf[:], data)
+	num := new(big.Int).SetBytes(buf[:])
+	return num.Mod(num, prime)
+}
+
+// Add adds the element to the set.
+func (m *MuHash) Add(data []byte) {
+	m.numerator.Mul(m.numerator, elementToNumber(data))
+	m.numerator.Mod(m.numerator, prime)
+}
+
+// Remove removes the element from the set, it should be added before.
+func (m *MuHash) Remove(data []byte) {
+	m.denominator.Mul(m.denominator, elementToNumber(data))
+	m.denominator.Mod(m.denominator, prime)
+}
+
+// Combine adds all the elements of other to the set.
+func (m *MuHash) Combine(other *MuHash) {
+	m.numerator.Mul(m.numerator, other.numerator)
+	m.numerator.Mod(m.numerator, prime)
+	m.denominator.Mul(m.denominator, other.denominator)
+	m.denominator.Mod(m.denominator, prime)
+}
+
+// normalize divides the numerator by the denominator.
+func (m *MuHash) normalize() {
+	if m.denominator.Cmp(big.NewInt(1)) == 0 {
+		return
+	}
+	inverse := new(big.Int).ModInverse(m.denominator, prime)
+	m.numerator.Mul(m.numerator, inverse)
+	m.numerator.Mod(m.numerator, prime)
+	m.denominator.SetInt64(1)
+}
+
+// Finalize returns the hash of the set, the same set always gets the same hash
+// whatever the elements are added and removed.
+func (m *MuHash) Finalize() hash.Hash {
+	m.normalize()
+	return hash.HashH(paddedBytes(m.numerator))
+}
+
+func (m *MuHash) Clone() *MuHash {
+	return &MuHash{
+		numerator:   new(big.Int).Set(m.numerator),
+		denominator: new(big.Int).Set(m.denominator),
+	}
+}
+
+// Serialize returns the numerator and denominator in big endian.
+func (m *MuHash) Serialize() []byte {
+	result := make([]byte, 0, SerializedSize)
+	result = append(result, paddedBytes(m.numerator)...)
+	return append(result, paddedBytes(m.denominator)...)
+}
+
+// Deserialize returns the MuHash from the serialized bytes.
+func Deserialize(data []byte) (*MuHash, error) {
+	if len(data) != SerializedSize {
+		return nil, fmt.Errorf("MuHash serialized size %d, expect %d", len(data), SerializedSize)
+	}
+	m := &MuHash{
+		numerator:   new(big.Int).SetBytes(data[:ElementSize]),
+		denominator: new(big.Int).SetBytes(data[ElementSize:]),
+	}
+	if m.numerator.Cmp(prime) >= 0 || m.denominator.Cmp(prime) >= 0 ||
+		m.denominator.Sign() == 0 {
+		return nil, fmt.Errorf("MuHash is out of range")
+	}
+	return m, nil
+}
+
+func paddedBytes(num *big.Int) []byte {
+	result := make([]byte, ElementSize)
+	b := num.Bytes()
+	copy(result[ElementSize-len(b):], b)
+	return result
+}
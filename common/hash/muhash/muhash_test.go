@@ -0,0 +1,78 @@
+package muhash
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMuHash(t *testing.T) {
+	empty := New().Finalize()
+
+	a := New()
+	a.Add([]byte("1"))
+	a.Add([]byte("2"))
+	a.Add([]byte("3"))
+
+	b := New()
+	b.Add([]byte("3"))
+	b.Add([]byte("1"))
+	b.Add([]byte("2"))
+	if a.Finalize() != b.Finalize() {
+		t.Fatalf("The order of adding should not change the hash")
+	}
+	if a.Finalize() == empty {
+		t.Fatalf("The set is not empty")
+	}
+
+	// Remove before add
+	c := New()
+	c.Remove([]byte("4"))
+	c.Add([]byte("1"))
+	c.Add([]byte("2"))
+	c.Add([]byte("4"))
+	c.Add([]byte("3"))
+	if c.Finalize() != a.Finalize() {
+		t.Fatalf("The removed element should not be in the set")
+	}
+
+	for _, e := range []string{"1", "2", "3"} {
+		b.Remove([]byte(e))
+	}
+	if b.Finalize() != empty {
+		t.Fatalf("All the elements are removed, it should be empty")
+	}
+
+	d := New()
+	d.Add([]byte("1"))
+	e := New()
+	e.Add([]byte("2"))
+	e.Add([]byte("3"))
+	d.Combine(e)
+	if d.Finalize() != a.Finalize() {
+		t.Fatalf("Combine should add all the elements")
+	}
+}
+
+func TestMuHashSerialize(t *testing.T) {
+	m := New()
+	m.Add([]byte("1"))
+	m.Remove([]byte("2"))
+	data := m.Serialize()
+	if len(data) != SerializedSize {
+		t.Fatalf("Serialized size %d, expect %d", len(data), SerializedSize)
+	}
+	decoded, err := Deserialize(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decoded.Serialize(), data) {
+		t.Fatalf("Deserialized MuHash is different")
+	}
+	if decoded.Finalize() != m.Finalize() {
+		t.Fatalf("Deserialized MuHash has different hash")
+	}
+	_, err = Deserialize(data[1:])
+	if err == nil {
+		t.Fatalf("Wrong size should fail")
+	}
+}
@@ -28,6 +28,8 @@ type Config struct {
 	DropTxIndex        bool     `long:"droptxindex" description:"Deletes the hash-based transaction index from the database on start up and then exits."`
 	AddrIndex          bool     `long:"addrindex" description:"Maintain a full address-based transaction index which makes the getrawtransactions RPC available"`
 	DropAddrIndex      bool     `long:"dropaddrindex" description:"Deletes the address-based transaction index from the database on start up and then exits."`
+	TxInclusionIndex   bool     `long:"txinclusionindex" description:"Maintain an index of the transactions which are included by several blocks or by invalid blocks, which makes the getTxInclusions RPC available"`
+	DropInclusionIndex bool     `long:"droptxinclusionindex" description:"Deletes the transaction inclusion index from the database on start up and then exits."`
 	LightNode          bool     `long:"light" description:"start as a qitmeer light node"`
 	SigCacheMaxSize    uint     `long:"sigcachemaxsize" description:"The maximum number of entries in the signature verification cache"`
 	DumpBlockchain     string   `long:"dumpblockchain" description:"Write blockchain as a flat file of blocks for use with addblock, to the specified filename"`
@@ -68,9 +70,13 @@ type Config struct {
 	//P2P - server ban
 	Banning bool `long:"banning" description:"Enable banning of misbehaving peers"`
 
-	DAGType     string `short:"G" long:"dagtype" description:"DAG type {phantom,spectre} "`
-	Cleanup     bool   `short:"L" long:"cleanup" description:"Cleanup the block database "`
-	BuildLedger bool   `long:"buildledger" description:"Generate the genesis ledger for the next qitmeer version."`
+	DAGType          string `short:"G" long:"dagtype" description:"DAG type {phantom,ghostdag,spectre} "`
+	DAGCacheSize     uint   `long:"dagcachesize" description:"The maximum number of DAG blocks kept in memory, others will be loaded from database on demand"`
+	Prune            uint64 `long:"prune" description:"Delete the old block bodies to keep the block files under <MB> (at least 1024), 0 disables pruning"`
+	UtxoCacheMaxSize uint64 `long:"utxocachemaxsize" description:"The maximum size in MiB of the utxo cache, 0 writes the utxo set to database for every block"`
+	AssumeValid      string `long:"assumevalid" description:"Skip the script checks of the blocks in the past set of this block hash, 0 disables it (default: the network's assumed valid block)"`
+	Cleanup          bool   `short:"L" long:"cleanup" description:"Cleanup the block database "`
+	BuildLedger      bool   `long:"buildledger" description:"Generate the genesis ledger for the next qitmeer version."`
 
 	Zmqpubhashblock string `long:"zmqpubhashblock" description:"Enable publish hash block  in <address>"`
 	Zmqpubrawblock  string `long:"zmqpubrawblock" description:"Enable publish raw block in <address>"`
@@ -78,6 +84,8 @@ type Config struct {
 	Zmqpubhashtx string `long:"zmqpubhashtx" description:"Enable publish hash transaction in <address>"`
 	Zmqpubrawtx  string `long:"zmqpubrawtx" description:"Enable publish raw transaction in <address>"`
 
+	Zmqpubreorg string `long:"zmqpubreorg" description:"Enable publish reorganization record in <address>"`
+
 	// Cache Invalid tx
 	CacheInvalidTx bool `long:"cacheinvalidtx" description:"Cache invalid transactions."`
 
@@ -109,7 +109,13 @@ func (b *BlockChain) maybeAcceptBlock(block *types.SerializedBlock, flags Behavi
 	b.pruner.pruneChainIfNeeded()
 
 	//dag
-	newOrders, oldOrders, ib, isMainChainTipChange := b.bd.AddBlock(newNode)
+	newOrders, oldOrders, ib, isMainChainTipChange, err := b.bd.AddBlock(newNode)
+	if err != nil {
+		if _, ok := err.(*blockdag.FinalityError); ok {
+			return ruleError(ErrFinalityViolation, err.Error())
+		}
+		return fmt.Errorf("Irreparable error![%s] %s\n", newNode.GetHash().String(), err)
+	}
 	if newOrders == nil || newOrders.Len() == 0 || ib == nil {
 		return fmt.Errorf("Irreparable error![%s]\n", newNode.GetHash().String())
 	}
@@ -195,7 +201,13 @@ func (b *BlockChain) FastAcceptBlock(block *types.SerializedBlock, flags Behavio
 		}
 	}
 	//dag
-	newOrders, oldOrders, ib, _ := b.bd.AddBlock(newNode)
+	newOrders, oldOrders, ib, _, err := b.bd.AddBlock(newNode)
+	if err != nil {
+		if _, ok := err.(*blockdag.FinalityError); ok {
+			return ruleError(ErrFinalityViolation, err.Error())
+		}
+		return fmt.Errorf("Irreparable error![%s] %s\n", newNode.GetHash().String(), err)
+	}
 	if newOrders == nil || newOrders.Len() == 0 || ib == nil {
 		return fmt.Errorf("Irreparable error![%s]\n", newNode.GetHash().String())
 	}
@@ -203,7 +215,7 @@ func (b *BlockChain) FastAcceptBlock(block *types.SerializedBlock, flags Behavio
 	block.SetOrder(uint64(ib.GetOrder()))
 	block.SetHeight(ib.GetHeight())
 
-	err := b.db.Update(func(dbTx database.Tx) error {
+	err = b.db.Update(func(dbTx database.Tx) error {
 		if err := dbMaybeStoreBlock(dbTx, block); err != nil {
 			return err
 		}
@@ -296,6 +308,37 @@ func (b *BlockChain) GetTokenState(bid uint32) *token.TokenState {
 	return state
 }
 
+// tokenStateAt returns the token state after the block ib, it is the latest
+// token state whose block is not ordered after ib. It returns nil if there
+// was no token state yet.
+//
+// This function MUST be called with the chain state lock held (for reads).
+func (b *BlockChain) tokenStateAt(ib blockdag.IBlock) (*token.TokenState, error) {
+	var state *token.TokenState
+	err := b.db.View(func(dbTx database.Tx) error {
+		for id := b.TokenTipID; uint(id) != blockdag.MaxId; {
+			sb := b.bd.GetBlockById(uint(id))
+			if sb == nil {
+				return fmt.Errorf("No block of token state:%d", id)
+			}
+			ts, err := token.DBFetchTokenState(dbTx, id)
+			if err != nil {
+				return err
+			}
+			if sb.GetOrder() <= ib.GetOrder() {
+				state = ts
+				return nil
+			}
+			id = ts.PrevStateID
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
 func (b *BlockChain) GetCurTokenState() *token.TokenState {
 	b.ChainRLock()
 	defer b.ChainRUnlock()
@@ -345,12 +388,57 @@ func (b *BlockChain) CheckTokenState(block *types.SerializedBlock) error {
 	return state.Update()
 }
 
+// isTokenV2Active returns whether the token rules of DeploymentTokenV2 are
+// valid in the block after prevNode, the networks which don't define the
+// deployment never are.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) isTokenV2Active(prevNode blockdag.IBlock) (bool, error) {
+	if len(b.params.Deployments) <= params.DeploymentTokenV2 {
+		return false, nil
+	}
+	state, err := b.deploymentState(prevNode, params.DeploymentTokenV2)
+	if err != nil {
+		return false, err
+	}
+	return state == ThresholdActive, nil
+}
+
+// IsTokenV2Active returns whether the DeploymentTokenV2 is active for the
+// block after prevNode, it is the main chain tip if prevNode is nil.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) IsTokenV2Active(prevNode blockdag.IBlock) (bool, error) {
+	b.ChainLock()
+	defer b.ChainUnlock()
+	if prevNode == nil {
+		prevNode = b.bd.GetMainChainTip()
+	}
+	return b.isTokenV2Active(prevNode)
+}
+
 func (b *BlockChain) IsValidTxType(tt types.TxType) bool {
 	txTypesCfg := types.StdTxs
 	ok, err := b.isDeploymentActive(params.DeploymentToken)
 	if err == nil && ok && len(types.NonStdTxs) > 0 {
 		txTypesCfg = append(txTypesCfg, types.NonStdTxs...)
 	}
+	ok, err = b.isTokenV2Active(b.bd.GetMainChainTip())
+	if err == nil && ok {
+		txTypesCfg = append(txTypesCfg, types.TokenV2Txs...)
+	}
+	ok, err = b.isStakeActive(b.bd.GetMainChainTip())
+	if err == nil && ok {
+		txTypesCfg = append(txTypesCfg, types.StakeTxs...)
+	}
+	ok, err = b.isGenesisLockActive(b.bd.GetMainChainTip())
+	if err == nil && ok {
+		txTypesCfg = append(txTypesCfg, types.GenesisLockTxs...)
+	} else if tt == types.TxTypeGenesisLock {
+		// The tag of genesis_lock is not recognized until the
+		// DeploymentGenesisLock is active, it is a regular transaction.
+		return true
+	}
 
 	for _, txt := range txTypesCfg {
 		if txt == tt {
@@ -0,0 +1,35 @@
+/*
+ * Copyright (c) 2017-2020 The qitmeer developers
+ */
+
+package blockchain
+
+import (
+	"github.com/Qitmeer/qitmeer/core/blockdag"
+)
+
+// isAssumedValid returns whether the block is the assumed valid block or in
+// its past set, so the scripts of its transactions need not be executed.
+//
+// The past set can only be known when the assumed valid block is in the DAG,
+// the orphan pool or its header was checked. Otherwise, or the assumed valid
+// block is invalid, all scripts are executed as usual.
+func (b *BlockChain) isAssumedValid(ib blockdag.IBlock) bool {
+	if b.assumeValid == nil {
+		return false
+	}
+	if ib.GetHash().IsEqual(b.assumeValid) {
+		return true
+	}
+	av := b.bd.GetBlock(b.assumeValid)
+	if av != nil {
+		if av.GetStatus().KnownInvalid() {
+			return false
+		}
+		return b.bd.IsAncestor(ib.GetID(), av.GetID())
+	}
+	if b.isHeaderAncestor(ib.GetHash(), b.assumeValid) {
+		return true
+	}
+	return b.isOrphanAncestor(ib.GetHash(), b.assumeValid)
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+
+package blockchain
+
+import (
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/engine/txscript"
+	"github.com/Qitmeer/qitmeer/params"
+	"testing"
+)
+
+// TestAssumeValid ensures the scripts are not executed for the assumed valid
+// block and its past set, even if the past set is only known by the orphans.
+func TestAssumeValid(t *testing.T) {
+	par := *params.PrivNetParam.Params
+	src := newTestChain(t, "test_assumevalid_src", &par)
+	defer src.cleanup()
+
+	// The block of badScript has a transaction whose script fails.
+	blocks := src.addBlocks(int(par.CoinbaseMaturity) + 1)
+	outpoint := types.TxOutPoint{Hash: *blocks[0].Transactions()[0].Hash(), OutIndex: 0}
+	amount := blocks[0].Transactions()[0].Tx.TxOut[0].Amount.Value
+	falseTx := newTestSpendTx([]types.TxOutPoint{outpoint}, []int64{amount}, []byte{txscript.OP_FALSE})
+	blocks = append(blocks, src.addBlock(nil, falseTx))
+	falseOut := types.TxOutPoint{Hash: *falseTx.Hash(), OutIndex: 0}
+	badScript := src.newBlock(nil, newTestSpendTx([]types.TxOutPoint{falseOut}, []int64{amount}, src.payScript))
+	src.bc.assumeValid = badScript.Hash()
+	_, err := src.bc.ProcessBlock(badScript, BFNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocks = append(blocks, badScript)
+	assumeValid := src.addBlock(nil)
+
+	isValid := func(tc *testChain, h *hash.Hash) bool {
+		ib := tc.bc.BlockDAG().GetBlock(h)
+		if ib == nil {
+			t.Fatalf("block %s is not in DAG", h)
+		}
+		return !ib.GetStatus().KnownInvalid()
+	}
+	if !isValid(src, badScript.Hash()) {
+		t.Fatal("the scripts of assumed valid block were executed")
+	}
+
+	replay := func(name string, av *hash.Hash, blocks ...*types.SerializedBlock) *testChain {
+		tc := newTestChain(t, name, &par)
+		err := tc.restart(func(config *Config) {
+			config.AssumeValid = av
+		})
+		if err != nil {
+			tc.cleanup()
+			t.Fatal(err)
+		}
+		for _, block := range blocks {
+			_, err := tc.bc.ProcessBlock(block, BFNone)
+			if err != nil {
+				tc.cleanup()
+				t.Fatalf("failed to process block %s : %v", block.Hash(), err)
+			}
+		}
+		return tc
+	}
+
+	// The assumed valid block is an orphan until its parent is processed.
+	dst := replay("test_assumevalid_dst", assumeValid.Hash(),
+		append(append(blocks[:len(blocks)-1:len(blocks)-1], assumeValid), badScript)...)
+	defer dst.cleanup()
+	if !isValid(dst, badScript.Hash()) || !isValid(dst, assumeValid.Hash()) {
+		t.Fatal("the scripts in the past set of assumed valid block were executed")
+	}
+	if !dst.bc.BlockDAG().GetMainChainTip().GetHash().IsEqual(assumeValid.Hash()) {
+		t.Fatalf("the main chain tip is %s, but the assumed valid block is %s",
+			dst.bc.BlockDAG().GetMainChainTip().GetHash(), assumeValid.Hash())
+	}
+
+	// All scripts are executed without the assumed valid block.
+	none := replay("test_assumevalid_none", nil, blocks...)
+	defer none.cleanup()
+	if isValid(none, badScript.Hash()) {
+		t.Fatal("the block whose script fails is valid")
+	}
+}
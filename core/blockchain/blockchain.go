@@ -70,6 +70,7 @@ type BlockChain struct {
 	// runtime.  They are protected by the chain lock.
 	noVerify      bool
 	noCheckpoints bool
+	assumeValid   *hash.Hash
 
 	// These fields are related to handling of orphan blocks.  They are
 	// protected by a combination of the chain lock and the orphan lock.
@@ -77,6 +78,10 @@ type BlockChain struct {
 	orphans      map[hash.Hash]*orphanBlock
 	oldestOrphan *orphanBlock
 
+	// The headers which were checked before their blocks are downloaded.
+	headersLock    sync.RWMutex
+	checkedHeaders map[hash.Hash]*checkedHeader
+
 	// These fields are related to checkpoint handling.  They are protected
 	// by the chain lock.
 	nextCheckpoint *params.Checkpoint
@@ -101,6 +106,9 @@ type BlockChain struct {
 	// it is unlikely to be referenced in the future.
 	pruner *chainPruner
 
+	// utxoCache is the write back cache of the utxo set.
+	utxoCache *utxoCache
+
 	//block dag
 	bd *blockdag.BlockDAG
 
@@ -177,8 +185,24 @@ type Config struct {
 	// Setting different dag types will use different consensus
 	DAGType string
 
+	// The maximum number of DAG blocks kept in memory. Zero means the
+	// default size.
+	DAGCacheSize uint
+
 	// Cache Invalid tx
 	CacheInvalidTx bool
+
+	// The target size in MiB of block files, the old block bodies are
+	// deleted to keep under it. Zero disables pruning.
+	Prune uint64
+
+	// The maximum size in MiB of the utxo cache. Zero means the utxo set
+	// is written to the database for every block.
+	UtxoCacheMaxSize uint64
+
+	// The known good block whose past set skips the script checks, nil
+	// means all scripts are executed.
+	AssumeValid *hash.Hash
 }
 
 // BestState houses information about the current best block and other info
@@ -339,7 +363,9 @@ func New(config *Config) (*BlockChain, error) {
 		sigCache:           config.SigCache,
 		indexManager:       config.IndexManager,
 		orphans:            make(map[hash.Hash]*orphanBlock),
+		checkedHeaders:     make(map[hash.Hash]*checkedHeader),
 		CacheInvalidTx:     config.CacheInvalidTx,
+		assumeValid:        config.AssumeValid,
 		CacheNotifications: []*Notification{},
 		warningCaches:      newThresholdCaches(VBNumBits),
 		deploymentCaches:   newThresholdCaches(params.DefinedDeployments),
@@ -350,12 +376,43 @@ func New(config *Config) (*BlockChain, error) {
 	b.bd.Init(config.DAGType, b.CalcWeight,
 		1.0/float64(par.TargetTimePerBlock/time.Second), b.db, b.getBlockData)
 	b.bd.SetTipsDisLimit(int64(par.CoinbaseMaturity))
+	b.bd.SetCacheSize(int(config.DAGCacheSize))
+	b.bd.SetFinalityDepth(par.FinalityDepth)
+	if config.Prune > 0 && config.Prune < MinPruneSize {
+		return nil, fmt.Errorf("The prune size %d MiB is less than %d MiB", config.Prune, MinPruneSize)
+	}
+	if config.Prune > 0 && par.FinalityDepth == 0 {
+		return nil, fmt.Errorf("The prune mode requires the finality depth of %s, "+
+			"otherwise the pruned blocks could be reordered", par.Name)
+	}
+	var pruned bool
+	err := config.DB.View(func(dbTx database.Tx) error {
+		var err error
+		pruned, err = dbTx.BeenPruned()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if pruned && config.Prune == 0 {
+		return nil, fmt.Errorf("The database was pruned, it can only be used in prune mode")
+	}
 	// Initialize the chain state from the passed database.  When the db
 	// does not yet contain any chain state, both it and the chain state
 	// will be initialized to contain only the genesis block.
 	if err := b.initChainState(config.Interrupt); err != nil {
 		return nil, err
 	}
+	b.utxoCache = newUtxoCache(b.db, config.UtxoCacheMaxSize*1024*1024)
+	if err := b.utxoCache.initialize(); err != nil {
+		return nil, err
+	}
+	if err := b.buildUtxoCommitment(false); err != nil {
+		return nil, err
+	}
+	if err := b.buildTicketIndex(false); err != nil {
+		return nil, err
+	}
 	// Initialize and catch up all of the currently active optional indexes
 	// as needed.
 	if config.IndexManager != nil {
@@ -364,11 +421,11 @@ func New(config *Config) (*BlockChain, error) {
 			return nil, err
 		}
 	}
-	err := b.CheckCacheInvalidTxConfig()
+	err = b.CheckCacheInvalidTxConfig()
 	if err != nil {
 		return nil, err
 	}
-	b.pruner = newChainPruner(&b)
+	b.pruner = newChainPruner(&b, config.Prune*1024*1024)
 
 	// Initialize rule change threshold state caches.
 	if err := b.initThresholdCaches(); err != nil {
@@ -376,6 +433,9 @@ func New(config *Config) (*BlockChain, error) {
 	}
 
 	log.Info(fmt.Sprintf("DAG Type:%s", b.bd.GetName()))
+	if b.assumeValid != nil {
+		log.Info(fmt.Sprintf("Assume valid block:%s", b.assumeValid))
+	}
 	log.Info("Blockchain database version", "chain", b.dbInfo.version, "compression", b.dbInfo.compVer,
 		"index", b.dbInfo.bidxVer)
 
@@ -772,6 +832,10 @@ func (b *BlockChain) fetchBlockByHash(hash *hash.Hash) (*types.SerializedBlock,
 	if dbErr == nil && block != nil {
 		return block, nil
 	}
+	// The genesis block may be pruned.
+	if hash.IsEqual(b.params.GenesisHash) {
+		return types.NewBlock(b.params.GenesisBlock), nil
+	}
 	return nil, fmt.Errorf("unable to find block %v db", hash)
 }
 
@@ -968,11 +1032,13 @@ func (b *BlockChain) updateBestState(ib blockdag.IBlock, block *types.Serialized
 // This function MUST be called with the chain state lock held (for writes).
 func (b *BlockChain) connectBlock(node blockdag.IBlock, block *types.SerializedBlock, view *UtxoViewpoint, stxos []SpentTxOut) error {
 	// Atomically insert info into the database.
+	var diff *utxoDiff
 	err := b.db.Update(func(dbTx database.Tx) error {
 		// Update the utxo set using the state of the utxo view.  This
 		// entails removing all of the utxos spent and adding the new
 		// ones created by the block.
-		err := dbPutUtxoView(dbTx, view)
+		var err error
+		diff, err = b.utxoCache.commitView(dbTx, view)
 		if err != nil {
 			return err
 		}
@@ -983,6 +1049,14 @@ func (b *BlockChain) connectBlock(node blockdag.IBlock, block *types.SerializedB
 		if err != nil {
 			return err
 		}
+
+		// Only the commitment of main chain block is stable.
+		if b.bd.IsOnMainChain(node.GetID()) {
+			err = dbPutBlockUtxoCommitment(dbTx, block.Hash(), diff.commitment)
+			if err != nil {
+				return err
+			}
+		}
 		// Allow the index manager to call each of the currently active
 		// optional indexes with the block being connected so they can
 		// update themselves accordingly.
@@ -998,6 +1072,10 @@ func (b *BlockChain) connectBlock(node blockdag.IBlock, block *types.SerializedB
 		return err
 	}
 
+	// The utxo cache is updated once the diff was committed.
+	b.utxoCache.applyDiff(diff)
+	b.maybeFlushUtxoCache()
+
 	// Prune fully spent entries and mark all entries in the view unmodified
 	// now that the modifications have been committed to the database.
 	view.commit()
@@ -1019,11 +1097,13 @@ func (b *BlockChain) connectBlock(node blockdag.IBlock, block *types.SerializedB
 // This function MUST be called with the chain state lock held (for writes).
 func (b *BlockChain) disconnectBlock(block *types.SerializedBlock, view *UtxoViewpoint, stxos []SpentTxOut) error {
 	// Calculate the exact subsidy produced by adding the block.
+	var diff *utxoDiff
 	err := b.db.Update(func(dbTx database.Tx) error {
 		// Update the utxo set using the state of the utxo view.  This
 		// entails restoring all of the utxos spent and removing the new
 		// ones created by the block.
-		err := dbPutUtxoView(dbTx, view)
+		var err error
+		diff, err = b.utxoCache.commitView(dbTx, view)
 		if err != nil {
 			return err
 		}
@@ -1033,6 +1113,10 @@ func (b *BlockChain) disconnectBlock(block *types.SerializedBlock, view *UtxoVie
 		if err != nil {
 			return err
 		}
+		err = dbRemoveBlockUtxoCommitment(dbTx, block.Hash())
+		if err != nil {
+			return err
+		}
 		// Allow the index manager to call each of the currently active
 		// optional indexes with the block being disconnected so they
 		// can update themselves accordingly.
@@ -1048,6 +1132,10 @@ func (b *BlockChain) disconnectBlock(block *types.SerializedBlock, view *UtxoVie
 		return err
 	}
 
+	// The utxo cache is updated once the diff was committed.
+	b.utxoCache.applyDiff(diff)
+	b.maybeFlushUtxoCache()
+
 	// Prune fully spent entries and mark all entries in the view unmodified
 	// now that the modifications have been committed to the database.
 	view.commit()
@@ -1089,12 +1177,39 @@ func (b *BlockChain) reorganizeChain(ib blockdag.IBlock, detachNodes *list.List,
 		NewOrder:  uint64(ib.GetOrder()),
 	})
 	b.ChainLock()
+	record := newReorgRecord(newBlock.Hash(), &b.BestSnapshot().Hash, b.bd.GetMainChainTip().GetHash(),
+		detachNodes, attachNodes)
 	// Why the old order is the order that was removed by the new block, because the new block
 	// must be one of the tip of the dag.This is very important for the following understanding.
 	// In the two case, the perspective is the same.In the other words, the future can not
 	// affect the past.
+	detachedTxs, err := b.detachBlocks(detachNodes)
+	if err != nil {
+		return err
+	}
+	attachedTxs, err := b.attachBlocks(ib, attachNodes, newBlock)
+	if err != nil {
+		return err
+	}
+	record.DetachedTxs = detachedTxs
+	record.AttachedTxs = attachedTxs
+	b.recordReorg(record)
+
+	// Log the point where the chain forked and old and new best chain
+	// heads.
+	log.Debug(fmt.Sprintf("End DAG REORGANIZE: Old Len= %d;New Len= %d", attachNodes.Len(), detachNodes.Len()))
+
+	return nil
+}
+
+// detachBlocks disconnects the blocks of detachNodes from back to front, it
+// returns the number of transactions of the disconnected blocks.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) detachBlocks(detachNodes *list.List) (uint64, error) {
 	var block *types.SerializedBlock
 	var err error
+	var txs uint64
 
 	for e := detachNodes.Back(); e != nil; e = e.Prev() {
 		n := e.Value.(*blockdag.BlockOrderHelp)
@@ -1118,7 +1233,7 @@ func (b *BlockChain) reorganizeChain(ib blockdag.IBlock, detachNodes *list.List,
 			b.CalculateDAGDuplicateTxs(block)
 			err = view.fetchInputUtxos(b.db, block, b)
 			if err != nil {
-				return err
+				return txs, err
 			}
 
 			// Load all of the spent txos for the block from the spend
@@ -1129,7 +1244,7 @@ func (b *BlockChain) reorganizeChain(ib blockdag.IBlock, detachNodes *list.List,
 				return err
 			})
 			if err != nil {
-				return err
+				return txs, err
 			}
 			// Store the loaded block and spend journal entry for later.
 			err = view.disconnectTransactions(block, stxos, b)
@@ -1144,13 +1259,26 @@ func (b *BlockChain) reorganizeChain(ib blockdag.IBlock, detachNodes *list.List,
 
 		err = b.disconnectBlock(block, view, stxos)
 		if err != nil {
-			return err
+			return txs, err
 		}
+		txs += uint64(len(block.Transactions()))
 	}
+	return txs, nil
+}
+
+// attachBlocks connects the blocks of attachNodes from front to back, the ib
+// is the new block which is not in the database yet, it can be nil. It returns
+// the number of transactions of the connected blocks.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) attachBlocks(ib blockdag.IBlock, attachNodes *list.List, newBlock *types.SerializedBlock) (uint64, error) {
+	var block *types.SerializedBlock
+	var err error
+	var txs uint64
 
 	for e := attachNodes.Front(); e != nil; e = e.Next() {
 		nodeBlock := e.Value.(blockdag.IBlock)
-		if nodeBlock.GetID() == ib.GetID() {
+		if ib != nil && nodeBlock.GetID() == ib.GetID() {
 			block = newBlock
 		} else {
 			// If any previous nodes in attachNodes failed validation,
@@ -1158,7 +1286,7 @@ func (b *BlockChain) reorganizeChain(ib blockdag.IBlock, detachNodes *list.List,
 			block, err = b.FetchBlockByHash(nodeBlock.GetHash())
 
 			if err != nil {
-				return err
+				return txs, err
 			}
 			block.SetOrder(uint64(nodeBlock.GetOrder()))
 			block.SetHeight(nodeBlock.GetHeight())
@@ -1185,14 +1313,9 @@ func (b *BlockChain) reorganizeChain(ib blockdag.IBlock, detachNodes *list.List,
 		if !nodeBlock.GetStatus().KnownInvalid() {
 			b.bd.ValidBlock(nodeBlock)
 		}
-
+		txs += uint64(len(block.Transactions()))
 	}
-
-	// Log the point where the chain forked and old and new best chain
-	// heads.
-	log.Debug(fmt.Sprintf("End DAG REORGANIZE: Old Len= %d;New Len= %d", attachNodes.Len(), detachNodes.Len()))
-
-	return nil
+	return txs, nil
 }
 
 // countSpentOutputs returns the number of utxos the passed block spends.
@@ -1436,6 +1559,11 @@ func (b *BlockChain) CalculateTokenStateRoot(txs []*types.Tx, parents []*hash.Ha
 func (b *BlockChain) getBlockData(hash *hash.Hash) blockdag.IBlockData {
 	block, err := b.fetchBlockByHash(hash)
 	if err != nil {
+		// The pruned block has no body, but its header is kept.
+		bn := b.fetchPrunedBlockNode(hash)
+		if bn != nil {
+			return bn
+		}
 		log.Error(err.Error())
 		return nil
 	}
@@ -0,0 +1,231 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+
+package blockchain
+
+import (
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/blockchain/opreturn"
+	"github.com/Qitmeer/qitmeer/core/merkle"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/core/types/pow"
+	"github.com/Qitmeer/qitmeer/database"
+	_ "github.com/Qitmeer/qitmeer/database/ffldb"
+	"github.com/Qitmeer/qitmeer/engine/txscript"
+	"github.com/Qitmeer/qitmeer/params"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// testChain is the chain on a database in the temporary directory, it builds
+// and processes the blocks for the tests which need a real chain.
+type testChain struct {
+	t      *testing.T
+	dbPath string
+	db     database.DB
+	bc     *BlockChain
+	params *params.Params
+
+	// The coinbase of the new blocks pays to payScript.
+	payScript []byte
+
+	// The database is wrapped by wrapDB when it is opened again if it is
+	// not nil.
+	wrapDB func(db database.DB) database.DB
+}
+
+// newTestChain creates the chain of par on a new database, the chain must be
+// cleaned up by the caller.
+func newTestChain(t *testing.T, name string, par *params.Params) *testChain {
+	dbPath, err := ioutil.TempDir("", name)
+	if err != nil {
+		t.Fatalf("failed to create %s db : %v", name, err)
+	}
+	db, err := database.Create("ffldb", dbPath, par.Net)
+	if err != nil {
+		os.RemoveAll(dbPath)
+		t.Fatalf("failed to create %s db : %v", name, err)
+	}
+	tc := &testChain{
+		t:         t,
+		dbPath:    dbPath,
+		db:        db,
+		params:    par,
+		payScript: []byte{txscript.OP_TRUE},
+	}
+	tc.bc, err = New(tc.config())
+	if err != nil {
+		tc.cleanup()
+		t.Fatal(err)
+	}
+	return tc
+}
+
+// config returns the config of chain on the current database.
+func (tc *testChain) config() *Config {
+	return &Config{
+		DB:          tc.db,
+		ChainParams: tc.params,
+		TimeSource:  NewMedianTime(),
+		DAGType:     "phantom",
+	}
+}
+
+// restart closes the database and creates the chain from it again with the
+// config, which is updated by the caller if it is not nil.
+func (tc *testChain) restart(update func(config *Config)) error {
+	tc.db.Close()
+	db, err := database.Open("ffldb", tc.dbPath, tc.params.Net)
+	if err != nil {
+		tc.t.Fatalf("failed to open db : %v", err)
+	}
+	if tc.wrapDB != nil {
+		db = tc.wrapDB(db)
+	}
+	tc.db = db
+	config := tc.config()
+	if update != nil {
+		update(config)
+	}
+	tc.bc, err = New(config)
+	return err
+}
+
+func (tc *testChain) cleanup() {
+	tc.db.Close()
+	os.RemoveAll(tc.dbPath)
+}
+
+// newBlock returns the new block with the transactions and a coinbase paying
+// the whole subsidy, the stake share is reserved once the stake is active. Its
+// main parent is the main chain tip if parents is nil.
+func (tc *testChain) newBlock(parents []*hash.Hash, txs ...*types.Tx) *types.SerializedBlock {
+	b := tc.bc
+	if parents == nil {
+		parents = []*hash.Hash{b.bd.GetMainChainTip().GetHash()}
+	}
+	mainParent, parents := b.bd.GetMainParentAndList(parents)
+	if mainParent == nil {
+		tc.t.Fatalf("no main parent of %v", parents)
+	}
+	height := uint64(mainParent.GetHeight() + 1)
+
+	coinbaseScript, err := txscript.NewScriptBuilder().AddInt64(int64(height)).
+		AddInt64(int64(b.bd.GetBlockTotal())).
+		AddData([]byte("/test/" + tc.params.CoinbaseConfig.GetCurrentVersion(int64(height)))).Script()
+	if err != nil {
+		tc.t.Fatal(err)
+	}
+	coinbase := types.NewTransaction()
+	coinbase.AddTxIn(&types.TxInput{
+		PreviousOut: *types.NewOutPoint(&hash.Hash{}, types.MaxPrevOutIndex),
+		Sequence:    types.MaxTxInSequenceNum,
+		SignScript:  coinbaseScript,
+	})
+	bi := b.bd.GetBlueInfo(mainParent)
+	subsidy := b.subsidyCache.CalcBlockSubsidy(bi)
+	stakeActive, err := b.isStakeActive(mainParent)
+	if err != nil {
+		tc.t.Fatal(err)
+	}
+	var stake int64
+	if stakeActive {
+		stake = int64(CalcBlockStakeSubsidy(b.subsidyCache, bi, tc.params))
+	}
+	coinbase.AddTxOut(&types.TxOutput{
+		Amount:   types.Amount{Value: subsidy - stake, Id: types.MEERID},
+		PkScript: tc.payScript,
+	})
+	if stake > 0 {
+		coinbase.AddTxOut(&types.TxOutput{
+			Amount:   types.Amount{Value: stake, Id: types.MEERID},
+			PkScript: tc.params.StakeReservePkScript,
+		})
+	}
+	coinbase.AddTxOut(opreturn.GetOPReturnTxOutput(opreturn.NewShowAmount(subsidy - stake)))
+	blockTxs := append([]*types.Tx{types.NewTx(coinbase)}, txs...)
+
+	// The witness commitment is in the previous outpoint of coinbase.
+	merkles := merkle.BuildMerkleTreeStore(blockTxs, true)
+	witnessPreimage := append(merkles[len(merkles)-1].Bytes(), coinbaseScript...)
+	coinbase.TxIn[0].PreviousOut.Hash = hash.DoubleHashH(witnessPreimage)
+	blockTxs[0] = types.NewTx(coinbase)
+
+	timestamp := b.GetBlockNode(mainParent).Timestamp().Add(tc.params.TargetTimePerBlock)
+	instance := pow.GetInstance(pow.MEERXKECCAKV1, 0, []byte{})
+	instance.SetParams(tc.params.PowConfig)
+	instance.SetMainHeight(pow.MainHeight(height))
+	difficulty, err := b.calcNextRequiredDifficulty(mainParent, timestamp, instance)
+	if err != nil {
+		tc.t.Fatal(err)
+	}
+	version, err := b.calcNextBlockVersion(mainParent)
+	if err != nil {
+		tc.t.Fatal(err)
+	}
+	merkles = merkle.BuildMerkleTreeStore(blockTxs, false)
+	paMerkles := merkle.BuildParentsMerkleTreeStore(parents)
+	block := &types.Block{
+		Header: types.BlockHeader{
+			Version:    version,
+			ParentRoot: *paMerkles[len(paMerkles)-1],
+			TxRoot:     *merkles[len(merkles)-1],
+			StateRoot:  b.CalculateTokenStateRoot(blockTxs, parents),
+			Timestamp:  timestamp,
+			Difficulty: difficulty,
+			Pow:        instance,
+		},
+	}
+	for _, parent := range parents {
+		block.AddParent(parent)
+	}
+	for _, tx := range blockTxs {
+		block.AddTransaction(tx.Tx)
+	}
+
+	// The pow limit of test networks is easy enough to solve at once.
+	for nonce := uint64(0); ; nonce++ {
+		block.Header.Pow.SetNonce(nonce)
+		err := block.Header.Pow.Verify(block.Header.BlockData(), block.Header.BlockHash(), difficulty)
+		if err == nil {
+			break
+		}
+	}
+	return types.NewBlock(block)
+}
+
+// addBlock builds the new block by newBlock and processes it, the block must
+// be accepted.
+func (tc *testChain) addBlock(parents []*hash.Hash, txs ...*types.Tx) *types.SerializedBlock {
+	block := tc.newBlock(parents, txs...)
+	isOrphan, err := tc.bc.ProcessBlock(block, BFNone)
+	if err != nil {
+		tc.t.Fatalf("failed to process block %s : %v", block.Hash(), err)
+	}
+	if isOrphan {
+		tc.t.Fatalf("block %s is orphan", block.Hash())
+	}
+	return block
+}
+
+// addBlocks extends the main chain by n blocks without transactions.
+func (tc *testChain) addBlocks(n int) []*types.SerializedBlock {
+	blocks := make([]*types.SerializedBlock, 0, n)
+	for i := 0; i < n; i++ {
+		blocks = append(blocks, tc.addBlock(nil))
+	}
+	return blocks
+}
+
+// newTestSpendTx returns the transaction which spends the outputs paying to
+// OP_TRUE, every output of it is the amount paying to pkScript.
+func newTestSpendTx(outpoints []types.TxOutPoint, amounts []int64, pkScript []byte) *types.Tx {
+	tx := types.NewTransaction()
+	for i := range outpoints {
+		tx.AddTxIn(types.NewTxInput(&outpoints[i], nil))
+	}
+	for _, amount := range amounts {
+		tx.AddTxOut(types.NewTxOutput(types.Amount{Value: amount, Id: types.MEERID}, pkScript))
+	}
+	return types.NewTx(tx)
+}
@@ -204,7 +204,10 @@ func (b *BlockChain) createChainState() error {
 	genesisBlock.SetOrder(0)
 	header := &genesisBlock.Block().Header
 	node := NewBlockNode(genesisBlock, genesisBlock.Block().Parents)
-	_, _, ib, _ := b.bd.AddBlock(node)
+	_, _, ib, _, err := b.bd.AddBlock(node)
+	if err != nil {
+		return err
+	}
 	//node.FlushToDB(b)
 	// Initialize the state related to the best block.  Since it is the
 	// genesis block, use its timestamp for the median time.
@@ -215,7 +218,7 @@ func (b *BlockChain) createChainState() error {
 	b.TokenTipID = 0
 	// Create the initial the database chain state including creating the
 	// necessary index buckets and inserting the genesis block.
-	err := b.db.Update(func(dbTx database.Tx) error {
+	err = b.db.Update(func(dbTx database.Tx) error {
 		meta := dbTx.Metadata()
 
 		// Create the bucket that houses information about the database's
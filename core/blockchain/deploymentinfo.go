@@ -0,0 +1,176 @@
+/*
+ * Copyright (c) 2017-2020 The qitmeer developers
+ */
+
+package blockchain
+
+import (
+	"github.com/Qitmeer/qitmeer/core/blockdag"
+	"github.com/Qitmeer/qitmeer/core/types/pow"
+	"github.com/Qitmeer/qitmeer/params"
+	"sort"
+)
+
+// PowSignalling is the number of blocks of a pow type in the current window
+// and how many of them signal for a deployment.
+type PowSignalling struct {
+	PowType pow.PowType
+	Blocks  uint32
+	Count   uint32
+}
+
+// DeploymentInfo is the status of a deployment for the block after the main
+// chain tip.
+type DeploymentInfo struct {
+	Id          uint32
+	Name        string
+	Bit         uint8
+	State       ThresholdState
+	StartTime   uint64
+	ExpireTime  uint64
+	PerformTime uint64
+
+	// Whether the start, expire and perform times are median block times,
+	// otherwise they are main heights.
+	TimeMode bool
+
+	Window    uint32
+	Threshold uint32
+
+	// The number of blocks in the current window and how many of them
+	// signal for the deployment, then broken down per pow type.
+	Elapsed   uint32
+	Count     uint32
+	PowCounts []*PowSignalling
+
+	// The main height and order of the first block with the deployment
+	// active. The order is zero if the block has not been connected.
+	ActivationHeight uint64
+	ActivationOrder  uint64
+}
+
+// FetchDeploymentInfo returns the status of every defined deployment for the
+// block after the main chain tip.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) FetchDeploymentInfo() ([]*DeploymentInfo, error) {
+	b.ChainLock()
+	defer b.ChainUnlock()
+
+	mainTip := b.bd.GetMainChainTip()
+	result := []*DeploymentInfo{}
+	for id := 0; id < len(b.params.Deployments); id++ {
+		deployment := &b.params.Deployments[id]
+		checker := deploymentChecker{deployment: deployment, chain: b}
+		state, err := b.thresholdState(mainTip, checker, &b.deploymentCaches[id])
+		if err != nil {
+			return nil, err
+		}
+		info := &DeploymentInfo{
+			Id:          uint32(id),
+			Name:        params.DeploymentName(id),
+			Bit:         deployment.BitNumber,
+			State:       state,
+			StartTime:   deployment.StartTime,
+			ExpireTime:  deployment.ExpireTime,
+			PerformTime: deployment.PerformTime,
+			TimeMode:    isCheckerTimeMode(checker),
+			Window:      checker.MinerConfirmationWindow(),
+			Threshold:   checker.RuleChangeActivationThreshold(),
+		}
+		err = b.countSignalling(mainTip, checker, info)
+		if err != nil {
+			return nil, err
+		}
+		if state == ThresholdActive {
+			err = b.findActivation(mainTip, checker, &b.deploymentCaches[id], info)
+			if err != nil {
+				return nil, err
+			}
+		}
+		result = append(result, info)
+	}
+	return result, nil
+}
+
+// countSignalling counts the main chain blocks of the current window which
+// signal for the deployment, the blocks of every pow type are counted apart.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) countSignalling(tip blockdag.IBlock, checker thresholdConditionChecker, info *DeploymentInfo) error {
+	window := checker.MinerConfirmationWindow()
+	if window == 0 {
+		return nil
+	}
+	powCounts := map[pow.PowType]*PowSignalling{}
+	info.Elapsed = (uint32(tip.GetHeight()) + 1) % window
+	node := tip
+	for i := uint32(0); i < info.Elapsed && node != nil; i++ {
+		bn := b.GetBlockNode(node)
+		if bn == nil {
+			break
+		}
+		powType := bn.GetHeader().Pow.GetPowType()
+		ps, ok := powCounts[powType]
+		if !ok {
+			ps = &PowSignalling{PowType: powType}
+			powCounts[powType] = ps
+		}
+		ps.Blocks++
+		condition, err := checker.Condition(node)
+		if err != nil {
+			return err
+		}
+		if condition {
+			ps.Count++
+			info.Count++
+		}
+		node = b.bd.GetBlockById(node.GetMainParent())
+	}
+	info.PowCounts = []*PowSignalling{}
+	for _, ps := range powCounts {
+		info.PowCounts = append(info.PowCounts, ps)
+	}
+	sort.Slice(info.PowCounts, func(i, j int) bool {
+		return info.PowCounts[i].PowType < info.PowCounts[j].PowType
+	})
+	return nil
+}
+
+// findActivation finds the first main chain block with the active deployment,
+// the state is the same for all blocks within a given window, so only the last
+// block of every previous window is checked.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) findActivation(tip blockdag.IBlock, checker thresholdConditionChecker, cache *thresholdStateCache, info *DeploymentInfo) error {
+	window := int64(checker.MinerConfirmationWindow())
+	if window <= 0 {
+		return nil
+	}
+	// The last block of the previous window, the state of the blocks after
+	// it is active.
+	windowEnd := b.bd.GetMainAncestor(tip, int64(tip.GetHeight())-(int64(tip.GetHeight())+1)%window)
+	for windowEnd != nil {
+		prev := b.bd.RelativeMainAncestor(windowEnd, window)
+		if prev == nil {
+			break
+		}
+		state, err := b.thresholdState(prev, checker, cache)
+		if err != nil {
+			return err
+		}
+		if state != ThresholdActive {
+			break
+		}
+		windowEnd = prev
+	}
+	if windowEnd == nil {
+		return nil
+	}
+	info.ActivationHeight = uint64(windowEnd.GetHeight()) + 1
+	activation := b.bd.GetMainAncestor(tip, int64(info.ActivationHeight))
+	if activation != nil {
+		info.ActivationOrder = uint64(activation.GetOrder())
+	}
+	return nil
+}
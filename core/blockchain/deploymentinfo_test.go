@@ -0,0 +1,87 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+
+package blockchain
+
+import (
+	"github.com/Qitmeer/qitmeer/params"
+	"testing"
+)
+
+// TestFetchDeploymentInfo ensures the signalling blocks of the current window
+// are counted per pow type, and the activation is found once the deployment is
+// active.
+func TestFetchDeploymentInfo(t *testing.T) {
+	par := *params.PrivNetParam.Params
+	par.RuleChangeActivationThreshold = 12
+	par.MinerConfirmationWindow = 16
+	par.Deployments = make([]params.ConsensusDeployment, params.DefinedDeployments)
+	copy(par.Deployments, params.PrivNetParam.Params.Deployments)
+	par.Deployments[params.DeploymentTestDummy] = params.ConsensusDeployment{
+		BitNumber:  28,
+		StartTime:  0,
+		ExpireTime: 16 * 100,
+	}
+	tc := newTestChain(t, "test_deploymentinfo", &par)
+	defer tc.cleanup()
+
+	fetch := func() *DeploymentInfo {
+		infos, err := tc.bc.FetchDeploymentInfo()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(infos) != len(par.Deployments) {
+			t.Fatalf("%d deployments are fetched, but %d are defined", len(infos), len(par.Deployments))
+		}
+		info := infos[params.DeploymentTestDummy]
+		if info.Name != params.DeploymentName(params.DeploymentTestDummy) || info.Bit != 28 ||
+			info.TimeMode || info.Window != 16 || info.Threshold != 12 {
+			t.Fatalf("the deployment is %+v", info)
+		}
+		return info
+	}
+	info := fetch()
+	if info.State != ThresholdDefined {
+		t.Fatalf("the deployment is %v at genesis", info.State)
+	}
+
+	// Every block signals for the started deployment, the deployment is
+	// active after the window of lock in.
+	for i := 0; info.State != ThresholdActive; i++ {
+		if i >= 4*16 {
+			t.Fatalf("the deployment is %v after %d blocks", info.State, i)
+		}
+		tc.addBlock(nil)
+		prevState := info.State
+		info = fetch()
+		height := uint32(tc.bc.BlockDAG().GetMainChainTip().GetHeight())
+		if info.Elapsed != (height+1)%16 {
+			t.Fatalf("%d blocks of the window elapsed at height %d", info.Elapsed, height)
+		}
+		var blocks, count uint32
+		for _, ps := range info.PowCounts {
+			blocks += ps.Blocks
+			count += ps.Count
+		}
+		if blocks != info.Elapsed || count != info.Count {
+			t.Fatalf("the pow counts %d/%d are not the same as %d/%d", count, blocks, info.Count, info.Elapsed)
+		}
+		if info.State == ThresholdStarted && prevState == ThresholdStarted && info.Count != info.Elapsed {
+			t.Fatalf("%d of %d blocks signal for the started deployment", info.Count, info.Elapsed)
+		}
+		if info.State == ThresholdActive && (info.ActivationHeight != uint64(height)+1 || info.ActivationOrder != 0) {
+			t.Fatalf("the deployment is active at height %d (order:%d), but the next height is %d",
+				info.ActivationHeight, info.ActivationOrder, height+1)
+		}
+	}
+
+	// The first block with the active deployment is found later.
+	activationHeight := info.ActivationHeight
+	block := tc.addBlock(nil)
+	tc.addBlock(nil)
+	info = fetch()
+	ib := tc.bc.BlockDAG().GetBlock(block.Hash())
+	if info.ActivationHeight != activationHeight || info.ActivationOrder != uint64(ib.GetOrder()) {
+		t.Fatalf("the deployment is active at height %d (order:%d), but the block %s is at order %d",
+			info.ActivationHeight, info.ActivationOrder, block.Hash(), ib.GetOrder())
+	}
+}
@@ -335,6 +335,22 @@ const (
 	// ErrNoViewpoint
 	ErrNoViewpoint
 
+	// ErrBlockInvalidated indicates the block or one of its ancestors was
+	// invalidated by invalidateblock.
+	ErrBlockInvalidated
+
+	// ErrFinalityViolation indicates the block would change the order of a
+	// finalized block.
+	ErrFinalityViolation
+
+	// ErrBadStakeTx indicates a stake transaction or a transaction which
+	// spends the ticket or the stake reserve output breaks the stake rules.
+	ErrBadStakeTx
+
+	// ErrBadGenesisLock indicates a locked genesis output is spent before
+	// its release height or by a transaction which is not a genesis lock.
+	ErrBadGenesisLock
+
 	// numErrorCodes is the maximum error code number used in tests.
 	numErrorCodes
 
@@ -413,6 +429,10 @@ var errorCodeStrings = map[ErrorCode]string{
 
 	ErrNoBlueCoinbase:         "ErrNoBlueCoinbase",
 	ErrNoViewpoint:            "ErrNoViewpoint",
+	ErrBlockInvalidated:       "ErrBlockInvalidated",
+	ErrFinalityViolation:      "ErrFinalityViolation",
+	ErrBadStakeTx:             "ErrBadStakeTx",
+	ErrBadGenesisLock:         "ErrBadGenesisLock",
 	ErrorCoinbaseBlockVersion: "ErrorCoinbaseBlockVersion",
 }
 
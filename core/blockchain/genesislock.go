@@ -0,0 +1,161 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+package blockchain
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/core/blockdag"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/engine/txscript"
+	"github.com/Qitmeer/qitmeer/params"
+)
+
+// isGenesisLockActive returns whether the locked genesis outputs can only be
+// released by genesis_lock in the block after prevNode, the networks which
+// don't define the deployment never are.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) isGenesisLockActive(prevNode blockdag.IBlock) (bool, error) {
+	if len(b.params.Deployments) <= params.DeploymentGenesisLock {
+		return false, nil
+	}
+	state, err := b.deploymentState(prevNode, params.DeploymentGenesisLock)
+	if err != nil {
+		return false, err
+	}
+	return state == ThresholdActive, nil
+}
+
+// IsGenesisLockActive returns whether the DeploymentGenesisLock is active for
+// the block after prevNode, it is the main chain tip if prevNode is nil.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) IsGenesisLockActive(prevNode blockdag.IBlock) (bool, error) {
+	b.ChainLock()
+	defer b.ChainUnlock()
+	if prevNode == nil {
+		prevNode = b.bd.GetMainChainTip()
+	}
+	return b.isGenesisLockActive(prevNode)
+}
+
+// checkGenesisLock checks the locked genesis outputs which the transaction
+// spends once the DeploymentGenesisLock is active. They can only be spent by
+// genesis_lock after their release heights, which are no more than the main
+// height of the main parent of viewpoints. The lock time of transaction is
+// checked against the release heights by the script engine (OP_MEER_LOCK).
+//
+// Before the deployment, genesis_lock is a regular transaction and the locked
+// genesis outputs are regular outputs.
+//
+// The inputs of the transaction must be in the utxo view.
+func (b *BlockChain) checkGenesisLock(tx *types.Tx, utxoView *UtxoViewpoint) error {
+	msgTx := tx.Transaction()
+	isGenesisLock := types.IsGenesisLockTx(msgTx)
+	var mainParent blockdag.IBlock
+	for idx, txIn := range msgTx.TxIn {
+		entry := utxoView.LookupEntry(txIn.PreviousOut)
+		if entry == nil || entry.IsSpent() {
+			str := fmt.Sprintf("output %v referenced from "+
+				"transaction %s:%d either does not exist or "+
+				"has already been spent", txIn.PreviousOut,
+				tx.Hash(), idx)
+			return ruleError(ErrMissingTxOut, str)
+		}
+		releaseHeight, ok := txscript.ExtractGenesisLockHeight(entry.PkScript())
+		isLocked := ok && entry.BlockHash().IsEqual(b.params.GenesisHash)
+		if !isLocked && !isGenesisLock {
+			continue
+		}
+		if mainParent == nil {
+			mainParent = b.bd.GetMainParentByHashs(utxoView.viewpoints)
+			if mainParent == nil {
+				str := fmt.Sprintf("transaction %s has no viewpoints", tx.Hash())
+				return ruleError(ErrNoViewpoint, str)
+			}
+			active, err := b.isGenesisLockActive(mainParent)
+			if err != nil {
+				return err
+			}
+			if !active {
+				return nil
+			}
+		}
+		if !isLocked {
+			str := fmt.Sprintf("genesis lock %s spends %v which is not "+
+				"a locked genesis output", tx.Hash(), txIn.PreviousOut)
+			return ruleError(ErrBadGenesisLock, str)
+		}
+		if !isGenesisLock {
+			str := fmt.Sprintf("transaction %s:%d spends the locked genesis "+
+				"output %v, but it is not a genesis lock", tx.Hash(), idx,
+				txIn.PreviousOut)
+			return ruleError(ErrBadGenesisLock, str)
+		}
+		mainHeight := int64(mainParent.GetHeight())
+		if releaseHeight > mainHeight {
+			str := fmt.Sprintf("the locked genesis output %v is released "+
+				"at main height %d, but transaction %s is after main "+
+				"height %d", txIn.PreviousOut, releaseHeight, tx.Hash(),
+				mainHeight)
+			return ruleError(ErrBadGenesisLock, str)
+		}
+	}
+	return nil
+}
+
+// GenesisLock is a locked genesis output which is not spent.
+type GenesisLock struct {
+	OutPoint      types.TxOutPoint
+	Amount        int64
+	PkScript      []byte
+	ReleaseHeight int64
+}
+
+// FetchGenesisLocks returns the release schedule of the genesis ledger, which
+// are the locked genesis outputs not spent at the main chain tip, and the main
+// height of the tip.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) FetchGenesisLocks() ([]*GenesisLock, uint, error) {
+	b.ChainRLock()
+	defer b.ChainRUnlock()
+
+	locks := []*GenesisLock{}
+	needed := map[types.TxOutPoint]struct{}{}
+	for _, tx := range b.params.GenesisBlock.Transactions {
+		txHash := tx.TxHash()
+		for idx, txOut := range tx.TxOut {
+			releaseHeight, ok := txscript.ExtractGenesisLockHeight(txOut.PkScript)
+			if !ok {
+				continue
+			}
+			lock := &GenesisLock{
+				OutPoint:      *types.NewOutPoint(&txHash, uint32(idx)),
+				Amount:        txOut.Amount.Value,
+				PkScript:      txOut.PkScript,
+				ReleaseHeight: releaseHeight,
+			}
+			locks = append(locks, lock)
+			needed[lock.OutPoint] = struct{}{}
+		}
+	}
+	mainHeight := b.bd.GetMainChainTip().GetHeight()
+	if len(locks) == 0 {
+		return locks, mainHeight, nil
+	}
+
+	view := NewUtxoViewpoint()
+	err := b.utxoCache.fetchEntries(view, needed)
+	if err != nil {
+		return nil, mainHeight, err
+	}
+	unspent := make([]*GenesisLock, 0, len(locks))
+	for _, lock := range locks {
+		entry := view.LookupEntry(lock.OutPoint)
+		if entry == nil || entry.IsSpent() {
+			continue
+		}
+		unspent = append(unspent, lock)
+	}
+	return unspent, mainHeight, nil
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+
+package blockchain
+
+import (
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/database"
+	_ "github.com/Qitmeer/qitmeer/database/ffldb"
+	"github.com/Qitmeer/qitmeer/engine/txscript"
+	"github.com/Qitmeer/qitmeer/params"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestCheckGenesisLock ensures the locked genesis outputs can only be spent by
+// genesis_lock after their release heights once the DeploymentGenesisLock is
+// active, and they are regular outputs before it.
+func TestCheckGenesisLock(t *testing.T) {
+	dbPath, err := ioutil.TempDir("", "test_genesislock_db")
+	if err != nil {
+		t.Fatalf("failed to create genesis lock db : %v", err)
+	}
+	defer os.RemoveAll(dbPath)
+
+	db, err := database.Create("ffldb", dbPath, params.PrivNetParam.Net)
+	if err != nil {
+		t.Fatalf("failed to create genesis lock db : %v", err)
+	}
+	defer db.Close()
+
+	// The threshold state of every block is in its own window, so the
+	// deployment can be activated from the genesis.
+	par := *params.PrivNetParam.Params
+	par.MinerConfirmationWindow = 1
+	bc, err := New(&Config{
+		DB:          db,
+		ChainParams: &par,
+		TimeSource:  NewMedianTime(),
+		DAGType:     "phantom",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lockScript := func(releaseHeight int64) []byte {
+		script, err := txscript.PayToGenesisLockScript(make([]byte, 20), releaseHeight)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return script
+	}
+	pkScript := append([]byte{0x76, 0xa9, 0x14}, make([]byte, 20)...)
+	pkScript = append(pkScript, 0x88, 0xac)
+	otherBlock := hash.HashH([]byte("block"))
+	released := types.TxOutPoint{Hash: hash.HashH([]byte("released"))}
+	locked := types.TxOutPoint{Hash: hash.HashH([]byte("locked"))}
+	nonGenesis := types.TxOutPoint{Hash: hash.HashH([]byte("non-genesis"))}
+	regular := types.TxOutPoint{Hash: hash.HashH([]byte("regular"))}
+
+	// The viewpoint is the block at main height 1.
+	genesis := bc.BlockDAG().GetBlock(par.GenesisHash)
+	node := &BlockNode{
+		hash:    hash.HashH([]byte("main")),
+		parents: []hash.Hash{*par.GenesisHash},
+		header:  types.BlockHeader{Timestamp: par.GenesisBlock.Header.Timestamp.Add(time.Second)},
+		txNum:   1,
+	}
+	_, _, mainParent, _, err := bc.BlockDAG().AddBlock(node)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	view := NewUtxoViewpoint()
+	view.SetViewpoints([]*hash.Hash{node.GetHash()})
+	amount := types.Amount{Value: 100, Id: types.MEERID}
+	view.addTxOut(released, types.NewTxOutput(amount, lockScript(1)), false, par.GenesisHash)
+	view.addTxOut(locked, types.NewTxOutput(amount, lockScript(2)), false, par.GenesisHash)
+	view.addTxOut(nonGenesis, types.NewTxOutput(amount, lockScript(1)), false, &otherBlock)
+	view.addTxOut(regular, types.NewTxOutput(amount, pkScript), false, par.GenesisHash)
+
+	newTx := func(isGenesisLock bool, outpoints ...types.TxOutPoint) *types.Tx {
+		tx := types.NewTransaction()
+		for _, outpoint := range outpoints {
+			op := outpoint
+			tx.AddTxIn(types.NewTxInput(&op, nil))
+		}
+		if isGenesisLock {
+			tx.TxIn[0].Sequence = types.TaggedSequence(types.TxTypeGenesisLock)
+		}
+		tx.AddTxOut(types.NewTxOutput(types.Amount{Value: 10, Id: types.MEERID}, pkScript))
+		return types.NewTx(tx)
+	}
+	// The relative lock time of 2 blocks is not the tag of genesis lock.
+	newRelativeLockTx := func(outpoint types.TxOutPoint) *types.Tx {
+		tx := newTx(false, outpoint).Tx
+		tx.TxIn[0].Sequence = uint32(types.TxTypeGenesisLock)
+		return types.NewTx(tx)
+	}
+
+	tests := []struct {
+		name  string
+		tx    *types.Tx
+		valid bool
+	}{
+		{"genesis lock after release", newTx(true, released), true},
+		{"genesis lock before release", newTx(true, locked), false},
+		{"regular spends locked genesis output", newTx(false, released), false},
+		{"regular spends non-genesis output", newTx(false, nonGenesis), true},
+		{"genesis lock spends non-genesis output", newTx(true, nonGenesis), false},
+		{"genesis lock spends regular output", newTx(true, released, regular), false},
+		{"relative lock spends locked genesis output", newRelativeLockTx(released), false},
+		{"relative lock spends non-genesis output", newRelativeLockTx(nonGenesis), true},
+	}
+
+	// All of them are regular before the deployment is active.
+	for _, test := range tests {
+		_, err := bc.CheckTransactionInputs(test.tx, view)
+		if err != nil {
+			t.Errorf("%s: inactive: %v", test.name, err)
+		}
+	}
+
+	bc.deploymentCaches[params.DeploymentGenesisLock].Update(genesis.GetID(), ThresholdActive)
+	bc.deploymentCaches[params.DeploymentGenesisLock].Update(mainParent.GetID(), ThresholdActive)
+	for _, test := range tests {
+		_, err := bc.CheckTransactionInputs(test.tx, view)
+		if test.valid && err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		}
+		if !test.valid {
+			rerr, ok := err.(RuleError)
+			if !ok || rerr.ErrorCode != ErrBadGenesisLock {
+				t.Errorf("%s: expected ErrBadGenesisLock, got %v", test.name, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,176 @@
+/*
+ * Copyright (c) 2017-2020 The qitmeer developers
+ */
+
+package blockchain
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/types"
+)
+
+// maxCheckedHeaders is the maximum number of checked headers whose blocks
+// are not in DAG yet, they are all dropped when it is exceeded.
+const maxCheckedHeaders = 10000
+
+// checkedHeader is the block header which passed the checks before its block
+// is downloaded.
+type checkedHeader struct {
+	parents []*hash.Hash
+
+	// The main height of block, it is the highest possible one if the
+	// main parent is not in DAG yet.
+	mainHeight uint
+}
+
+// ProcessBlockHeaders checks the headers of blocks before downloading their
+// bodies, so the invalid branches can be rejected early. The blocks must have
+// no transactions and they are checked in order, the parents of each must be
+// in DAG, downloaded or checked before.
+//
+// The proof of work, timestamp and the parents of every header are checked.
+// The difficulty is checked against the retarget rules when all parents are
+// in DAG, otherwise it is checked when the block is connected.
+//
+// It returns the number of headers which passed the checks.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ProcessBlockHeaders(blocks []*types.Block) (int, error) {
+	b.ChainLock()
+	defer b.ChainUnlock()
+	b.headersLock.Lock()
+	defer b.headersLock.Unlock()
+
+	b.pruneCheckedHeaders()
+	for i, block := range blocks {
+		err := b.checkBlockHeader(block)
+		if err != nil {
+			return i, err
+		}
+	}
+	return len(blocks), nil
+}
+
+// checkBlockHeader checks the header of block and keeps it if it's valid.
+//
+// This function MUST be called with the chain state lock and the headers lock
+// held (for writes).
+func (b *BlockChain) checkBlockHeader(block *types.Block) error {
+	header := &block.Header
+	blockHash := header.BlockHash()
+	if b.bd.HasBlock(&blockHash) {
+		return nil
+	}
+	if _, ok := b.checkedHeaders[blockHash]; ok {
+		return nil
+	}
+	if len(block.Transactions) > 0 {
+		return fmt.Errorf("The header of block %s has transactions", blockHash)
+	}
+	err := checkBlockParentsSanity(block)
+	if err != nil {
+		return err
+	}
+
+	var mainHeight uint
+	inDAG := true
+	for _, parent := range block.Parents {
+		ib := b.bd.GetBlock(parent)
+		if ib != nil {
+			if ib.GetStatus().KnownInvalid() {
+				str := fmt.Sprintf("parent %s of block %s is known to be invalid", parent, blockHash)
+				return ruleError(ErrInvalidAncestorBlock, str)
+			}
+			if ib.GetHeight()+1 > mainHeight {
+				mainHeight = ib.GetHeight() + 1
+			}
+			continue
+		}
+		inDAG = false
+		var parentHeight uint
+		if ch, ok := b.checkedHeaders[*parent]; ok {
+			parentHeight = ch.mainHeight
+		} else if pb, err := b.fetchBlockByHash(parent); err == nil {
+			// The orphan or the downloaded block which is not in
+			// DAG, its main height is in the coinbase.
+			height, err := ExtractCoinbaseHeight(pb.Block().Transactions[0])
+			if err != nil {
+				return err
+			}
+			parentHeight = uint(height)
+		} else {
+			str := fmt.Sprintf("parent %s of block %s is unknown", parent, blockHash)
+			return ruleError(ErrMissingParent, str)
+		}
+		if parentHeight+1 > mainHeight {
+			mainHeight = parentHeight + 1
+		}
+	}
+	if inDAG {
+		mainParent := b.bd.GetMainParentByHashs(block.Parents)
+		if mainParent == nil {
+			return fmt.Errorf("Can't find main parent\n")
+		}
+		mainHeight = mainParent.GetHeight() + 1
+		err = b.checkHeaderDifficulty(header, mainParent)
+		if err != nil {
+			return err
+		}
+	}
+	err = checkBlockHeaderSanity(header, b.timeSource, BFNone, b.params, mainHeight)
+	if err != nil {
+		return err
+	}
+
+	b.checkedHeaders[blockHash] = &checkedHeader{
+		parents:    block.Parents,
+		mainHeight: mainHeight,
+	}
+	return nil
+}
+
+// pruneCheckedHeaders drops the checked headers whose blocks are in DAG.
+//
+// This function MUST be called with the headers lock held (for writes).
+func (b *BlockChain) pruneCheckedHeaders() {
+	for h := range b.checkedHeaders {
+		if b.bd.HasBlock(&h) {
+			delete(b.checkedHeaders, h)
+		}
+	}
+	if len(b.checkedHeaders) > maxCheckedHeaders {
+		b.checkedHeaders = map[hash.Hash]*checkedHeader{}
+	}
+}
+
+// isHeaderAncestor returns whether the block is in the past set of the block
+// whose header was checked, only the ancestors which are connected by the
+// checked headers are known.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) isHeaderAncestor(h *hash.Hash, descendant *hash.Hash) bool {
+	b.headersLock.RLock()
+	defer b.headersLock.RUnlock()
+
+	visited := map[hash.Hash]struct{}{}
+	queue := []*hash.Hash{descendant}
+	for len(queue) > 0 {
+		ch, ok := b.checkedHeaders[*queue[0]]
+		queue = queue[1:]
+		if !ok {
+			continue
+		}
+		for _, parent := range ch.parents {
+			if parent.IsEqual(h) {
+				return true
+			}
+			if _, ok := visited[*parent]; ok {
+				continue
+			}
+			visited[*parent] = struct{}{}
+			queue = append(queue, parent)
+		}
+	}
+	return false
+}
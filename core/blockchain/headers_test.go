@@ -0,0 +1,93 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+
+package blockchain
+
+import (
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/params"
+	"testing"
+)
+
+// TestProcessBlockHeaders ensures the headers are checked in order before the
+// blocks are downloaded, and the checked headers are dropped once their blocks
+// are in DAG.
+func TestProcessBlockHeaders(t *testing.T) {
+	par := *params.PrivNetParam.Params
+	src := newTestChain(t, "test_headers_src", &par)
+	defer src.cleanup()
+	blocks := src.addBlocks(10)
+
+	header := func(block *types.SerializedBlock) *types.Block {
+		return &types.Block{
+			Header:  block.Block().Header,
+			Parents: block.Block().Parents,
+		}
+	}
+	headers := make([]*types.Block, 0, len(blocks))
+	for _, block := range blocks {
+		headers = append(headers, header(block))
+	}
+
+	dst := newTestChain(t, "test_headers_dst", &par)
+	defer dst.cleanup()
+
+	// The parent of the first header is unknown.
+	n, err := dst.bc.ProcessBlockHeaders(headers[1:])
+	if n != 0 {
+		t.Fatalf("%d headers passed without their parents", n)
+	}
+	rerr, ok := err.(RuleError)
+	if !ok || rerr.ErrorCode != ErrMissingParent {
+		t.Fatalf("expected ErrMissingParent, got %v", err)
+	}
+
+	// The difficulty is checked when all parents are in DAG.
+	badDifficulty := header(blocks[0])
+	badDifficulty.Header.Difficulty++
+	n, err = dst.bc.ProcessBlockHeaders([]*types.Block{badDifficulty})
+	if n != 0 || err == nil {
+		t.Fatal("the header of wrong difficulty passed")
+	}
+
+	// The header must have no transactions.
+	n, err = dst.bc.ProcessBlockHeaders([]*types.Block{headers[0], blocks[1].Block()})
+	if n != 1 || err == nil {
+		t.Fatalf("%d headers passed with the transactions: %v", n, err)
+	}
+
+	n, err = dst.bc.ProcessBlockHeaders(headers)
+	if n != len(headers) || err != nil {
+		t.Fatalf("%d of %d headers passed: %v", n, len(headers), err)
+	}
+	last := blocks[len(blocks)-1].Hash()
+	if !dst.bc.isHeaderAncestor(blocks[0].Hash(), last) {
+		t.Fatalf("block %s is not in the past set of checked header %s", blocks[0].Hash(), last)
+	}
+	if dst.bc.isHeaderAncestor(last, blocks[0].Hash()) {
+		t.Fatalf("block %s is in the past set of checked header %s", last, blocks[0].Hash())
+	}
+
+	// The checked headers tell the past set of the assumed valid block.
+	dst.bc.assumeValid = last
+	_, err = dst.bc.ProcessBlock(blocks[0], BFNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dst.bc.isAssumedValid(dst.bc.BlockDAG().GetBlock(blocks[0].Hash())) {
+		t.Fatalf("block %s is not assumed valid by the checked headers", blocks[0].Hash())
+	}
+
+	for _, block := range blocks[1:] {
+		_, err := dst.bc.ProcessBlock(block, BFNone)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	n, err = dst.bc.ProcessBlockHeaders(nil)
+	if n != 0 || err != nil {
+		t.Fatal(err)
+	}
+	if len(dst.bc.checkedHeaders) != 0 {
+		t.Fatalf("%d checked headers are kept after their blocks are in DAG", len(dst.bc.checkedHeaders))
+	}
+}
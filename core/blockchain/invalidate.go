@@ -0,0 +1,163 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+package blockchain
+
+import (
+	"container/list"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/blockdag"
+	"github.com/Qitmeer/qitmeer/core/types/pow"
+	"github.com/Qitmeer/qitmeer/database"
+)
+
+// InvalidateBlock marks the block and its future set invalid, so that their
+// transactions are removed from the utxo set and token state. They stay
+// invalid until ReconsiderBlock is called.
+// The main chain is moved to the bluest block which is not invalidated, so the
+// blocks from the order of block or the fork point of main chain are
+// disconnected, and the blocks of new orders are connected again.
+// The invalidated tips are no longer chosen as the parents of new blocks.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) InvalidateBlock(h *hash.Hash) error {
+	b.ChainLock()
+	defer b.ChainUnlock()
+
+	ib := b.bd.GetBlock(h)
+	if ib == nil {
+		return fmt.Errorf("No block:%s", h)
+	}
+	if h.IsEqual(b.params.GenesisHash) {
+		return fmt.Errorf("Can't invalidate the genesis block")
+	}
+	if ib.GetStatus()&blockdag.StatusInvalidated != 0 {
+		return fmt.Errorf("The block %s was already invalidated", h)
+	}
+	return b.reconnectFromBlock(ib, func() []blockdag.IBlock {
+		return b.bd.InvalidateBlock(ib)
+	})
+}
+
+// ReconsiderBlock removes the invalidity of block which was set by
+// InvalidateBlock, and its future set will be validated again unless
+// they are still in the future of other invalidated blocks.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ReconsiderBlock(h *hash.Hash) error {
+	b.ChainLock()
+	defer b.ChainUnlock()
+
+	ib := b.bd.GetBlock(h)
+	if ib == nil {
+		return fmt.Errorf("No block:%s", h)
+	}
+	if ib.GetStatus()&blockdag.StatusInvalidated == 0 {
+		return fmt.Errorf("The block %s was not invalidated", h)
+	}
+	return b.reconnectFromBlock(ib, func() []blockdag.IBlock {
+		return b.bd.ReconsiderBlock(ib)
+	})
+}
+
+// reconnectFromBlock disconnects all the blocks whose order are not less than
+// ib, then it calls update to change the status of blocks and reselects the
+// main chain. The blocks before ib are also disconnected if the new main chain
+// forks before it, and all the blocks of new orders are connected again.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) reconnectFromBlock(ib blockdag.IBlock, update func() []blockdag.IBlock) error {
+	detachNodes := list.New()
+	mainOrder := b.bd.GetMainChainTip().GetOrder()
+	startOrder := mainOrder + 1
+	if ib.IsOrdered() {
+		startOrder = ib.GetOrder()
+		for order := startOrder; order <= mainOrder; order++ {
+			node := b.bd.GetBlockByOrder(order)
+			if node == nil {
+				return fmt.Errorf("No block in order:%d", order)
+			}
+			detachNodes.PushBack(&blockdag.BlockOrderHelp{OldOrder: order, Block: node})
+		}
+	}
+	// The blocks must be disconnected before their status are changed.
+	_, err := b.detachBlocks(detachNodes)
+	if err != nil {
+		return err
+	}
+	changed := update()
+	oldOrders, err := b.bd.UpdateMainChain()
+	if err != nil {
+		return err
+	}
+	// The status of blocks before ib are not changed, because they are not
+	// in the future of ib.
+	forkNodes := list.New()
+	if oldOrders != nil {
+		for e := oldOrders.Front(); e != nil; e = e.Next() {
+			node := e.Value.(*blockdag.BlockOrderHelp)
+			if node.OldOrder >= startOrder {
+				break
+			}
+			forkNodes.PushBack(node)
+		}
+	}
+	_, err = b.detachBlocks(forkNodes)
+	if err != nil {
+		return err
+	}
+	if forkNodes.Len() > 0 {
+		startOrder = forkNodes.Front().Value.(*blockdag.BlockOrderHelp).OldOrder
+	}
+
+	attachNodes := list.New()
+	mainOrder = b.bd.GetMainChainTip().GetOrder()
+	for order := startOrder; order <= mainOrder; order++ {
+		node := b.bd.GetBlockByOrder(order)
+		if node == nil {
+			return fmt.Errorf("No block in order:%d", order)
+		}
+		attachNodes.PushBack(node)
+	}
+	_, err = b.attachBlocks(nil, attachNodes, nil)
+	if err != nil {
+		return err
+	}
+	for e := attachNodes.Front(); e != nil; e = e.Next() {
+		b.bd.UpdateWeight(e.Value.(blockdag.IBlock))
+	}
+	log.Info(fmt.Sprintf("Change the validity of %d blocks, reconnect %d blocks from %s",
+		len(changed), attachNodes.Len(), ib.GetHash()))
+
+	return b.updateBestStateAfterReconnect()
+}
+
+// The main chain tip may be changed after reconnecting, so the best state is
+// updated with the new tip.
+func (b *BlockChain) updateBestStateAfterReconnect() error {
+	lastState := b.BestSnapshot()
+	mainTip := b.bd.GetMainChainTip()
+	mainTipNode := b.GetBlockNode(mainTip)
+	if mainTipNode == nil {
+		return fmt.Errorf("No main tip node\n")
+	}
+	block, err := b.fetchBlockByHash(mainTip.GetHash())
+	if err != nil {
+		return err
+	}
+	blockSize := uint64(block.Block().SerializeSize())
+	numTxns := uint64(len(block.Block().Transactions))
+	state := newBestState(mainTip.GetHash(), mainTipNode.Difficulty(), blockSize, numTxns,
+		b.CalcPastMedianTime(mainTip), lastState.TotalTxns, mainTip.GetWeight(), b.bd.GetGraphState(), b.GetTokenTipHash())
+
+	err = b.db.Update(func(dbTx database.Tx) error {
+		return dbPutBestState(dbTx, state, pow.CalcWork(mainTipNode.Difficulty(), mainTipNode.Pow().GetPowType()))
+	})
+	if err != nil {
+		return err
+	}
+	b.stateLock.Lock()
+	b.stateSnapshot = state
+	b.stateLock.Unlock()
+
+	return b.bd.Commit()
+}
@@ -34,15 +34,20 @@ const (
 	// Reorganization indicates that a blockchain reorganization is in
 	// progress.
 	Reorganization
+
+	// ReorganizationRecorded indicates that a blockchain reorganization
+	// was finished and recorded.
+	ReorganizationRecorded
 )
 
 // notificationTypeStrings is a map of notification types back to their constant
 // names for pretty printing.
 var notificationTypeStrings = map[NotificationType]string{
-	BlockAccepted:     "BlockAccepted",
-	BlockConnected:    "BlockConnected",
-	BlockDisconnected: "BlockDisconnected",
-	Reorganization:    "Reorganization",
+	BlockAccepted:          "BlockAccepted",
+	BlockConnected:         "BlockConnected",
+	BlockDisconnected:      "BlockDisconnected",
+	Reorganization:         "Reorganization",
+	ReorganizationRecorded: "ReorganizationRecorded",
 }
 
 // String returns the NotificationType in human-readable form.
@@ -81,6 +86,7 @@ type ReorganizationNotifyData struct {
 // 	- BlockConnected:        []*types.Block of len 2
 // 	- BlockDisconnected:     []*types.Block of len 2
 //  - Reorganization:        *ReorganizationNotifyData
+//  - ReorganizationRecorded: *ReorgRecord
 
 type Notification struct {
 	Type NotificationType
@@ -265,3 +265,33 @@ func (b *BlockChain) refreshOrphans() {
 		}
 	}
 }
+
+// isOrphanAncestor returns whether the block is in the past set of the orphan
+// block, only the ancestors which are connected by the orphans are known.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) isOrphanAncestor(h *hash.Hash, orphan *hash.Hash) bool {
+	b.orphanLock.RLock()
+	defer b.orphanLock.RUnlock()
+
+	visited := map[hash.Hash]struct{}{}
+	queue := []*hash.Hash{orphan}
+	for len(queue) > 0 {
+		block := b.getOrphan(queue[0])
+		queue = queue[1:]
+		if block == nil {
+			continue
+		}
+		for _, parent := range block.Block().Parents {
+			if parent.IsEqual(h) {
+				return true
+			}
+			if _, ok := visited[*parent]; ok {
+				continue
+			}
+			visited[*parent] = struct{}{}
+			queue = append(queue, parent)
+		}
+	}
+	return false
+}
@@ -2,7 +2,14 @@
 package blockchain
 
 import (
+	"bytes"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
 	"github.com/Qitmeer/qitmeer/common/roughtime"
+	"github.com/Qitmeer/qitmeer/core/dbnamespace"
+	s "github.com/Qitmeer/qitmeer/core/serialization"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/database"
 	"time"
 )
 
@@ -10,18 +17,49 @@ import (
 // nodes and restore memory to the garbage collector.
 const pruningIntervalInMinutes = 5
 
+const (
+	// MinPruneSize is the minimum target size in MiB of block files for
+	// pruning, the block files of ffldb are 512 MiB and the current one is
+	// never deleted.
+	MinPruneSize = 1024
+
+	// PruneDepth is the number of the latest orders whose blocks are never
+	// pruned, so the reorganization and the peers which are catching up can
+	// still be served.
+	PruneDepth = 2880
+)
+
 // chainPruner is used to occasionally prune the blockchain of old nodes that
 // can be freed to the garbage collector.
+//
+// When the target size is set, the bodies and spend journals of old blocks
+// are also deleted to keep the block files under it, but the headers of them
+// are kept with the DAG metadata and utxo set.
 type chainPruner struct {
 	chain              *BlockChain
 	lastNodeInsertTime time.Time
+
+	// The target size in bytes of block files, zero means pruning the
+	// block files is disabled.
+	targetSize uint64
+
+	// The number of the latest orders whose blocks are never pruned, it
+	// is not less than the finality depth, so the pruned blocks can't be
+	// reordered any more.
+	depth uint
 }
 
 // newChainPruner returns a new chain pruner.
-func newChainPruner(chain *BlockChain) *chainPruner {
+func newChainPruner(chain *BlockChain, targetSize uint64) *chainPruner {
+	depth := uint(PruneDepth)
+	if chain.params.FinalityDepth > depth {
+		depth = chain.params.FinalityDepth
+	}
 	return &chainPruner{
 		chain:              chain,
 		lastNodeInsertTime: roughtime.Now(),
+		targetSize:         targetSize,
+		depth:              depth,
 	}
 }
 
@@ -36,4 +74,126 @@ func (c *chainPruner) pruneChainIfNeeded() {
 		return
 	}
 	c.lastNodeInsertTime = now
+
+	if c.targetSize == 0 {
+		return
+	}
+	err := c.pruneBlocks()
+	if err != nil {
+		log.Error(fmt.Sprintf("Failed to prune blocks:%v", err))
+	}
+}
+
+// pruneBlocks deletes the oldest blocks which are deeper than the prune depth
+// until the block files are under the target size.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (c *chainPruner) pruneBlocks() error {
+	b := c.chain
+	mainOrder := b.bd.GetMainChainTip().GetOrder()
+	if mainOrder <= c.depth {
+		return nil
+	}
+	maxOrder := mainOrder - c.depth
+	canPrune := func(h *hash.Hash) bool {
+		ib := b.bd.GetBlock(h)
+		if ib == nil {
+			// Nothing refers to the block which is not in DAG.
+			return true
+		}
+		return ib.IsOrdered() && ib.GetOrder() < maxOrder
+	}
+
+	var pruned int
+	err := b.db.Update(func(dbTx database.Tx) error {
+		var err error
+		pruned, err = dbTx.PruneBlocks(c.targetSize, canPrune, func(h *hash.Hash, blockBytes []byte) error {
+			block, err := types.NewBlockFromBytes(blockBytes)
+			if err != nil {
+				return err
+			}
+			err = dbPutPrunedBlock(dbTx, block)
+			if err != nil {
+				return err
+			}
+			return dbRemoveSpendJournalEntry(dbTx, h)
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if pruned > 0 {
+		log.Info(fmt.Sprintf("Pruned %d blocks before order %d", pruned, maxOrder))
+	}
+	return nil
+}
+
+// The pruned block is kept as the block without transactions and the number of
+// its transactions, which are enough to restore the DAG block node.
+func dbPutPrunedBlock(dbTx database.Tx, block *types.SerializedBlock) error {
+	bucket, err := dbTx.Metadata().CreateBucketIfNotExists(dbnamespace.PrunedBlockBucketName)
+	if err != nil {
+		return err
+	}
+	pb := &types.Block{Header: block.Block().Header, Parents: block.Block().Parents}
+	var buff bytes.Buffer
+	err = pb.Serialize(&buff)
+	if err != nil {
+		return err
+	}
+	err = s.WriteElements(&buff, uint32(len(block.Transactions())))
+	if err != nil {
+		return err
+	}
+	return bucket.Put(block.Hash()[:], buff.Bytes())
+}
+
+// dbFetchPrunedBlockNode returns the block node of pruned block, it is nil if
+// the block was not pruned.
+func dbFetchPrunedBlockNode(dbTx database.Tx, h *hash.Hash) (*BlockNode, error) {
+	bucket := dbTx.Metadata().Bucket(dbnamespace.PrunedBlockBucketName)
+	if bucket == nil {
+		return nil, nil
+	}
+	serialized := bucket.Get(h[:])
+	if serialized == nil {
+		return nil, nil
+	}
+	r := bytes.NewReader(serialized)
+	var pb types.Block
+	err := pb.Deserialize(r)
+	if err != nil {
+		return nil, err
+	}
+	var txNum uint32
+	err = s.ReadElements(r, &txNum)
+	if err != nil {
+		return nil, err
+	}
+	bn := NewBlockNode(types.NewBlock(&pb), pb.Parents)
+	bn.txNum = int(txNum)
+	return bn, nil
+}
+
+// fetchPrunedBlockNode returns the block node of pruned block from database.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) fetchPrunedBlockNode(h *hash.Hash) *BlockNode {
+	var bn *BlockNode
+	err := b.db.View(func(dbTx database.Tx) error {
+		var err error
+		bn, err = dbFetchPrunedBlockNode(dbTx, h)
+		return err
+	})
+	if err != nil {
+		log.Error(err.Error())
+		return nil
+	}
+	return bn
+}
+
+// IsPruned returns whether the old block bodies are deleted.
+func (b *BlockChain) IsPruned() bool {
+	return b.pruner.targetSize > 0
 }
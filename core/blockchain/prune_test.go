@@ -0,0 +1,208 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+
+package blockchain
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/dbnamespace"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/database"
+	"github.com/Qitmeer/qitmeer/params"
+	"strings"
+	"testing"
+)
+
+// testPruneDB deletes the blocks one by one instead of the block files, so the
+// pruning of chain can be tested without filling the block files of ffldb.
+type testPruneDB struct {
+	database.DB
+
+	// All the stored blocks from old to new.
+	blocks []*hash.Hash
+	pruned map[hash.Hash]bool
+}
+
+func (db *testPruneDB) View(fn func(tx database.Tx) error) error {
+	return db.DB.View(func(tx database.Tx) error {
+		return fn(&testPruneTx{Tx: tx, db: db})
+	})
+}
+
+func (db *testPruneDB) Update(fn func(tx database.Tx) error) error {
+	return db.DB.Update(func(tx database.Tx) error {
+		return fn(&testPruneTx{Tx: tx, db: db})
+	})
+}
+
+type testPruneTx struct {
+	database.Tx
+	db *testPruneDB
+}
+
+func (tx *testPruneTx) StoreBlock(block *types.SerializedBlock) error {
+	err := tx.Tx.StoreBlock(block)
+	if err != nil {
+		return err
+	}
+	tx.db.blocks = append(tx.db.blocks, block.Hash())
+	return nil
+}
+
+func (tx *testPruneTx) HasBlock(h *hash.Hash) (bool, error) {
+	if tx.db.pruned[*h] {
+		return false, nil
+	}
+	return tx.Tx.HasBlock(h)
+}
+
+func (tx *testPruneTx) FetchBlock(h *hash.Hash) ([]byte, error) {
+	if tx.db.pruned[*h] {
+		str := fmt.Sprintf("block %s was pruned", h)
+		return nil, database.Error{ErrorCode: database.ErrBlockNotFound, Description: str}
+	}
+	return tx.Tx.FetchBlock(h)
+}
+
+func (tx *testPruneTx) PruneBlocks(targetSize uint64, canPrune func(hash *hash.Hash) bool,
+	onPrune func(hash *hash.Hash, block []byte) error) (int, error) {
+	pruned := 0
+	for _, h := range tx.db.blocks {
+		if tx.db.pruned[*h] {
+			continue
+		}
+		if !canPrune(h) {
+			break
+		}
+		block, err := tx.Tx.FetchBlock(h)
+		if err != nil {
+			return pruned, err
+		}
+		err = onPrune(h, block)
+		if err != nil {
+			return pruned, err
+		}
+		tx.db.pruned[*h] = true
+		pruned++
+	}
+	return pruned, nil
+}
+
+func (tx *testPruneTx) BeenPruned() (bool, error) {
+	return len(tx.db.pruned) > 0, nil
+}
+
+// TestPruneBlocks ensures only the blocks deeper than the prune depth are
+// pruned, their headers are kept, and the pruned database can be restarted
+// only in prune mode.
+func TestPruneBlocks(t *testing.T) {
+	par := *params.PrivNetParam.Params
+	tc := newTestChain(t, "test_prune", &par)
+	defer tc.cleanup()
+
+	pdb := &testPruneDB{pruned: make(map[hash.Hash]bool)}
+	tc.wrapDB = func(db database.DB) database.DB {
+		pdb.DB = db
+		return pdb
+	}
+	pruneMode := func(config *Config) {
+		config.Prune = MinPruneSize
+	}
+	err := tc.restart(pruneMode)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The later blocks spend the coinbases of the earlier ones, so they
+	// have the spend journals.
+	blocks := tc.addBlocks(20)
+	for i := 0; i < 20; i++ {
+		outpoint := types.TxOutPoint{Hash: *blocks[i].Transactions()[0].Hash(), OutIndex: 0}
+		amount := blocks[i].Transactions()[0].Tx.TxOut[0].Amount.Value
+		spendTx := newTestSpendTx([]types.TxOutPoint{outpoint}, []int64{amount}, tc.payScript)
+		blocks = append(blocks, tc.addBlock(nil, spendTx))
+	}
+
+	depth := uint(10)
+	tc.bc.pruner.depth = depth
+	tc.bc.ChainLock()
+	err = tc.bc.pruner.pruneBlocks()
+	tc.bc.ChainUnlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	maxOrder := tc.bc.BlockDAG().GetMainChainTip().GetOrder() - depth
+	checkBlocks := func() {
+		for _, block := range blocks {
+			ib := tc.bc.BlockDAG().GetBlock(block.Hash())
+			if ib == nil {
+				t.Fatalf("block %s is not in DAG", block.Hash())
+			}
+			_, err := tc.bc.FetchBlockByHash(block.Hash())
+			var journal []byte
+			var pruned *BlockNode
+			err2 := tc.db.View(func(dbTx database.Tx) error {
+				journal = dbTx.Metadata().Bucket(dbnamespace.SpendJournalBucketName).Get(block.Hash()[:])
+				var err error
+				pruned, err = dbFetchPrunedBlockNode(dbTx, block.Hash())
+				return err
+			})
+			if err2 != nil {
+				t.Fatal(err2)
+			}
+			hasSpends := len(block.Transactions()) > 1
+			if ib.GetOrder() >= maxOrder {
+				if err != nil || pruned != nil {
+					t.Errorf("block %s (order:%d) is pruned: %v", block.Hash(), ib.GetOrder(), err)
+				}
+				if hasSpends && journal == nil {
+					t.Errorf("the spend journal of block %s (order:%d) is lost", block.Hash(), ib.GetOrder())
+				}
+				continue
+			}
+			if err == nil || journal != nil {
+				t.Errorf("block %s (order:%d) is not pruned", block.Hash(), ib.GetOrder())
+			}
+			if pruned == nil || *pruned.GetHash() != *block.Hash() ||
+				pruned.txNum != len(block.Transactions()) {
+				t.Errorf("the header of pruned block %s (order:%d) is lost", block.Hash(), ib.GetOrder())
+			}
+		}
+	}
+	checkBlocks()
+
+	// The pruned database can't be used without prune mode.
+	err = tc.restart(nil)
+	if err == nil || !strings.Contains(err.Error(), "prune mode") {
+		t.Fatalf("expected the pruned database error, got %v", err)
+	}
+
+	// The DAG is restored from the headers of pruned blocks.
+	mainTip := blocks[len(blocks)-1].Hash()
+	err = tc.restart(pruneMode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tc.bc.BlockDAG().GetMainChainTip().GetHash().IsEqual(mainTip) {
+		t.Fatalf("the main chain tip is %s after restart, but %s before it",
+			tc.bc.BlockDAG().GetMainChainTip().GetHash(), mainTip)
+	}
+	checkBlocks()
+	tc.addBlocks(1)
+}
+
+// TestPruneRequiresFinality ensures the prune mode is refused if the network
+// has no finality, since the pruned blocks could be reordered.
+func TestPruneRequiresFinality(t *testing.T) {
+	par := *params.PrivNetParam.Params
+	par.FinalityDepth = 0
+	tc := newTestChain(t, "test_prune_finality", &par)
+	defer tc.cleanup()
+
+	err := tc.restart(func(config *Config) {
+		config.Prune = MinPruneSize
+	})
+	if err == nil || !strings.Contains(err.Error(), "finality") {
+		t.Fatalf("expected the finality error, got %v", err)
+	}
+}
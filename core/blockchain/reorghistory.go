@@ -0,0 +1,200 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+package blockchain
+
+import (
+	"bytes"
+	"container/list"
+	"encoding/binary"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/common/roughtime"
+	"github.com/Qitmeer/qitmeer/core/blockdag"
+	"github.com/Qitmeer/qitmeer/core/dbnamespace"
+	"github.com/Qitmeer/qitmeer/core/json"
+	s "github.com/Qitmeer/qitmeer/core/serialization"
+	"github.com/Qitmeer/qitmeer/database"
+	"io"
+	"time"
+)
+
+// The max number of reorganization records which are kept in database, the
+// oldest one is removed when it is exceeded.
+const MaxReorgHistory = 10000
+
+// ReorgRecord is one reorganization of the DAG orders.
+type ReorgRecord struct {
+	ID             uint64
+	Time           int64
+	NewBlock       hash.Hash
+	OldMainTip     hash.Hash
+	NewMainTip     hash.Hash
+	DetachedOrders []uint64
+	AttachedOrders []uint64
+	DetachedTxs    uint64
+	AttachedTxs    uint64
+}
+
+func newReorgRecord(newBlock *hash.Hash, oldMainTip *hash.Hash, newMainTip *hash.Hash,
+	detachNodes *list.List, attachNodes *list.List) *ReorgRecord {
+	r := &ReorgRecord{
+		Time:           roughtime.Now().Unix(),
+		NewBlock:       *newBlock,
+		OldMainTip:     *oldMainTip,
+		NewMainTip:     *newMainTip,
+		DetachedOrders: []uint64{},
+		AttachedOrders: []uint64{},
+	}
+	for e := detachNodes.Front(); e != nil; e = e.Next() {
+		r.DetachedOrders = append(r.DetachedOrders, uint64(e.Value.(*blockdag.BlockOrderHelp).OldOrder))
+	}
+	for e := attachNodes.Front(); e != nil; e = e.Next() {
+		ib := e.Value.(blockdag.IBlock)
+		if ib.IsOrdered() {
+			r.AttachedOrders = append(r.AttachedOrders, uint64(ib.GetOrder()))
+		}
+	}
+	return r
+}
+
+func (r *ReorgRecord) Encode(w io.Writer) error {
+	err := s.WriteElements(w, r.Time, &r.NewBlock, &r.OldMainTip, &r.NewMainTip)
+	if err != nil {
+		return err
+	}
+	for _, orders := range [][]uint64{r.DetachedOrders, r.AttachedOrders} {
+		err = s.WriteElements(w, uint32(len(orders)))
+		if err != nil {
+			return err
+		}
+		for _, order := range orders {
+			err = s.WriteElements(w, order)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return s.WriteElements(w, r.DetachedTxs, r.AttachedTxs)
+}
+
+func (r *ReorgRecord) Decode(rd io.Reader) error {
+	err := s.ReadElements(rd, &r.Time, &r.NewBlock, &r.OldMainTip, &r.NewMainTip)
+	if err != nil {
+		return err
+	}
+	for _, orders := range []*[]uint64{&r.DetachedOrders, &r.AttachedOrders} {
+		var size uint32
+		err = s.ReadElements(rd, &size)
+		if err != nil {
+			return err
+		}
+		*orders = make([]uint64, size)
+		for i := uint32(0); i < size; i++ {
+			err = s.ReadElements(rd, &(*orders)[i])
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return s.ReadElements(rd, &r.DetachedTxs, &r.AttachedTxs)
+}
+
+func (r *ReorgRecord) Result() *json.ReorgRecordResult {
+	return &json.ReorgRecordResult{
+		ID:             r.ID,
+		Time:           time.Unix(r.Time, 0).String(),
+		NewBlock:       r.NewBlock.String(),
+		OldMainTip:     r.OldMainTip.String(),
+		NewMainTip:     r.NewMainTip.String(),
+		DetachedOrders: r.DetachedOrders,
+		AttachedOrders: r.AttachedOrders,
+		DetachedTxs:    r.DetachedTxs,
+		AttachedTxs:    r.AttachedTxs,
+	}
+}
+
+// The key is big endian, so the cursor can iterate the records by id.
+func reorgRecordKey(id uint64) []byte {
+	var key [8]byte
+	binary.BigEndian.PutUint64(key[:], id)
+	return key[:]
+}
+
+// dbPutReorgRecord assigns the next id to record and stores it, the records
+// exceed MaxReorgHistory are removed.
+func dbPutReorgRecord(dbTx database.Tx, r *ReorgRecord) error {
+	bucket, err := dbTx.Metadata().CreateBucketIfNotExists(dbnamespace.ReorgHistoryBucketName)
+	if err != nil {
+		return err
+	}
+	r.ID = 1
+	cursor := bucket.Cursor()
+	if cursor.Last() {
+		r.ID = binary.BigEndian.Uint64(cursor.Key()) + 1
+	}
+	var buff bytes.Buffer
+	err = r.Encode(&buff)
+	if err != nil {
+		return err
+	}
+	err = bucket.Put(reorgRecordKey(r.ID), buff.Bytes())
+	if err != nil {
+		return err
+	}
+	if r.ID > MaxReorgHistory {
+		return bucket.Delete(reorgRecordKey(r.ID - MaxReorgHistory))
+	}
+	return nil
+}
+
+// dbFetchReorgHistory returns the latest count records, the newest is first.
+func dbFetchReorgHistory(dbTx database.Tx, count int) ([]*ReorgRecord, error) {
+	result := []*ReorgRecord{}
+	bucket := dbTx.Metadata().Bucket(dbnamespace.ReorgHistoryBucketName)
+	if bucket == nil {
+		return result, nil
+	}
+	cursor := bucket.Cursor()
+	for ok := cursor.Last(); ok && len(result) < count; ok = cursor.Prev() {
+		r := &ReorgRecord{ID: binary.BigEndian.Uint64(cursor.Key())}
+		err := r.Decode(bytes.NewReader(cursor.Value()))
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, r)
+	}
+	return result, nil
+}
+
+// GetReorgHistory returns the latest count reorganizations, the newest is
+// first.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) GetReorgHistory(count int) ([]*ReorgRecord, error) {
+	if count <= 0 {
+		return nil, fmt.Errorf("The count must be positive")
+	}
+	var result []*ReorgRecord
+	err := b.db.View(func(dbTx database.Tx) error {
+		var err error
+		result, err = dbFetchReorgHistory(dbTx, count)
+		return err
+	})
+	return result, err
+}
+
+// recordReorg stores the reorganization and notifies it. The failure of
+// storing doesn't affect the reorganization.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) recordReorg(r *ReorgRecord) {
+	err := b.db.Update(func(dbTx database.Tx) error {
+		return dbPutReorgRecord(dbTx, r)
+	})
+	if err != nil {
+		log.Error(fmt.Sprintf("Failed to record reorganization:%v", err))
+		return
+	}
+	b.ChainUnlock()
+	b.sendNotification(ReorganizationRecorded, r)
+	b.ChainLock()
+}
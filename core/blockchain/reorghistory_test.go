@@ -0,0 +1,47 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+
+package blockchain
+
+import (
+	"bytes"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"reflect"
+	"testing"
+)
+
+// TestReorgRecordSerialization ensures the reorganization record can be
+// encoded and decoded without losing anything.
+func TestReorgRecordSerialization(t *testing.T) {
+	tests := []*ReorgRecord{
+		{
+			Time:           1577836800,
+			NewBlock:       hash.HashH([]byte("new")),
+			OldMainTip:     hash.HashH([]byte("old tip")),
+			NewMainTip:     hash.HashH([]byte("new tip")),
+			DetachedOrders: []uint64{5, 6, 7},
+			AttachedOrders: []uint64{5, 6, 7, 8},
+			DetachedTxs:    3,
+			AttachedTxs:    5,
+		},
+		{
+			Time:           1577836801,
+			DetachedOrders: []uint64{},
+			AttachedOrders: []uint64{},
+		},
+	}
+	for i, r := range tests {
+		var buff bytes.Buffer
+		err := r.Encode(&buff)
+		if err != nil {
+			t.Fatalf("test %d: encode error:%v", i, err)
+		}
+		decoded := &ReorgRecord{}
+		err = decoded.Decode(bytes.NewReader(buff.Bytes()))
+		if err != nil {
+			t.Fatalf("test %d: decode error:%v", i, err)
+		}
+		if !reflect.DeepEqual(r, decoded) {
+			t.Fatalf("test %d: mismatched record\ngot: %+v\nwant: %+v", i, decoded, r)
+		}
+	}
+}
@@ -0,0 +1,299 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+package blockchain
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/blockchain/stake"
+	"github.com/Qitmeer/qitmeer/core/blockdag"
+	"github.com/Qitmeer/qitmeer/core/dbnamespace"
+	"github.com/Qitmeer/qitmeer/core/serialization"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/database"
+	"github.com/Qitmeer/qitmeer/params"
+)
+
+// isStakeActive returns whether the stake transactions are valid and the
+// subsidy is reserved for the stake holders in the block after prevNode, the
+// networks which don't define the deployment never are.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) isStakeActive(prevNode blockdag.IBlock) (bool, error) {
+	if len(b.params.Deployments) <= params.DeploymentStake {
+		return false, nil
+	}
+	state, err := b.deploymentState(prevNode, params.DeploymentStake)
+	if err != nil {
+		return false, err
+	}
+	return state == ThresholdActive, nil
+}
+
+// IsStakeActive returns whether the DeploymentStake is active for the block
+// after prevNode, it is the main chain tip if prevNode is nil.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) IsStakeActive(prevNode blockdag.IBlock) (bool, error) {
+	b.ChainLock()
+	defer b.ChainUnlock()
+	if prevNode == nil {
+		prevNode = b.bd.GetMainChainTip()
+	}
+	return b.isStakeActive(prevNode)
+}
+
+// isStakeReserve returns whether the utxo is a stake_reserve output of
+// coinbase.
+func (b *BlockChain) isStakeReserve(entry *UtxoEntry) bool {
+	return entry.IsCoinBase() && len(b.params.StakeReservePkScript) > 0 &&
+		bytes.Equal(entry.PkScript(), b.params.StakeReservePkScript)
+}
+
+// CheckStakeTransaction checks the stake rules of the transaction once the
+// DeploymentStake is active. Only stake_purchase can create the tickets, the
+// tickets can only be spent by stakebase and stake_dispose after they are
+// mature, and the stake_reserve outputs can only be spent by stakebase.
+//
+// The inputs of the transaction must be in the utxo view, so it is called
+// after CheckTransactionInputs.
+func (b *BlockChain) CheckStakeTransaction(tx *types.Tx, utxoView *UtxoViewpoint) error {
+	msgTx := tx.Transaction()
+	if msgTx.IsCoinBase() {
+		return nil
+	}
+	isStakebase := types.IsStakebaseTx(msgTx)
+	isDispose := types.IsStakeDisposeTx(msgTx)
+	for idx, txIn := range msgTx.TxIn {
+		entry := utxoView.LookupEntry(txIn.PreviousOut)
+		if entry == nil || entry.IsSpent() {
+			str := fmt.Sprintf("output %v referenced from "+
+				"transaction %s:%d either does not exist or "+
+				"has already been spent", txIn.PreviousOut,
+				tx.Hash(), idx)
+			return ruleError(ErrMissingTxOut, str)
+		}
+		if stake.IsTicketScript(entry.PkScript()) && !entry.IsCoinBase() {
+			if idx != 0 || (!isStakebase && !isDispose) {
+				str := fmt.Sprintf("transaction %s:%d spends the ticket %v, "+
+					"but it is not a stakebase or stake dispose", tx.Hash(), idx, txIn.PreviousOut)
+				return ruleError(ErrBadStakeTx, str)
+			}
+			err := b.checkTicketMaturity(tx, entry, utxoView)
+			if err != nil {
+				return err
+			}
+		} else if idx == 0 && (isStakebase || isDispose) {
+			str := fmt.Sprintf("transaction %s spends %v which is not a ticket",
+				tx.Hash(), txIn.PreviousOut)
+			return ruleError(ErrBadStakeTx, str)
+		}
+		if b.isStakeReserve(entry) {
+			if idx != 1 || !isStakebase {
+				str := fmt.Sprintf("transaction %s:%d spends the stake reserve %v, "+
+					"but it is not a stakebase", tx.Hash(), idx, txIn.PreviousOut)
+				return ruleError(ErrBadStakeTx, str)
+			}
+		} else if idx == 1 && isStakebase {
+			str := fmt.Sprintf("stakebase %s spends %v which is not a stake reserve",
+				tx.Hash(), txIn.PreviousOut)
+			return ruleError(ErrBadStakeTx, str)
+		}
+	}
+
+	var err error
+	switch {
+	case types.IsStakePurchaseTx(msgTx):
+		err = stake.CheckStakePurchase(msgTx, b.params.StakeMinAmount)
+	case isStakebase:
+		ticket := utxoView.LookupEntry(msgTx.TxIn[0].PreviousOut)
+		reserve := utxoView.LookupEntry(msgTx.TxIn[1].PreviousOut)
+		err = stake.CheckStakebase(msgTx, types.NewTxOutput(ticket.Amount(), ticket.PkScript()),
+			types.NewTxOutput(reserve.Amount(), reserve.PkScript()), b.params.StakeReservePkScript)
+	case isDispose:
+		ticket := utxoView.LookupEntry(msgTx.TxIn[0].PreviousOut)
+		err = stake.CheckStakeDispose(msgTx, ticket.PkScript())
+	default:
+		for idx, txOut := range msgTx.TxOut {
+			if stake.IsTicketScript(txOut.PkScript) {
+				err = fmt.Errorf("The output %d of %s is a ticket, but it is not a stake purchase", idx, tx.Hash())
+				break
+			}
+		}
+	}
+	if err != nil {
+		return ruleError(ErrBadStakeTx, err.Error())
+	}
+	return nil
+}
+
+// checkTicketMaturity checks the block of ticket is blue and the ticket has
+// reached the StakeMaturity from the viewpoints of utxo view.
+func (b *BlockChain) checkTicketMaturity(tx *types.Tx, ticket *UtxoEntry, utxoView *UtxoViewpoint) error {
+	ib := b.bd.GetBlock(ticket.BlockHash())
+	if ib == nil {
+		str := fmt.Sprintf("utxoEntry blockhash error:%s", ticket.BlockHash())
+		return ruleError(ErrNoViewpoint, str)
+	}
+	viewpoints := []uint{}
+	for _, blockHash := range utxoView.viewpoints {
+		vIB := b.bd.GetBlock(blockHash)
+		if vIB != nil {
+			viewpoints = append(viewpoints, vIB.GetID())
+		}
+	}
+	if len(viewpoints) == 0 {
+		str := fmt.Sprintf("transaction %s has no viewpoints", tx.Hash())
+		return ruleError(ErrNoViewpoint, str)
+	}
+	err := b.bd.CheckBlueAndMatureMT([]uint{ib.GetID()}, viewpoints, uint(b.params.StakeMaturity))
+	if err != nil {
+		return ruleError(ErrImmatureSpend, err.Error())
+	}
+	return nil
+}
+
+// isTicket returns whether the utxo is a ticket, the coinbase outputs are
+// never tickets.
+func isTicket(entry *UtxoEntry) bool {
+	return !entry.IsCoinBase() && stake.IsTicketScript(entry.PkScript())
+}
+
+// dbUpdateTicketIndex keeps the ticket index the same as the utxo set when the
+// utxo entry of key is stored or removed, nothing is done before the index
+// is built. The spent entry may have lost its script in the cache, so its key
+// is removed from the index anyway.
+func dbUpdateTicketIndex(dbTx database.Tx, key []byte, entry *UtxoEntry) error {
+	bucket := dbTx.Metadata().Bucket(dbnamespace.TicketBucketName)
+	if bucket == nil {
+		return nil
+	}
+	if entry.IsSpent() {
+		return bucket.Delete(key)
+	}
+	if !isTicket(entry) {
+		return nil
+	}
+	return bucket.Put(key, []byte{})
+}
+
+// buildTicketIndex builds the ticket index from the whole utxo set when it is
+// missing or rebuild is true.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) buildTicketIndex(rebuild bool) error {
+	var exists bool
+	err := b.db.View(func(dbTx database.Tx) error {
+		exists = dbTx.Metadata().Bucket(dbnamespace.TicketBucketName) != nil
+		return nil
+	})
+	if err != nil || (exists && !rebuild) {
+		return err
+	}
+	err = b.utxoCache.flush()
+	if err != nil {
+		return err
+	}
+	log.Info("Building the ticket index...")
+	return b.db.Update(func(dbTx database.Tx) error {
+		meta := dbTx.Metadata()
+		if meta.Bucket(dbnamespace.TicketBucketName) != nil {
+			err := meta.DeleteBucket(dbnamespace.TicketBucketName)
+			if err != nil {
+				return err
+			}
+		}
+		bucket, err := meta.CreateBucket(dbnamespace.TicketBucketName)
+		if err != nil {
+			return err
+		}
+		cursor := meta.Bucket(dbnamespace.UtxoSetBucketName).Cursor()
+		for ok := cursor.First(); ok; ok = cursor.Next() {
+			entry, err := DeserializeUtxoEntry(cursor.Value())
+			if err != nil {
+				return err
+			}
+			if !isTicket(entry) {
+				continue
+			}
+			err = bucket.Put(cursor.Key(), []byte{})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Ticket is a ticket in the utxo set.
+type Ticket struct {
+	OutPoint  types.TxOutPoint
+	Amount    int64
+	PkScript  []byte
+	BlockHash hash.Hash
+
+	// Whether the ticket can be rewarded or disposed after the main chain
+	// tip.
+	Mature bool
+}
+
+// FetchTickets returns the tickets which are not spent at the main chain tip
+// from the ticket index.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) FetchTickets() ([]*Ticket, error) {
+	b.ChainRLock()
+	defer b.ChainRUnlock()
+
+	err := b.utxoCache.flush()
+	if err != nil {
+		return nil, err
+	}
+	tickets := []*Ticket{}
+	err = b.db.View(func(dbTx database.Tx) error {
+		meta := dbTx.Metadata()
+		utxoBucket := meta.Bucket(dbnamespace.UtxoSetBucketName)
+		cursor := meta.Bucket(dbnamespace.TicketBucketName).Cursor()
+		for ok := cursor.First(); ok; ok = cursor.Next() {
+			key := cursor.Key()
+			if len(key) <= hash.HashSize {
+				return database.Error{
+					ErrorCode:   database.ErrCorruption,
+					Description: fmt.Sprintf("corrupt ticket key %x", key),
+				}
+			}
+			serialized := utxoBucket.Get(key)
+			if serialized == nil {
+				return AssertError(fmt.Sprintf("the ticket %x is not in the utxo set", key))
+			}
+			entry, err := DeserializeUtxoEntry(serialized)
+			if err != nil {
+				return err
+			}
+			ticket := &Ticket{
+				Amount:    entry.Amount().Value,
+				PkScript:  entry.PkScript(),
+				BlockHash: *entry.BlockHash(),
+			}
+			copy(ticket.OutPoint.Hash[:], key[:hash.HashSize])
+			index, _ := serialization.DeserializeVLQ(key[hash.HashSize:])
+			ticket.OutPoint.OutIndex = uint32(index)
+			tickets = append(tickets, ticket)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	mainTip := b.bd.GetMainChainTip()
+	for _, ticket := range tickets {
+		ib := b.bd.GetBlock(&ticket.BlockHash)
+		if ib == nil {
+			continue
+		}
+		err := b.bd.CheckBlueAndMatureMT([]uint{ib.GetID()}, []uint{mainTip.GetID()}, uint(b.params.StakeMaturity))
+		ticket.Mature = err == nil
+	}
+	return tickets, nil
+}
@@ -3,3 +3,134 @@
 // license that can be found in the LICENSE file.
 
 package stake
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/engine/txscript"
+)
+
+// The stake transactions are tagged by the sequence of the first input, see
+// core/types/txtype.go. Their layouts are:
+//
+// stake_purchase
+//   TxIn[0:]   the regular outputs to lock
+//   TxOut[0]   OP_SSTX tagged P2PKH or P2SH of the stake holder, it is the
+//              ticket which locks the value into the stake pool
+//   TxOut[1:]  the changes which are not tagged by the stake opcodes
+//
+// stakebase
+//   TxIn[0]    the mature ticket
+//   TxIn[1]    a stake_reserve output of coinbase
+//   TxOut[0]   OP_SSGEN tagged script of the stake holder, it pays exactly
+//              the value of ticket and the reward, so stakebase has no fee
+//
+// The reward of a ticket is the whole stake_reserve output which its stakebase
+// spends, every stake_reserve output rewards one ticket.
+//
+// stake_dispose
+//   TxIn[0]    the mature ticket
+//   TxOut[0]   the script of the stake holder, it releases the value of ticket
+//
+// The ticket and the stake_reserve outputs can't be spent by any other
+// transactions.
+
+// IsTicketScript returns whether the output script is a ticket.
+func IsTicketScript(pkScript []byte) bool {
+	return txscript.GetScriptClass(txscript.DefaultScriptVersion, pkScript) == txscript.StakeSubmissionTy
+}
+
+// TicketOwnerScript returns the script of the stake holder of the ticket, it
+// is the ticket script without the OP_SSTX tag.
+func TicketOwnerScript(pkScript []byte) []byte {
+	if !IsTicketScript(pkScript) {
+		return nil
+	}
+	return pkScript[1:]
+}
+
+// CheckStakePurchase checks the outputs of a stake_purchase transaction, the
+// ticket must lock no less than minAmount MEER.
+func CheckStakePurchase(tx *types.Transaction, minAmount int64) error {
+	if !types.IsStakePurchaseTx(tx) {
+		return fmt.Errorf("%s is not a stake purchase transaction", tx.TxHash())
+	}
+	ticket := tx.TxOut[0]
+	if !IsTicketScript(ticket.PkScript) {
+		return fmt.Errorf("The first output of stake purchase %s is not a ticket", tx.TxHash())
+	}
+	if ticket.Amount.Id != types.MEERID {
+		return fmt.Errorf("The ticket of %s must be %s, but it is %s", tx.TxHash(), types.MEERID.Name(), ticket.Amount.Id.Name())
+	}
+	if ticket.Amount.Value < minAmount {
+		return fmt.Errorf("The ticket of %s locks %d which is less than the minimum %d", tx.TxHash(), ticket.Amount.Value, minAmount)
+	}
+	for i, txOut := range tx.TxOut[1:] {
+		has, err := txscript.ContainsStakeOpCodes(txOut.PkScript)
+		if err != nil {
+			return err
+		}
+		if has {
+			return fmt.Errorf("The change output %d of stake purchase %s is tagged by the stake opcodes", i+1, tx.TxHash())
+		}
+	}
+	return nil
+}
+
+// TicketReward returns the reward of a ticket from the stake_reserve output.
+func TicketReward(reserve *types.TxOutput) int64 {
+	return reserve.Amount.Value
+}
+
+// CheckStakebase checks the stakebase transaction against the ticket and the
+// stake_reserve output it spends, all of its outputs must pay the ticket and
+// the reward to the stake holder.
+func CheckStakebase(tx *types.Transaction, ticket *types.TxOutput, reserve *types.TxOutput, stakeReservePkScript []byte) error {
+	if !types.IsStakebaseTx(tx) {
+		return fmt.Errorf("%s is not a stakebase transaction", tx.TxHash())
+	}
+	owner := TicketOwnerScript(ticket.PkScript)
+	if owner == nil {
+		return fmt.Errorf("The first input of stakebase %s is not a ticket", tx.TxHash())
+	}
+	if len(stakeReservePkScript) == 0 || !bytes.Equal(reserve.PkScript, stakeReservePkScript) ||
+		reserve.Amount.Id != types.MEERID {
+		return fmt.Errorf("The second input of stakebase %s is not a stake reserve output", tx.TxHash())
+	}
+	amount := ticket.Amount.Value + TicketReward(reserve)
+	for i, txOut := range tx.TxOut {
+		pkScript := txOut.PkScript
+		if txscript.GetScriptClass(txscript.DefaultScriptVersion, pkScript) != txscript.StakeGenTy ||
+			!bytes.Equal(pkScript[1:], owner) {
+			return fmt.Errorf("The output %d of stakebase %s doesn't pay to the stake holder", i, tx.TxHash())
+		}
+		if txOut.Amount.Id != types.MEERID {
+			return fmt.Errorf("The output %d of stakebase %s must be %s", i, tx.TxHash(), types.MEERID.Name())
+		}
+		if txOut.Amount.Value != amount {
+			return fmt.Errorf("The output %d of stakebase %s pays %d, but the ticket and its reward are %d",
+				i, tx.TxHash(), txOut.Amount.Value, amount)
+		}
+	}
+	return nil
+}
+
+// CheckStakeDispose checks the stake_dispose transaction against the ticket
+// script it spends.
+func CheckStakeDispose(tx *types.Transaction, ticketScript []byte) error {
+	if !types.IsStakeDisposeTx(tx) {
+		return fmt.Errorf("%s is not a stake dispose transaction", tx.TxHash())
+	}
+	owner := TicketOwnerScript(ticketScript)
+	if owner == nil {
+		return fmt.Errorf("The input of stake dispose %s is not a ticket", tx.TxHash())
+	}
+	if !bytes.Equal(tx.TxOut[0].PkScript, owner) {
+		return fmt.Errorf("The output of stake dispose %s doesn't pay to the stake holder", tx.TxHash())
+	}
+	if tx.TxOut[0].Amount.Id != types.MEERID {
+		return fmt.Errorf("The output of stake dispose %s must be %s", tx.TxHash(), types.MEERID.Name())
+	}
+	return nil
+}
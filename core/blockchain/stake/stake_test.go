@@ -0,0 +1,117 @@
+// Copyright (c) 2021 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stake
+
+import (
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/address"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/crypto/ecc"
+	"github.com/Qitmeer/qitmeer/engine/txscript"
+	"github.com/Qitmeer/qitmeer/params"
+	"testing"
+)
+
+func newStakeTx(txType types.TxType, numIn int) *types.Transaction {
+	tx := types.NewTransaction()
+	for i := 0; i < numIn; i++ {
+		prevOut := types.NewOutPoint(&hash.Hash{byte(i + 1)}, uint32(i))
+		tx.AddTxIn(types.NewTxInput(prevOut, nil))
+	}
+	tx.TxIn[0].Sequence = types.TaggedSequence(txType)
+	return tx
+}
+
+func TestStakeTransactions(t *testing.T) {
+	addr, err := address.NewPubKeyHashAddress(make([]byte, 20), &params.PrivNetParams, ecc.ECDSA_Secp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ownerScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ticketScript, err := txscript.PayToSStx(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	genScript, err := txscript.PayToSSGen(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reserveScript := []byte{txscript.OP_SSGEN, txscript.OP_TRUE}
+	meer := func(v int64) types.Amount { return types.Amount{Value: v, Id: types.MEERID} }
+
+	if !IsTicketScript(ticketScript) || IsTicketScript(ownerScript) {
+		t.Fatal("The ticket script is not recognized")
+	}
+
+	// The sequence which is a relative lock time is not a stake tag.
+	locked := newStakeTx(types.TxTypeStakePurchase, 1)
+	locked.TxIn[0].Sequence = uint32(types.TxTypeStakePurchase)
+	locked.AddTxOut(types.NewTxOutput(meer(100), ticketScript))
+	if types.DetermineTxType(locked) != types.TxTypeRegular {
+		t.Fatalf("The transaction with the relative lock time is %s", types.DetermineTxType(locked))
+	}
+
+	// stake_purchase
+	purchase := newStakeTx(types.TxTypeStakePurchase, 1)
+	purchase.AddTxOut(types.NewTxOutput(meer(100), ticketScript))
+	purchase.AddTxOut(types.NewTxOutput(meer(10), ownerScript))
+	if types.DetermineTxType(purchase) != types.TxTypeStakePurchase {
+		t.Fatalf("The type of stake purchase is %s", types.DetermineTxType(purchase))
+	}
+	if err := CheckStakePurchase(purchase, 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckStakePurchase(purchase, 101); err == nil {
+		t.Fatal("The ticket less than the minimum amount is accepted")
+	}
+	purchase.TxOut[1].PkScript = genScript
+	if err := CheckStakePurchase(purchase, 100); err == nil {
+		t.Fatal("The change tagged by the stake opcodes is accepted")
+	}
+
+	// stakebase
+	ticket := types.NewTxOutput(meer(100), ticketScript)
+	reserve := types.NewTxOutput(meer(10), reserveScript)
+	stakebase := newStakeTx(types.TxTypeStakebase, 2)
+	stakebase.AddTxOut(types.NewTxOutput(meer(110), genScript))
+	if types.DetermineTxType(stakebase) != types.TxTypeStakebase {
+		t.Fatalf("The type of stakebase is %s", types.DetermineTxType(stakebase))
+	}
+	if err := CheckStakebase(stakebase, ticket, reserve, reserveScript); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckStakebase(stakebase, ticket, types.NewTxOutput(meer(10), ownerScript), reserveScript); err == nil {
+		t.Fatal("The stakebase without the stake reserve is accepted")
+	}
+	stakebase.TxOut[0].Amount = meer(109)
+	if err := CheckStakebase(stakebase, ticket, reserve, reserveScript); err == nil {
+		t.Fatal("The stakebase which leaves the reward as the fee is accepted")
+	}
+	stakebase.TxOut[0].Amount = meer(111)
+	if err := CheckStakebase(stakebase, ticket, reserve, reserveScript); err == nil {
+		t.Fatal("The stakebase which pays more than the reward is accepted")
+	}
+	stakebase.TxOut[0].Amount = meer(110)
+	stakebase.TxOut[0].PkScript = ownerScript
+	if err := CheckStakebase(stakebase, ticket, reserve, reserveScript); err == nil {
+		t.Fatal("The stakebase output without OP_SSGEN is accepted")
+	}
+
+	// stake_dispose
+	dispose := newStakeTx(types.TxTypeStakeDispose, 1)
+	dispose.AddTxOut(types.NewTxOutput(meer(100), ownerScript))
+	if types.DetermineTxType(dispose) != types.TxTypeStakeDispose {
+		t.Fatalf("The type of stake dispose is %s", types.DetermineTxType(dispose))
+	}
+	if err := CheckStakeDispose(dispose, ticketScript); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckStakeDispose(dispose, ownerScript); err == nil {
+		t.Fatal("The stake dispose without the ticket is accepted")
+	}
+}
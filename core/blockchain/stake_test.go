@@ -0,0 +1,134 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+
+package blockchain
+
+import (
+	"github.com/Qitmeer/qitmeer/core/address"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/engine/txscript"
+	"github.com/Qitmeer/qitmeer/params"
+	"testing"
+)
+
+// TestStake ensures the stake share of subsidy is paid to the miner until the
+// stake is active, the tickets are indexed, and the stakebase must pay exactly
+// the ticket and its reward.
+func TestStake(t *testing.T) {
+	par := *params.PrivNetParam.Params
+	tc := newTestChain(t, "test_stake", &par)
+	defer tc.cleanup()
+
+	blocks := tc.addBlocks(1)
+	coinbase := blocks[0].Transactions()[0].Tx
+	subsidy := tc.bc.subsidyCache.CalcBlockSubsidy(tc.bc.bd.GetBlueInfo(tc.bc.bd.GetBlock(par.GenesisHash)))
+	if coinbase.TxOut[0].Amount.Value != subsidy {
+		t.Fatalf("the miner is paid %d before the stake is active, but the subsidy is %d",
+			coinbase.TxOut[0].Amount.Value, subsidy)
+	}
+	for i := 0; ; i++ {
+		active, err := tc.bc.IsStakeActive(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if active {
+			break
+		}
+		if i >= 100 {
+			t.Fatal("the stake is not active")
+		}
+		blocks = append(blocks, tc.addBlock(nil))
+	}
+	reserveBlock := tc.addBlock(nil)
+	reserveOut := reserveBlock.Transactions()[0].Tx.TxOut[1]
+	if reserveOut.Amount.Value == 0 || string(reserveOut.PkScript) != string(par.StakeReservePkScript) {
+		t.Fatalf("the stake subsidy is not reserved: %v", reserveOut)
+	}
+
+	// The ticket is paid to the script hash of OP_TRUE.
+	redeemScript := []byte{txscript.OP_TRUE}
+	holder, err := address.NewScriptHashAddress(redeemScript, &par)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ticketScript, err := txscript.PayToSStx(holder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	genScript, err := txscript.PayToSSGen(holder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	redeemSigScript, err := txscript.NewScriptBuilder().AddData(redeemScript).Script()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	coinbaseOut := types.TxOutPoint{Hash: *blocks[0].Transactions()[0].Hash(), OutIndex: 0}
+	purchase := newTestSpendTx([]types.TxOutPoint{coinbaseOut}, []int64{subsidy}, ticketScript)
+	purchase.Tx.TxIn[0].Sequence = types.TaggedSequence(types.TxTypeStakePurchase)
+	purchase = types.NewTx(purchase.Tx)
+	tc.addBlock(nil, purchase)
+
+	checkTickets := func(mature bool) {
+		tickets, err := tc.bc.FetchTickets()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tickets) != 1 || tickets[0].OutPoint.Hash != *purchase.Hash() ||
+			tickets[0].Amount != subsidy || tickets[0].Mature != mature {
+			t.Fatalf("the tickets are %+v, but %s is expected", tickets, purchase.Hash())
+		}
+	}
+	checkTickets(false)
+
+	// The ticket index is kept after restart.
+	err = tc.restart(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTickets(false)
+	tc.addBlocks(int(par.StakeMaturity))
+	checkTickets(true)
+
+	newStakebase := func(amount int64) *types.Tx {
+		tx := types.NewTransaction()
+		ticketIn := types.NewTxInput(types.NewOutPoint(purchase.Hash(), 0), redeemSigScript)
+		ticketIn.Sequence = types.TaggedSequence(types.TxTypeStakebase)
+		tx.AddTxIn(ticketIn)
+		tx.AddTxIn(types.NewTxInput(types.NewOutPoint(reserveBlock.Transactions()[0].Hash(), 1), nil))
+		tx.AddTxOut(types.NewTxOutput(types.Amount{Value: amount, Id: types.MEERID}, genScript))
+		return types.NewTx(tx)
+	}
+
+	// The reward can't be left to the miner as the fee, the block is kept in
+	// DAG but known invalid.
+	reward := subsidy + reserveOut.Amount.Value
+	block := tc.newBlock(nil, newStakebase(reward-1))
+	err = tc.bc.CheckConnectBlockTemplate(block)
+	if err == nil {
+		t.Fatal("the stakebase which leaves the reward as the fee is accepted")
+	}
+	tc.addBlock(nil, newStakebase(reward-1))
+	if !tc.bc.bd.GetMainChainTip().GetStatus().KnownInvalid() {
+		t.Fatal("the block of stakebase which leaves the reward as the fee is valid")
+	}
+	tickets, err := tc.bc.FetchTickets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tickets) != 1 {
+		t.Fatalf("the ticket is spent by the invalid block: %+v", tickets)
+	}
+
+	tc.addBlock(nil, newStakebase(reward))
+	if tc.bc.bd.GetMainChainTip().GetStatus().KnownInvalid() {
+		t.Fatal("the block of stakebase is invalid")
+	}
+	tickets, err = tc.bc.FetchTickets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tickets) != 0 {
+		t.Fatalf("the rewarded ticket is still indexed: %+v", tickets)
+	}
+}
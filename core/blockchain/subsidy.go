@@ -134,23 +134,39 @@ func (s *SubsidyCache) GetMode() string {
 
 // CalcBlockWorkSubsidy calculates the proof of work subsidy for a block as a
 // proportion of the total subsidy. (aka, the coinbase subsidy)
-func CalcBlockWorkSubsidy(subsidyCache *SubsidyCache, bi *blockdag.BlueInfo, params *params.Params) uint64 {
-	work, _, _ := calcBlockProportion(subsidyCache, bi, params)
+// The stake proportion is not counted until the stake is active.
+func CalcBlockWorkSubsidy(subsidyCache *SubsidyCache, bi *blockdag.BlueInfo, params *params.Params, stakeActive bool) uint64 {
+	work, _, _ := calcBlockProportion(subsidyCache, bi, params, stakeActive)
 	return work
 }
 
 // CalcBlockTaxSubsidy calculates the subsidy for the organization address in the
 // coinbase.
-func CalcBlockTaxSubsidy(subsidyCache *SubsidyCache, bi *blockdag.BlueInfo, params *params.Params) uint64 {
-	_, _, tax := calcBlockProportion(subsidyCache, bi, params)
+// The stake proportion is not counted until the stake is active.
+func CalcBlockTaxSubsidy(subsidyCache *SubsidyCache, bi *blockdag.BlueInfo, params *params.Params, stakeActive bool) uint64 {
+	_, _, tax := calcBlockProportion(subsidyCache, bi, params, stakeActive)
 	return tax
 }
 
-func calcBlockProportion(subsidyCache *SubsidyCache, bi *blockdag.BlueInfo, params *params.Params) (uint64, uint64, uint64) {
+// CalcBlockStakeSubsidy calculates the subsidy reserved for the stake holders
+// in the coinbase once the stake is active.
+func CalcBlockStakeSubsidy(subsidyCache *SubsidyCache, bi *blockdag.BlueInfo, params *params.Params) uint64 {
+	_, stake, _ := calcBlockProportion(subsidyCache, bi, params, true)
+	return stake
+}
+
+// calcBlockProportion returns the work, stake and tax subsidy, the subsidy is
+// the same as the network without the stake proportion until the stake is
+// active.
+func calcBlockProportion(subsidyCache *SubsidyCache, bi *blockdag.BlueInfo, params *params.Params, stakeActive bool) (uint64, uint64, uint64) {
 	subsidy := uint64(subsidyCache.CalcBlockSubsidy(bi))
 	workPro := float64(params.WorkRewardProportion)
 	stakePro := float64(params.StakeRewardProportion)
 	proportions := float64(params.TotalSubsidyProportions())
+	if !stakeActive {
+		proportions -= stakePro
+		stakePro = 0
+	}
 
 	work := uint64(workPro / proportions * float64(subsidy))
 	stake := uint64(stakePro / proportions * float64(subsidy))
@@ -366,7 +366,8 @@ func NewUpdateFromTx(tx *types.Transaction) (ITokenUpdate, error) {
 	} else if types.IsTokenNewTx(tx) ||
 		types.IsTokenRenewTx(tx) ||
 		types.IsTokenValidateTx(tx) ||
-		types.IsTokenInvalidateTx(tx) {
+		types.IsTokenInvalidateTx(tx) ||
+		types.IsTokenRevokeTx(tx) {
 		return NewTypeUpdateFromTx(tx)
 	}
 	return nil, fmt.Errorf("Not supported:%s\n", types.DetermineTxType(tx))
@@ -0,0 +1,138 @@
+/*
+ * Copyright (c) 2017-2020 The qitmeer developers
+ */
+
+package token
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/core/serialization"
+	"github.com/Qitmeer/qitmeer/engine/txscript"
+)
+
+const (
+	MaxTokenDecimals          = 18
+	MaxTokenLongNameLength    = 32
+	MaxTokenDescriptionLength = 128
+	MaxTokenIssuerLength      = 64
+)
+
+// TokenMetadata is the extended metadata of token which tells the wallets how
+// to display it. It is set by the optional second output of token new and
+// token renew, which is an OP_RETURN push of the serialized metadata.
+type TokenMetadata struct {
+	Decimals uint8
+	LongName string
+
+	// The hash or URL of the token description.
+	Description string
+
+	// The contact of the token issuer.
+	Issuer string
+}
+
+func (tm *TokenMetadata) SerializeSize() int {
+	serializeSize := serialization.SerializeSizeVLQ(uint64(tm.Decimals))
+	serializeSize += serialization.SerializeSizeVLQ(uint64(len(tm.LongName)))
+	serializeSize += len(tm.LongName)
+	serializeSize += serialization.SerializeSizeVLQ(uint64(len(tm.Description)))
+	serializeSize += len(tm.Description)
+	serializeSize += serialization.SerializeSizeVLQ(uint64(len(tm.Issuer)))
+	serializeSize += len(tm.Issuer)
+	return serializeSize
+}
+
+// PutSerialized serializes the metadata into target, which must be at least
+// SerializeSize bytes, and returns the number of bytes written.
+func (tm *TokenMetadata) PutSerialized(target []byte) int {
+	offset := serialization.PutVLQ(target, uint64(tm.Decimals))
+	for _, field := range []string{tm.LongName, tm.Description, tm.Issuer} {
+		offset += serialization.PutVLQ(target[offset:], uint64(len(field)))
+		copy(target[offset:offset+len(field)], field)
+		offset += len(field)
+	}
+	return offset
+}
+
+func (tm *TokenMetadata) Serialize() ([]byte, error) {
+	serialized := make([]byte, tm.SerializeSize())
+	tm.PutSerialized(serialized)
+	return serialized, nil
+}
+
+func (tm *TokenMetadata) Deserialize(data []byte) (int, error) {
+	decimals, offset := serialization.DeserializeVLQ(data)
+	if offset == 0 {
+		return offset, fmt.Errorf("unexpected end of data while reading decimals")
+	}
+	if decimals > MaxTokenDecimals {
+		return offset, fmt.Errorf("Token decimals (%d) exceeds the maximum (%d).\n", decimals, MaxTokenDecimals)
+	}
+	fields := make([]string, 3)
+	for i := range fields {
+		length, bytesRead := serialization.DeserializeVLQ(data[offset:])
+		if bytesRead == 0 {
+			return offset, fmt.Errorf("unexpected end of data while reading metadata field %d", i)
+		}
+		offset += bytesRead
+		if uint64(len(data[offset:])) < length {
+			return offset, fmt.Errorf("unexpected end of data while reading metadata field %d", i)
+		}
+		fields[i] = string(data[offset : offset+int(length)])
+		offset += int(length)
+	}
+	tm.Decimals = uint8(decimals)
+	tm.LongName = fields[0]
+	tm.Description = fields[1]
+	tm.Issuer = fields[2]
+	return offset, nil
+}
+
+func (tm *TokenMetadata) CheckSanity() error {
+	if tm.Decimals > MaxTokenDecimals {
+		return fmt.Errorf("Token decimals (%d) exceeds the maximum (%d).\n", tm.Decimals, MaxTokenDecimals)
+	}
+	if len(tm.LongName) > MaxTokenLongNameLength {
+		return fmt.Errorf("Token long name (%s) exceeds the maximum length (%d).\n", tm.LongName, MaxTokenLongNameLength)
+	}
+	if len(tm.Description) > MaxTokenDescriptionLength {
+		return fmt.Errorf("Token description exceeds the maximum length (%d).\n", MaxTokenDescriptionLength)
+	}
+	if len(tm.Issuer) > MaxTokenIssuerLength {
+		return fmt.Errorf("Token issuer exceeds the maximum length (%d).\n", MaxTokenIssuerLength)
+	}
+	return nil
+}
+
+// Script returns the OP_RETURN output script which carries the metadata.
+func (tm *TokenMetadata) Script() ([]byte, error) {
+	serialized, err := tm.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	return txscript.GenerateProvablyPruneableOut(serialized)
+}
+
+// NewTokenMetadataFromScript returns the metadata carried by the OP_RETURN
+// output script of token new or token renew.
+func NewTokenMetadataFromScript(pkScript []byte) (*TokenMetadata, error) {
+	if txscript.GetScriptClass(txscript.DefaultScriptVersion, pkScript) != txscript.NullDataTy {
+		return nil, fmt.Errorf("Token metadata must be an OP_RETURN output\n")
+	}
+	pushes, err := txscript.PushedData(pkScript)
+	if err != nil {
+		return nil, err
+	}
+	if len(pushes) != 1 {
+		return nil, fmt.Errorf("Token metadata must be a single push\n")
+	}
+	tm := &TokenMetadata{}
+	bytesRead, err := tm.Deserialize(pushes[0])
+	if err != nil {
+		return nil, err
+	}
+	if bytesRead != len(pushes[0]) {
+		return nil, fmt.Errorf("Token metadata has %d trailing bytes\n", len(pushes[0])-bytesRead)
+	}
+	return tm, nil
+}
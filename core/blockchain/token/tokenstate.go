@@ -176,12 +176,24 @@ func (ts *TokenState) Deserialize(data []byte) (int, error) {
 func (ts *TokenState) Update() error {
 	for _, tu := range ts.Updates {
 		if bu, ok := tu.(*BalanceUpdate); ok {
+			if tt, ok := ts.Types[bu.TokenAmount.Id]; ok && tt.Revoked {
+				return fmt.Errorf("It was revoked: Coin id (%d)\n", bu.TokenAmount.Id)
+			}
 			err := ts.Balances.Update(bu)
 			if err != nil {
 				return err
 			}
 		}
 		if tu, ok := tu.(*TypeUpdate); ok {
+			if tu.Typ == types.TxTypeTokenRevoke {
+				// The locked MEER is released by revoke, so all the
+				// token amount must have been unminted.
+				tb := ts.Balances[tu.Tt.Id]
+				if tb.Balance != 0 {
+					return fmt.Errorf("Revoke is allowed only when no token amount: Coin id (%d) balance (%d)\n", tu.Tt.Id, tb.Balance)
+				}
+				delete(ts.Balances, tu.Tt.Id)
+			}
 			err := ts.Types.Update(tu)
 			if err != nil {
 				return err
@@ -195,7 +207,11 @@ func (ts *TokenState) Commit() error {
 	types.CoinNameMap = map[types.CoinID]string{}
 	types.CoinIDList = []types.CoinID{}
 	for _, v := range ts.Types {
-		types.CoinIDList = append(types.CoinIDList, v.Id)
+		// The name of revoked token is still known, but it can't be
+		// used by the transactions any more.
+		if !v.Revoked {
+			types.CoinIDList = append(types.CoinIDList, v.Id)
+		}
 		types.CoinNameMap[v.Id] = v.Name
 	}
 	return nil
@@ -11,6 +11,7 @@ import (
 	"github.com/Qitmeer/qitmeer/core/types"
 	"github.com/Qitmeer/qitmeer/database"
 	_ "github.com/Qitmeer/qitmeer/database/ffldb"
+	"github.com/Qitmeer/qitmeer/engine/txscript"
 	"github.com/Qitmeer/qitmeer/params"
 	"io/ioutil"
 	"os"
@@ -169,3 +170,202 @@ func Test_FeeConfigs(t *testing.T) {
 		t.Fatalf("txFees:%v Expect:%v", test.txFees, test.expect)
 	}
 }
+
+func TestTokenStateRevoke(t *testing.T) {
+	ts := &TokenState{
+		Types: TokenTypesMap{
+			QITID: TokenType{Id: QITID, Name: "QIT", UpLimit: 100 * 1e8},
+		},
+		Balances: TokenBalancesMap{
+			QITID: TokenBalance{Balance: 0, LockedMeer: 10},
+		},
+		Updates: []ITokenUpdate{&TypeUpdate{
+			TokenUpdate: &TokenUpdate{Typ: types.TxTypeTokenRevoke},
+			Tt:          TokenType{Id: QITID},
+		}},
+	}
+	err := ts.Update()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ts.Types[QITID].Revoked || ts.Types[QITID].Enable {
+		t.Fatalf("token %v is not revoked", ts.Types[QITID])
+	}
+	if _, ok := ts.Balances[QITID]; ok {
+		t.Fatalf("the locked meer of token is not released")
+	}
+
+	// The revoked state is kept by serialization.
+	serialized, err := ts.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	deserialized := &TokenState{}
+	_, err = deserialized.Deserialize(serialized)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !deserialized.Types[QITID].Revoked || deserialized.Types[QITID].Enable {
+		t.Fatalf("token %v is not revoked after deserialization", deserialized.Types[QITID])
+	}
+
+	// The revoked token can't be revoked, validated or minted again.
+	for _, update := range []ITokenUpdate{
+		&TypeUpdate{TokenUpdate: &TokenUpdate{Typ: types.TxTypeTokenRevoke}, Tt: TokenType{Id: QITID}},
+		&TypeUpdate{TokenUpdate: &TokenUpdate{Typ: types.TxTypeTokenValidate}, Tt: TokenType{Id: QITID}},
+		&BalanceUpdate{
+			TokenUpdate: &TokenUpdate{Typ: types.TxTypeTokenMint},
+			MeerAmount:  100,
+			TokenAmount: types.Amount{Value: 100, Id: QITID},
+		},
+	} {
+		deserialized.Updates = []ITokenUpdate{update}
+		if err := deserialized.Update(); err == nil {
+			t.Fatalf("update %v of revoked token is accepted", update.GetType())
+		}
+	}
+}
+
+func TestTokenStateRevokeWithBalance(t *testing.T) {
+	ts := &TokenState{
+		Types: TokenTypesMap{
+			QITID: TokenType{Id: QITID, Name: "QIT", UpLimit: 100 * 1e8, Enable: true},
+		},
+		Balances: TokenBalancesMap{
+			QITID: TokenBalance{Balance: 200, LockedMeer: 100},
+		},
+		Updates: []ITokenUpdate{&TypeUpdate{
+			TokenUpdate: &TokenUpdate{Typ: types.TxTypeTokenRevoke},
+			Tt:          TokenType{Id: QITID},
+		}},
+	}
+	if err := ts.Update(); err == nil {
+		t.Fatalf("revoke of token with amount is accepted")
+	}
+}
+
+func TestTokenMetadata(t *testing.T) {
+	metadata := &TokenMetadata{
+		Decimals:    8,
+		LongName:    "Qitmeer Token",
+		Description: "https://example.com/qit.json",
+		Issuer:      "issuer@example.com",
+	}
+	script, err := metadata.Script()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := NewTokenMetadataFromScript(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed, metadata) {
+		t.Fatalf("want metadata %v but got %v", metadata, parsed)
+	}
+	if err := (&TokenMetadata{Decimals: MaxTokenDecimals + 1}).CheckSanity(); err == nil {
+		t.Fatal("The decimals exceeding the maximum is accepted")
+	}
+
+	// The token type without metadata is serialized as before.
+	tt := TokenType{Id: QITID, Name: "QIT", UpLimit: 100 * 1e8, Enable: true}
+	serialized, err := tt.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tt.Metadata = metadata
+	serializedWithMetadata, err := tt.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(serializedWithMetadata) != len(serialized)+metadata.SerializeSize() {
+		t.Fatalf("The serialization of token type is not compatible: %x %x", serialized, serializedWithMetadata)
+	}
+	deserialized := TokenType{}
+	_, err = deserialized.Deserialize(serialized)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deserialized.Metadata != nil || !deserialized.Enable {
+		t.Fatalf("want token type without metadata but got %v", deserialized)
+	}
+	bytesRead, err := deserialized.Deserialize(serializedWithMetadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytesRead != len(serializedWithMetadata) || !reflect.DeepEqual(deserialized.Metadata, metadata) ||
+		!deserialized.Enable || deserialized.Revoked {
+		t.Fatalf("want token type %v but got %v", tt, deserialized)
+	}
+
+	// The metadata is replaced by renew.
+	ts := &TokenState{
+		Types: TokenTypesMap{
+			QITID: TokenType{Id: QITID, Name: "QIT", UpLimit: 100 * 1e8, Metadata: metadata},
+		},
+		Balances: TokenBalancesMap{},
+		Updates: []ITokenUpdate{&TypeUpdate{
+			TokenUpdate: &TokenUpdate{Typ: types.TxTypeTokenRenew},
+			Tt:          TokenType{Id: QITID, Name: "QIT", UpLimit: 100 * 1e8, Metadata: &TokenMetadata{Decimals: 2}},
+		}},
+	}
+	err = ts.Update()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts.Types[QITID].Metadata == nil || ts.Types[QITID].Metadata.Decimals != 2 {
+		t.Fatalf("The metadata is not renewed: %v", ts.Types[QITID].Metadata)
+	}
+
+	// The metadata is kept by the renew without metadata.
+	ts.Updates = []ITokenUpdate{&TypeUpdate{
+		TokenUpdate: &TokenUpdate{Typ: types.TxTypeTokenRenew},
+		Tt:          TokenType{Id: QITID, Name: "QIT", UpLimit: 200 * 1e8},
+	}}
+	err = ts.Update()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts.Types[QITID].Metadata == nil || ts.Types[QITID].Metadata.Decimals != 2 ||
+		ts.Types[QITID].UpLimit != 200*1e8 {
+		t.Fatalf("The metadata is not kept: %v", ts.Types[QITID])
+	}
+}
+
+// TestTypeUpdateCheckOwners ensures the owners which are not a token pubkey
+// hash are accepted by CheckSanity as before, and only CheckOwners rejects
+// them after DeploymentTokenV2.
+func TestTypeUpdateCheckOwners(t *testing.T) {
+	coinId := types.QitmeerReservedID + 1
+	pkh := make([]byte, 20)
+	tokenOwners, err := txscript.PayToTokenPubKeyHashScript(pkh, coinId, 100*1e8, "TEST", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2pkh := append(append([]byte{0x76, 0xa9, 0x14}, pkh...), 0x88, 0xac)
+	tests := []struct {
+		name        string
+		owners      []byte
+		upLimit     uint64
+		sanity      bool
+		checkOwners bool
+	}{
+		{"token pubkey hash", tokenOwners, 100 * 1e8, true, true},
+		{"token pubkey hash without uplimit", tokenOwners, 0, false, false},
+		{"not token script", p2pkh, 100 * 1e8, true, false},
+		{"not token script without uplimit", p2pkh, 0, true, false},
+	}
+	for _, test := range tests {
+		tu := &TypeUpdate{
+			TokenUpdate: &TokenUpdate{Typ: types.TxTypeTokenNew},
+			Tt:          TokenType{Id: coinId, Owners: test.owners, UpLimit: test.upLimit, Name: "TEST"},
+		}
+		err := tu.CheckSanity()
+		if (err == nil) != test.sanity {
+			t.Errorf("%s: CheckSanity: %v", test.name, err)
+		}
+		err = tu.CheckOwners()
+		if (err == nil) != test.checkOwners {
+			t.Errorf("%s: CheckOwners: %v", test.name, err)
+		}
+	}
+}
@@ -9,6 +9,21 @@ import (
 
 const MaxTokenNameLength = 6
 
+// MaxTokenOwners is the maximum number of the M-of-N multi-signature token
+// owners, the number of public keys of the token script is a small integer.
+const MaxTokenOwners = 16
+
+const (
+	// The state of token is serialized with enable flag, the metadata flag
+	// is set when the extended metadata follows the fee config. Only the
+	// token new and renew after DeploymentTokenV2 carry the metadata, so
+	// the earlier tokens are serialized the same as before.
+	tokenStateEnable   = 1
+	tokenStateRevoked  = 2
+	tokenStateMask     = 3
+	tokenStateMetadata = 4
+)
+
 type TokenType struct {
 	Id      types.CoinID
 	Owners  []byte
@@ -16,6 +31,12 @@ type TokenType struct {
 	Enable  bool
 	Name    string
 	FeeCfg  TokenFeeConfig
+
+	// The revoked coin id can't be used again, it is kept to prevent it.
+	Revoked bool
+
+	// The optional extended metadata, nil if the token doesn't set it.
+	Metadata *TokenMetadata
 }
 
 func (tt *TokenType) Serialize() ([]byte, error) {
@@ -29,6 +50,9 @@ func (tt *TokenType) Serialize() ([]byte, error) {
 	serializeSize += len(tt.Name)
 	serializeSize += serialization.SerializeSizeVLQ(uint64(tt.FeeCfg.Type))
 	serializeSize += serialization.SerializeSizeVLQ(uint64(tt.FeeCfg.Value))
+	if tt.Metadata != nil {
+		serializeSize += tt.Metadata.SerializeSize()
+	}
 
 	serialized := make([]byte, serializeSize)
 	offset := 0
@@ -39,16 +63,26 @@ func (tt *TokenType) Serialize() ([]byte, error) {
 	offset += len(tt.Owners)
 	offset += serialization.PutVLQ(serialized[offset:], tt.UpLimit)
 
-	if tt.Enable {
-		offset += serialization.PutVLQ(serialized[offset:], uint64(1))
-	} else {
-		offset += serialization.PutVLQ(serialized[offset:], uint64(0))
+	// The state of token is serialized with enable flag, which is
+	// 2 for the revoked token.
+	state := uint64(0)
+	if tt.Revoked {
+		state = tokenStateRevoked
+	} else if tt.Enable {
+		state = tokenStateEnable
 	}
+	if tt.Metadata != nil {
+		state |= tokenStateMetadata
+	}
+	offset += serialization.PutVLQ(serialized[offset:], state)
 	offset += serialization.PutVLQ(serialized[offset:], uint64(len(tt.Name)))
 	copy(serialized[offset:offset+len(tt.Name)], tt.Name)
 	offset += len(tt.Name)
 	offset += serialization.PutVLQ(serialized[offset:], uint64(tt.FeeCfg.Type))
 	offset += serialization.PutVLQ(serialized[offset:], uint64(tt.FeeCfg.Value))
+	if tt.Metadata != nil {
+		tt.Metadata.PutSerialized(serialized[offset:])
+	}
 
 	return serialized, nil
 }
@@ -111,15 +145,30 @@ func (tt *TokenType) Deserialize(data []byte) (int, error) {
 	}
 	offset += bytesRead
 
+	//metadata
+	var metadata *TokenMetadata
+	if enableB&tokenStateMetadata != 0 {
+		metadata = &TokenMetadata{}
+		bytesRead, err := metadata.Deserialize(data[offset:])
+		if err != nil {
+			return offset, err
+		}
+		offset += bytesRead
+	}
+
 	tt.Id = types.CoinID(Id)
 	tt.Owners = Owners
 	tt.UpLimit = UpLimit
-	tt.Enable = enableB > 0
+	tt.Enable = enableB&tokenStateMask == tokenStateEnable
+	tt.Revoked = enableB&tokenStateMask == tokenStateRevoked
 	tt.Name = string(Name)
 	tt.FeeCfg = TokenFeeConfig{Type: types.FeeType(feeType), Value: int64(feeValue)}
+	tt.Metadata = metadata
 	return offset, nil
 }
 
+// GetAddress returns the address of the token owners, it is nil when the
+// owners are an M-of-N multi-signature.
 func (tt *TokenType) GetAddress() types.Address {
 	script, err := txscript.ParsePkScript(tt.Owners)
 	if err != nil {
@@ -127,7 +176,7 @@ func (tt *TokenType) GetAddress() types.Address {
 		return nil
 	}
 
-	if tnScript, ok := script.(*txscript.TokenScript); ok {
+	if tnScript, ok := script.(*txscript.TokenScript); ok && tnScript.GetClass() == txscript.TokenPubKeyHashTy {
 		addr := tnScript.GetAddresses()
 		if len(addr) > 0 {
 			return addr[0]
@@ -152,12 +201,19 @@ func (ttm *TokenTypesMap) Update(update *TypeUpdate) error {
 		if !ok {
 			return fmt.Errorf("It doesn't exist: Coin id (%d)\n", update.Tt.Id)
 		}
+		if tt.Revoked {
+			return fmt.Errorf("It was revoked: Coin id (%d)\n", update.Tt.Id)
+		}
 		if tt.Enable {
 			return fmt.Errorf("Renew is allowed only when disable: Coin id (%d)\n", update.Tt.Id)
 		}
 		tt.Owners = update.Tt.Owners
 		tt.UpLimit = update.Tt.UpLimit
 		tt.Name = update.Tt.Name
+		// The metadata is kept if the renew doesn't carry a new one.
+		if update.Tt.Metadata != nil {
+			tt.Metadata = update.Tt.Metadata
+		}
 		(*ttm)[tt.Id] = tt
 		log.Trace(fmt.Sprintf("Token type update: renew %s(%d)", update.Tt.Name, update.Tt.Id))
 	case types.TxTypeTokenValidate:
@@ -165,6 +221,9 @@ func (ttm *TokenTypesMap) Update(update *TypeUpdate) error {
 		if !ok {
 			return fmt.Errorf("It doesn't exist: Coin id (%d)\n", update.Tt.Id)
 		}
+		if tt.Revoked {
+			return fmt.Errorf("It was revoked: Coin id (%d)\n", update.Tt.Id)
+		}
 		if tt.Enable {
 			return fmt.Errorf("Validate is allowed only when disable: Coin id (%d)\n", update.Tt.Id)
 		}
@@ -182,6 +241,18 @@ func (ttm *TokenTypesMap) Update(update *TypeUpdate) error {
 		tt.Enable = false
 		(*ttm)[tt.Id] = tt
 		log.Trace(fmt.Sprintf("Token type update: invalidate %s(%d)", update.Tt.Name, update.Tt.Id))
+	case types.TxTypeTokenRevoke:
+		tt, ok := (*ttm)[update.Tt.Id]
+		if !ok {
+			return fmt.Errorf("It doesn't exist: Coin id (%d)\n", update.Tt.Id)
+		}
+		if tt.Revoked {
+			return fmt.Errorf("It was revoked: Coin id (%d)\n", update.Tt.Id)
+		}
+		tt.Enable = false
+		tt.Revoked = true
+		(*ttm)[tt.Id] = tt
+		log.Trace(fmt.Sprintf("Token type update: revoke %s(%d)", tt.Name, update.Tt.Id))
 	default:
 		return fmt.Errorf("unknown update type %v", update.Typ)
 	}
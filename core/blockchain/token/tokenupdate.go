@@ -42,7 +42,7 @@ func NewTokenUpdate(tu *TokenUpdate) ITokenUpdate {
 	switch tu.GetType() {
 	case types.TxTypeTokenMint, types.TxTypeTokenUnmint:
 		return &BalanceUpdate{TokenUpdate: tu}
-	case types.TxTypeTokenNew, types.TxTypeTokenRenew, types.TxTypeTokenValidate, types.TxTypeTokenInvalidate:
+	case types.TxTypeTokenNew, types.TxTypeTokenRenew, types.TxTypeTokenValidate, types.TxTypeTokenInvalidate, types.TxTypeTokenRevoke:
 		return &TypeUpdate{TokenUpdate: tu}
 	}
 	return nil
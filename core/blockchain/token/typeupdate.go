@@ -79,34 +79,81 @@ func (tu *TypeUpdate) CheckSanity() error {
 		}
 	}
 	if tu.GetType() == types.TxTypeTokenNew || tu.GetType() == types.TxTypeTokenRenew {
+		// The rest is not checked if the owners are not a token pubkey
+		// hash, which is kept for the tokens before DeploymentTokenV2.
+		// CheckOwners checks the owners after it.
 		class, _, _, err := txscript.ExtractPkScriptAddrs(tu.Tt.Owners, params.ActiveNetParams.Params)
 		if err != nil || class != txscript.TokenPubKeyHashTy {
 			return err
 		}
-		if tu.Tt.UpLimit == 0 {
-			return fmt.Errorf("UpLimit cannot be zero")
-		}
-		if len(tu.Tt.Name) <= 0 {
-			return fmt.Errorf("Must have token name.\n")
-		}
-		if tu.Tt.FeeCfg.Type != types.FloorFeeType &&
-			tu.Tt.FeeCfg.Type != types.EqualFeeType {
-			return fmt.Errorf("Fee type (%d) is invalid.\n", tu.Tt.FeeCfg.Type)
+		err = tu.checkTokenType()
+		if err != nil {
+			return err
 		}
 
-	} else if tu.GetType() == types.TxTypeTokenValidate || tu.GetType() == types.TxTypeTokenInvalidate {
+	} else if tu.GetType() == types.TxTypeTokenValidate || tu.GetType() == types.TxTypeTokenInvalidate ||
+		tu.GetType() == types.TxTypeTokenRevoke {
 		if tu.Tt.UpLimit != 0 {
 			return fmt.Errorf("UpLimit must be zero")
 		}
 		if len(tu.Tt.Name) != 0 {
 			return fmt.Errorf("Token name must empty.\n")
 		}
+		if tu.Tt.Metadata != nil {
+			return fmt.Errorf("Token metadata must empty.\n")
+		}
 	} else {
 		return fmt.Errorf("This type (%v) is not supported\n", tu.GetType())
 	}
 	return nil
 }
 
+// CheckOwners checks the owners of token new and token renew once the
+// DeploymentTokenV2 is active. They must be a token pubkey hash or a M-of-N
+// multi-signature, and the token type is checked whatever the owners are.
+func (tu *TypeUpdate) CheckOwners() error {
+	if tu.GetType() != types.TxTypeTokenNew && tu.GetType() != types.TxTypeTokenRenew {
+		return nil
+	}
+	class, addrs, requiredSigs, err := txscript.ExtractPkScriptAddrs(tu.Tt.Owners, params.ActiveNetParams.Params)
+	if err != nil {
+		return err
+	}
+	switch class {
+	case txscript.TokenPubKeyHashTy:
+	case txscript.TokenMultiSigTy:
+		// The invalid public keys are skipped, so the rest must be
+		// able to satisfy the required signatures.
+		if len(addrs) < requiredSigs {
+			return fmt.Errorf("Token owners require %d signatures, but only %d valid public keys.\n", requiredSigs, len(addrs))
+		}
+	default:
+		return fmt.Errorf("Token owners (%s) is not supported.\n", class)
+	}
+	return tu.checkTokenType()
+}
+
+// checkTokenType checks the token type of token new and token renew.
+func (tu *TypeUpdate) checkTokenType() error {
+	if tu.Tt.UpLimit == 0 {
+		return fmt.Errorf("UpLimit cannot be zero")
+	}
+	if len(tu.Tt.Name) <= 0 {
+		return fmt.Errorf("Must have token name.\n")
+	}
+	if tu.Tt.FeeCfg.Type != types.FloorFeeType &&
+		tu.Tt.FeeCfg.Type != types.EqualFeeType {
+		return fmt.Errorf("Fee type (%d) is invalid.\n", tu.Tt.FeeCfg.Type)
+	}
+	if tu.Tt.Metadata != nil {
+		err := tu.Tt.Metadata.CheckSanity()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func NewTypeUpdateFromTx(tx *types.Transaction) (*TypeUpdate, error) {
 	script, err := txscript.ParsePkScript(tx.TxOut[0].PkScript)
 	if err != nil {
@@ -115,15 +162,25 @@ func NewTypeUpdateFromTx(tx *types.Transaction) (*TypeUpdate, error) {
 	}
 
 	if tnScript, ok := script.(*txscript.TokenScript); ok {
+		// The optional second output of token new and token renew
+		// carries the metadata.
+		var metadata *TokenMetadata
+		if (types.IsTokenNewTx(tx) || types.IsTokenRenewTx(tx)) && len(tx.TxOut) > 1 {
+			metadata, err = NewTokenMetadataFromScript(tx.TxOut[1].PkScript)
+			if err != nil {
+				return nil, err
+			}
+		}
 		return &TypeUpdate{
 			TokenUpdate: &TokenUpdate{Typ: types.DetermineTxType(tx)},
 			Tt: TokenType{
-				Id:      tnScript.GetCoinId(),
-				Owners:  tx.TxOut[0].PkScript,
-				UpLimit: tnScript.GetUpLimit(),
-				Enable:  false,
-				Name:    tnScript.GetName(),
-				FeeCfg:  *NewTokenFeeConfig(tnScript.GetFeeCfgData()),
+				Id:       tnScript.GetCoinId(),
+				Owners:   tx.TxOut[0].PkScript,
+				UpLimit:  tnScript.GetUpLimit(),
+				Enable:   false,
+				Name:     tnScript.GetName(),
+				FeeCfg:   *NewTokenFeeConfig(tnScript.GetFeeCfgData()),
+				Metadata: metadata,
 			},
 		}, nil
 	}
@@ -0,0 +1,184 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+package blockchain
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/blockchain/token"
+	"github.com/Qitmeer/qitmeer/core/dbnamespace"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/database"
+	"github.com/Qitmeer/qitmeer/engine/txscript"
+	"sort"
+)
+
+// CoinSupply is the amount of a coin in the utxo set and the amount which was
+// issued. The amount in the utxo set can be less than the issued one because
+// of the fees which are not claimed by spending coinbase and the outputs
+// which are provably unspendable, but it is never more.
+type CoinSupply struct {
+	Id       types.CoinID
+	Name     string
+	Amount   int64
+	Expected int64
+}
+
+// TxOutSetInfo is the statistics of the utxo set at the main chain tip.
+type TxOutSetInfo struct {
+	Hash           hash.Hash
+	Order          uint64
+	Utxos          uint64
+	SerializedSize uint64
+
+	// The same hash as the utxo set snapshot.
+	UtxoSetHash hash.Hash
+
+	// The utxo set commitment, it is nil if the commitment was not built.
+	Commitment *hash.Hash
+
+	// The supply of every coin which was issued or is in the utxo set,
+	// sorted by coin id.
+	Supply []*CoinSupply
+}
+
+// Valid returns whether no coin in the utxo set is more than its issued
+// amount.
+func (info *TxOutSetInfo) Valid() bool {
+	for _, s := range info.Supply {
+		if s.Amount > s.Expected {
+			return false
+		}
+	}
+	return true
+}
+
+// FetchTxOutSetInfo walks the whole utxo set at the main chain tip, and checks
+// the amount of every coin against the subsidy of blocks and the token state.
+//
+// The utxo set is read from a snapshot of database, so the chain state lock is
+// only held to take the snapshot.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) FetchTxOutSetInfo() (*TxOutSetInfo, error) {
+	b.ChainRLock()
+	locked := true
+	defer func() {
+		if locked {
+			b.ChainRUnlock()
+		}
+	}()
+
+	err := b.utxoCache.flush()
+	if err != nil {
+		return nil, err
+	}
+	mainTip := b.bd.GetMainChainTip()
+	info := &TxOutSetInfo{
+		Hash:  *mainTip.GetHash(),
+		Order: uint64(mainTip.GetOrder()),
+	}
+	amounts := types.AmountMap{}
+	var state *token.TokenState
+	err = b.db.View(func(dbTx database.Tx) error {
+		var err error
+		state, err = token.DBFetchTokenState(dbTx, b.TokenTipID)
+		if err != nil {
+			return err
+		}
+		// The database transaction doesn't see the later blocks, so
+		// the chain can go on.
+		b.ChainRUnlock()
+		locked = false
+
+		m, err := dbFetchUtxoCommitment(dbTx)
+		if err != nil {
+			return err
+		}
+		if m != nil {
+			commitment := m.Finalize()
+			info.Commitment = &commitment
+		}
+		uh := newUtxoSetHasher(&info.Hash)
+		cursor := dbTx.Metadata().Bucket(dbnamespace.UtxoSetBucketName).Cursor()
+		for ok := cursor.First(); ok; ok = cursor.Next() {
+			entry, err := DeserializeUtxoEntry(cursor.Value())
+			if err != nil {
+				return err
+			}
+			amounts[entry.Amount().Id] += entry.Amount().Value
+			info.SerializedSize += uint64(len(cursor.Key()) + len(cursor.Value()))
+			uh.add(cursor.Key(), cursor.Value())
+		}
+		info.UtxoSetHash = uh.sum()
+		info.Utxos = uh.count
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	expected, err := b.calcIssuedSupply(mainTip.GetHash(), info.Order, state)
+	if err != nil {
+		return nil, err
+	}
+	for id := range amounts {
+		if _, ok := expected[id]; !ok {
+			expected[id] = 0
+		}
+	}
+	for id, e := range expected {
+		s := &CoinSupply{
+			Id:       id,
+			Name:     id.Name(),
+			Amount:   amounts[id],
+			Expected: e,
+		}
+		if tt, ok := state.Types[id]; ok {
+			s.Name = tt.Name
+		}
+		info.Supply = append(info.Supply, s)
+	}
+	sort.Slice(info.Supply, func(i, j int) bool {
+		return info.Supply[i].Id < info.Supply[j].Id
+	})
+	return info, nil
+}
+
+// calcIssuedSupply returns the amount of every coin which was issued until the
+// base order. MEER is issued by the genesis ledger and the subsidy of valid
+// blocks, the minted tokens lock MEER and they are issued by the token state.
+//
+// The chain state lock is not held, so the base block is checked again after
+// walking the blocks.
+func (b *BlockChain) calcIssuedSupply(baseHash *hash.Hash, baseOrder uint64, state *token.TokenState) (types.AmountMap, error) {
+	issued := types.AmountMap{}
+	for _, tx := range b.params.GenesisBlock.Transactions {
+		for _, txOut := range tx.TxOut {
+			if txscript.IsUnspendable(txOut.PkScript) {
+				continue
+			}
+			issued[txOut.Amount.Id] += txOut.Amount.Value
+		}
+	}
+	for order := uint64(1); order <= baseOrder; order++ {
+		ib := b.bd.GetBlockByOrder(uint(order))
+		if ib == nil {
+			return nil, fmt.Errorf("No block in order:%d", order)
+		}
+		if ib.GetStatus().KnownInvalid() {
+			continue
+		}
+		// The same subsidy as the coinbase is checked with.
+		mainParent := b.bd.GetBlockById(ib.GetMainParent())
+		issued[types.MEERID] += b.subsidyCache.CalcBlockSubsidy(b.bd.GetBlueInfo(mainParent))
+	}
+	ib := b.bd.GetBlockByOrder(uint(baseOrder))
+	if ib == nil || !ib.GetHash().IsEqual(baseHash) {
+		return nil, fmt.Errorf("The chain was reorganized while walking the utxo set, please try again")
+	}
+	for id, balance := range state.Balances {
+		issued[id] += balance.Balance
+		issued[types.MEERID] -= balance.LockedMeer
+	}
+	return issued, nil
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+
+package blockchain
+
+import (
+	"bytes"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/params"
+	"testing"
+)
+
+// TestFetchTxOutSetInfo ensures the statistics are of the utxo set at the main
+// chain tip, and the unclaimed fees make MEER in the utxo set less than the
+// issued amount.
+func TestFetchTxOutSetInfo(t *testing.T) {
+	par := *params.PrivNetParam.Params
+	tc := newTestChain(t, "test_txoutsetinfo", &par)
+	defer tc.cleanup()
+
+	meerSupply := func(info *TxOutSetInfo) *CoinSupply {
+		for _, s := range info.Supply {
+			if s.Id == types.MEERID {
+				return s
+			}
+		}
+		t.Fatalf("no MEER supply in %+v", info.Supply)
+		return nil
+	}
+
+	blocks := tc.addBlocks(20)
+	info, err := tc.bc.FetchTxOutSetInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mainTip := tc.bc.BlockDAG().GetMainChainTip()
+	if info.Hash != *mainTip.GetHash() || info.Order != uint64(mainTip.GetOrder()) {
+		t.Fatalf("the info is at %s (order:%d), but the main chain tip is %s (order:%d)",
+			info.Hash, info.Order, mainTip.GetHash(), mainTip.GetOrder())
+	}
+	meer := meerSupply(info)
+	if !info.Valid() || meer.Amount != meer.Expected {
+		t.Fatalf("MEER in the utxo set is %d, but %d was issued", meer.Amount, meer.Expected)
+	}
+	var buff bytes.Buffer
+	us, err := tc.bc.ExportUtxoSet(&buff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.UtxoSetHash != us.UtxoSetHash {
+		t.Fatalf("the utxo set hash is %s, but %s in snapshot", info.UtxoSetHash, us.UtxoSetHash)
+	}
+
+	// The fee is not claimed by the coinbase of test chain.
+	fee := int64(1000)
+	outpoint := types.TxOutPoint{Hash: *blocks[0].Transactions()[0].Hash(), OutIndex: 0}
+	amount := blocks[0].Transactions()[0].Tx.TxOut[0].Amount.Value
+	tc.addBlock(nil, newTestSpendTx([]types.TxOutPoint{outpoint}, []int64{amount - fee}, tc.payScript))
+	issued := meer.Expected
+	info, err = tc.bc.FetchTxOutSetInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	meer = meerSupply(info)
+	subsidy := meer.Expected - issued
+	if !info.Valid() || meer.Amount != meer.Expected-fee || subsidy <= 0 {
+		t.Fatalf("MEER in the utxo set is %d, but %d was issued with the fee %d",
+			meer.Amount, meer.Expected, fee)
+	}
+
+	// More coins than the issued amount is invalid.
+	meer.Amount = meer.Expected + 1
+	if info.Valid() {
+		t.Fatal("the inflated supply is valid")
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"math"
 	"runtime"
 
+	"github.com/Qitmeer/qitmeer/core/blockchain/token"
 	"github.com/Qitmeer/qitmeer/core/types"
 	"github.com/Qitmeer/qitmeer/engine/txscript"
 )
@@ -209,8 +210,11 @@ func ValidateTransactionScripts(tx *types.Tx, utxoView *UtxoViewpoint, flags txs
 // checkBlockScripts executes and validates the scripts for all transactions in
 // the passed block using multiple goroutines.
 // txTree = true is TxTreeRegular, txTree = false is TxTreeStake.
+// The token owners are from tokenState, which is the token state of the main
+// parent of block.
 func (b *BlockChain) checkBlockScripts(block *types.SerializedBlock, utxoView *UtxoViewpoint,
-	scriptFlags txscript.ScriptFlags, sigCache *txscript.SigCache) error {
+	tokenState *token.TokenState, tokenV2Active bool, scriptFlags txscript.ScriptFlags,
+	sigCache *txscript.SigCache) error {
 
 	// Collect all of the transaction inputs and required information for
 	// validation for all transactions in the block into a single slice.
@@ -227,11 +231,19 @@ func (b *BlockChain) checkBlockScripts(block *types.SerializedBlock, utxoView *U
 		if tx.IsDuplicate {
 			continue
 		}
+		// The renew is signed by the token admin before DeploymentTokenV2,
+		// and by the current token owners after it.
+		isOwnersRenew := tokenV2Active && types.IsTokenRenewTx(tx.Tx)
+		// The token revoke and renew are validated alone.
+		isOwnersSigned := types.IsTokenRevokeTx(tx.Tx) || isOwnersRenew
 		for txInIdx, txIn := range tx.Transaction().TxIn {
 			// Skip coinbases.
 			if txIn.PreviousOut.OutIndex == math.MaxUint32 {
 				continue
 			}
+			if isOwnersSigned {
+				continue
+			}
 
 			txVI := &txValidateItem{
 				txInIndex: txInIdx,
@@ -243,15 +255,37 @@ func (b *BlockChain) checkBlockScripts(block *types.SerializedBlock, utxoView *U
 
 		if types.IsTokenTx(tx.Tx) {
 			if types.IsTokenMintTx(tx.Tx) {
-				state := b.GetTokenState(b.TokenTipID)
-				if state == nil {
+				if tokenState == nil {
 					return fmt.Errorf("Token state error\n")
 				}
-				tt, ok := state.Types[tx.Tx.TxOut[0].Amount.Id]
+				tt, ok := tokenState.Types[tx.Tx.TxOut[0].Amount.Id]
 				if !ok {
 					return fmt.Errorf("It doesn't exist: Coin id (%d)\n", tx.Tx.TxOut[0].Amount.Id)
 				}
 				utxoView.AddTokenTxOut(tx.Tx.TxIn[0].PreviousOut, tt.Owners)
+			} else if isOwnersSigned {
+				// The revoke and the renew after DeploymentTokenV2 are
+				// signed by the current token owners, but their input is
+				// the same as the token type updates which are signed by
+				// the token admin, so they are validated with their own
+				// views.
+				update, err := token.NewTypeUpdateFromTx(tx.Tx)
+				if err != nil {
+					return err
+				}
+				if tokenState == nil {
+					return fmt.Errorf("Token state error\n")
+				}
+				tt, ok := tokenState.Types[update.Tt.Id]
+				if !ok {
+					return fmt.Errorf("It doesn't exist: Coin id (%d)\n", update.Tt.Id)
+				}
+				view := NewUtxoViewpoint()
+				view.AddTokenTxOut(tx.Tx.TxIn[0].PreviousOut, tt.Owners)
+				err = ValidateTransactionScripts(tx, view, scriptFlags, sigCache)
+				if err != nil {
+					return err
+				}
 			} else {
 				utxoView.AddTokenTxOut(tx.Tx.TxIn[0].PreviousOut, nil)
 			}
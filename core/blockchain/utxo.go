@@ -28,6 +28,10 @@ const (
 	// tfModified indicates that a txout has been modified since it was
 	// loaded.
 	tfModified
+
+	// tfFresh indicates that a txout of the utxo cache is not in the
+	// database, so it is simply dropped once it is spent.
+	tfFresh
 )
 
 // utxoOutput houses details about an individual unspent transaction output such
@@ -58,6 +62,11 @@ func (entry *UtxoEntry) isModified() bool {
 	return entry.packedFlags&tfModified == tfModified
 }
 
+// isFresh returns whether or not the output is not in the database.
+func (entry *UtxoEntry) isFresh() bool {
+	return entry.packedFlags&tfFresh == tfFresh
+}
+
 // IsCoinBase returns whether or not the output was contained in a coinbase
 // transaction.
 func (entry *UtxoEntry) IsCoinBase() bool {
@@ -232,12 +241,12 @@ func (view *UtxoViewpoint) SetViewpoints(views []*hash.Hash) {
 
 // fetchUtxosMain fetches unspent transaction output data about the provided
 // set of transactions from the point of view of the end of the main chain at
-// the time of the call.
+// the time of the call.  They are served by the utxo cache when possible.
 //
 // Upon completion of this function, the view will contain an entry for each
 // requested transaction.  Fully spent transactions, or those which otherwise
 // don't exist, will result in a nil entry in the view.
-func (view *UtxoViewpoint) fetchUtxosMain(db database.DB, outpoints map[types.TxOutPoint]struct{}) error {
+func (view *UtxoViewpoint) fetchUtxosMain(cache *utxoCache, outpoints map[types.TxOutPoint]struct{}) error {
 	// Nothing to do if there are no requested hashes.
 	if len(outpoints) == 0 {
 		return nil
@@ -251,20 +260,7 @@ func (view *UtxoViewpoint) fetchUtxosMain(db database.DB, outpoints map[types.Tx
 	// since other code uses the presence of an entry in the store as a way
 	// to optimize spend and unspend updates to apply only to the specific
 	// utxos that the caller needs access to.
-	return db.View(func(dbTx database.Tx) error {
-		for outpoint := range outpoints {
-			entry, err := dbFetchUtxoEntry(dbTx, outpoint)
-			if err != nil {
-				return err
-			}
-			if entry == nil {
-				continue
-			}
-			view.entries[outpoint] = entry
-		}
-
-		return nil
-	})
+	return cache.fetchEntries(view, outpoints)
 }
 
 func (view *UtxoViewpoint) FilterInvalidOut(bc *BlockChain) {
@@ -356,7 +352,7 @@ func (view *UtxoViewpoint) fetchInputUtxos(db database.DB, block *types.Serializ
 			txNeededSet[txIn.PreviousOut] = struct{}{}
 		}
 	}
-	err := view.fetchUtxosMain(db, txNeededSet)
+	err := view.fetchUtxosMain(bc.utxoCache, txNeededSet)
 	if err != nil {
 		return err
 	}
@@ -369,7 +365,7 @@ func (view *UtxoViewpoint) fetchInputUtxos(db database.DB, block *types.Serializ
 // fetchUtxos loads the unspent transaction outputs for the provided set of
 // outputs into the view from the database as needed unless they already exist
 // in the view in which case they are ignored.
-func (view *UtxoViewpoint) fetchUtxos(db database.DB, outpoints map[types.TxOutPoint]struct{}) error {
+func (view *UtxoViewpoint) fetchUtxos(cache *utxoCache, outpoints map[types.TxOutPoint]struct{}) error {
 	// Nothing to do if there are no requested outputs.
 	if len(outpoints) == 0 {
 		return nil
@@ -387,7 +383,7 @@ func (view *UtxoViewpoint) fetchUtxos(db database.DB, outpoints map[types.TxOutP
 	}
 
 	// Request the input utxos from the database.
-	return view.fetchUtxosMain(db, neededSet)
+	return view.fetchUtxosMain(cache, neededSet)
 }
 
 // connectTransaction updates the view by adding all new utxos created by the
@@ -408,7 +404,7 @@ func (view *UtxoViewpoint) connectTransaction(tx *types.Tx, node *BlockNode, blo
 	// if a slice was provided for the spent txout details, append an entry
 	// to it.
 	for txInIndex, txIn := range msgTx.TxIn {
-		if txInIndex == 0 && types.IsTokenMintTx(tx.Tx) {
+		if txInIndex == 0 && (types.IsTokenMintTx(tx.Tx) || types.IsTokenRevokeTx(tx.Tx)) {
 			continue
 		}
 		entry := view.entries[txIn.PreviousOut]
@@ -449,6 +445,13 @@ func (view *UtxoViewpoint) connectTransaction(tx *types.Tx, node *BlockNode, blo
 		*stxos = append(*stxos, stxo)
 	}
 
+	// The first output of revoke is the token script, only the released
+	// MEER is available.
+	if types.IsTokenRevokeTx(tx.Tx) {
+		view.AddTxOut(tx, 1, node.GetHash())
+		return nil
+	}
+
 	// Add the transaction's outputs as available utxos.
 	view.AddTxOuts(tx, node.GetHash()) //TODO, remove type conversion
 
@@ -477,7 +480,7 @@ func (view *UtxoViewpoint) disconnectTransactions(block *types.SerializedBlock,
 			continue
 		}
 		if types.IsTokenTx(tx.Tx) {
-			if !types.IsTokenMintTx(tx.Tx) {
+			if !types.IsTokenMintTx(tx.Tx) && !types.IsTokenRevokeTx(tx.Tx) {
 				continue
 			}
 		}
@@ -493,6 +496,9 @@ func (view *UtxoViewpoint) disconnectTransactions(block *types.SerializedBlock,
 			if txscript.IsUnspendable(txOut.PkScript) {
 				continue
 			}
+			if txOutIdx == 0 && types.IsTokenRevokeTx(tx.Tx) {
+				continue
+			}
 
 			prevOut.OutIndex = uint32(txOutIdx)
 			entry := view.entries[prevOut]
@@ -514,7 +520,7 @@ func (view *UtxoViewpoint) disconnectTransactions(block *types.SerializedBlock,
 			continue
 		}
 		for txInIdx := len(tx.Tx.TxIn) - 1; txInIdx > -1; txInIdx-- {
-			if types.IsTokenMintTx(tx.Tx) && txInIdx == 0 {
+			if (types.IsTokenMintTx(tx.Tx) || types.IsTokenRevokeTx(tx.Tx)) && txInIdx == 0 {
 				continue
 			}
 			stxo := &stxos[stxoIdx]
@@ -598,7 +604,7 @@ func (b *BlockChain) FetchUtxoView(tx *types.Tx) (*UtxoViewpoint, error) {
 	view := NewUtxoViewpoint()
 	view.SetViewpoints(b.GetMiningTips(blockdag.MaxPriority))
 	b.ChainRLock()
-	err := view.fetchUtxosMain(b.db, neededSet)
+	err := view.fetchUtxosMain(b.utxoCache, neededSet)
 	b.ChainRUnlock()
 	if err != nil {
 		return view, err
@@ -621,15 +627,12 @@ func (b *BlockChain) FetchUtxoEntry(outpoint types.TxOutPoint) (*UtxoEntry, erro
 	b.ChainRLock()
 	defer b.ChainRUnlock()
 
-	var entry *UtxoEntry
-	err := b.db.View(func(dbTx database.Tx) error {
-		var err error
-		entry, err = dbFetchUtxoEntry(dbTx, outpoint)
-		return err
-	})
+	view := NewUtxoViewpoint()
+	err := b.utxoCache.fetchEntries(view, map[types.TxOutPoint]struct{}{outpoint: {}})
 	if err != nil {
 		return nil, err
 	}
+	entry := view.LookupEntry(outpoint)
 	if b.IsInvalidOut(entry) {
 		entry = nil
 	}
@@ -680,16 +683,34 @@ func dbFetchUtxoEntry(dbTx database.Tx, outpoint types.TxOutPoint) (*UtxoEntry,
 
 func dbPutUtxoView(dbTx database.Tx, view *UtxoViewpoint) error {
 	utxoBucket := dbTx.Metadata().Bucket(dbnamespace.UtxoSetBucketName)
+	// The commitment is nil before it was built.
+	commitment, err := dbFetchUtxoCommitment(dbTx)
+	if err != nil {
+		return err
+	}
 	for outpoint, entry := range view.entries {
 		// No need to update the database if the entry was not modified.
 		if entry == nil || !entry.isModified() {
 			continue
 		}
 
+		// Remove the replaced utxo entry from the commitment.
+		if commitment != nil {
+			key := outpointKey(outpoint)
+			old := utxoBucket.Get(*key)
+			if old != nil {
+				commitment.Remove(utxoCommitmentElement(*key, old))
+			}
+			recycleOutpointKey(key)
+		}
+
 		// Remove the utxo entry if it is spent.
 		if entry.IsSpent() {
 			key := outpointKey(outpoint)
 			err := utxoBucket.Delete(*key)
+			if err == nil {
+				err = dbUpdateTicketIndex(dbTx, *key, entry)
+			}
 			recycleOutpointKey(key)
 			if err != nil {
 				return err
@@ -712,9 +733,19 @@ func dbPutUtxoView(dbTx database.Tx, view *UtxoViewpoint) error {
 		if err != nil {
 			return err
 		}
+		err = dbUpdateTicketIndex(dbTx, *key, entry)
+		if err != nil {
+			return err
+		}
+		if commitment != nil {
+			commitment.Add(utxoCommitmentElement(*key, serialized))
+		}
 	}
 
-	return nil
+	if commitment == nil {
+		return nil
+	}
+	return dbPutUtxoCommitment(dbTx, commitment)
 }
 
 const UtxoEntryAmountCoinIDSize = 2
@@ -0,0 +1,494 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+package blockchain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/common/hash/muhash"
+	"github.com/Qitmeer/qitmeer/common/roughtime"
+	"github.com/Qitmeer/qitmeer/core/dbnamespace"
+	s "github.com/Qitmeer/qitmeer/core/serialization"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/database"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultUtxoCacheMaxSize is the default maximum size in MiB of the utxo
+	// cache.
+	DefaultUtxoCacheMaxSize = 250
+
+	// utxoFlushInterval is the maximum interval of flushing the utxo cache,
+	// which also limits the number of diffs to recover after the unclean
+	// shutdown.
+	utxoFlushInterval = time.Minute * 10
+
+	// utxoEntryOverhead is the estimated memory of a cached utxo entry
+	// besides its public key script, it includes the outpoint, the entry
+	// and the overhead of map.
+	utxoEntryOverhead = 144
+)
+
+// The utxo cache keeps the utxo entries which were loaded or modified by the
+// recent blocks in memory, so the spends and creates of many blocks are
+// coalesced into one write of database when the cache is flushed.
+//
+// The utxo set of database is only changed by the flushing, the entries which
+// are changed by every connected or disconnected block are also kept as a diff
+// in the same database transaction with its spend journal. After the unclean
+// shutdown, the diffs which were not flushed are applied to the utxo set in
+// order, so the utxo set is consistent with the other chain state. The diff is
+// used rather than replaying the blocks because the token transactions also
+// put the entries which only depend on the token state at that time.
+
+// utxoCache is the write back cache of the utxo set.
+type utxoCache struct {
+	db database.DB
+
+	// The maximum size in bytes, zero means the cache is flushed for
+	// every block.
+	maxSize uint64
+
+	lock    sync.Mutex
+	entries map[types.TxOutPoint]*UtxoEntry
+	size    uint64
+
+	// The rolling hash of the utxo set including the modifications of
+	// cache, it is nil if the commitment was not built.
+	commitment *muhash.MuHash
+
+	// The id of the next diff, it is also the number of diffs which were
+	// not flushed.
+	nextDiff  uint64
+	lastFlush time.Time
+}
+
+// utxoDiff is the modifications of a view to the utxo cache, it is applied
+// to the cache after the database transaction is committed.
+type utxoDiff struct {
+	entries    map[types.TxOutPoint]*UtxoEntry
+	commitment *muhash.MuHash
+}
+
+// newUtxoCache returns a new utxo cache whose maximum size is in bytes.
+func newUtxoCache(db database.DB, maxSize uint64) *utxoCache {
+	return &utxoCache{
+		db:        db,
+		maxSize:   maxSize,
+		entries:   make(map[types.TxOutPoint]*UtxoEntry),
+		lastFlush: roughtime.Now(),
+	}
+}
+
+func utxoEntrySize(entry *UtxoEntry) uint64 {
+	return utxoEntryOverhead + uint64(len(entry.pkScript))
+}
+
+// initialize applies the diffs which were not flushed before the last
+// shutdown and loads the commitment of utxo set.
+func (c *utxoCache) initialize() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.db.Update(func(dbTx database.Tx) error {
+		num, err := dbApplyUtxoDiffs(dbTx)
+		if err != nil {
+			return err
+		}
+		if num > 0 {
+			log.Info(fmt.Sprintf("Recovered the utxo set from %d diffs which were not flushed", num))
+		}
+		c.commitment, err = dbFetchUtxoCommitment(dbTx)
+		return err
+	})
+}
+
+// reset drops all the entries of the flushed cache and replaces the
+// commitment, it is used after the utxo set of database was rebuilt.
+func (c *utxoCache) reset(commitment *muhash.MuHash) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.entries = make(map[types.TxOutPoint]*UtxoEntry)
+	c.size = 0
+	c.commitment = commitment
+}
+
+// currentCommitment returns a copy of the rolling hash of the utxo set, it is
+// nil if the commitment was not built.
+func (c *utxoCache) currentCommitment() *muhash.MuHash {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.commitment == nil {
+		return nil
+	}
+	return c.commitment.Clone()
+}
+
+// addEntry caches the unmodified entry which was loaded from database unless
+// the cache is full.
+//
+// This function MUST be called with the cache lock held.
+func (c *utxoCache) addEntry(outpoint types.TxOutPoint, entry *UtxoEntry) {
+	size := utxoEntrySize(entry)
+	if c.size+size > c.maxSize {
+		return
+	}
+	c.entries[outpoint] = entry
+	c.size += size
+}
+
+// fetchEntries loads the unspent outputs of outpoints into the view, the ones
+// which are not in the cache are loaded from database.
+//
+// This function is safe for concurrent access.
+func (c *utxoCache) fetchEntries(view *UtxoViewpoint, outpoints map[types.TxOutPoint]struct{}) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	missing := make([]types.TxOutPoint, 0, len(outpoints))
+	for outpoint := range outpoints {
+		entry, ok := c.entries[outpoint]
+		if !ok {
+			missing = append(missing, outpoint)
+			continue
+		}
+		if entry.IsSpent() {
+			continue
+		}
+		// The view gets its own copy because it will spend the entry.
+		viewEntry := entry.Clone()
+		viewEntry.packedFlags &= tfCoinBase
+		view.entries[outpoint] = viewEntry
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return c.db.View(func(dbTx database.Tx) error {
+		for _, outpoint := range missing {
+			entry, err := dbFetchUtxoEntry(dbTx, outpoint)
+			if err != nil {
+				return err
+			}
+			if entry == nil {
+				continue
+			}
+			c.addEntry(outpoint, entry)
+			view.entries[outpoint] = entry.Clone()
+		}
+		return nil
+	})
+}
+
+// commitView stores the modified entries of view as a diff in the database
+// transaction, the returned diff is applied to the cache by applyDiff once
+// the transaction was committed.
+func (c *utxoCache) commitView(dbTx database.Tx, view *UtxoViewpoint) (*utxoDiff, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	diff := &utxoDiff{entries: make(map[types.TxOutPoint]*UtxoEntry)}
+	if c.commitment != nil {
+		diff.commitment = c.commitment.Clone()
+	}
+	utxoBucket := dbTx.Metadata().Bucket(dbnamespace.UtxoSetBucketName)
+	var buff bytes.Buffer
+	for outpoint, entry := range view.entries {
+		// No need to update the utxo set if the entry was not modified.
+		if entry == nil || !entry.isModified() {
+			continue
+		}
+
+		// The replaced entry is in the cache, otherwise it is the one of
+		// database since the modified entries are never evicted.
+		var old []byte
+		var fresh bool
+		var err error
+		key := outpointKey(outpoint)
+		cached, ok := c.entries[outpoint]
+		if ok {
+			fresh = cached.isFresh()
+			if !cached.IsSpent() {
+				old, err = serializeUtxoEntry(cached)
+				if err != nil {
+					recycleOutpointKey(key)
+					return nil, err
+				}
+			}
+		} else {
+			old = utxoBucket.Get(*key)
+			fresh = old == nil
+		}
+		serialized, err := serializeUtxoEntry(entry)
+		if err != nil {
+			recycleOutpointKey(key)
+			return nil, err
+		}
+		if diff.commitment != nil {
+			if old != nil {
+				diff.commitment.Remove(utxoCommitmentElement(*key, old))
+			}
+			if serialized != nil {
+				diff.commitment.Add(utxoCommitmentElement(*key, serialized))
+			}
+		}
+		err = s.WriteVarBytes(&buff, 0, *key)
+		recycleOutpointKey(key)
+		if err != nil {
+			return nil, err
+		}
+		err = s.WriteVarBytes(&buff, 0, serialized)
+		if err != nil {
+			return nil, err
+		}
+
+		cachedEntry := entry.Clone()
+		if fresh {
+			cachedEntry.packedFlags |= tfFresh
+		}
+		diff.entries[outpoint] = cachedEntry
+	}
+	if len(diff.entries) == 0 {
+		return diff, nil
+	}
+
+	var serialized bytes.Buffer
+	err := s.WriteVarInt(&serialized, 0, uint64(len(diff.entries)))
+	if err != nil {
+		return nil, err
+	}
+	_, err = serialized.Write(buff.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	bucket, err := dbTx.Metadata().CreateBucketIfNotExists(dbnamespace.UtxoDiffBucketName)
+	if err != nil {
+		return nil, err
+	}
+	var diffKey [8]byte
+	binary.BigEndian.PutUint64(diffKey[:], c.nextDiff)
+	return diff, bucket.Put(diffKey[:], serialized.Bytes())
+}
+
+// applyDiff applies the diff whose database transaction was committed to the
+// cache.
+func (c *utxoCache) applyDiff(diff *utxoDiff) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for outpoint, entry := range diff.entries {
+		if old, ok := c.entries[outpoint]; ok {
+			c.size -= utxoEntrySize(old)
+		}
+		if entry.IsSpent() {
+			// The spent entry which is not in database doesn't need
+			// to be flushed.
+			if entry.isFresh() {
+				delete(c.entries, outpoint)
+				continue
+			}
+			entry = &UtxoEntry{packedFlags: tfSpent | tfModified}
+		}
+		c.entries[outpoint] = entry
+		c.size += utxoEntrySize(entry)
+	}
+	c.commitment = diff.commitment
+	if len(diff.entries) > 0 {
+		c.nextDiff++
+	}
+}
+
+// maybeFlush flushes the cache if it is beyond the maximum size or it was not
+// flushed for the flush interval.
+func (c *utxoCache) maybeFlush() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.size <= c.maxSize && roughtime.Since(c.lastFlush) < utxoFlushInterval {
+		return nil
+	}
+	return c.flushEntries()
+}
+
+// flush writes all the modified entries to the utxo set of database.
+//
+// This function is safe for concurrent access.
+func (c *utxoCache) flush() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.flushEntries()
+}
+
+// flushEntries writes all the modified entries to the utxo set of database
+// and removes the diffs in a database transaction. All the entries are
+// dropped if the cache is still beyond the maximum size.
+//
+// This function MUST be called with the cache lock held.
+func (c *utxoCache) flushEntries() error {
+	c.lastFlush = roughtime.Now()
+	// Every modification has a diff, so nothing is modified without diffs.
+	if c.nextDiff == 0 {
+		return nil
+	}
+	err := c.db.Update(func(dbTx database.Tx) error {
+		meta := dbTx.Metadata()
+		utxoBucket := meta.Bucket(dbnamespace.UtxoSetBucketName)
+		for outpoint, entry := range c.entries {
+			if !entry.isModified() {
+				continue
+			}
+			key := outpointKey(outpoint)
+			if entry.IsSpent() {
+				err := utxoBucket.Delete(*key)
+				if err == nil {
+					err = dbUpdateTicketIndex(dbTx, *key, entry)
+				}
+				recycleOutpointKey(key)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			serialized, err := serializeUtxoEntry(entry)
+			if err != nil {
+				return err
+			}
+			// NOTE: The key is intentionally not recycled here since
+			// the database interface contract prohibits
+			// modifications.
+			err = utxoBucket.Put(*key, serialized)
+			if err != nil {
+				return err
+			}
+			err = dbUpdateTicketIndex(dbTx, *key, entry)
+			if err != nil {
+				return err
+			}
+		}
+		if c.commitment != nil {
+			err := dbPutUtxoCommitment(dbTx, c.commitment)
+			if err != nil {
+				return err
+			}
+		}
+		if meta.Bucket(dbnamespace.UtxoDiffBucketName) == nil {
+			return nil
+		}
+		return meta.DeleteBucket(dbnamespace.UtxoDiffBucketName)
+	})
+	if err != nil {
+		return err
+	}
+	for outpoint, entry := range c.entries {
+		if entry.IsSpent() {
+			c.size -= utxoEntrySize(entry)
+			delete(c.entries, outpoint)
+			continue
+		}
+		entry.packedFlags &^= tfModified | tfFresh
+	}
+	c.nextDiff = 0
+	if c.size > c.maxSize {
+		c.entries = make(map[types.TxOutPoint]*UtxoEntry)
+		c.size = 0
+	}
+	return nil
+}
+
+// dbApplyUtxoDiffs applies the diffs which were not flushed to the utxo set in
+// order and removes them, it returns the number of diffs.
+func dbApplyUtxoDiffs(dbTx database.Tx) (int, error) {
+	meta := dbTx.Metadata()
+	diffBucket := meta.Bucket(dbnamespace.UtxoDiffBucketName)
+	if diffBucket == nil {
+		return 0, nil
+	}
+	commitment, err := dbFetchUtxoCommitment(dbTx)
+	if err != nil {
+		return 0, err
+	}
+	utxoBucket := meta.Bucket(dbnamespace.UtxoSetBucketName)
+	var num int
+	cursor := diffBucket.Cursor()
+	for ok := cursor.First(); ok; ok = cursor.Next() {
+		r := bytes.NewReader(cursor.Value())
+		count, err := s.ReadVarInt(r, 0)
+		if err != nil {
+			return 0, err
+		}
+		for i := uint64(0); i < count; i++ {
+			key, err := s.ReadVarBytes(r, 0, uint32(hash.HashSize+s.MaxUint32VLQSerializeSize), "utxo key")
+			if err != nil {
+				return 0, err
+			}
+			serialized, err := s.ReadVarBytes(r, 0, types.MaxBlockPayload, "utxo entry")
+			if err != nil {
+				return 0, err
+			}
+			old := utxoBucket.Get(key)
+			if commitment != nil && old != nil {
+				commitment.Remove(utxoCommitmentElement(key, old))
+			}
+			if len(serialized) == 0 {
+				err = utxoBucket.Delete(key)
+				if err != nil {
+					return 0, err
+				}
+				err = dbUpdateTicketIndex(dbTx, key, &UtxoEntry{packedFlags: tfSpent})
+				if err != nil {
+					return 0, err
+				}
+				continue
+			}
+			err = utxoBucket.Put(key, serialized)
+			if err != nil {
+				return 0, err
+			}
+			entry, err := DeserializeUtxoEntry(serialized)
+			if err != nil {
+				return 0, err
+			}
+			err = dbUpdateTicketIndex(dbTx, key, entry)
+			if err != nil {
+				return 0, err
+			}
+			if commitment != nil {
+				commitment.Add(utxoCommitmentElement(key, serialized))
+			}
+		}
+		num++
+	}
+	if commitment != nil {
+		err = dbPutUtxoCommitment(dbTx, commitment)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return num, meta.DeleteBucket(dbnamespace.UtxoDiffBucketName)
+}
+
+// FlushUtxoCache writes all the modified entries of utxo cache to database.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) FlushUtxoCache() error {
+	b.ChainLock()
+	defer b.ChainUnlock()
+
+	return b.utxoCache.flush()
+}
+
+// maybeFlushUtxoCache flushes the utxo cache when it is needed, the failure is
+// only logged because the modifications are kept for the next flushing.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) maybeFlushUtxoCache() {
+	err := b.utxoCache.maybeFlush()
+	if err != nil {
+		log.Error(fmt.Sprintf("Failed to flush utxo cache:%v", err))
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+
+package blockchain
+
+import (
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/common/hash/muhash"
+	"github.com/Qitmeer/qitmeer/core/dbnamespace"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/database"
+	_ "github.com/Qitmeer/qitmeer/database/ffldb"
+	"github.com/Qitmeer/qitmeer/params"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func testCommitUtxoView(t *testing.T, db database.DB, cache *utxoCache, view *UtxoViewpoint) {
+	var diff *utxoDiff
+	err := db.Update(func(dbTx database.Tx) error {
+		var err error
+		diff, err = cache.commitView(dbTx, view)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("failed to commit view: %v", err)
+	}
+	cache.applyDiff(diff)
+	view.commit()
+}
+
+// testCheckUtxoSet checks the utxo set of database and its commitment.
+func testCheckUtxoSet(t *testing.T, db database.DB, expected *muhash.MuHash, unspent []types.TxOutPoint, spent []types.TxOutPoint) {
+	err := db.View(func(dbTx database.Tx) error {
+		for _, outpoint := range unspent {
+			entry, err := dbFetchUtxoEntry(dbTx, outpoint)
+			if err != nil {
+				return err
+			}
+			if entry == nil {
+				t.Fatalf("utxo %v is missing", outpoint)
+			}
+		}
+		for _, outpoint := range spent {
+			entry, err := dbFetchUtxoEntry(dbTx, outpoint)
+			if err != nil {
+				return err
+			}
+			if entry != nil {
+				t.Fatalf("utxo %v was spent", outpoint)
+			}
+		}
+		m, err := dbFetchUtxoCommitment(dbTx)
+		if err != nil {
+			return err
+		}
+		if m.Finalize() != expected.Finalize() {
+			t.Fatalf("the commitment is different")
+		}
+		if dbCalcUtxoCommitment(dbTx).Finalize() != expected.Finalize() {
+			t.Fatalf("the commitment is not of the utxo set")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestUtxoCache ensures the utxo set of database is the same whether the utxo
+// cache is flushed or recovered from the diffs.
+func TestUtxoCache(t *testing.T) {
+	dbPath, err := ioutil.TempDir("", "test_utxocache_db")
+	if err != nil {
+		t.Fatalf("failed to create utxo cache db : %v", err)
+	}
+	defer os.RemoveAll(dbPath)
+
+	db, err := database.Create("ffldb", dbPath, params.PrivNetParam.Net)
+	if err != nil {
+		t.Fatalf("failed to create utxo cache db : %v", err)
+	}
+	defer db.Close()
+
+	err = db.Update(func(dbTx database.Tx) error {
+		_, err := dbTx.Metadata().CreateBucket(dbnamespace.UtxoSetBucketName)
+		if err != nil {
+			return err
+		}
+		return dbPutUtxoCommitment(dbTx, muhash.New())
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache := newUtxoCache(db, 1024*1024)
+	err = cache.initialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkScript := append([]byte{0x76, 0xa9, 0x14}, make([]byte, 20)...)
+	pkScript = append(pkScript, 0x88, 0xac)
+	blockHash := hash.HashH([]byte("block"))
+	txOut := &types.TxOutput{Amount: types.Amount{Value: 100, Id: types.MEERID}, PkScript: pkScript}
+	a := types.TxOutPoint{Hash: hash.HashH([]byte("a"))}
+	b := types.TxOutPoint{Hash: hash.HashH([]byte("b"))}
+	c := types.TxOutPoint{Hash: hash.HashH([]byte("c"))}
+
+	// Create a and b, then flush them.
+	view := NewUtxoViewpoint()
+	view.addTxOut(a, txOut, false, &blockHash)
+	view.addTxOut(b, txOut, true, &blockHash)
+	testCommitUtxoView(t, db, cache, view)
+	err = cache.flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCheckUtxoSet(t, db, cache.currentCommitment(), []types.TxOutPoint{a, b}, nil)
+
+	// Spend a and create c which is spent in the next block.
+	view = NewUtxoViewpoint()
+	err = cache.fetchEntries(view, map[types.TxOutPoint]struct{}{a: {}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	view.LookupEntry(a).Spend()
+	view.addTxOut(c, txOut, false, &blockHash)
+	testCommitUtxoView(t, db, cache, view)
+	if entry, ok := cache.entries[a]; !ok || !entry.IsSpent() {
+		t.Fatalf("the spent utxo should be kept in the cache until flushing")
+	}
+
+	view = NewUtxoViewpoint()
+	err = cache.fetchEntries(view, map[types.TxOutPoint]struct{}{c: {}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	view.LookupEntry(c).Spend()
+	testCommitUtxoView(t, db, cache, view)
+
+	// Without flushing, the database was not changed.
+	err = db.View(func(dbTx database.Tx) error {
+		entry, err := dbFetchUtxoEntry(dbTx, a)
+		if err != nil {
+			return err
+		}
+		if entry == nil {
+			t.Fatalf("the utxo set was changed before flushing")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The unclean shutdown is recovered by the diffs.
+	expected := cache.currentCommitment()
+	recovered := newUtxoCache(db, 1024*1024)
+	err = recovered.initialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recovered.currentCommitment().Finalize() != expected.Finalize() {
+		t.Fatalf("the recovered commitment is different")
+	}
+	testCheckUtxoSet(t, db, expected, []types.TxOutPoint{b}, []types.TxOutPoint{a, c})
+
+	// Flushing the same modifications again changes nothing.
+	err = cache.flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCheckUtxoSet(t, db, expected, []types.TxOutPoint{b}, []types.TxOutPoint{a, c})
+	if len(cache.entries) != 1 {
+		t.Fatalf("the cache has %d entries after flushing, expect 1", len(cache.entries))
+	}
+}
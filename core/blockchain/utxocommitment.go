@@ -0,0 +1,261 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+package blockchain
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/common/hash/muhash"
+	"github.com/Qitmeer/qitmeer/core/blockdag"
+	"github.com/Qitmeer/qitmeer/core/dbnamespace"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/database"
+	"github.com/Qitmeer/qitmeer/params"
+)
+
+// The utxo set commitment is the MuHash of all the utxo entries, every entry
+// is the element of (outpoint key | serialized utxo entry). It is updated
+// with the utxo set in the utxo cache, so it catches the silent corruption of
+// database and makes the utxo set snapshot verifiable.
+//
+// The commitment after each main chain block is connected is also kept, once
+// the DeploymentUtxoCommitment is active, the state root of block header
+// commits to the one of the main parent. The blocks before a main chain block
+// in the DAG order are exactly its past set, so its commitment doesn't change
+// by the reorder, which is not true for the blocks off the main chain.
+
+func utxoCommitmentElement(key []byte, serialized []byte) []byte {
+	element := make([]byte, 0, len(key)+len(serialized))
+	element = append(element, key...)
+	return append(element, serialized...)
+}
+
+// dbFetchUtxoCommitment returns the rolling hash of the current utxo set, it
+// is nil if the commitment was not built yet.
+func dbFetchUtxoCommitment(dbTx database.Tx) (*muhash.MuHash, error) {
+	serialized := dbTx.Metadata().Get(dbnamespace.UtxoCommitmentKeyName)
+	if serialized == nil {
+		return nil, nil
+	}
+	return muhash.Deserialize(serialized)
+}
+
+func dbPutUtxoCommitment(dbTx database.Tx, m *muhash.MuHash) error {
+	return dbTx.Metadata().Put(dbnamespace.UtxoCommitmentKeyName, m.Serialize())
+}
+
+// dbCalcUtxoCommitment calculates the rolling hash from all the utxo entries
+// of database.
+func dbCalcUtxoCommitment(dbTx database.Tx) *muhash.MuHash {
+	m := muhash.New()
+	cursor := dbTx.Metadata().Bucket(dbnamespace.UtxoSetBucketName).Cursor()
+	for ok := cursor.First(); ok; ok = cursor.Next() {
+		m.Add(utxoCommitmentElement(cursor.Key(), cursor.Value()))
+	}
+	return m
+}
+
+// dbPutBlockUtxoCommitment keeps the commitment of current utxo set for the
+// block which was just connected, nothing is kept if m is nil.
+func dbPutBlockUtxoCommitment(dbTx database.Tx, blockHash *hash.Hash, m *muhash.MuHash) error {
+	if m == nil {
+		return nil
+	}
+	bucket, err := dbTx.Metadata().CreateBucketIfNotExists(dbnamespace.UtxoCommitmentBucketName)
+	if err != nil {
+		return err
+	}
+	commitment := m.Finalize()
+	return bucket.Put(blockHash[:], commitment[:])
+}
+
+func dbRemoveBlockUtxoCommitment(dbTx database.Tx, blockHash *hash.Hash) error {
+	bucket := dbTx.Metadata().Bucket(dbnamespace.UtxoCommitmentBucketName)
+	if bucket == nil {
+		return nil
+	}
+	return bucket.Delete(blockHash[:])
+}
+
+// dbFetchBlockUtxoCommitment returns the commitment of utxo set after the
+// block was connected, it is nil if there is not.
+func dbFetchBlockUtxoCommitment(dbTx database.Tx, blockHash *hash.Hash) (*hash.Hash, error) {
+	bucket := dbTx.Metadata().Bucket(dbnamespace.UtxoCommitmentBucketName)
+	if bucket == nil {
+		return nil, nil
+	}
+	serialized := bucket.Get(blockHash[:])
+	if serialized == nil {
+		return nil, nil
+	}
+	return hash.NewHash(serialized)
+}
+
+// buildUtxoCommitment calculates the commitment from the whole utxo set when
+// it is missing or rebuild is true. The commitment of main chain tip is also
+// kept.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) buildUtxoCommitment(rebuild bool) error {
+	if !rebuild && b.utxoCache.currentCommitment() != nil {
+		return nil
+	}
+	err := b.utxoCache.flush()
+	if err != nil {
+		return err
+	}
+	log.Info("Building the utxo set commitment...")
+	var m *muhash.MuHash
+	err = b.db.Update(func(dbTx database.Tx) error {
+		m = dbCalcUtxoCommitment(dbTx)
+		err := dbPutUtxoCommitment(dbTx, m)
+		if err != nil {
+			return err
+		}
+		return dbPutBlockUtxoCommitment(dbTx, b.bd.GetMainChainTip().GetHash(), m)
+	})
+	if err != nil {
+		return err
+	}
+	b.utxoCache.reset(m)
+	return nil
+}
+
+// UtxoCommitment returns the commitment of current utxo set.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) UtxoCommitment() (*hash.Hash, error) {
+	b.ChainRLock()
+	defer b.ChainRUnlock()
+
+	m := b.utxoCache.currentCommitment()
+	if m == nil {
+		return nil, fmt.Errorf("The utxo set commitment was not built")
+	}
+	commitment := m.Finalize()
+	return &commitment, nil
+}
+
+// BlockUtxoCommitment returns the commitment of utxo set after the block was
+// connected.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) BlockUtxoCommitment(blockHash *hash.Hash) (*hash.Hash, error) {
+	var commitment *hash.Hash
+	err := b.db.View(func(dbTx database.Tx) error {
+		var err error
+		commitment, err = dbFetchBlockUtxoCommitment(dbTx, blockHash)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if commitment == nil {
+		return nil, fmt.Errorf("No utxo set commitment of block %s", blockHash)
+	}
+	return commitment, nil
+}
+
+// VerifyUtxoCommitment recalculates the commitment from the whole utxo set and
+// compares it with the rolling one.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) VerifyUtxoCommitment() error {
+	b.ChainRLock()
+	defer b.ChainRUnlock()
+
+	err := b.utxoCache.flush()
+	if err != nil {
+		return err
+	}
+	return b.db.View(func(dbTx database.Tx) error {
+		m, err := dbFetchUtxoCommitment(dbTx)
+		if err != nil {
+			return err
+		}
+		if m == nil {
+			return fmt.Errorf("The utxo set commitment was not built")
+		}
+		rolling := m.Finalize()
+		calculated := dbCalcUtxoCommitment(dbTx).Finalize()
+		if rolling != calculated {
+			return fmt.Errorf("The utxo set is corrupted, commitment %s but calculated %s",
+				rolling, calculated)
+		}
+		return nil
+	})
+}
+
+// isUtxoCommitmentActive returns whether the block after prevNode commits to
+// the utxo set, the networks which don't define the deployment never do.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) isUtxoCommitmentActive(prevNode blockdag.IBlock) (bool, error) {
+	if len(b.params.Deployments) <= params.DeploymentUtxoCommitment {
+		return false, nil
+	}
+	state, err := b.deploymentState(prevNode, params.DeploymentUtxoCommitment)
+	if err != nil {
+		return false, err
+	}
+	return state == ThresholdActive, nil
+}
+
+// calcStateRoot returns the state root of block header. It is the token state
+// root, which also commits to the utxo set of main parent once the
+// DeploymentUtxoCommitment is active.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) calcStateRoot(txs []*types.Tx, parents []*hash.Hash, mainParent blockdag.IBlock) (hash.Hash, error) {
+	tokenRoot := b.CalculateTokenStateRoot(txs, parents)
+	if mainParent == nil {
+		return tokenRoot, nil
+	}
+	active, err := b.isUtxoCommitmentActive(mainParent)
+	if err != nil || !active {
+		return tokenRoot, err
+	}
+	commitment, err := b.BlockUtxoCommitment(mainParent.GetHash())
+	if err != nil {
+		return hash.ZeroHash, err
+	}
+	root := make([]byte, 0, hash.HashSize*2)
+	root = append(root, tokenRoot[:]...)
+	root = append(root, commitment[:]...)
+	return hash.HashH(root), nil
+}
+
+// checkStateRoot ensures the state root of block header matches the one
+// calculated with the main parent.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) checkStateRoot(block *types.SerializedBlock, mainParent blockdag.IBlock) error {
+	calculatedStateRoot, err := b.calcStateRoot(block.Transactions(), block.Block().Parents, mainParent)
+	if err != nil {
+		return err
+	}
+	if !block.Block().Header.StateRoot.IsEqual(&calculatedStateRoot) {
+		str := fmt.Sprintf("block state root is invalid - block "+
+			"header indicates %s, but calculated value is %s",
+			block.Block().Header.StateRoot, calculatedStateRoot)
+		return ruleError(ErrBadMerkleRoot, str)
+	}
+	return nil
+}
+
+// CalculateStateRoot returns the state root of block header for the new block
+// template.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) CalculateStateRoot(txs []*types.Tx, parents []*hash.Hash) (hash.Hash, error) {
+	b.ChainLock()
+	defer b.ChainUnlock()
+
+	var mainParent blockdag.IBlock
+	if len(parents) > 0 {
+		mainParent = b.bd.GetMainParentByHashs(parents)
+		if mainParent == nil {
+			return hash.ZeroHash, fmt.Errorf("Can't find main parent")
+		}
+	}
+	return b.calcStateRoot(txs, parents, mainParent)
+}
@@ -0,0 +1,613 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+package blockchain
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/blockchain/token"
+	"github.com/Qitmeer/qitmeer/core/blockdag"
+	"github.com/Qitmeer/qitmeer/core/dbnamespace"
+	s "github.com/Qitmeer/qitmeer/core/serialization"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/database"
+	"io"
+)
+
+// The utxo set snapshot file is:
+//
+//	magic | version | network | base hash | base order
+//	blocks:       (block bytes | spend journal | invalid) of order 1 ... base order
+//	token states: count | (block hash | token state) from old to new
+//	utxo set:     (outpoint key | utxo entry)... | empty key
+//	checksum
+//
+// The blocks are included because the DAG is rebuilt from them, but they are
+// not connected again. The utxo set hash is calculated by the base hash and
+// the utxo set, it must be the same as the one of params.
+const (
+	utxoSnapshotVersion = 1
+
+	// The max number of utxo entries which are written in one database
+	// transaction when the snapshot is loaded.
+	utxoSnapshotBatchSize = 10000
+
+	maxSnapshotElementSize = types.MaxBlockPayload * 4
+)
+
+var utxoSnapshotMagic = [4]byte{'Q', 'U', 'T', 'X'}
+
+// UtxoSnapshot is the summary of utxo set snapshot.
+type UtxoSnapshot struct {
+	BaseHash    hash.Hash
+	BaseOrder   uint64
+	UtxoSetHash hash.Hash
+	Blocks      uint64
+	Utxos       uint64
+
+	// The history of snapshot was validated by replaying all the blocks.
+	Validated bool
+}
+
+func (us *UtxoSnapshot) encode(w io.Writer) error {
+	return s.WriteElements(w, &us.BaseHash, us.BaseOrder, &us.UtxoSetHash, us.Blocks, us.Utxos, us.Validated)
+}
+
+func (us *UtxoSnapshot) decode(r io.Reader) error {
+	return s.ReadElements(r, &us.BaseHash, &us.BaseOrder, &us.UtxoSetHash, &us.Blocks, &us.Utxos, &us.Validated)
+}
+
+// utxoSetHasher calculates the utxo set hash, the entries must be fed in the
+// order of database keys.
+type utxoSetHasher struct {
+	hasher hash.Hasher
+	count  uint64
+}
+
+func newUtxoSetHasher(baseHash *hash.Hash) *utxoSetHasher {
+	hasher := hash.GetHasher(hash.Blake2b_256)
+	hasher.Write(baseHash[:])
+	return &utxoSetHasher{hasher: hasher}
+}
+
+func (uh *utxoSetHasher) add(key []byte, serialized []byte) {
+	s.WriteVarBytes(uh.hasher, 0, key)
+	s.WriteVarBytes(uh.hasher, 0, serialized)
+	uh.count++
+}
+
+func (uh *utxoSetHasher) sum() hash.Hash {
+	var result hash.Hash
+	copy(result[:], uh.hasher.Sum(nil))
+	return result
+}
+
+// dbCalcUtxoSetHash returns the utxo set hash of database and the number of
+// utxo entries.
+func dbCalcUtxoSetHash(dbTx database.Tx, baseHash *hash.Hash) (hash.Hash, uint64) {
+	uh := newUtxoSetHasher(baseHash)
+	cursor := dbTx.Metadata().Bucket(dbnamespace.UtxoSetBucketName).Cursor()
+	for ok := cursor.First(); ok; ok = cursor.Next() {
+		uh.add(cursor.Key(), cursor.Value())
+	}
+	return uh.sum(), uh.count
+}
+
+func dbPutUtxoSnapshot(dbTx database.Tx, us *UtxoSnapshot) error {
+	var buff bytes.Buffer
+	err := us.encode(&buff)
+	if err != nil {
+		return err
+	}
+	return dbTx.Metadata().Put(dbnamespace.UtxoSnapshotKeyName, buff.Bytes())
+}
+
+func dbFetchUtxoSnapshot(dbTx database.Tx) (*UtxoSnapshot, error) {
+	serialized := dbTx.Metadata().Get(dbnamespace.UtxoSnapshotKeyName)
+	if serialized == nil {
+		return nil, nil
+	}
+	us := &UtxoSnapshot{}
+	err := us.decode(bytes.NewReader(serialized))
+	if err != nil {
+		return nil, err
+	}
+	return us, nil
+}
+
+// FetchUtxoSnapshot returns the state of loaded utxo set snapshot, it is nil
+// if the chain was not bootstrapped from a snapshot.
+func (b *BlockChain) FetchUtxoSnapshot() (*UtxoSnapshot, error) {
+	var us *UtxoSnapshot
+	err := b.db.View(func(dbTx database.Tx) error {
+		var err error
+		us, err = dbFetchUtxoSnapshot(dbTx)
+		return err
+	})
+	return us, err
+}
+
+// ExportUtxoSet writes the utxo set snapshot whose base is the current main
+// chain tip.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ExportUtxoSet(w io.Writer) (*UtxoSnapshot, error) {
+	b.ChainRLock()
+	defer b.ChainRUnlock()
+
+	mainTip := b.bd.GetMainChainTip()
+	if mainTip.GetOrder() == 0 {
+		return nil, fmt.Errorf("No blocks to export")
+	}
+	// The utxo set is read from database.
+	err := b.utxoCache.flush()
+	if err != nil {
+		return nil, err
+	}
+	us := &UtxoSnapshot{
+		BaseHash:  *mainTip.GetHash(),
+		BaseOrder: uint64(mainTip.GetOrder()),
+	}
+	checksum := hash.GetHasher(hash.Blake2b_256)
+	hw := io.MultiWriter(w, checksum)
+	_, err = hw.Write(utxoSnapshotMagic[:])
+	if err != nil {
+		return nil, err
+	}
+	err = s.WriteElements(hw, uint32(utxoSnapshotVersion), uint32(b.params.Net), &us.BaseHash, us.BaseOrder)
+	if err != nil {
+		return nil, err
+	}
+
+	for order := uint64(1); order <= us.BaseOrder; order++ {
+		ib := b.bd.GetBlockByOrder(uint(order))
+		if ib == nil {
+			return nil, fmt.Errorf("No block in order:%d", order)
+		}
+		block, err := b.fetchBlockByHash(ib.GetHash())
+		if err != nil {
+			return nil, err
+		}
+		blockBytes, err := block.Bytes()
+		if err != nil {
+			return nil, err
+		}
+		var journal []byte
+		err = b.db.View(func(dbTx database.Tx) error {
+			journal = dbTx.Metadata().Bucket(dbnamespace.SpendJournalBucketName).Get(ib.GetHash()[:])
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		err = s.WriteVarBytes(hw, 0, blockBytes)
+		if err != nil {
+			return nil, err
+		}
+		err = s.WriteVarBytes(hw, 0, journal)
+		if err != nil {
+			return nil, err
+		}
+		err = s.WriteElements(hw, ib.GetStatus().KnownInvalid())
+		if err != nil {
+			return nil, err
+		}
+		us.Blocks++
+	}
+
+	err = b.exportTokenStates(hw)
+	if err != nil {
+		return nil, err
+	}
+
+	err = b.db.View(func(dbTx database.Tx) error {
+		uh := newUtxoSetHasher(&us.BaseHash)
+		cursor := dbTx.Metadata().Bucket(dbnamespace.UtxoSetBucketName).Cursor()
+		for ok := cursor.First(); ok; ok = cursor.Next() {
+			err := s.WriteVarBytes(hw, 0, cursor.Key())
+			if err != nil {
+				return err
+			}
+			err = s.WriteVarBytes(hw, 0, cursor.Value())
+			if err != nil {
+				return err
+			}
+			uh.add(cursor.Key(), cursor.Value())
+		}
+		us.UtxoSetHash = uh.sum()
+		us.Utxos = uh.count
+		return s.WriteVarBytes(hw, 0, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	_, err = w.Write(checksum.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	return us, nil
+}
+
+// The token states are written from the genesis to the token tip, since
+// the ids of blocks are changed after the DAG is rebuilt.
+func (b *BlockChain) exportTokenStates(w io.Writer) error {
+	hashes := []*hash.Hash{}
+	states := [][]byte{}
+	err := b.db.View(func(dbTx database.Tx) error {
+		for id := b.TokenTipID; uint(id) != blockdag.MaxId; {
+			ib := b.bd.GetBlockById(uint(id))
+			if ib == nil {
+				return fmt.Errorf("No block of token state:%d", id)
+			}
+			state, err := token.DBFetchTokenState(dbTx, id)
+			if err != nil {
+				return err
+			}
+			serialized, err := state.Serialize()
+			if err != nil {
+				return err
+			}
+			hashes = append([]*hash.Hash{ib.GetHash()}, hashes...)
+			states = append([][]byte{serialized}, states...)
+			id = state.PrevStateID
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	err = s.WriteElements(w, uint32(len(hashes)))
+	if err != nil {
+		return err
+	}
+	for i, h := range hashes {
+		err = s.WriteElements(w, h)
+		if err != nil {
+			return err
+		}
+		err = s.WriteVarBytes(w, 0, states[i])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// snapshotHandler receives the elements of snapshot in the order of file.
+type snapshotHandler struct {
+	block      func(order uint64, blockBytes []byte, journal []byte, invalid bool) error
+	tokenState func(h *hash.Hash, serialized []byte) error
+	utxo       func(key []byte, serialized []byte) error
+}
+
+// readUtxoSnapshot reads the whole snapshot and checks the checksum and the
+// utxo set hash, the handler can be nil.
+func (b *BlockChain) readUtxoSnapshot(r io.Reader, handler *snapshotHandler) (*UtxoSnapshot, error) {
+	checksum := hash.GetHasher(hash.Blake2b_256)
+	hr := io.TeeReader(r, checksum)
+
+	var magic [4]byte
+	_, err := io.ReadFull(hr, magic[:])
+	if err != nil {
+		return nil, err
+	}
+	if magic != utxoSnapshotMagic {
+		return nil, fmt.Errorf("It is not utxo set snapshot")
+	}
+	var version, net uint32
+	us := &UtxoSnapshot{}
+	err = s.ReadElements(hr, &version, &net, &us.BaseHash, &us.BaseOrder)
+	if err != nil {
+		return nil, err
+	}
+	if version != utxoSnapshotVersion {
+		return nil, fmt.Errorf("Unsupported utxo set snapshot version:%d", version)
+	}
+	if net != uint32(b.params.Net) {
+		return nil, fmt.Errorf("The utxo set snapshot is not for %s", b.params.Name)
+	}
+
+	for order := uint64(1); order <= us.BaseOrder; order++ {
+		blockBytes, err := s.ReadVarBytes(hr, 0, maxSnapshotElementSize, "block")
+		if err != nil {
+			return nil, err
+		}
+		journal, err := s.ReadVarBytes(hr, 0, maxSnapshotElementSize, "spend journal")
+		if err != nil {
+			return nil, err
+		}
+		var invalid bool
+		err = s.ReadElements(hr, &invalid)
+		if err != nil {
+			return nil, err
+		}
+		if handler != nil {
+			err = handler.block(order, blockBytes, journal, invalid)
+			if err != nil {
+				return nil, err
+			}
+		}
+		us.Blocks++
+	}
+
+	var tokenStates uint32
+	err = s.ReadElements(hr, &tokenStates)
+	if err != nil {
+		return nil, err
+	}
+	for i := uint32(0); i < tokenStates; i++ {
+		var h hash.Hash
+		err = s.ReadElements(hr, &h)
+		if err != nil {
+			return nil, err
+		}
+		serialized, err := s.ReadVarBytes(hr, 0, maxSnapshotElementSize, "token state")
+		if err != nil {
+			return nil, err
+		}
+		if handler != nil {
+			err = handler.tokenState(&h, serialized)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	uh := newUtxoSetHasher(&us.BaseHash)
+	for {
+		key, err := s.ReadVarBytes(hr, 0, maxSnapshotElementSize, "outpoint")
+		if err != nil {
+			return nil, err
+		}
+		if len(key) == 0 {
+			break
+		}
+		serialized, err := s.ReadVarBytes(hr, 0, maxSnapshotElementSize, "utxo entry")
+		if err != nil {
+			return nil, err
+		}
+		uh.add(key, serialized)
+		if handler != nil {
+			err = handler.utxo(key, serialized)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	us.UtxoSetHash = uh.sum()
+	us.Utxos = uh.count
+
+	expect := checksum.Sum(nil)
+	var sum hash.Hash
+	_, err = io.ReadFull(r, sum[:])
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(sum[:], expect) {
+		return nil, fmt.Errorf("The checksum of utxo set snapshot is wrong")
+	}
+	return us, nil
+}
+
+// checkAssumeUTXO makes sure the snapshot is committed in params.
+func (b *BlockChain) checkAssumeUTXO(us *UtxoSnapshot) error {
+	for _, au := range b.params.AssumeUTXO {
+		if !au.Hash.IsEqual(&us.BaseHash) {
+			continue
+		}
+		if au.Order != us.BaseOrder || !au.UtxoSetHash.IsEqual(&us.UtxoSetHash) {
+			return fmt.Errorf("The utxo set snapshot %s (order:%d utxo set hash:%s) is not the same as params",
+				us.BaseHash, us.BaseOrder, us.UtxoSetHash)
+		}
+		return nil
+	}
+	return fmt.Errorf("The base %s of utxo set snapshot is unknown for %s", us.BaseHash, b.params.Name)
+}
+
+// LoadUtxoSet bootstraps the empty chain from the utxo set snapshot. The
+// snapshot is read twice, it is only loaded after the checksum and utxo set
+// hash were checked.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) LoadUtxoSet(r io.ReadSeeker) (*UtxoSnapshot, error) {
+	b.ChainLock()
+	defer b.ChainUnlock()
+
+	if b.bd.GetMainChainTip().GetOrder() > 0 {
+		return nil, fmt.Errorf("The chain is not empty, please cleanup the database")
+	}
+	us, err := b.readUtxoSnapshot(r, nil)
+	if err != nil {
+		return nil, err
+	}
+	err = b.checkAssumeUTXO(us)
+	if err != nil {
+		return nil, err
+	}
+	_, err = r.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	var tokenTipID uint32
+	prevTokenID := uint32(blockdag.MaxId)
+	batch := [][2][]byte{}
+	putUtxos := func() error {
+		err := b.db.Update(func(dbTx database.Tx) error {
+			bucket := dbTx.Metadata().Bucket(dbnamespace.UtxoSetBucketName)
+			for _, kv := range batch {
+				err := bucket.Put(kv[0], kv[1])
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		batch = batch[:0]
+		return err
+	}
+	handler := &snapshotHandler{
+		block: func(order uint64, blockBytes []byte, journal []byte, invalid bool) error {
+			return b.loadSnapshotBlock(order, blockBytes, journal, invalid)
+		},
+		tokenState: func(h *hash.Hash, serialized []byte) error {
+			ib := b.bd.GetBlock(h)
+			if ib == nil {
+				return fmt.Errorf("No block of token state:%s", h)
+			}
+			state := &token.TokenState{}
+			_, err := state.Deserialize(serialized)
+			if err != nil {
+				return err
+			}
+			state.PrevStateID = prevTokenID
+			tokenTipID = uint32(ib.GetID())
+			prevTokenID = tokenTipID
+			return b.db.Update(func(dbTx database.Tx) error {
+				return token.DBPutTokenState(dbTx, tokenTipID, state)
+			})
+		},
+		utxo: func(key []byte, serialized []byte) error {
+			batch = append(batch, [2][]byte{key, serialized})
+			if len(batch) < utxoSnapshotBatchSize {
+				return nil
+			}
+			return putUtxos()
+		},
+	}
+	loaded, err := b.readUtxoSnapshot(r, handler)
+	if err != nil {
+		return nil, err
+	}
+	err = putUtxos()
+	if err != nil {
+		return nil, err
+	}
+	if loaded.UtxoSetHash != us.UtxoSetHash {
+		return nil, fmt.Errorf("The utxo set snapshot was changed while loading")
+	}
+	if !b.bd.GetMainChainTip().GetHash().IsEqual(&us.BaseHash) {
+		return nil, fmt.Errorf("The main chain tip %s is not the base %s of snapshot",
+			b.bd.GetMainChainTip().GetHash(), us.BaseHash)
+	}
+	b.TokenTipID = tokenTipID
+	err = b.db.Update(func(dbTx database.Tx) error {
+		return dbPutUtxoSnapshot(dbTx, us)
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = b.buildUtxoCommitment(true)
+	if err != nil {
+		return nil, err
+	}
+	err = b.buildTicketIndex(true)
+	if err != nil {
+		return nil, err
+	}
+	return us, b.updateBestStateAfterReconnect()
+}
+
+// loadSnapshotBlock adds the block of snapshot into DAG without connecting
+// it, the spend journal is kept for the disconnection of reorganization.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) loadSnapshotBlock(order uint64, blockBytes []byte, journal []byte, invalid bool) error {
+	block, err := types.NewBlockFromBytes(blockBytes)
+	if err != nil {
+		return err
+	}
+	newNode := NewBlockNode(block, block.Block().Parents)
+	newOrders, _, ib, _, err := b.bd.AddBlock(newNode)
+	if err != nil {
+		return err
+	}
+	if ib == nil || uint64(ib.GetOrder()) != order {
+		return fmt.Errorf("The order of block %s is not %d in snapshot", block.Hash(), order)
+	}
+	if invalid {
+		b.bd.InvalidBlock(ib)
+	}
+	block.SetOrder(order)
+	block.SetHeight(ib.GetHeight())
+	err = b.db.Update(func(dbTx database.Tx) error {
+		err := dbMaybeStoreBlock(dbTx, block)
+		if err != nil {
+			return err
+		}
+		if len(journal) == 0 {
+			return nil
+		}
+		return dbTx.Metadata().Bucket(dbnamespace.SpendJournalBucketName).Put(block.Hash()[:], journal)
+	})
+	if err != nil {
+		return err
+	}
+	return b.updateBestState(ib, block, newOrders)
+}
+
+// ValidateUtxoSnapshot replays the blocks before the base of loaded snapshot
+// in a scratch chain, and the snapshot is marked as validated if the utxo set
+// hash is the same.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ValidateUtxoSnapshot(scratchDB database.DB, interrupt <-chan struct{}) error {
+	us, err := b.FetchUtxoSnapshot()
+	if err != nil {
+		return err
+	}
+	if us == nil || us.Validated {
+		return nil
+	}
+	scratch, err := New(&Config{
+		DB:          scratchDB,
+		Interrupt:   interrupt,
+		ChainParams: b.params,
+		TimeSource:  b.timeSource,
+		DAGType:     b.bd.GetName(),
+	})
+	if err != nil {
+		return err
+	}
+	log.Info(fmt.Sprintf("Validate utxo set snapshot %s by replaying %d blocks", us.BaseHash, us.BaseOrder))
+	for order := uint64(1); order <= us.BaseOrder; order++ {
+		select {
+		case <-interrupt:
+			return fmt.Errorf("Interrupted validating utxo set snapshot")
+		default:
+		}
+		block, err := b.BlockByOrder(order)
+		if err != nil {
+			return err
+		}
+		err = scratch.FastAcceptBlock(block, BFFastAdd)
+		if err != nil {
+			return err
+		}
+	}
+	err = scratch.FlushUtxoCache()
+	if err != nil {
+		return err
+	}
+	var utxoSetHash hash.Hash
+	err = scratchDB.View(func(dbTx database.Tx) error {
+		utxoSetHash, _ = dbCalcUtxoSetHash(dbTx, &us.BaseHash)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if utxoSetHash != us.UtxoSetHash {
+		return fmt.Errorf("The utxo set snapshot %s is not consistent with its history (%s != %s)",
+			us.BaseHash, utxoSetHash, us.UtxoSetHash)
+	}
+	us.Validated = true
+	err = b.db.Update(func(dbTx database.Tx) error {
+		return dbPutUtxoSnapshot(dbTx, us)
+	})
+	if err != nil {
+		return err
+	}
+	log.Info(fmt.Sprintf("The utxo set snapshot %s was validated", us.BaseHash))
+	return nil
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+
+package blockchain
+
+import (
+	"bytes"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/database"
+	"github.com/Qitmeer/qitmeer/params"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestUtxoSnapshot ensures the utxo set snapshot can only be loaded if it is
+// committed in params, and the loaded chain is the same as the exported one.
+func TestUtxoSnapshot(t *testing.T) {
+	par := *params.PrivNetParam.Params
+	src := newTestChain(t, "test_utxosnapshot_src", &par)
+	defer src.cleanup()
+	src.addBlocks(20)
+
+	var buff bytes.Buffer
+	us, err := src.bc.ExportUtxoSet(&buff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mainTip := src.bc.BlockDAG().GetMainChainTip()
+	if us.BaseHash != *mainTip.GetHash() || us.BaseOrder != uint64(mainTip.GetOrder()) {
+		t.Fatalf("the base of snapshot is %s (order:%d), but the main chain tip is %s (order:%d)",
+			us.BaseHash, us.BaseOrder, mainTip.GetHash(), mainTip.GetOrder())
+	}
+	snapshot := buff.Bytes()
+
+	load := func(name string, au []params.AssumeUTXO, snapshot []byte) (*testChain, error) {
+		dstPar := par
+		dstPar.AssumeUTXO = au
+		dst := newTestChain(t, name, &dstPar)
+		_, err := dst.bc.LoadUtxoSet(bytes.NewReader(snapshot))
+		return dst, err
+	}
+
+	// The snapshot which is not committed in params can't be loaded.
+	dst, err := load("test_utxosnapshot_unknown", nil, snapshot)
+	dst.cleanup()
+	if err == nil || !strings.Contains(err.Error(), "unknown") {
+		t.Fatalf("expected the unknown snapshot error, got %v", err)
+	}
+	wrongHash := hash.HashH([]byte("wrong"))
+	dst, err = load("test_utxosnapshot_wrong", []params.AssumeUTXO{
+		{Order: us.BaseOrder, Hash: &us.BaseHash, UtxoSetHash: &wrongHash},
+	}, snapshot)
+	dst.cleanup()
+	if err == nil || !strings.Contains(err.Error(), "not the same as params") {
+		t.Fatalf("expected the mismatched snapshot error, got %v", err)
+	}
+
+	// The corrupted snapshot is rejected by the checksum.
+	assumeUTXO := []params.AssumeUTXO{
+		{Order: us.BaseOrder, Hash: &us.BaseHash, UtxoSetHash: &us.UtxoSetHash},
+	}
+	corrupted := append([]byte{}, snapshot...)
+	corrupted[len(corrupted)/2] ^= 0xff
+	dst, err = load("test_utxosnapshot_corrupted", assumeUTXO, corrupted)
+	dst.cleanup()
+	if err == nil {
+		t.Fatal("the corrupted snapshot was loaded")
+	}
+
+	dst, err = load("test_utxosnapshot_dst", assumeUTXO, snapshot)
+	defer dst.cleanup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dstTip := dst.bc.BlockDAG().GetMainChainTip()
+	if *dstTip.GetHash() != us.BaseHash {
+		t.Fatalf("the main chain tip is %s, but the base of snapshot is %s", dstTip.GetHash(), us.BaseHash)
+	}
+	var utxoSetHash hash.Hash
+	err = dst.db.View(func(dbTx database.Tx) error {
+		utxoSetHash, _ = dbCalcUtxoSetHash(dbTx, &us.BaseHash)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if utxoSetHash != us.UtxoSetHash {
+		t.Fatalf("the utxo set hash is %s, but %s in snapshot", utxoSetHash, us.UtxoSetHash)
+	}
+
+	// The loaded chain goes on from the base.
+	dst.addBlocks(1)
+
+	// The history of snapshot is validated by replaying the blocks.
+	scratchPath, err := ioutil.TempDir("", "test_utxosnapshot_scratch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(scratchPath)
+	scratchDB, err := database.Create("ffldb", scratchPath, par.Net)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer scratchDB.Close()
+	err = dst.bc.ValidateUtxoSnapshot(scratchDB, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := dst.bc.FetchUtxoSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded == nil || !loaded.Validated {
+		t.Fatalf("the snapshot was not validated: %+v", loaded)
+	}
+}
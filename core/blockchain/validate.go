@@ -7,11 +7,13 @@
 package blockchain
 
 import (
+	"bytes"
 	"encoding/binary"
 	"encoding/hex"
 	"fmt"
 	"github.com/Qitmeer/qitmeer/common/hash"
 	"github.com/Qitmeer/qitmeer/core/blockchain/opreturn"
+	"github.com/Qitmeer/qitmeer/core/blockchain/stake"
 	"github.com/Qitmeer/qitmeer/core/blockchain/token"
 	"github.com/Qitmeer/qitmeer/core/blockdag"
 	"github.com/Qitmeer/qitmeer/core/dbnamespace"
@@ -80,42 +82,9 @@ func (b *BlockChain) checkBlockSanity(block *types.SerializedBlock, timeSource M
 	if err != nil {
 		return err
 	}
-	// A block must have at least one parent.
-	numPb := len(msgBlock.Parents)
-	if numPb == 0 {
-		return ruleError(ErrNoParents, "block does not contain "+
-			"any parent")
-	}
-
-	// A block must not have more parents than the max block payload or
-	// else it is certainly over the weight limit.
-	if numPb > types.MaxParentsPerBlock {
-		str := fmt.Sprintf("block contains too many parents - "+
-			"got %d, max %d", numPb, types.MaxParentsPerBlock)
-		return ruleError(ErrBlockTooBig, str)
-	}
-	// Build the block parents merkle tree and ensure the calculated merkle
-	// parents root matches the entry in the block header.
-	// This also has the effect of caching all
-	// of the parents hashes in the block to speed up future hash
-	// checks.  The tree here and checks the merkle root
-	// after the following checks, but there is no reason not to check the
-	// merkle root matches here.
-	paMerkles := merkle.BuildParentsMerkleTreeStore(msgBlock.Parents)
-	paMerkleRoot := paMerkles[len(paMerkles)-1]
-	if !header.ParentRoot.IsEqual(paMerkleRoot) {
-		str := fmt.Sprintf("block parents merkle root is invalid - block "+
-			"header indicates %v, but calculated value is %v",
-			&header.ParentRoot, paMerkleRoot)
-		return ruleError(ErrBadParentsMerkleRoot, str)
-	}
-
-	// Repeated parents
-	parentsSet := blockdag.NewHashSet()
-	parentsSet.AddList(msgBlock.Parents)
-	if len(msgBlock.Parents) != parentsSet.Size() {
-		str := fmt.Sprintf("parents:%v", msgBlock.Parents)
-		return ruleError(ErrDuplicateParent, str)
+	err = checkBlockParentsSanity(msgBlock)
+	if err != nil {
+		return err
 	}
 
 	// A block must not exceed the maximum allowed block payload when
@@ -182,17 +151,7 @@ func (b *BlockChain) checkBlockSanity(block *types.SerializedBlock, timeSource M
 		return ruleError(ErrBadMerkleRoot, str)
 	}
 
-	// Build merkle tree and ensure the calculated merkle root matches the
-	// entry in the block header.  This also has the effect of caching all
-	// of the token balance hashes in the block to speed up future hash
-	// checks.
-	calculatedTokenStateRoot := b.CalculateTokenStateRoot(block.Transactions(), msgBlock.Parents)
-	if !header.StateRoot.IsEqual(&calculatedTokenStateRoot) {
-		str := fmt.Sprintf("block merkle state root is invalid - block "+
-			"header indicates %s, but calculated value is %s",
-			header.StateRoot, calculatedTokenStateRoot)
-		return ruleError(ErrBadMerkleRoot, str)
-	}
+	// The state root is checked with the main parent in checkBlockContext.
 
 	// Check for duplicate transactions.  This check will be fairly quick
 	// since the transaction hashes are already cached due to building the
@@ -275,6 +234,50 @@ func checkBlockHeaderSanity(header *types.BlockHeader, timeSource MedianTimeSour
 	return nil
 }
 
+// checkBlockParentsSanity ensures the parents of block are not empty, not too
+// many and not repeated, and they match the parents root in the header.
+func checkBlockParentsSanity(msgBlock *types.Block) error {
+	header := &msgBlock.Header
+	// A block must have at least one parent.
+	numPb := len(msgBlock.Parents)
+	if numPb == 0 {
+		return ruleError(ErrNoParents, "block does not contain "+
+			"any parent")
+	}
+
+	// A block must not have more parents than the max block payload or
+	// else it is certainly over the weight limit.
+	if numPb > types.MaxParentsPerBlock {
+		str := fmt.Sprintf("block contains too many parents - "+
+			"got %d, max %d", numPb, types.MaxParentsPerBlock)
+		return ruleError(ErrBlockTooBig, str)
+	}
+	// Build the block parents merkle tree and ensure the calculated merkle
+	// parents root matches the entry in the block header.
+	// This also has the effect of caching all
+	// of the parents hashes in the block to speed up future hash
+	// checks.  The tree here and checks the merkle root
+	// after the following checks, but there is no reason not to check the
+	// merkle root matches here.
+	paMerkles := merkle.BuildParentsMerkleTreeStore(msgBlock.Parents)
+	paMerkleRoot := paMerkles[len(paMerkles)-1]
+	if !header.ParentRoot.IsEqual(paMerkleRoot) {
+		str := fmt.Sprintf("block parents merkle root is invalid - block "+
+			"header indicates %v, but calculated value is %v",
+			&header.ParentRoot, paMerkleRoot)
+		return ruleError(ErrBadParentsMerkleRoot, str)
+	}
+
+	// Repeated parents
+	parentsSet := blockdag.NewHashSet()
+	parentsSet.AddList(msgBlock.Parents)
+	if len(msgBlock.Parents) != parentsSet.Size() {
+		str := fmt.Sprintf("parents:%v", msgBlock.Parents)
+		return ruleError(ErrDuplicateParent, str)
+	}
+	return nil
+}
+
 // checkProofOfWork ensures the block header bits which indicate the target
 // difficulty is in min/max range and that the block hash is less than the
 // target difficulty as claimed.
@@ -323,9 +326,38 @@ func CheckTransactionSanity(tx *types.Transaction, params *params.Params) error
 		if err != nil {
 			return err
 		}
+		// The optional output of revoke releases the locked MEER.
+		if types.IsTokenRevokeTx(tx) && len(tx.TxOut) > 1 {
+			atom := tx.TxOut[1].Amount
+			if atom.Id != types.MEERID {
+				str := fmt.Sprintf("token revoke must release %s, but %s", types.MEERID.Name(), atom.Id.Name())
+				return ruleError(ErrInvalidTxOutValue, str)
+			}
+			if atom.Value <= 0 || atom.Value > types.MaxAmount {
+				str := fmt.Sprintf("token revoke release value of %v is "+
+					"out of range (0, %v]", atom, types.MaxAmount)
+				return ruleError(ErrInvalidTxOutValue, str)
+			}
+		}
+		// The optional output of token new and token renew carries the
+		// metadata without value.
+		if (types.IsTokenNewTx(tx) || types.IsTokenRenewTx(tx)) && len(tx.TxOut) > 1 {
+			if tx.TxOut[1].Amount.Value != 0 {
+				str := fmt.Sprintf("token metadata output must have no value, but %v", tx.TxOut[1].Amount)
+				return ruleError(ErrInvalidTxOutValue, str)
+			}
+		}
 		return update.CheckSanity()
 	}
 
+	// The ticket of stake purchase locks enough MEER.
+	if types.IsStakePurchaseTx(tx) {
+		err := stake.CheckStakePurchase(tx, params.StakeMinAmount)
+		if err != nil {
+			return ruleError(ErrBadStakeTx, err.Error())
+		}
+	}
+
 	// Ensure the transaction amounts are in range.  Each transaction
 	// output must not be negative or more than the max allowed per
 	// transaction.  Also, the total of all outputs must abide by the same
@@ -561,6 +593,21 @@ func (b *BlockChain) checkBlockContext(block *types.SerializedBlock, mainParent
 	if err != nil {
 		return err
 	}
+
+	// Ensure the state root matches the token state.  Once the
+	// DeploymentUtxoCommitment is active, it also commits to the utxo set
+	// of main parent, which is only stable on the main chain, so it is
+	// checked in checkConnectBlock instead.
+	utxoCommitment, err := b.isUtxoCommitmentActive(mainParent)
+	if err != nil {
+		return err
+	}
+	if !utxoCommitment {
+		err = b.checkStateRoot(block, mainParent)
+		if err != nil {
+			return err
+		}
+	}
 	header := &block.Block().Header
 	fastAdd := flags&BFFastAdd == BFFastAdd
 	if !fastAdd {
@@ -634,10 +681,15 @@ func (b *BlockChain) checkBlockContext(block *types.SerializedBlock, mainParent
 
 func (b *BlockChain) checkBlockSubsidy(block *types.SerializedBlock) error {
 	bi := b.bd.GetBlueInfoByHash(block.Block().Parents[0])
+	stakeActive, err := b.isStakeActive(b.bd.GetBlock(block.Block().Parents[0]))
+	if err != nil {
+		return err
+	}
 	// check subsidy
 	transactions := block.Transactions()
 	subsidy := b.subsidyCache.CalcBlockSubsidy(bi)
 	workAmountOut := int64(0)
+	stakeAmountOut := int64(0)
 	txoutLen := len(transactions[0].Tx.TxOut)
 	hasOPR := opreturn.IsOPReturn(transactions[0].Tx.TxOut[txoutLen-1].PkScript)
 	for k, v := range transactions[0].Tx.TxOut {
@@ -660,28 +712,38 @@ func (b *BlockChain) checkBlockSubsidy(block *types.SerializedBlock) error {
 			}
 
 		}
+		// The stake reserve outputs are not paid to the miner.
+		if stakeActive && k != CoinbaseOutput_subsidy &&
+			bytes.Equal(v.PkScript, b.params.StakeReservePkScript) {
+			stakeAmountOut += v.Amount.Value
+			continue
+		}
 		workAmountOut += v.Amount.Value
 	}
 
 	var work int64
 	var tax int64
+	var stakeSubsidy int64
 	var taxAmountOut int64 = 0
 	var taxOutput *types.TxOutput
 	var totalAmountOut int64 = 0
 
+	if stakeActive {
+		stakeSubsidy = int64(CalcBlockStakeSubsidy(b.subsidyCache, bi, b.params))
+	}
 	if b.params.HasTax() {
-		work = int64(CalcBlockWorkSubsidy(b.subsidyCache, bi, b.params))
-		tax = int64(CalcBlockTaxSubsidy(b.subsidyCache, bi, b.params))
+		work = int64(CalcBlockWorkSubsidy(b.subsidyCache, bi, b.params, stakeActive))
+		tax = int64(CalcBlockTaxSubsidy(b.subsidyCache, bi, b.params, stakeActive))
 		taxOutput = transactions[0].Tx.TxOut[len(transactions[0].Tx.TxOut)-1]
 
 		taxAmountOut = taxOutput.Amount.Value
 	} else {
-		work = subsidy
+		work = subsidy - stakeSubsidy
 		tax = 0
 		taxAmountOut = 0
 	}
 
-	totalAmountOut = workAmountOut + taxAmountOut
+	totalAmountOut = workAmountOut + stakeAmountOut + taxAmountOut
 
 	if totalAmountOut != subsidy {
 		str := fmt.Sprintf("coinbase transaction for block pays %v which is not the subsidy %v",
@@ -696,6 +758,12 @@ func (b *BlockChain) checkBlockSubsidy(block *types.SerializedBlock) error {
 		return ruleError(ErrBadCoinbaseValue, str)
 	}
 
+	if stakeAmountOut != stakeSubsidy {
+		str := fmt.Sprintf("coinbase transaction for block reserves %d for the stake holders which is not the %d",
+			stakeAmountOut, stakeSubsidy)
+		return ruleError(ErrBadCoinbaseValue, str)
+	}
+
 	if b.params.HasTax() {
 		orgPkScriptStr := hex.EncodeToString(b.params.OrganizationPkScript)
 		curPkScriptStr := hex.EncodeToString(taxOutput.PkScript)
@@ -725,33 +793,10 @@ func (b *BlockChain) checkBlockHeaderContext(block *types.SerializedBlock, prevN
 	header := &block.Block().Header
 	fastAdd := flags&BFFastAdd == BFFastAdd
 	if !fastAdd {
-		instance := pow.GetInstance(header.Pow.GetPowType(), 0, []byte{})
-		instance.SetMainHeight(pow.MainHeight(prevNode.GetHeight() + 1))
-		instance.SetParams(b.params.PowConfig)
-		// Ensure the difficulty specified in the block header matches
-		// the calculated difficulty based on the previous block and
-		// difficulty retarget rules.
-		expDiff, err := b.calcNextRequiredDifficulty(prevNode,
-			header.Timestamp, instance)
+		err := b.checkHeaderDifficulty(header, prevNode)
 		if err != nil {
 			return err
 		}
-		blockDifficulty := header.Difficulty
-		if blockDifficulty != expDiff {
-			str := fmt.Sprintf("block difficulty of %d is not the"+
-				" expected value of %d", blockDifficulty,
-				expDiff)
-			return ruleError(ErrUnexpectedDifficulty, str)
-		}
-
-		// Ensure the timestamp for the block header is after the
-		// median time of the last several blocks (medianTimeBlocks).
-		medianTime := b.CalcPastMedianTime(prevNode)
-		if !header.Timestamp.After(medianTime) {
-			str := "block timestamp of %v is not after expected %v"
-			str = fmt.Sprintf(str, header.Timestamp.Unix(), medianTime.Unix())
-			return ruleError(ErrTimeTooOld, str)
-		}
 	}
 
 	// checkpoint
@@ -789,6 +834,41 @@ func (b *BlockChain) checkBlockHeaderContext(block *types.SerializedBlock, prevN
 	return nil
 }
 
+// checkHeaderDifficulty ensures the difficulty and the timestamp of the block
+// header follow the retarget rules and the median time of its main parent.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) checkHeaderDifficulty(header *types.BlockHeader, prevNode blockdag.IBlock) error {
+	instance := pow.GetInstance(header.Pow.GetPowType(), 0, []byte{})
+	instance.SetMainHeight(pow.MainHeight(prevNode.GetHeight() + 1))
+	instance.SetParams(b.params.PowConfig)
+	// Ensure the difficulty specified in the block header matches
+	// the calculated difficulty based on the previous block and
+	// difficulty retarget rules.
+	expDiff, err := b.calcNextRequiredDifficulty(prevNode,
+		header.Timestamp, instance)
+	if err != nil {
+		return err
+	}
+	blockDifficulty := header.Difficulty
+	if blockDifficulty != expDiff {
+		str := fmt.Sprintf("block difficulty of %d is not the"+
+			" expected value of %d", blockDifficulty,
+			expDiff)
+		return ruleError(ErrUnexpectedDifficulty, str)
+	}
+
+	// Ensure the timestamp for the block header is after the
+	// median time of the last several blocks (medianTimeBlocks).
+	medianTime := b.CalcPastMedianTime(prevNode)
+	if !header.Timestamp.After(medianTime) {
+		str := "block timestamp of %v is not after expected %v"
+		str = fmt.Sprintf(str, header.Timestamp.Unix(), medianTime.Unix())
+		return ruleError(ErrTimeTooOld, str)
+	}
+	return nil
+}
+
 // checkConnectBlock performs several checks to confirm connecting the passed
 // block to the chain represented by the passed view does not violate any
 // rules.  In addition, the passed view is updated to spend all of the
@@ -820,6 +900,10 @@ func (b *BlockChain) checkConnectBlock(ib blockdag.IBlock, block *types.Serializ
 		str := "the coinbase for the genesis block is not spendable"
 		return ruleError(ErrMissingTxOut, str)
 	}
+	if ib.GetStatus().IsInvalidated() {
+		str := fmt.Sprintf("the block %s was invalidated", ib.GetHash())
+		return ruleError(ErrBlockInvalidated, str)
+	}
 	// Don't run scripts if this node is before the latest known good
 	// checkpoint since the validity is verified via the checkpoints (all
 	// transactions are included in the merkle root hash and any changes
@@ -831,10 +915,15 @@ func (b *BlockChain) checkConnectBlock(ib blockdag.IBlock, block *types.Serializ
 	if checkpoint != nil && uint64(ib.GetLayer()) <= checkpoint.Layer {
 		runScripts = false
 	}
+	// The same for the past set of the assumed valid block, the other
+	// checks of the transactions are still done to build the utxo set.
+	if runScripts && b.isAssumedValid(ib) {
+		runScripts = false
+	}
 	var scriptFlags txscript.ScriptFlags
 	var err error
 	if runScripts {
-		scriptFlags, err = b.consensusScriptVerifyFlags()
+		scriptFlags, err = b.consensusScriptVerifyFlags(b.bd.GetBlockById(ib.GetMainParent()))
 		if err != nil {
 			return err
 		}
@@ -866,16 +955,30 @@ func (b *BlockChain) checkConnectBlock(ib blockdag.IBlock, block *types.Serializ
 		return err
 	}
 
+	// The utxo set commitment of main parent is kept when it is connected on
+	// the main chain, so the state root of a block off the main chain is
+	// checked once it joins the main chain.
+	mainParent := b.bd.GetBlockById(ib.GetMainParent())
+	if mainParent == nil {
+		return fmt.Errorf("Block Main Parent error:%s\n", ib.GetHash().String())
+	}
+	utxoCommitment, err := b.isUtxoCommitmentActive(mainParent)
+	if err != nil {
+		return err
+	}
+	if utxoCommitment && b.bd.IsOnMainChain(ib.GetID()) {
+		err = b.checkStateRoot(block, mainParent)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Enforce all relative lock times via sequence numbers for the regular
 	// transaction tree once the stake vote for the agenda is active.
 
 	// Use the past median time of the *previous* block in order
 	// to determine if the transactions in the current block are
 	// final.
-	mainParent := b.bd.GetBlockById(ib.GetMainParent())
-	if mainParent == nil {
-		return fmt.Errorf("Block Main Parent error:%s\n", ib.GetHash().String())
-	}
 	prevMedianTime := b.CalcPastMedianTime(mainParent)
 
 	// Skip the coinbase since it does not have any inputs and thus
@@ -898,7 +1001,24 @@ func (b *BlockChain) checkConnectBlock(ib blockdag.IBlock, block *types.Serializ
 	}
 
 	if runScripts {
-		err = b.checkBlockScripts(block, utxoView,
+		// The token owners who sign the token transactions are from the
+		// token state of the main parent.
+		mainParent := b.bd.GetBlockById(ib.GetMainParent())
+		var tokenState *token.TokenState
+		for _, tx := range block.Transactions() {
+			if types.IsTokenTx(tx.Tx) {
+				tokenState, err = b.tokenStateAt(mainParent)
+				if err != nil {
+					return err
+				}
+				break
+			}
+		}
+		tokenV2Active, err := b.isTokenV2Active(mainParent)
+		if err != nil {
+			return err
+		}
+		err = b.checkBlockScripts(block, utxoView, tokenState, tokenV2Active,
 			scriptFlags, b.sigCache)
 		if err != nil {
 			log.Trace("checkBlockScripts failed; error returned "+
@@ -913,9 +1033,10 @@ func (b *BlockChain) checkConnectBlock(ib blockdag.IBlock, block *types.Serializ
 // consensusScriptVerifyFlags returns the script flags that must be used when
 // executing transaction scripts to enforce the consensus rules. This includes
 // any flags required as the result of any agendas that have passed and become
-// active.
-func (b *BlockChain) consensusScriptVerifyFlags() (txscript.ScriptFlags, error) {
-	//TODO, refactor the txvm flag, the flag should decided by node.parent
+// active for the block after prevNode.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) consensusScriptVerifyFlags(prevNode blockdag.IBlock) (txscript.ScriptFlags, error) {
 	scriptFlags := txscript.ScriptBip16 |
 		txscript.ScriptVerifyDERSignatures |
 		txscript.ScriptVerifyStrictEncoding |
@@ -925,6 +1046,16 @@ func (b *BlockChain) consensusScriptVerifyFlags() (txscript.ScriptFlags, error)
 
 	scriptFlags |= txscript.ScriptVerifyCheckSequenceVerify
 	scriptFlags |= txscript.ScriptVerifySHA256
+
+	// Enforce the release height of the locked genesis outputs once the
+	// genesis lock is active.
+	active, err := b.isGenesisLockActive(prevNode)
+	if err != nil {
+		return 0, err
+	}
+	if active {
+		scriptFlags |= txscript.ScriptVerifyMeerLock
+	}
 	return scriptFlags, nil
 }
 
@@ -950,6 +1081,14 @@ func (b *BlockChain) checkTransactionsAndConnect(node *BlockNode, block *types.S
 		}
 	}
 
+	stakeActive, err := b.isStakeActive(b.bd.GetBlock(block.Block().Parents[0]))
+	if err != nil {
+		return err
+	}
+	tokenV2Active, err := b.isTokenV2Active(b.bd.GetBlock(block.Block().Parents[0]))
+	if err != nil {
+		return err
+	}
 	totalFees := types.AmountMap{}
 	for idx, tx := range transactions {
 		if tx.IsDuplicate {
@@ -959,6 +1098,10 @@ func (b *BlockChain) checkTransactionsAndConnect(node *BlockNode, block *types.S
 			continue
 		}
 		if types.IsTokenTx(tx.Tx) {
+			err := b.CheckTokenTypeUpdate(tx, tokenV2Active)
+			if err != nil {
+				return err
+			}
 			if types.IsTokenMintTx(tx.Tx) {
 				err := b.CheckTokenTransactionInputs(tx, utxoView)
 				if err != nil {
@@ -968,6 +1111,19 @@ func (b *BlockChain) checkTransactionsAndConnect(node *BlockNode, block *types.S
 				if err != nil {
 					return err
 				}
+			} else if types.IsTokenRevokeTx(tx.Tx) {
+				state, err := b.blockTokenState(block, idx)
+				if err != nil {
+					return err
+				}
+				err = b.CheckTokenRevoke(tx, state)
+				if err != nil {
+					return err
+				}
+				err = utxoView.connectTransaction(tx, node, uint32(idx), stxos, b)
+				if err != nil {
+					return err
+				}
 			}
 			continue
 		}
@@ -975,6 +1131,12 @@ func (b *BlockChain) checkTransactionsAndConnect(node *BlockNode, block *types.S
 		if err != nil {
 			return err
 		}
+		if stakeActive {
+			err = b.CheckStakeTransaction(tx, utxoView)
+			if err != nil {
+				return err
+			}
+		}
 		// Sum the total fees and ensure we don't overflow the
 		// accumulator.
 		for _, coinId := range types.CoinIDList {
@@ -1199,6 +1361,11 @@ func (b *BlockChain) CheckTransactionInputs(tx *types.Tx, utxoView *UtxoViewpoin
 		}
 	}
 
+	// Ensure the locked genesis outputs are spent by the release schedule.
+	if err := b.checkGenesisLock(tx, utxoView); err != nil {
+		return nil, err
+	}
+
 	if len(targets) > 0 {
 		viewpoints := []uint{}
 		for _, blockHash := range utxoView.viewpoints {
@@ -1459,3 +1626,82 @@ func (b *BlockChain) CheckTokenTransactionInputs(tx *types.Tx, utxoView *UtxoVie
 
 	return nil
 }
+
+// CheckTokenTypeUpdate checks the token new and token renew against the
+// token rules which depend on DeploymentTokenV2, they can only carry the
+// metadata once it is active, and then the owners are checked by CheckOwners.
+func (b *BlockChain) CheckTokenTypeUpdate(tx *types.Tx, tokenV2Active bool) error {
+	if !types.IsTokenNewTx(tx.Tx) && !types.IsTokenRenewTx(tx.Tx) {
+		return nil
+	}
+	if !tokenV2Active {
+		if len(tx.Tx.TxOut) > 1 {
+			return fmt.Errorf("Token transaction (%s) can't carry the metadata before %s is active\n",
+				tx.Hash(), params.DeploymentName(params.DeploymentTokenV2))
+		}
+		return nil
+	}
+	update, err := token.NewTypeUpdateFromTx(tx.Tx)
+	if err != nil {
+		return err
+	}
+	return update.CheckOwners()
+}
+
+// blockTokenState returns the token state which the transaction at txIdx of
+// the block is applied to. It is the state of token tip updated by the token
+// transactions before txIdx, just as CheckTokenState and updateTokenState
+// apply the block.
+func (b *BlockChain) blockTokenState(block *types.SerializedBlock, txIdx int) (*token.TokenState, error) {
+	state := b.GetTokenState(b.TokenTipID)
+	if state == nil {
+		return nil, fmt.Errorf("Token state error\n")
+	}
+	updates := []token.ITokenUpdate{}
+	for _, tx := range block.Transactions()[:txIdx] {
+		if tx.IsDuplicate || !types.IsTokenTx(tx.Tx) {
+			continue
+		}
+		update, err := token.NewUpdateFromTx(tx.Tx)
+		if err != nil {
+			return nil, err
+		}
+		updates = append(updates, update)
+	}
+	state.Updates = updates
+	err := state.Update()
+	if err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// CheckTokenRevoke checks the token revoke transaction against the token
+// state which it is applied to, the token must have no amount and the locked
+// MEER must be released totally.
+func (b *BlockChain) CheckTokenRevoke(tx *types.Tx, state *token.TokenState) error {
+	update, err := token.NewTypeUpdateFromTx(tx.Tx)
+	if err != nil {
+		return err
+	}
+	coinId := update.Tt.Id
+	tt, ok := state.Types[coinId]
+	if !ok {
+		return fmt.Errorf("It doesn't exist: Coin id (%d)\n", coinId)
+	}
+	if tt.Revoked {
+		return fmt.Errorf("It was revoked: Coin id (%d)\n", coinId)
+	}
+	tb := state.Balances[coinId]
+	if tb.Balance != 0 {
+		return fmt.Errorf("Revoke is allowed only when no token amount: Coin id (%d) balance (%d)\n", coinId, tb.Balance)
+	}
+	released := int64(0)
+	if len(tx.Tx.TxOut) > 1 {
+		released = tx.Tx.TxOut[1].Amount.Value
+	}
+	if released != tb.LockedMeer {
+		return fmt.Errorf("Token transaction revoke releases (%d), but the locked is (%d)\n", released, tb.LockedMeer)
+	}
+	return nil
+}
@@ -41,7 +41,8 @@ type IBlock interface {
 	// IsOrdered
 	IsOrdered() bool
 
-	// Get all parents set,the dag block has more than one parent
+	// Get all parents set,the dag block has more than one parent.
+	// Only the ids are kept, use BlockDAG to acquire the parent block.
 	GetParents() *IdSet
 
 	// Testing whether it has parents
@@ -50,7 +51,7 @@ type IBlock interface {
 	// Add child nodes to block
 	AddChild(child IBlock)
 
-	// Get all the children of block
+	// Get all the children of block. Only the ids are kept.
 	GetChildren() *IdSet
 
 	// Detecting the presence of child nodes
@@ -178,7 +179,7 @@ func (b *Block) AddChild(child IBlock) {
 	if b.children == nil {
 		b.children = NewIdSet()
 	}
-	b.children.AddPair(child.GetID(), child)
+	b.children.Add(child.GetID())
 }
 
 // Get all the children of block
@@ -453,6 +454,13 @@ const (
 
 	// StatusInvalid indicates that the block data has failed validation.
 	StatusInvalid BlockStatus = 1 << 2
+
+	// StatusInvalidated indicates that the block was invalidated by operator.
+	StatusInvalidated BlockStatus = 1 << 3
+
+	// StatusInvalidAncestor indicates that one of the ancestors was invalidated
+	// by operator.
+	StatusInvalidAncestor BlockStatus = 1 << 4
 )
 
 func (status BlockStatus) IsBadSide() bool {
@@ -460,5 +468,10 @@ func (status BlockStatus) IsBadSide() bool {
 }
 
 func (status BlockStatus) KnownInvalid() bool {
-	return status&StatusInvalid != 0
+	return status&(StatusInvalid|StatusInvalidated|StatusInvalidAncestor) != 0
+}
+
+// The block is invalid until the operator reconsiders it or its ancestor.
+func (status BlockStatus) IsInvalidated() bool {
+	return status&(StatusInvalidated|StatusInvalidAncestor) != 0
 }
@@ -73,8 +73,8 @@ func (bd *BlockDAG) getBlueInfo(ib IBlock) *BlueInfo {
 	if ib.GetMainParent() == 0 {
 		return NewBlueInfo(1, 0, 0)
 	}
-	mainIB, ok := ib.GetParents().Get(ib.GetMainParent()).(IBlock)
-	if !ok {
+	mainIB := bd.getBlockById(ib.GetMainParent())
+	if mainIB == nil {
 		return NewBlueInfo(1, 0, 0)
 	}
 	mt := ib.GetData().GetTimestamp() - mainIB.GetData().GetTimestamp()
@@ -0,0 +1,253 @@
+/*
+ * Copyright (c) 2017-2020 The qitmeer developers
+ */
+
+package blockdag
+
+import (
+	"container/list"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/database"
+	"github.com/Qitmeer/qitmeer/metrics"
+	"sync"
+)
+
+// The default maximum number of blocks that DAG keeps in memory
+const DefaultBlockCacheSize = 100000
+
+// Blocks whose layer is this close to the main chain tip will never be evicted,
+// because new blocks will almost always touch them.
+const CachePinnedLayers = MaxTipLayerGap * 2
+
+var (
+	blockCacheHitCounter   = metrics.NewRegisteredCounter("blockdag/cache/hit", nil)
+	blockCacheMissCounter  = metrics.NewRegisteredCounter("blockdag/cache/miss", nil)
+	blockCacheEvictCounter = metrics.NewRegisteredCounter("blockdag/cache/evict", nil)
+)
+
+// blockCache is a LRU set of DAG blocks by id. The block that is not in the cache
+// will be loaded from the database on demand.
+type blockCache struct {
+	lock     sync.Mutex
+	capacity int
+	items    map[uint]*list.Element
+	lru      *list.List
+}
+
+// Return the block by id, and mark it as recently used.
+func (bc *blockCache) get(id uint) IBlock {
+	bc.lock.Lock()
+	defer bc.lock.Unlock()
+
+	e, ok := bc.items[id]
+	if !ok {
+		blockCacheMissCounter.Inc(1)
+		return nil
+	}
+	blockCacheHitCounter.Inc(1)
+	bc.lru.MoveToFront(e)
+	return e.Value.(IBlock)
+}
+
+func (bc *blockCache) has(id uint) bool {
+	bc.lock.Lock()
+	defer bc.lock.Unlock()
+
+	_, ok := bc.items[id]
+	return ok
+}
+
+// Add or replace a block
+func (bc *blockCache) add(ib IBlock) {
+	bc.lock.Lock()
+	defer bc.lock.Unlock()
+
+	if e, ok := bc.items[ib.GetID()]; ok {
+		e.Value = ib
+		bc.lru.MoveToFront(e)
+		return
+	}
+	bc.items[ib.GetID()] = bc.lru.PushFront(ib)
+}
+
+// Add the block if it is absent, otherwise return the existing one.
+func (bc *blockCache) addIfAbsent(ib IBlock) IBlock {
+	bc.lock.Lock()
+	defer bc.lock.Unlock()
+
+	if e, ok := bc.items[ib.GetID()]; ok {
+		return e.Value.(IBlock)
+	}
+	bc.items[ib.GetID()] = bc.lru.PushFront(ib)
+	return ib
+}
+
+func (bc *blockCache) remove(id uint) {
+	bc.lock.Lock()
+	defer bc.lock.Unlock()
+
+	if e, ok := bc.items[id]; ok {
+		bc.lru.Remove(e)
+		delete(bc.items, id)
+	}
+}
+
+func (bc *blockCache) size() int {
+	bc.lock.Lock()
+	defer bc.lock.Unlock()
+
+	return bc.lru.Len()
+}
+
+func (bc *blockCache) clean() {
+	bc.lock.Lock()
+	defer bc.lock.Unlock()
+
+	bc.items = map[uint]*list.Element{}
+	bc.lru.Init()
+}
+
+// Evict the least recently used blocks until the cache is not over capacity.
+// The block that isPinned return true will be skipped.
+func (bc *blockCache) evict(isPinned func(ib IBlock) bool) int {
+	bc.lock.Lock()
+	defer bc.lock.Unlock()
+
+	if bc.capacity <= 0 {
+		return 0
+	}
+	count := 0
+	for e := bc.lru.Back(); e != nil && bc.lru.Len() > bc.capacity; {
+		prev := e.Prev()
+		ib := e.Value.(IBlock)
+		if !isPinned(ib) {
+			bc.lru.Remove(e)
+			delete(bc.items, ib.GetID())
+			count++
+		}
+		e = prev
+	}
+	if count > 0 {
+		blockCacheEvictCounter.Inc(int64(count))
+	}
+	return count
+}
+
+func newBlockCache(capacity int) *blockCache {
+	return &blockCache{
+		capacity: capacity,
+		items:    map[uint]*list.Element{},
+		lru:      list.New(),
+	}
+}
+
+// Set the maximum number of blocks in memory
+func (bd *BlockDAG) SetCacheSize(size int) {
+	bd.stateLock.Lock()
+	defer bd.stateLock.Unlock()
+
+	if size <= 0 {
+		size = DefaultBlockCacheSize
+	}
+	bd.blocks.capacity = size
+}
+
+// Return the number of blocks in memory
+func (bd *BlockDAG) GetCacheSize() int {
+	return bd.blocks.size()
+}
+
+// Cache statistics: hits and misses since process startup.
+func (bd *BlockDAG) GetCacheStats() (int64, int64) {
+	return blockCacheHitCounter.Count(), blockCacheMissCounter.Count()
+}
+
+// Load one block from database, include its children.
+func (bd *BlockDAG) loadBlock(dbTx database.Tx, id uint) (IBlock, error) {
+	block := Block{id: id}
+	ib := bd.instance.CreateBlock(&block)
+	err := DBGetDAGBlock(dbTx, ib)
+	if err != nil {
+		return nil, err
+	}
+	children, err := DBGetDAGBlockChildren(dbTx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(children) > 0 {
+		block.children = NewIdSet()
+		block.children.AddList(children)
+	}
+	if bd.getBlockData != nil {
+		block.data = bd.getBlockData(ib.GetHash())
+	}
+	return ib, nil
+}
+
+// Load the block from database when it is not in memory
+func (bd *BlockDAG) loadBlockById(id uint) IBlock {
+	var ib IBlock
+	err := bd.db.View(func(dbTx database.Tx) error {
+		var er error
+		ib, er = bd.loadBlock(dbTx, id)
+		return er
+	})
+	if err != nil {
+		if de, ok := err.(*DAGError); !ok || !de.IsEmpty() {
+			log.Error(fmt.Sprintf("Load block(%d) error:%s", id, err.Error()))
+		}
+		return nil
+	}
+	return bd.blocks.addIfAbsent(ib)
+}
+
+// Whether the block must stay in memory
+func (bd *BlockDAG) isPinned(ib IBlock, mainLayer uint) bool {
+	id := ib.GetID()
+	if id == GenesisId ||
+		bd.tips.Has(id) ||
+		bd.commitBlock.Has(id) {
+		return true
+	}
+	if bd.lastSnapshot.block != nil && bd.lastSnapshot.block.GetID() == id ||
+		bd.lastSnapshot.orders.Has(id) {
+		return true
+	}
+	if ph, ok := bd.getPhantom(); ok {
+		if ph.diffAnticone.Has(id) || id == ph.mainChain.tip {
+			return true
+		}
+	}
+	return ib.GetLayer()+CachePinnedLayers >= mainLayer
+}
+
+// Release some blocks that are not be used recently
+func (bd *BlockDAG) shrinkCache() {
+	mainLayer := uint(0)
+	mainTip := bd.getMainChainTip()
+	if mainTip != nil {
+		mainLayer = mainTip.GetLayer()
+	}
+	count := bd.blocks.evict(func(ib IBlock) bool {
+		return bd.isPinned(ib, mainLayer)
+	})
+	if count > 0 {
+		log.Trace(fmt.Sprintf("Block DAG cache evict:%d (size=%d)", count, bd.blocks.size()))
+	}
+}
+
+// Resolve ids to the blocks, the result can be sorted by SortHashList.
+func (bd *BlockDAG) getBlockSet(ids *IdSet) *IdSet {
+	result := NewIdSet()
+	if ids == nil {
+		return result
+	}
+	for k := range ids.GetMap() {
+		ib := bd.getBlockById(k)
+		if ib == nil {
+			continue
+		}
+		result.AddPair(k, ib)
+	}
+	return result
+}
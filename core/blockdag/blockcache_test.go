@@ -0,0 +1,41 @@
+package blockdag
+
+import (
+	"testing"
+)
+
+func Test_BlockCacheEvict(t *testing.T) {
+	bc := newBlockCache(3)
+	for i := uint(0); i < 5; i++ {
+		bc.add(&Block{id: i})
+	}
+	// touch 0, then 1 is the least recently used.
+	if bc.get(0) == nil {
+		t.FailNow()
+	}
+	pinned := func(ib IBlock) bool {
+		return ib.GetID() == 1
+	}
+	if bc.evict(pinned) != 2 {
+		t.Fatalf("evict count error")
+	}
+	if bc.size() != 3 || !bc.has(0) || !bc.has(1) || !bc.has(4) {
+		t.Fatalf("evict result error")
+	}
+	if bc.has(2) || bc.has(3) {
+		t.Fatalf("evict result error")
+	}
+}
+
+func Test_BlockCacheAddIfAbsent(t *testing.T) {
+	bc := newBlockCache(3)
+	first := &Block{id: 1}
+	bc.add(first)
+	if bc.addIfAbsent(&Block{id: 1}) != first {
+		t.FailNow()
+	}
+	bc.remove(1)
+	if bc.get(1) != nil {
+		t.FailNow()
+	}
+}
@@ -26,6 +26,9 @@ const (
 
 	// Confirming Transactions via Recursive Elections
 	spectre = "spectre"
+
+	// The greedy k-cluster version of Phantom
+	ghostdag = "ghostdag"
 )
 
 // Maximum number of the DAG tip
@@ -58,6 +61,8 @@ func NewBlockDAG(dagType string) IBlockDAG {
 		return &Phantom_v2{}
 	case spectre:
 		return &Spectre{}
+	case ghostdag:
+		return &GhostDAG{}
 	}
 	return nil
 }
@@ -70,6 +75,8 @@ func GetDAGTypeIndex(dagType string) byte {
 		return 1
 	case spectre:
 		return 3
+	case ghostdag:
+		return 4
 	}
 	return 0
 }
@@ -82,6 +89,8 @@ func GetDAGTypeByIndex(dagType byte) string {
 		return phantom_v2
 	case 3:
 		return spectre
+	case 4:
+		return ghostdag
 	}
 	return phantom
 }
@@ -150,8 +159,8 @@ type BlockDAG struct {
 	// The genesis of block dag
 	genesis hash.Hash
 
-	// Use block hash to save all blocks with mapping
-	blocks map[uint]IBlock
+	// The blocks in memory by id, others will be loaded from database on demand
+	blocks *blockCache
 
 	// The total number blocks that this dag currently owned
 	blockTotal uint
@@ -184,10 +193,16 @@ type BlockDAG struct {
 	// blocks per second
 	blockRate float64
 
+	// The number of orders under the main order that can't be reordered
+	finalityDepth uint
+
 	db database.DB
 
 	// Rollback mechanism
 	lastSnapshot *DAGSnapshot
+
+	// The index answers whether a block is in the past of another one
+	reachability *reachability
 }
 
 // Acquire the name of DAG instance
@@ -200,6 +215,17 @@ func (bd *BlockDAG) GetInstance() IBlockDAG {
 	return bd.instance
 }
 
+// Return the Phantom that the instance is built on
+func (bd *BlockDAG) getPhantom() (*Phantom, bool) {
+	switch instance := bd.instance.(type) {
+	case *Phantom:
+		return instance, true
+	case *GhostDAG:
+		return &instance.Phantom, true
+	}
+	return nil, false
+}
+
 // Initialize self, the function to be invoked at the beginning
 func (bd *BlockDAG) Init(dagType string, calcWeight CalcWeight, blockRate float64, db database.DB, getBlockData GetBlockData) IBlockDAG {
 	bd.lastTime = time.Unix(roughtime.Now().Unix(), 0)
@@ -211,6 +237,8 @@ func (bd *BlockDAG) Init(dagType string, calcWeight CalcWeight, blockRate float6
 	bd.lastSnapshot = NewDAGSnapshot()
 	bd.blockRate = blockRate
 	bd.tipsDisLimit = StableConfirmations
+	bd.blocks = newBlockCache(DefaultBlockCacheSize)
+	bd.reachability = newReachability(bd)
 	if bd.blockRate < 0 {
 		bd.blockRate = anticone.DefaultBlockRate
 	}
@@ -250,6 +278,23 @@ func (bd *BlockDAG) Init(dagType string, calcWeight CalcWeight, blockRate float6
 		if err != nil {
 			return err
 		}
+		if meta.Bucket(dbnamespace.DAGChildrenBucketName) == nil {
+			_, err = meta.CreateBucket(dbnamespace.DAGChildrenBucketName)
+			if err != nil {
+				return err
+			}
+			err = bd.buildChildrenIndex(dbTx)
+			if err != nil {
+				return err
+			}
+		}
+		if meta.Bucket(dbnamespace.ReachabilityBucketName) == nil {
+			_, err = meta.CreateBucket(dbnamespace.ReachabilityBucketName)
+			if err != nil {
+				return err
+			}
+			return bd.reachability.build(dbTx)
+		}
 		return nil
 	})
 	if err != nil {
@@ -260,45 +305,43 @@ func (bd *BlockDAG) Init(dagType string, calcWeight CalcWeight, blockRate float6
 }
 
 // This is an entry for update the block dag,you need pass in a block parameter,
-// If add block have failure,it will return false.
-func (bd *BlockDAG) AddBlock(b IBlockData) (*list.List, *list.List, IBlock, bool) {
+// If add block have failure,it will return an error.
+func (bd *BlockDAG) AddBlock(b IBlockData) (*list.List, *list.List, IBlock, bool, error) {
 	bd.stateLock.Lock()
 	defer bd.stateLock.Unlock()
 
 	if b == nil {
-		return nil, nil, nil, false
+		return nil, nil, nil, false, fmt.Errorf("No block data")
 	}
 	// Must keep no block in outside.
 	if bd.hasBlock(b.GetHash()) {
-		return nil, nil, nil, false
+		return nil, nil, nil, false, fmt.Errorf("Already have block:%s", b.GetHash())
 	}
 	parents := []IBlock{}
 	if bd.blockTotal > 0 {
 		parentsIds := b.GetParents()
 		if len(parentsIds) == 0 {
-			return nil, nil, nil, false
+			return nil, nil, nil, false, fmt.Errorf("No parents:%s", b.GetHash())
 		}
 		for _, v := range parentsIds {
 			pib := bd.getBlock(v)
 			if pib == nil {
-				return nil, nil, nil, false
+				return nil, nil, nil, false, fmt.Errorf("No parent %s of block:%s", v, b.GetHash())
 			}
 			parents = append(parents, pib)
 		}
 
 		if !bd.isDAG(parents, b) {
-			return nil, nil, nil, false
+			return nil, nil, nil, false, fmt.Errorf("The block %s is not DAG", b.GetHash())
 		}
 	}
 	lastMT := bd.instance.GetMainChainTipId()
+	finalityOrder, hasFinality := bd.getFinalityOrder()
 	//
 	block := Block{id: bd.blockTotal, hash: *b.GetHash(), layer: 0, status: StatusNone, mainParent: MaxId, data: b}
 
-	if bd.blocks == nil {
-		bd.blocks = map[uint]IBlock{}
-	}
 	ib := bd.instance.CreateBlock(&block)
-	bd.blocks[block.id] = ib
+	bd.blocks.add(ib)
 
 	// db
 	bd.commitBlock.AddPair(ib.GetID(), ib)
@@ -317,9 +360,8 @@ func (bd *BlockDAG) AddBlock(b IBlockData) (*list.List, *list.List, IBlock, bool
 	if len(parents) > 0 {
 		block.parents = NewIdSet()
 		var maxLayer uint = 0
-		for _, v := range parents {
-			parent := v.(IBlock)
-			block.parents.AddPair(parent.GetID(), parent)
+		for _, parent := range parents {
+			block.parents.Add(parent.GetID())
 			parent.AddChild(ib)
 			if block.mainParent > parent.GetID() {
 				block.mainParent = parent.GetID()
@@ -328,6 +370,9 @@ func (bd *BlockDAG) AddBlock(b IBlockData) (*list.List, *list.List, IBlock, bool
 			if maxLayer == 0 || maxLayer < parent.GetLayer() {
 				maxLayer = parent.GetLayer()
 			}
+			if parent.GetStatus().IsInvalidated() {
+				block.SetStatusFlags(StatusInvalidAncestor)
+			}
 		}
 		block.SetLayer(maxLayer + 1)
 	}
@@ -340,15 +385,38 @@ func (bd *BlockDAG) AddBlock(b IBlockData) (*list.List, *list.List, IBlock, bool
 		bd.lastTime = t
 	}
 	//
+	// The instance depends on the reachability of new block
+	mainParent := block.mainParent
+	if len(parents) > 0 {
+		mp := bd.instance.GetMainParent(block.parents)
+		if mp != nil {
+			mainParent = mp.GetID()
+		}
+	}
+	err := bd.reachability.addBlock(ib, mainParent)
+	if err != nil {
+		log.Error(err.Error())
+	}
+	//
 	news, olds := bd.instance.AddBlock(ib)
 	bd.optimizeReorganizeResult(news, olds)
+	if hasFinality {
+		err := bd.checkFinality(ib, finalityOrder, olds)
+		if err != nil {
+			rerr := bd.rollback()
+			if rerr != nil {
+				log.Error(rerr.Error())
+			}
+			return nil, nil, nil, false, err
+		}
+	}
 	if news == nil {
 		news = list.New()
 	}
 	if olds == nil {
 		olds = list.New()
 	}
-	return news, olds, ib, lastMT != bd.instance.GetMainChainTipId()
+	return news, olds, ib, lastMT != bd.instance.GetMainChainTipId(), nil
 }
 
 // Acquire the genesis block of chain
@@ -392,9 +460,9 @@ func (bd *BlockDAG) getFutureSet(fs *IdSet, b IBlock) {
 	if children == nil || children.IsEmpty() {
 		return
 	}
-	for k, v := range children.GetMap() {
-		ib := v.(IBlock)
+	for k := range children.GetMap() {
 		if !fs.Has(k) {
+			ib := bd.getBlockById(k)
 			fs.AddPair(k, ib)
 			bd.getFutureSet(fs, ib)
 		}
@@ -494,56 +562,38 @@ func (bd *BlockDAG) getGraphState() *GraphState {
 	return gs
 }
 
-// Judging whether block is the virtual tip that it have not future set.
-func isVirtualTip(bs *IdSet, futureSet *IdSet, anticone *IdSet, children *IdSet) bool {
-	for k := range children.GetMap() {
-		if bs.Has(k) {
-			return false
-		}
-		if !futureSet.Has(k) && !anticone.Has(k) {
-			return false
-		}
-	}
-	return true
-}
-
-// This function is used to GetAnticone recursion
-func (bd *BlockDAG) recAnticone(bs *IdSet, futureSet *IdSet, anticone *IdSet, ib IBlock) {
-	if bs.Has(ib.GetID()) || anticone.Has(ib.GetID()) {
-		return
-	}
-	children := ib.GetChildren()
-	needRecursion := false
-	if children == nil || children.Size() == 0 {
-		needRecursion = true
-	} else {
-		needRecursion = isVirtualTip(bs, futureSet, anticone, children)
-	}
-	if needRecursion {
-		if !futureSet.Has(ib.GetID()) {
-			anticone.AddPair(ib.GetID(), ib)
-		}
-		parents := ib.GetParents()
-
-		//Because parents can not be empty, so there is no need to judge.
-		for _, v := range parents.GetMap() {
-			pib := v.(IBlock)
-			bd.recAnticone(bs, futureSet, anticone, pib)
-		}
-	}
-}
-
 // This function can get anticone set for an block that you offered in the block dag,If
 // the exclude set is not empty,the final result will exclude set that you passed in.
 func (bd *BlockDAG) getAnticone(b IBlock, exclude *IdSet) *IdSet {
-	futureSet := NewIdSet()
-	bd.getFutureSet(futureSet, b)
 	anticone := NewIdSet()
-	bs := NewIdSet()
-	bs.AddPair(b.GetID(), b)
+	visited := NewIdSet()
+	queue := []IBlock{}
 	for _, v := range bd.tips.GetMap() {
-		ib := v.(IBlock)
-		bd.recAnticone(bs, futureSet, anticone, ib)
+		queue = append(queue, v.(IBlock))
+	}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+
+		if visited.Has(cur.GetID()) {
+			continue
+		}
+		visited.Add(cur.GetID())
+		// The past set of block is not needed
+		if cur.GetID() == b.GetID() || bd.isAncestor(cur, b) {
+			continue
+		}
+		if !bd.isAncestor(b, cur) {
+			anticone.AddPair(cur.GetID(), cur)
+		}
+		if !cur.HasParents() {
+			continue
+		}
+		for k := range cur.GetParents().GetMap() {
+			if !visited.Has(k) {
+				queue = append(queue, bd.getBlockById(k))
+			}
+		}
 	}
 	if exclude != nil {
 		anticone.Exclude(exclude)
@@ -551,14 +601,17 @@ func (bd *BlockDAG) getAnticone(b IBlock, exclude *IdSet) *IdSet {
 	return anticone
 }
 
-// getParentsAnticone
-func (bd *BlockDAG) getParentsAnticone(parents *IdSet) *IdSet {
-	anticone := NewIdSet()
-	for _, v := range bd.tips.GetMap() {
-		ib := v.(IBlock)
-		bd.recAnticone(parents, NewIdSet(), anticone, ib)
-	}
-	return anticone
+// Whether a is in the past set of b
+func (bd *BlockDAG) isAncestor(a IBlock, b IBlock) bool {
+	return bd.reachability.isDAGAncestor(a.GetID(), b.GetID())
+}
+
+// Whether the block a is in the past set of block b
+func (bd *BlockDAG) IsAncestor(a uint, b uint) bool {
+	bd.stateLock.Lock()
+	defer bd.stateLock.Unlock()
+
+	return bd.reachability.isDAGAncestor(a, b)
 }
 
 // getTreeTips
@@ -651,8 +704,8 @@ func (bd *BlockDAG) getDiffAnticone(b IBlock, verbose bool) *IdSet {
 
 	var curMP IBlock
 
-	for _, v := range parents.GetMap() {
-		ib := v.(IBlock)
+	for k := range parents.GetMap() {
+		ib := bd.getBlockById(k)
 		cur := &Block{id: ib.GetID(), hash: *ib.GetHash(), parents: NewIdSet(), mainParent: MaxId, layer: ib.GetLayer()}
 		if ib.GetID() == b.GetMainParent() {
 			mainsubdag.Add(ib.GetID())
@@ -673,8 +726,8 @@ func (bd *BlockDAG) getDiffAnticone(b IBlock, verbose bool) *IdSet {
 			tb := v.(*Block)
 			realib := bd.getBlockById(tb.GetID())
 			if realib.HasParents() {
-				for _, pv := range realib.GetParents().GetMap() {
-					pib := pv.(IBlock)
+				for pk := range realib.GetParents().GetMap() {
+					pib := bd.getBlockById(pk)
 					var cur *Block
 					if anticone.Has(pib.GetID()) {
 						cur = anticone.Get(pib.GetID()).(*Block)
@@ -751,10 +804,9 @@ func (bd *BlockDAG) GetConfirmations(id uint) uint {
 		if !cur.HasChildren() {
 			continue
 		} else {
-			childList := cur.GetChildren().SortHashList(false)
-			for _, v := range childList {
-				ib := cur.GetChildren().Get(v).(IBlock)
-				queue = append(queue, ib)
+			children := bd.getBlockSet(cur.GetChildren())
+			for _, v := range children.SortHashList(false) {
+				queue = append(queue, children.Get(v).(IBlock))
 			}
 		}
 	}
@@ -883,9 +935,14 @@ func (bd *BlockDAG) Load(dbTx database.Tx, blockTotal uint, genesis *hash.Hash)
 	}
 	bd.genesis = *genesis
 	bd.blockTotal = blockTotal
-	bd.blocks = map[uint]IBlock{}
+	bd.blocks.clean()
 	bd.tips = NewIdSet()
-	return bd.instance.Load(dbTx)
+	err = bd.instance.Load(dbTx)
+	if err != nil {
+		return err
+	}
+	bd.shrinkCache()
+	return nil
 }
 
 func (bd *BlockDAG) Encode(w io.Writer) error {
@@ -954,9 +1011,12 @@ func (bd *BlockDAG) IsHourglass(id uint) bool {
 		if !cur.HasParents() {
 			continue
 		}
-		for _, v := range cur.GetParents().GetMap() {
-			ib := v.(IBlock)
-			if queueSet.Has(ib.GetID()) || !ib.IsOrdered() {
+		for k := range cur.GetParents().GetMap() {
+			if queueSet.Has(k) {
+				continue
+			}
+			ib := bd.getBlockById(k)
+			if !ib.IsOrdered() {
 				continue
 			}
 			queue = append(queue, ib)
@@ -1027,13 +1087,12 @@ func (bd *BlockDAG) GetMaturity(target uint, views []uint) uint {
 			continue
 		}
 
-		for _, v := range cur.GetParents().GetMap() {
-			ib := v.(IBlock)
-			if queueSet.Has(ib.GetID()) {
+		for k := range cur.GetParents().GetMap() {
+			if queueSet.Has(k) {
 				continue
 			}
-			queue = append(queue, ib)
-			queueSet.Add(ib.GetID())
+			queue = append(queue, bd.getBlockById(k))
+			queueSet.Add(k)
 		}
 	}
 
@@ -1065,20 +1124,18 @@ func (bd *BlockDAG) getMainFork(ib IBlock, backward bool) IBlock {
 			if !cur.HasChildren() {
 				continue
 			} else {
-				childList := cur.GetChildren().SortHashList(false)
-				for _, v := range childList {
-					ib := cur.GetChildren().Get(v).(IBlock)
-					queue = append(queue, ib)
+				children := bd.getBlockSet(cur.GetChildren())
+				for _, v := range children.SortHashList(false) {
+					queue = append(queue, children.Get(v).(IBlock))
 				}
 			}
 		} else {
 			if !cur.HasParents() {
 				continue
 			} else {
-				parentsList := cur.GetParents().SortHashList(false)
-				for _, v := range parentsList {
-					ib := cur.GetParents().Get(v).(IBlock)
-					queue = append(queue, ib)
+				parents := bd.getBlockSet(cur.GetParents())
+				for _, v := range parents.SortHashList(false) {
+					queue = append(queue, parents.Get(v).(IBlock))
 				}
 			}
 		}
@@ -1112,7 +1169,9 @@ func (bd *BlockDAG) GetBlockConcurrency(h *hash.Hash) (uint, error) {
 }
 
 func (bd *BlockDAG) UpdateWeight(ib IBlock) {
-	bd.instance.(*Phantom).UpdateWeight(ib)
+	if ph, ok := bd.getPhantom(); ok {
+		ph.UpdateWeight(ib)
+	}
 }
 
 // Commit the consensus content to the database for persistence
@@ -1134,7 +1193,21 @@ func (bd *BlockDAG) commit() error {
 	}
 	if needPB {
 		err := bd.db.Update(func(dbTx database.Tx) error {
-			return DBPutDAGBlockIdByHash(dbTx, bd.lastSnapshot.block)
+			block := bd.lastSnapshot.block
+			err := DBPutDAGBlockIdByHash(dbTx, block)
+			if err != nil {
+				return err
+			}
+			if !block.HasParents() {
+				return nil
+			}
+			for k := range block.GetParents().GetMap() {
+				err := DBPutDAGBlockChild(dbTx, k, block.GetID())
+				if err != nil {
+					return err
+				}
+			}
+			return nil
 		})
 		if err != nil {
 			return err
@@ -1164,6 +1237,17 @@ func (bd *BlockDAG) commit() error {
 				return err
 			}
 		}
+		// The old main chain tip was not a tip of DAG if its future was invalidated.
+		oldMainTip := bd.lastSnapshot.mainChainTip
+		if oldMainTip != MaxId && oldMainTip != bd.instance.GetMainChainTipId() &&
+			!bd.tips.Has(oldMainTip) && !bd.lastSnapshot.tips.Has(oldMainTip) {
+			err := bd.db.Update(func(dbTx database.Tx) error {
+				return DBDelDAGTip(dbTx, oldMainTip)
+			})
+			if err != nil {
+				return err
+			}
+		}
 		bd.lastSnapshot.Clean()
 	}
 
@@ -1196,6 +1280,8 @@ func (bd *BlockDAG) commit() error {
 				if e != nil {
 					return e
 				}
+				// The latest one should be used in memory
+				bd.blocks.add(block)
 			}
 			return nil
 		})
@@ -1204,7 +1290,15 @@ func (bd *BlockDAG) commit() error {
 			return err
 		}
 	}
-	ph, ok := bd.instance.(*Phantom)
+	if len(bd.reachability.staging) > 0 {
+		err := bd.db.Update(func(dbTx database.Tx) error {
+			return bd.reachability.commit(dbTx)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	ph, ok := bd.getPhantom()
 	if !ok {
 		return nil
 	}
@@ -1216,6 +1310,7 @@ func (bd *BlockDAG) commit() error {
 		bd.optimizeTips(dbTx)
 		return nil
 	})
+	bd.shrinkCache()
 
 	return nil
 }
@@ -1225,12 +1320,13 @@ func (bd *BlockDAG) rollback() error {
 		log.Debug(fmt.Sprintf("Block DAG try to roll back ... ..."))
 
 		block := bd.lastSnapshot.block
-		delete(bd.blocks, block.GetID())
+		bd.blocks.remove(block.GetID())
 		bd.commitBlock.Clean()
+		bd.reachability.rollback()
 
-		for _, v := range block.GetParents().GetMap() {
-			parent, ok := v.(IBlock)
-			if !ok {
+		for k := range block.GetParents().GetMap() {
+			parent := bd.getBlockById(k)
+			if parent == nil {
 				log.Error(fmt.Sprintf("Can't remove child info for %s", block.GetHash()))
 				continue
 			}
@@ -1241,7 +1337,7 @@ func (bd *BlockDAG) rollback() error {
 		bd.tips = bd.lastSnapshot.tips
 		bd.lastTime = bd.lastSnapshot.lastTime
 
-		if ph, ok := bd.instance.(*Phantom); ok {
+		if ph, ok := bd.getPhantom(); ok {
 			ph.mainChain.tip = bd.lastSnapshot.mainChainTip
 			ph.mainChain.genesis = bd.lastSnapshot.mainChainGenesis
 			ph.mainChain.commitBlocks.Clean()
@@ -1270,7 +1366,7 @@ func (bd *BlockDAG) rollback() error {
 
 // Just for custom Virtual block
 func (bd *BlockDAG) CreateVirtualBlock(data IBlockData) IBlock {
-	if _, ok := bd.instance.(*Phantom); !ok {
+	if _, ok := bd.getPhantom(); !ok {
 		return nil
 	}
 	parents := NewIdSet()
@@ -1284,7 +1380,7 @@ func (bd *BlockDAG) CreateVirtualBlock(data IBlockData) IBlock {
 		if k == 0 {
 			mainParent = ib
 		}
-		parents.AddPair(ib.GetID(), ib)
+		parents.Add(ib.GetID())
 		if maxLayer == 0 || maxLayer < ib.GetLayer() {
 			maxLayer = ib.GetLayer()
 		}
@@ -61,6 +61,10 @@ type TestData struct {
 	PH_MPConcurrency   TestInOutData2
 	PH_BConcurrency    TestInOutData2
 	PH_MainChainTip    []TestInOutData3
+	GD_BlueSetFig2     TestInOutData
+	GD_BlueSetFig4     TestInOutData
+	GD_OrderFig2       TestInOutData
+	GD_BConcurrency    TestInOutData2
 }
 
 // Load some data that phantom test need,it can use to build the dag ;This is the
@@ -214,7 +218,7 @@ func addBlock(tag string, parents []*hash.Hash) (*TestBlock, IBlock, error) {
 	}
 	block := &TestBlock{block: types.NewBlock(b)}
 
-	l, _, ib, _ := bd.AddBlock(block)
+	l, _, ib, _, _ := bd.AddBlock(block)
 	if l != nil && l.Len() > 0 {
 		return block, ib, nil
 	} else {
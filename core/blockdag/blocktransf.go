@@ -1,6 +1,7 @@
 package blockdag
 
 import (
+	"container/list"
 	"fmt"
 	"github.com/Qitmeer/qitmeer/common/hash"
 	"github.com/Qitmeer/qitmeer/database"
@@ -96,11 +97,14 @@ func (bd *BlockDAG) getBlockById(id uint) IBlock {
 	if id == MaxId {
 		return nil
 	}
-	block, ok := bd.blocks[id]
-	if !ok {
+	block := bd.blocks.get(id)
+	if block != nil {
+		return block
+	}
+	if id >= bd.blockTotal {
 		return nil
 	}
-	return block
+	return bd.loadBlockById(id)
 }
 
 // Obtain block hash by global order
@@ -245,6 +249,99 @@ func (bd *BlockDAG) InvalidBlock(block IBlock) {
 	bd.commitBlock.AddPair(block.GetID(), block)
 }
 
+// Invalidate the block by operator, and all of its future set will be invalid.
+// It returns the blocks whose status were changed.
+func (bd *BlockDAG) InvalidateBlock(block IBlock) []IBlock {
+	bd.stateLock.Lock()
+	defer bd.stateLock.Unlock()
+
+	block.SetStatus(block.GetStatus() | StatusInvalidated)
+	bd.commitBlock.AddPair(block.GetID(), block)
+	result := []IBlock{block}
+
+	fs := NewIdSet()
+	bd.getFutureSet(fs, block)
+	for _, id := range fs.SortList(false) {
+		ib := bd.getBlockById(id)
+		if ib.GetStatus()&StatusInvalidAncestor != 0 {
+			continue
+		}
+		ib.SetStatus(ib.GetStatus() | StatusInvalidAncestor)
+		bd.commitBlock.AddPair(ib.GetID(), ib)
+		result = append(result, ib)
+	}
+	return result
+}
+
+// Reconsider the block which was invalidated by operator. Its future set will be
+// valid again, unless they are still in the future of other invalidated blocks.
+// It returns the blocks whose status were changed.
+func (bd *BlockDAG) ReconsiderBlock(block IBlock) []IBlock {
+	bd.stateLock.Lock()
+	defer bd.stateLock.Unlock()
+
+	block.SetStatus(block.GetStatus() &^ StatusInvalidated)
+	bd.commitBlock.AddPair(block.GetID(), block)
+	result := []IBlock{block}
+
+	fs := NewIdSet()
+	bd.getFutureSet(fs, block)
+	// The id of parent is always less than child, so the parents are updated first.
+	for _, id := range fs.SortList(false) {
+		ib := bd.getBlockById(id)
+		invalidAncestor := false
+		for pid := range ib.GetParents().GetMap() {
+			if bd.getBlockById(pid).GetStatus().IsInvalidated() {
+				invalidAncestor = true
+				break
+			}
+		}
+		if invalidAncestor || ib.GetStatus()&StatusInvalidAncestor == 0 {
+			continue
+		}
+		ib.SetStatus(ib.GetStatus() &^ StatusInvalidAncestor)
+		bd.commitBlock.AddPair(ib.GetID(), ib)
+		result = append(result, ib)
+	}
+	return result
+}
+
+// UpdateMainChain moves the main chain to the bluest block which is not
+// invalidated by operator, it should be called after InvalidateBlock or
+// ReconsiderBlock. It returns the old orders which were changed, they are nil
+// if the main chain tip is not changed.
+func (bd *BlockDAG) UpdateMainChain() (*list.List, error) {
+	bd.stateLock.Lock()
+	defer bd.stateLock.Unlock()
+
+	ph, ok := bd.getPhantom()
+	if !ok {
+		return nil, nil
+	}
+	oldTip := ph.mainChain.tip
+	oldOrders := ph.reselectMainChain()
+	if oldTip == ph.mainChain.tip {
+		return nil, nil
+	}
+	// The main chain tip may be not a tip of DAG when its future is invalidated.
+	err := bd.db.Update(func(dbTx database.Tx) error {
+		var err error
+		if bd.tips.Has(oldTip) {
+			err = DBPutDAGTip(dbTx, oldTip, false)
+		} else {
+			err = DBDelDAGTip(dbTx, oldTip)
+		}
+		if err != nil {
+			return err
+		}
+		return DBPutDAGTip(dbTx, ph.mainChain.tip, true)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return oldOrders, nil
+}
+
 // GetIdSet
 func (bd *BlockDAG) GetIdSet(hs []*hash.Hash) *IdSet {
 	result := NewIdSet()
@@ -325,8 +422,8 @@ func (bd *BlockDAG) locateBlocks(gs *GraphState, maxHashes uint) []*hash.Hash {
 		}
 		needRec := true
 		if cur.HasChildren() {
-			for _, v := range cur.GetChildren().GetMap() {
-				ib := v.(IBlock)
+			for k := range cur.GetChildren().GetMap() {
+				ib := bd.getBlockById(k)
 				if gs.GetTips().Has(ib.GetHash()) || !fs.Has(ib.GetHash()) && ib.IsOrdered() {
 					needRec = false
 					break
@@ -336,9 +433,8 @@ func (bd *BlockDAG) locateBlocks(gs *GraphState, maxHashes uint) []*hash.Hash {
 		if needRec {
 			fs.AddPair(cur.GetHash(), cur)
 			if cur.HasParents() {
-				for _, v := range cur.GetParents().GetMap() {
-					value := v.(IBlock)
-					ib := value
+				for k := range cur.GetParents().GetMap() {
+					ib := bd.getBlockById(k)
 					if fs.Has(ib.GetHash()) {
 						continue
 					}
@@ -358,8 +454,8 @@ func (bd *BlockDAG) locateBlocks(gs *GraphState, maxHashes uint) []*hash.Hash {
 		}
 		if ib.HasChildren() {
 			need := true
-			for _, v := range ib.GetChildren().GetMap() {
-				ib := v.(IBlock)
+			for k := range ib.GetChildren().GetMap() {
+				ib := bd.getBlockById(k)
 				if gs.GetTips().Has(ib.GetHash()) {
 					need = false
 					break
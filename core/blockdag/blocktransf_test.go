@@ -0,0 +1,126 @@
+package blockdag
+
+import (
+	"testing"
+)
+
+func checkInvalidated(t *testing.T, tags []string, expect bool) {
+	for _, tag := range tags {
+		if tbMap[tag].GetStatus().IsInvalidated() != expect {
+			t.Fatalf("The invalidated status of %s should be %v", tag, expect)
+		}
+	}
+}
+
+func Test_InvalidateBlock(t *testing.T) {
+	ibd := InitBlockDAG(phantom, "PH_fig2-blocks")
+	if ibd == nil {
+		t.FailNow()
+	}
+	changed := bd.InvalidateBlock(tbMap["C"])
+	if len(changed) != 5 {
+		t.Fatalf("changed size is %d, expect 5", len(changed))
+	}
+	checkInvalidated(t, []string{"C", "F", "G", "I", "J"}, true)
+	checkInvalidated(t, []string{"A", "B", "D", "E", "H", "K"}, false)
+	if !tbMap["F"].GetStatus().KnownInvalid() {
+		t.Fatalf("F should be invalid")
+	}
+
+	bd.InvalidateBlock(tbMap["D"])
+	checkInvalidated(t, []string{"D", "K"}, true)
+
+	// The new block inherits the invalid ancestor
+	_, err := buildBlock("L", getBlocksByTag([]string{"H", "G"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkInvalidated(t, []string{"L"}, true)
+
+	bd.ReconsiderBlock(tbMap["C"])
+	checkInvalidated(t, []string{"C", "F"}, false)
+	checkInvalidated(t, []string{"D", "G", "I", "J", "K", "L"}, true)
+
+	bd.ReconsiderBlock(tbMap["D"])
+	checkInvalidated(t, []string{"A", "B", "C", "D", "E", "F", "G", "H", "I", "J", "K", "L"}, false)
+}
+
+func Test_InvalidateMainTip(t *testing.T) {
+	ibd := InitBlockDAG(phantom, "PH_fig2-blocks")
+	if ibd == nil {
+		t.FailNow()
+	}
+	mainTip := bd.GetMainChainTip()
+	bd.InvalidateBlock(mainTip)
+	_, err := bd.UpdateMainChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = bd.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	newTip := bd.GetMainChainTip()
+	if newTip.GetID() == mainTip.GetID() || newTip.GetStatus().IsInvalidated() {
+		t.Fatalf("The main chain tip %s should be changed", getBlockTag(newTip.GetID()))
+	}
+	if mainTip.IsOrdered() {
+		t.Fatalf("The invalidated block %s should not be ordered", getBlockTag(mainTip.GetID()))
+	}
+	// The orders are continuous, and none of the ordered blocks is invalidated.
+	ordered := 0
+	for _, ib := range tbMap {
+		if ib.IsOrdered() {
+			ordered++
+		}
+	}
+	if uint(ordered) != newTip.GetOrder()+1 {
+		t.Fatalf("ordered size is %d, expect %d", ordered, newTip.GetOrder()+1)
+	}
+	for order := uint(0); order <= newTip.GetOrder(); order++ {
+		ib := bd.GetBlockByOrder(order)
+		if ib == nil || ib.GetOrder() != order || ib.GetStatus().IsInvalidated() {
+			t.Fatalf("The block in order %d is wrong", order)
+		}
+	}
+	// New blocks are built on the new main chain tip
+	if bd.getValidTips(false)[0].GetID() != newTip.GetID() {
+		t.Fatalf("The main parent of new block should be %s", getBlockTag(newTip.GetID()))
+	}
+
+	bd.ReconsiderBlock(mainTip)
+	_, err = bd.UpdateMainChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = bd.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bd.GetMainChainTip().GetID() != mainTip.GetID() || !mainTip.IsOrdered() {
+		t.Fatalf("The main chain tip should be %s again", getBlockTag(mainTip.GetID()))
+	}
+
+	// The main chain tip is not a tip of DAG if all of its children are invalidated.
+	_, err = buildBlock("L", getBlocksByTag([]string{getBlockTag(mainTip.GetID())}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bd.InvalidateBlock(tbMap["L"])
+	_, err = bd.UpdateMainChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = bd.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bd.GetMainChainTip().GetID() != mainTip.GetID() || bd.tips.Has(mainTip.GetID()) {
+		t.Fatalf("The main chain tip should be %s which is not a tip", getBlockTag(mainTip.GetID()))
+	}
+	bd.db.Close()
+	checkLoad(t)
+	if bd.GetMainChainTip().GetID() != mainTip.GetID() {
+		t.Fatalf("The main chain tip should be %s after loading", getBlockTag(mainTip.GetID()))
+	}
+}
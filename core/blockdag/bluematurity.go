@@ -30,6 +30,10 @@ func (bd *BlockDAG) doCheckBlueAndMature(targets []uint, views []uint, max uint,
 	bd.stateLock.Lock()
 	defer bd.stateLock.Unlock()
 
+	ph, ok := bd.getPhantom()
+	if !ok {
+		return fmt.Errorf("DAG type (%s) does not support blue check", bd.instance.GetName())
+	}
 	targetIBs := []IBlock{}
 	maxTargetLayer := uint(0)
 	for _, target := range targets {
@@ -104,7 +108,7 @@ func (bd *BlockDAG) doCheckBlueAndMature(targets []uint, views []uint, max uint,
 					resultPro.Store(RET_KEY, fmt.Errorf("Target Block Hash(%s) is immature", t.GetHash().String()))
 				}
 
-				if !bd.instance.(*Phantom).doIsBlue(t, targetMainFork) {
+				if !ph.doIsBlue(t, targetMainFork) {
 					resultPro.Store(RET_KEY, fmt.Errorf("Target Block Hash(%s) is not blue", t.GetHash().String()))
 				}
 				if v == nil && viewMainFork != nil {
@@ -132,7 +136,7 @@ func (bd *BlockDAG) doCheckBlueAndMature(targets []uint, views []uint, max uint,
 			if !result {
 				return fmt.Errorf("Target Block Hash(%s) is immature", target.GetHash().String())
 			}
-			if !bd.instance.(*Phantom).doIsBlue(target, targetMainFork) {
+			if !ph.doIsBlue(target, targetMainFork) {
 				return fmt.Errorf("Target Block Hash(%s) is not blue", target.GetHash().String())
 			}
 		}
@@ -171,13 +175,11 @@ func (bd *BlockDAG) processMaturity(target IBlock, views []IBlock, mainViewIB IB
 			return true, viewMainFork, targetMainFork
 		}
 	}
-	//
-	queueSet := NewIdSet()
-	queue := []IBlock{}
-
+	connected := false
 	for _, v := range views {
-		queue = append(queue, v)
-		queueSet.Add(v.GetID())
+		if v.GetID() == target.GetID() || bd.isAncestor(target, v) {
+			connected = true
+		}
 		//
 		if v.GetID() == maxViewIB.GetID() {
 			continue
@@ -189,29 +191,5 @@ func (bd *BlockDAG) processMaturity(target IBlock, views []IBlock, mainViewIB IB
 			}
 		}
 	}
-	connected := false
-	for len(queue) > 0 {
-		cur := queue[0]
-		queue = queue[1:]
-		if cur.GetID() == target.GetID() {
-			connected = true
-			break
-		}
-		if !cur.HasParents() {
-			continue
-		}
-		if cur.GetLayer() <= target.GetLayer() {
-			continue
-		}
-
-		for _, v := range cur.GetParents().GetMap() {
-			ib := v.(IBlock)
-			if queueSet.Has(ib.GetID()) {
-				continue
-			}
-			queue = append(queue, ib)
-			queueSet.Add(ib.GetID())
-		}
-	}
 	return connected, viewMainFork, targetMainFork
 }
@@ -158,7 +158,6 @@ func DBGetBlockIdByHash(dbTx database.Tx, h *hash.Hash) (uint32, error) {
 	return dbnamespace.ByteOrder.Uint32(data), nil
 }
 
-
 func DBDelBlockIdByHash(dbTx database.Tx, h *hash.Hash) error {
 	bucket := dbTx.Metadata().Bucket(dbnamespace.BlockIdBucketName)
 	return bucket.Delete(h[:])
@@ -213,3 +212,40 @@ func DBDelDAGTip(dbTx database.Tx, id uint) error {
 	return bucket.Delete(serializedID[:])
 }
 
+// children
+func dbChildKey(id uint, child uint) []byte {
+	var key [8]byte
+	dbnamespace.ByteOrder.PutUint32(key[:4], uint32(id))
+	dbnamespace.ByteOrder.PutUint32(key[4:], uint32(child))
+	return key[:]
+}
+
+func DBPutDAGBlockChild(dbTx database.Tx, id uint, child uint) error {
+	bucket := dbTx.Metadata().Bucket(dbnamespace.DAGChildrenBucketName)
+	return bucket.Put(dbChildKey(id, child), []byte{0})
+}
+
+func DBDelDAGBlockChild(dbTx database.Tx, id uint, child uint) error {
+	bucket := dbTx.Metadata().Bucket(dbnamespace.DAGChildrenBucketName)
+	return bucket.Delete(dbChildKey(id, child))
+}
+
+func DBGetDAGBlockChildren(dbTx database.Tx, id uint) ([]uint, error) {
+	bucket := dbTx.Metadata().Bucket(dbnamespace.DAGChildrenBucketName)
+	if bucket == nil {
+		return nil, fmt.Errorf("no %s", string(dbnamespace.DAGChildrenBucketName))
+	}
+	var prefix [4]byte
+	dbnamespace.ByteOrder.PutUint32(prefix[:], uint32(id))
+
+	result := []uint{}
+	cursor := bucket.Cursor()
+	for cok := cursor.Seek(prefix[:]); cok; cok = cursor.Next() {
+		key := cursor.Key()
+		if len(key) != 8 || !bytes.Equal(key[:4], prefix[:]) {
+			break
+		}
+		result = append(result, uint(dbnamespace.ByteOrder.Uint32(key[4:])))
+	}
+	return result, nil
+}
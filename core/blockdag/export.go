@@ -0,0 +1,265 @@
+/*
+ * Copyright (c) 2017-2020 The qitmeer developers
+ */
+
+package blockdag
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+const (
+	ExportFormatDOT     = "dot"
+	ExportFormatGraphML = "graphml"
+	ExportFormatJSON    = "json"
+
+	// The version of export schema, change it when the fields are changed.
+	DAGExportVersion = 1
+
+	// The maximum number of blocks in one export, the rest can be exported
+	// from the next order of EndOrder.
+	MaxExportBlocks = 2000
+)
+
+// The blocks are exported page by page, and the state lock is released between
+// pages, so that the DAG is not blocked by a long export.
+var exportPageSize uint = 100
+
+// The sub-DAG between two orders. The JSON field names are the schema for
+// visualiser, so don't rename them.
+type DAGExport struct {
+	Version    int               `json:"version"`
+	DAGType    string            `json:"dagtype"`
+	StartOrder uint              `json:"startorder"`
+	EndOrder   uint              `json:"endorder"`
+	Blocks     []*DAGExportBlock `json:"blocks"`
+}
+
+type DAGExportBlock struct {
+	Hash          string   `json:"hash"`
+	ID            uint     `json:"id"`
+	Order         uint     `json:"order"`
+	Layer         uint     `json:"layer"`
+	Height        uint     `json:"height"`
+	MainParent    string   `json:"mainparent,omitempty"`
+	Parents       []string `json:"parents"`
+	IsBlue        bool     `json:"isblue"`
+	IsOnMainChain bool     `json:"isonmainchain"`
+}
+
+// Export the blocks from startOrder to endOrder (include self), at most
+// MaxExportBlocks blocks are exported.
+func (bd *BlockDAG) Export(startOrder uint, endOrder uint) (*DAGExport, error) {
+	if endOrder < startOrder {
+		return nil, fmt.Errorf("endOrder(%d) is less than startOrder(%d)", endOrder, startOrder)
+	}
+	if endOrder-startOrder >= MaxExportBlocks {
+		endOrder = startOrder + MaxExportBlocks - 1
+	}
+	result := &DAGExport{
+		Version:    DAGExportVersion,
+		DAGType:    bd.GetName(),
+		StartOrder: startOrder,
+		Blocks:     []*DAGExportBlock{},
+	}
+	for order := startOrder; order <= endOrder; order += exportPageSize {
+		pageEnd := order + exportPageSize - 1
+		if pageEnd > endOrder {
+			pageEnd = endOrder
+		}
+		more, err := bd.exportPage(result, order, pageEnd)
+		if err != nil {
+			return nil, err
+		}
+		if !more {
+			break
+		}
+	}
+	result.EndOrder = result.Blocks[len(result.Blocks)-1].Order
+	return result, nil
+}
+
+// Export one page of blocks into the result, it returns false if the page
+// reaches the main order.
+func (bd *BlockDAG) exportPage(de *DAGExport, startOrder uint, endOrder uint) (bool, error) {
+	bd.stateLock.Lock()
+	defer bd.stateLock.Unlock()
+	// The blocks that were loaded by export are not needed any more.
+	defer bd.shrinkCache()
+
+	mainOrder := bd.getMainChainTip().GetOrder()
+	if startOrder > mainOrder {
+		if len(de.Blocks) > 0 {
+			return false, nil
+		}
+		return false, fmt.Errorf("startOrder(%d) is greater than the main order(%d)", startOrder, mainOrder)
+	}
+	more := true
+	if endOrder >= mainOrder {
+		endOrder = mainOrder
+		more = false
+	}
+	for i := startOrder; i <= endOrder; i++ {
+		ib := bd.getBlockByOrder(i)
+		if ib == nil {
+			return false, fmt.Errorf("No block in order:%d", i)
+		}
+		eb := &DAGExportBlock{
+			Hash:          ib.GetHash().String(),
+			ID:            ib.GetID(),
+			Order:         ib.GetOrder(),
+			Layer:         ib.GetLayer(),
+			Height:        ib.GetHeight(),
+			Parents:       []string{},
+			IsBlue:        bd.instance.IsBlue(ib.GetID()),
+			IsOnMainChain: bd.isOnMainChain(ib.GetID()),
+		}
+		if ib.HasParents() {
+			for _, pid := range ib.GetParents().SortList(false) {
+				parent := bd.getBlockById(pid)
+				if parent == nil {
+					return false, fmt.Errorf("No parent:%d of block:%s", pid, eb.Hash)
+				}
+				eb.Parents = append(eb.Parents, parent.GetHash().String())
+				if pid == ib.GetMainParent() {
+					eb.MainParent = parent.GetHash().String()
+				}
+			}
+		}
+		de.Blocks = append(de.Blocks, eb)
+	}
+	return more, nil
+}
+
+// Write the export in format
+func (de *DAGExport) Write(w io.Writer, format string) error {
+	switch format {
+	case ExportFormatDOT:
+		return de.writeDOT(w)
+	case ExportFormatGraphML:
+		return de.writeGraphML(w)
+	case ExportFormatJSON:
+		data, err := json.MarshalIndent(de, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+	return fmt.Errorf("Unknown export format:%s {%s,%s,%s}", format, ExportFormatDOT, ExportFormatGraphML, ExportFormatJSON)
+}
+
+// The parents which are out of the range, they are drawn as the external blocks.
+func (de *DAGExport) getExternals() []string {
+	inside := map[string]struct{}{}
+	for _, b := range de.Blocks {
+		inside[b.Hash] = struct{}{}
+	}
+	result := []string{}
+	for _, b := range de.Blocks {
+		for _, p := range b.Parents {
+			if _, ok := inside[p]; ok {
+				continue
+			}
+			inside[p] = struct{}{}
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+func (de *DAGExport) writeDOT(w io.Writer) error {
+	out := &exportWriter{w: w}
+	out.printf("digraph DAG {\n")
+	out.printf("  rankdir=RL;\n")
+	out.printf("  label=\"%s orders:%d-%d\";\n", de.DAGType, de.StartOrder, de.EndOrder)
+	out.printf("  node [style=filled, fontcolor=white];\n")
+	for _, b := range de.Blocks {
+		color := "red"
+		if b.IsBlue {
+			color = "blue"
+		}
+		shape := "ellipse"
+		if b.IsOnMainChain {
+			shape = "box"
+		}
+		out.printf("  \"%s\" [label=\"%d\\nlayer:%d\", shape=%s, fillcolor=%s];\n",
+			b.Hash, b.Order, b.Layer, shape, color)
+	}
+	for _, h := range de.getExternals() {
+		out.printf("  \"%s\" [label=\"%s\", style=dashed, fontcolor=black];\n", h, h[:8])
+	}
+	for _, b := range de.Blocks {
+		for _, p := range b.Parents {
+			if p == b.MainParent {
+				out.printf("  \"%s\" -> \"%s\" [style=bold];\n", b.Hash, p)
+			} else {
+				out.printf("  \"%s\" -> \"%s\";\n", b.Hash, p)
+			}
+		}
+	}
+	out.printf("}\n")
+	return out.err
+}
+
+func (de *DAGExport) writeGraphML(w io.Writer) error {
+	out := &exportWriter{w: w}
+	out.printf("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	out.printf("<graphml xmlns=\"http://graphml.graphdrawing.org/xmlns\">\n")
+	keys := []struct {
+		id     string
+		domain string
+		typ    string
+	}{
+		{"order", "node", "long"},
+		{"layer", "node", "long"},
+		{"height", "node", "long"},
+		{"isblue", "node", "boolean"},
+		{"isonmainchain", "node", "boolean"},
+		{"external", "node", "boolean"},
+		{"mainparent", "edge", "boolean"},
+	}
+	for _, k := range keys {
+		out.printf("  <key id=\"%s\" for=\"%s\" attr.name=\"%s\" attr.type=\"%s\"/>\n", k.id, k.domain, k.id, k.typ)
+	}
+	out.printf("  <graph id=\"%s\" edgedefault=\"directed\">\n", de.DAGType)
+	for _, b := range de.Blocks {
+		out.printf("    <node id=\"%s\">\n", b.Hash)
+		out.printf("      <data key=\"order\">%d</data>\n", b.Order)
+		out.printf("      <data key=\"layer\">%d</data>\n", b.Layer)
+		out.printf("      <data key=\"height\">%d</data>\n", b.Height)
+		out.printf("      <data key=\"isblue\">%t</data>\n", b.IsBlue)
+		out.printf("      <data key=\"isonmainchain\">%t</data>\n", b.IsOnMainChain)
+		out.printf("    </node>\n")
+	}
+	for _, h := range de.getExternals() {
+		out.printf("    <node id=\"%s\">\n", h)
+		out.printf("      <data key=\"external\">true</data>\n")
+		out.printf("    </node>\n")
+	}
+	for _, b := range de.Blocks {
+		for _, p := range b.Parents {
+			out.printf("    <edge source=\"%s\" target=\"%s\">\n", b.Hash, p)
+			out.printf("      <data key=\"mainparent\">%t</data>\n", p == b.MainParent)
+			out.printf("    </edge>\n")
+		}
+	}
+	out.printf("  </graph>\n")
+	out.printf("</graphml>\n")
+	return out.err
+}
+
+// Keep the first error, so that the writing code can be simple.
+type exportWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *exportWriter) printf(format string, a ...interface{}) {
+	if ew.err != nil {
+		return
+	}
+	_, ew.err = fmt.Fprintf(ew.w, format, a...)
+}
@@ -0,0 +1,88 @@
+package blockdag
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func Test_Export(t *testing.T) {
+	ibd := InitBlockDAG(phantom, "PH_fig2-blocks")
+	if ibd == nil {
+		t.FailNow()
+	}
+	mainOrder := bd.GetMainChainTip().GetOrder()
+	de, err := bd.Export(0, MaxBlockOrder-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if de.EndOrder != mainOrder || uint(len(de.Blocks)) != mainOrder+1 {
+		t.Fatalf("export size is %d, expect %d", len(de.Blocks), mainOrder+1)
+	}
+	for i, b := range de.Blocks {
+		ib := bd.GetBlockById(b.ID)
+		if ib == nil || b.Order != uint(i) || b.Hash != ib.GetHash().String() {
+			t.Fatalf("export block %d error", i)
+		}
+		if ib.HasParents() && len(b.Parents) != ib.GetParents().Size() {
+			t.Fatalf("export parents of %s error", getBlockTag(b.ID))
+		}
+		if b.IsOnMainChain != bd.IsOnMainChain(b.ID) || b.IsBlue != bd.IsBlue(b.ID) {
+			t.Fatalf("export color of %s error", getBlockTag(b.ID))
+		}
+		if ib.HasParents() && len(b.MainParent) == 0 {
+			t.Fatalf("export main parent of %s error", getBlockTag(b.ID))
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := de.Write(&buf, ExportFormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	other := &DAGExport{}
+	if err := json.Unmarshal(buf.Bytes(), other); err != nil {
+		t.Fatal(err)
+	}
+	if len(other.Blocks) != len(de.Blocks) || other.Version != DAGExportVersion {
+		t.Fatalf("export json error")
+	}
+
+	last := de.Blocks[len(de.Blocks)-1]
+	for _, format := range []string{ExportFormatDOT, ExportFormatGraphML} {
+		buf.Reset()
+		if err := de.Write(&buf, format); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), last.MainParent) {
+			t.Fatalf("export %s error", format)
+		}
+	}
+	if err := de.Write(&buf, "svg"); err == nil {
+		t.Fatalf("export unknown format")
+	}
+	if _, err := bd.Export(2, 1); err == nil {
+		t.Fatalf("export error range")
+	}
+
+	// The pages are joined into the same result.
+	exportPageSize = 2
+	defer func() {
+		exportPageSize = 100
+	}()
+	paged, err := bd.Export(1, MaxBlockOrder-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paged.EndOrder != mainOrder || len(paged.Blocks) != len(de.Blocks)-1 {
+		t.Fatalf("paged export size is %d, expect %d", len(paged.Blocks), len(de.Blocks)-1)
+	}
+	for i, b := range paged.Blocks {
+		if b.Hash != de.Blocks[i+1].Hash {
+			t.Fatalf("paged export block %d error", i)
+		}
+	}
+	if _, err := bd.Export(mainOrder+1, MaxBlockOrder-1); err == nil {
+		t.Fatalf("export out of main order")
+	}
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright (c) 2017-2020 The qitmeer developers
+ */
+
+package blockdag
+
+import (
+	"container/list"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+)
+
+// FinalityError means that the block would change the order of a finalized block.
+type FinalityError struct {
+	Hash          hash.Hash
+	Order         uint
+	FinalityOrder uint
+}
+
+func (e *FinalityError) Error() string {
+	return fmt.Sprintf("The block %s would reorder the finalized order %d (finality point order:%d)",
+		e.Hash, e.Order, e.FinalityOrder)
+}
+
+// Set the finality depth, zero disables the finality.
+func (bd *BlockDAG) SetFinalityDepth(depth uint) {
+	bd.stateLock.Lock()
+	defer bd.stateLock.Unlock()
+	bd.finalityDepth = depth
+}
+
+func (bd *BlockDAG) GetFinalityDepth() uint {
+	bd.stateLock.Lock()
+	defer bd.stateLock.Unlock()
+	return bd.finalityDepth
+}
+
+// Return the finality point, the orders of it and the blocks before it can't
+// be changed any more. It returns nil if the finality is disabled.
+func (bd *BlockDAG) GetFinalityPoint() IBlock {
+	bd.stateLock.Lock()
+	defer bd.stateLock.Unlock()
+
+	order, ok := bd.getFinalityOrder()
+	if !ok {
+		return nil
+	}
+	return bd.getBlockByOrder(order)
+}
+
+func (bd *BlockDAG) getFinalityOrder() (uint, bool) {
+	if bd.finalityDepth == 0 || bd.blockTotal == 0 {
+		return 0, false
+	}
+	mainOrder := bd.getMainChainTip().GetOrder()
+	if mainOrder <= bd.finalityDepth {
+		return 0, true
+	}
+	return mainOrder - bd.finalityDepth, true
+}
+
+// Check whether the old orders changed by new block are finalized.
+func (bd *BlockDAG) checkFinality(ib IBlock, finalityOrder uint, olds *list.List) error {
+	if olds == nil {
+		return nil
+	}
+	for e := olds.Front(); e != nil; e = e.Next() {
+		boh, ok := e.Value.(*BlockOrderHelp)
+		if !ok {
+			continue
+		}
+		if boh.OldOrder == MaxBlockOrder || boh.OldOrder > finalityOrder {
+			continue
+		}
+		return &FinalityError{Hash: *ib.GetHash(), Order: boh.OldOrder, FinalityOrder: finalityOrder}
+	}
+	return nil
+}
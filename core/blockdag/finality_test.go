@@ -0,0 +1,83 @@
+package blockdag
+
+import (
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/core/types/pow"
+	"testing"
+	"time"
+)
+
+func Test_FinalityPoint(t *testing.T) {
+	ibd := InitBlockDAG(phantom, "PH_fig2-blocks")
+	if ibd == nil {
+		t.FailNow()
+	}
+	if bd.GetFinalityPoint() != nil {
+		t.Fatalf("The finality is disabled")
+	}
+	mainOrder := bd.GetMainChainTip().GetOrder()
+	bd.SetFinalityDepth(mainOrder + 10)
+	fp := bd.GetFinalityPoint()
+	if fp == nil || fp.GetOrder() != 0 {
+		t.Fatalf("The finality point should be genesis")
+	}
+	bd.SetFinalityDepth(1)
+	fp = bd.GetFinalityPoint()
+	if fp == nil || fp.GetOrder() != mainOrder-1 {
+		t.Fatalf("The finality point order should be %d", mainOrder-1)
+	}
+}
+
+func Test_FinalityReorder(t *testing.T) {
+	ibd := InitBlockDAG(phantom, "PH_fig2-blocks")
+	if ibd == nil {
+		t.FailNow()
+	}
+	total := bd.GetBlockTotal()
+	tips := bd.tips.Clone()
+	mainOrder := bd.GetMainChainTip().GetOrder()
+
+	b := &types.Block{
+		Header: types.BlockHeader{
+			Pow:        pow.GetInstance(pow.MEERXKECCAKV1, 0, []byte{}),
+			Timestamp:  time.Unix(int64(len(tbMap)), 0),
+			Difficulty: uint32(len(tbMap)),
+		},
+		Parents:      []*hash.Hash{tbMap["I"].GetHash(), tbMap["G"].GetHash()},
+		Transactions: []*types.Transaction{},
+	}
+	block := &TestBlock{block: types.NewBlock(b)}
+
+	// Find the lowest order which is changed by the new block
+	_, olds, _, _, err := bd.AddBlock(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	minOld := MaxBlockOrder
+	for e := olds.Front(); e != nil; e = e.Next() {
+		boh := e.Value.(*BlockOrderHelp)
+		if boh.OldOrder < minOld {
+			minOld = boh.OldOrder
+		}
+	}
+	bd.rollback()
+	if minOld == MaxBlockOrder || minOld >= mainOrder {
+		t.Skip("The block doesn't reorder the past")
+	}
+
+	bd.SetFinalityDepth(mainOrder - minOld)
+	_, _, _, _, err = bd.AddBlock(block)
+	if _, ok := err.(*FinalityError); !ok {
+		t.Fatalf("Expect finality error, but %v", err)
+	}
+	if bd.GetBlockTotal() != total || !bd.tips.IsEqual(tips) {
+		t.Fatalf("The DAG was not restored after finality error")
+	}
+
+	bd.SetFinalityDepth(mainOrder - minOld + 1)
+	_, _, _, _, err = bd.AddBlock(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,141 @@
+/*
+ * Copyright (c) 2017-2020 The qitmeer developers
+ */
+
+package blockdag
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/params"
+	"sort"
+)
+
+// GHOSTDAG is the greedy version of PHANTOM. It inherits the blue set of the
+// main parent, and then adds the blocks in the diff anticone greedily as long
+// as the blue set is still a k-cluster.
+// The main chain, order and persistence are shared with Phantom.
+type GhostDAG struct {
+	Phantom
+
+	// The maximum anticone size of any blue block in the blue set
+	k int
+}
+
+func (gd *GhostDAG) GetName() string {
+	return ghostdag
+}
+
+func (gd *GhostDAG) Init(bd *BlockDAG) bool {
+	if !gd.Phantom.Init(bd) {
+		return false
+	}
+	gd.k = params.ActiveNetParams.GHOSTDAGK
+	if gd.k <= 0 {
+		gd.k = gd.anticoneSize
+	}
+	gd.anticoneSize = gd.k
+	gd.coloring = gd.colorKCluster
+
+	if log != nil {
+		log.Info(fmt.Sprintf("GHOSTDAG k:%d", gd.k))
+	}
+	return true
+}
+
+// Return the k of k-cluster
+func (gd *GhostDAG) GetK() int {
+	return gd.k
+}
+
+// Color the diff anticone of block by the greedy k-cluster rule
+func (gd *GhostDAG) colorKCluster(pb *PhantomBlock, diffAnticone *IdSet) {
+	candidates := make([]*PhantomBlock, 0, diffAnticone.Size())
+	for _, v := range diffAnticone.GetMap() {
+		cur, ok := v.(*PhantomBlock)
+		if !ok {
+			panic("phantom block type is error.")
+		}
+		candidates = append(candidates, cur)
+	}
+	// The block is always bluer than its ancestors, so the ancestors will be
+	// colored before their descendants.
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[j].IsBluer(candidates[i])
+	})
+	for _, cur := range candidates {
+		if gd.isKCluster(pb, cur) {
+			pb.blueDiffAnticone.Add(cur.GetID())
+		} else {
+			pb.redDiffAnticone.Add(cur.GetID())
+		}
+	}
+}
+
+// Whether the blue set of pb is still a k-cluster after adding the block
+func (gd *GhostDAG) isKCluster(pb *PhantomBlock, candidate *PhantomBlock) bool {
+	anticoneBlues := []IBlock{}
+	ok := gd.forEachBlue(pb, candidate, func(blue IBlock) bool {
+		if gd.bd.isAncestor(blue, candidate) {
+			return true
+		}
+		anticoneBlues = append(anticoneBlues, blue)
+		return len(anticoneBlues) <= gd.k
+	})
+	if !ok {
+		return false
+	}
+	for _, blue := range anticoneBlues {
+		if gd.getBlueAnticoneSize(pb, blue) >= gd.k {
+			return false
+		}
+	}
+	return true
+}
+
+// The number of blue blocks in the anticone of blue, it will stop counting
+// once it reaches k.
+func (gd *GhostDAG) getBlueAnticoneSize(pb *PhantomBlock, blue IBlock) int {
+	size := 0
+	gd.forEachBlue(pb, blue, func(other IBlock) bool {
+		if other.GetID() == blue.GetID() ||
+			gd.bd.isAncestor(other, blue) ||
+			gd.bd.isAncestor(blue, other) {
+			return true
+		}
+		size++
+		return size < gd.k
+	})
+	return size
+}
+
+// Traverse the blue set of pb which may be outside the past of target, from new
+// to old. It returns false if the traversal was stopped by fn.
+func (gd *GhostDAG) forEachBlue(pb *PhantomBlock, target IBlock, fn func(blue IBlock) bool) bool {
+	for k := range pb.blueDiffAnticone.GetMap() {
+		if !fn(gd.bd.getBlockById(k)) {
+			return false
+		}
+	}
+	if pb.mainParent == MaxId {
+		return true
+	}
+	for cur := gd.getBlock(pb.mainParent); cur != nil; cur = gd.getBlock(cur.mainParent) {
+		// All the blue blocks of cur are in its past.
+		if cur.GetID() == target.GetID() ||
+			gd.bd.isAncestor(cur, target) {
+			break
+		}
+		if !fn(cur) {
+			return false
+		}
+		for k := range cur.blueDiffAnticone.GetMap() {
+			if !fn(gd.bd.getBlockById(k)) {
+				return false
+			}
+		}
+		if cur.mainParent == MaxId {
+			break
+		}
+	}
+	return true
+}
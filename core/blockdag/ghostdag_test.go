@@ -0,0 +1,212 @@
+package blockdag
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/params"
+	"strconv"
+	"testing"
+)
+
+// Return the whole blue set, include the main chain
+func getGhostDAGBlueSet(gd *GhostDAG) *IdSet {
+	blueSet := gd.GetDiffBlueSet()
+	for cur := bd.GetMainChainTip(); cur != nil; cur = bd.GetBlockById(cur.GetMainParent()) {
+		blueSet.Add(cur.GetID())
+	}
+	return blueSet
+}
+
+func Test_GhostDAGGetAnticone(t *testing.T) {
+	ibd := InitBlockDAG(ghostdag, "PH_fig2-blocks")
+	if ibd == nil {
+		t.FailNow()
+	}
+	gd := ibd.(*GhostDAG)
+	anBlock := tbMap[testData.PH_GetAnticone.Input]
+	bset := gd.bd.getAnticone(anBlock, nil)
+	if !processResult(bset, changeToIDList(testData.PH_GetAnticone.Output)) {
+		t.FailNow()
+	}
+}
+
+func Test_GhostDAGKCluster(t *testing.T) {
+	graphs := []string{"PH_fig2-blocks", "PH_fig4-blocks"}
+	for _, graph := range graphs {
+		ibd := InitBlockDAG(ghostdag, graph)
+		if ibd == nil {
+			t.FailNow()
+		}
+		gd := ibd.(*GhostDAG)
+		if gd.GetK() != params.ActiveNetParams.GHOSTDAGK {
+			t.Fatalf("k is %d, expect %d", gd.GetK(), params.ActiveNetParams.GHOSTDAGK)
+		}
+		blueSet := getGhostDAGBlueSet(gd)
+		fmt.Printf("%s blue set：", graph)
+		printBlockSetTag(blueSet)
+		for k := range blueSet.GetMap() {
+			anticone := bd.getAnticone(bd.getBlockById(k), nil)
+			blueAnticone := anticone.Intersection(blueSet)
+			if blueAnticone.Size() > gd.GetK() {
+				t.Fatalf("%s: the blue anticone size of %s is %d", graph, getBlockTag(k), blueAnticone.Size())
+			}
+		}
+	}
+}
+
+func Test_GhostDAGAllBlue(t *testing.T) {
+	k := params.PrivNetParams.GHOSTDAGK
+	params.PrivNetParams.GHOSTDAGK = 100
+	defer func() {
+		params.PrivNetParams.GHOSTDAGK = k
+	}()
+
+	ibd := InitBlockDAG(ghostdag, "PH_fig4-blocks")
+	if ibd == nil {
+		t.FailNow()
+	}
+	blueSet := getGhostDAGBlueSet(ibd.(*GhostDAG))
+	if uint(blueSet.Size()) != bd.GetBlockTotal() {
+		t.Fatalf("blue set size is %d, expect %d", blueSet.Size(), bd.GetBlockTotal())
+	}
+}
+
+func Test_GhostDAGOrder(t *testing.T) {
+	ibd := InitBlockDAG(ghostdag, "PH_fig4-blocks")
+	if ibd == nil {
+		t.FailNow()
+	}
+	gd := ibd.(*GhostDAG)
+	gd.UpdateVirtualBlockOrder()
+	order := []uint{}
+	ordered := NewIdSet()
+	for i := uint(0); i < bd.GetBlockTotal(); i++ {
+		ib := bd.getBlockByOrder(i)
+		if ib == nil || ordered.Has(ib.GetID()) {
+			t.Fatalf("order %d error", i)
+		}
+		if ib.HasParents() && !ordered.Contain(ib.GetParents()) {
+			t.Fatalf("%s is ordered before its parents", getBlockTag(ib.GetID()))
+		}
+		ordered.Add(ib.GetID())
+		order = append(order, ib.GetID())
+	}
+	fmt.Printf("The GHOSTDAG Fig.4 Order: ")
+	printBlockChainTag(order)
+}
+
+func Test_GhostDAGIsDAG(t *testing.T) {
+	ibd := InitBlockDAG(ghostdag, "PH_fig2-blocks")
+	if ibd == nil {
+		t.FailNow()
+	}
+	_, err := buildBlock("L", getBlocksByTag([]string{"I", "G"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Return the blocks by order, include the virtual order of the tips
+func getGhostDAGOrder(gd *GhostDAG) []uint {
+	gd.UpdateVirtualBlockOrder()
+	order := []uint{}
+	for i := uint(0); i < bd.GetBlockTotal(); i++ {
+		order = append(order, bd.getBlockByOrder(i).GetID())
+	}
+	return order
+}
+
+// Run all the cases of Phantom test data by GHOSTDAG. The structure of DAG and
+// the main chain are the same, so most of them expect the Phantom output.
+// GHOSTDAG colors the k-cluster of PHANTOM paper, which is smaller than the
+// blue set of Phantom coloring rule with the same k. So the blue set, the order
+// of Fig.2 which sorts blue blocks first and the blue number expect GD_ output.
+func Test_GhostDAGPhantomData(t *testing.T) {
+	cases := []struct {
+		name  string
+		graph string
+		check func(gd *GhostDAG) bool
+	}{
+		{"GetFutureSet", "PH_fig2-blocks", func(gd *GhostDAG) bool {
+			fs := NewIdSet()
+			bd.getFutureSet(fs, tbMap[testData.PH_GetFutureSet.Input])
+			return processResult(fs, changeToIDList(testData.PH_GetFutureSet.Output))
+		}},
+		{"GetAnticone", "PH_fig2-blocks", func(gd *GhostDAG) bool {
+			anticone := bd.getAnticone(tbMap[testData.PH_GetAnticone.Input], nil)
+			return processResult(anticone, changeToIDList(testData.PH_GetAnticone.Output))
+		}},
+		{"BlueSetFig2", "PH_fig2-blocks", func(gd *GhostDAG) bool {
+			return processResult(gd.GetDiffBlueSet(), changeToIDList(testData.GD_BlueSetFig2.Output))
+		}},
+		{"BlueSetFig4", "PH_fig4-blocks", func(gd *GhostDAG) bool {
+			return processResult(gd.GetDiffBlueSet(), changeToIDList(testData.GD_BlueSetFig4.Output))
+		}},
+		{"OrderFig2", "PH_fig2-blocks", func(gd *GhostDAG) bool {
+			return processResult(getGhostDAGOrder(gd), changeToIDList(testData.GD_OrderFig2.Output))
+		}},
+		{"OrderFig4", "PH_fig4-blocks", func(gd *GhostDAG) bool {
+			return processResult(getGhostDAGOrder(gd), changeToIDList(testData.PH_OrderFig4.Output))
+		}},
+		{"GetLayer", "PH_fig2-blocks", func(gd *GhostDAG) bool {
+			result := ""
+			for _, id := range getGhostDAGOrder(gd) {
+				result = fmt.Sprintf("%s%d", result, bd.GetLayer(id))
+			}
+			return result == testData.PH_GetLayer.Output[0]
+		}},
+		{"IsOnMainChain", "PH_fig2-blocks", func(gd *GhostDAG) bool {
+			onMain := bd.IsOnMainChain(tbMap[testData.PH_IsOnMainChain.Input].GetID())
+			return strconv.FormatBool(onMain) == testData.PH_IsOnMainChain.Output[0]
+		}},
+		{"LocateBlocks", "PH_fig2-blocks", func(gd *GhostDAG) bool {
+			gs := NewGraphState()
+			gs.GetTips().Add(bd.GetGenesisHash())
+			gs.SetTotal(1)
+			gs.SetLayer(0)
+			lb := NewHashSet()
+			lb.AddList(bd.locateBlocks(gs, 100))
+			return processResult(lb, changeToIDList(testData.PH_LocateBlocks.Output))
+		}},
+		{"LocateMaxBlocks", "PH_fig2-blocks", func(gd *GhostDAG) bool {
+			gs := NewGraphState()
+			gs.GetTips().Add(bd.GetGenesisHash())
+			gs.GetTips().Add(tbMap["G"].GetHash())
+			gs.SetTotal(4)
+			gs.SetLayer(2)
+			return processResult(bd.locateBlocks(gs, 4), changeToIDList(testData.PH_LocateMaxBlocks.Output))
+		}},
+		{"MainParentConcurrency", "PH_fig2-blocks", func(gd *GhostDAG) bool {
+			ib := tbMap[testData.PH_MPConcurrency.Input]
+			return bd.GetMainParentConcurrency(ib) == testData.PH_MPConcurrency.Output
+		}},
+		{"BlockConcurrency", "PH_fig2-blocks", func(gd *GhostDAG) bool {
+			blueNum, err := bd.GetBlockConcurrency(tbMap[testData.GD_BConcurrency.Input].GetHash())
+			return err == nil && blueNum == uint(testData.GD_BConcurrency.Output)
+		}},
+		{"MainChainTip", "PH_fig2-blocks", func(gd *GhostDAG) bool {
+			gd.UpdateVirtualBlockOrder()
+			for _, v := range testData.PH_MainChainTip {
+				_, ret := bd.CheckSubMainChainTip(getBlocksByTag(v.Input))
+				if ret != v.Output {
+					return false
+				}
+			}
+			return true
+		}},
+	}
+	for _, c := range cases {
+		ibd := InitBlockDAG(ghostdag, c.graph)
+		if ibd == nil {
+			t.FailNow()
+		}
+		if !c.check(ibd.(*GhostDAG)) {
+			t.Fatalf("GHOSTDAG %s error", c.name)
+		}
+	}
+}
+
+func Test_GhostDAGTypeIndex(t *testing.T) {
+	if GetDAGTypeByIndex(GetDAGTypeIndex(ghostdag)) != ghostdag {
+		t.FailNow()
+	}
+}
@@ -30,6 +30,9 @@ type Phantom struct {
 	diffAnticone *IdSet
 
 	virtualBlock *PhantomBlock
+
+	// The coloring rule of diff anticone, it can be replaced by the successor.
+	coloring func(pb *PhantomBlock, diffAnticone *IdSet)
 }
 
 func (ph *Phantom) GetName() string {
@@ -50,6 +53,7 @@ func (ph *Phantom) Init(bd *BlockDAG) bool {
 	vb := &Block{hash: hash.ZeroHash, layer: 0, mainParent: MaxId}
 	ph.virtualBlock = &PhantomBlock{vb, 0, NewIdSet(), NewIdSet()}
 
+	ph.coloring = ph.colorDiffAnticone
 	return true
 }
 
@@ -61,7 +65,7 @@ func (ph *Phantom) AddBlock(ib IBlock) (*list.List, *list.List) {
 	ph.updateBlockColor(pb)
 	ph.updateBlockOrder(pb)
 
-	changeBlock, oldOrders := ph.updateMainChain(ph.getBluest(ph.bd.tips), pb)
+	changeBlock, oldOrders := ph.updateMainChain(ph.getBluest(ph.bd.getNotInvalidatedTips()), pb)
 	ph.preUpdateVirtualBlock()
 	return ph.getOrderChangeList(changeBlock), oldOrders
 }
@@ -118,6 +122,14 @@ func (ph *Phantom) getExtremeBlue(bs *IdSet, bluest bool) *PhantomBlock {
 }
 
 func (ph *Phantom) calculateBlueSet(pb *PhantomBlock, diffAnticone *IdSet) {
+	ph.coloring(pb, diffAnticone)
+	if diffAnticone.Size() != pb.blueDiffAnticone.Size()+pb.redDiffAnticone.Size() {
+		log.Error(fmt.Sprintf("error blue set"))
+	}
+	pb.blueNum += uint(pb.blueDiffAnticone.Size())
+}
+
+func (ph *Phantom) colorDiffAnticone(pb *PhantomBlock, diffAnticone *IdSet) {
 	kc := ph.getKChain(pb)
 	for _, v := range diffAnticone.GetMap() {
 		cur, ok := v.(*PhantomBlock)
@@ -126,10 +138,6 @@ func (ph *Phantom) calculateBlueSet(pb *PhantomBlock, diffAnticone *IdSet) {
 		}
 		ph.colorBlock(kc, cur, pb.blueDiffAnticone, pb.redDiffAnticone)
 	}
-	if diffAnticone.Size() != pb.blueDiffAnticone.Size()+pb.redDiffAnticone.Size() {
-		log.Error(fmt.Sprintf("error blue set"))
-	}
-	pb.blueNum += uint(pb.blueDiffAnticone.Size())
 }
 
 func (ph *Phantom) getKChain(pb *PhantomBlock) *KChain {
@@ -159,19 +167,20 @@ func (ph *Phantom) colorBlock(kc *KChain, pb *PhantomBlock, blueOrder *IdSet, re
 	}
 }
 
+// The block is blue if one of the k-chain is on its main chain. The layers are
+// decreasing along the main chain, so it is answered by the reachability index
+// instead of walking the main chain.
 func (ph *Phantom) coloringRule(kc *KChain, pb *PhantomBlock) bool {
-	curPb := pb
-	for {
-		if curPb.GetLayer() < kc.miniLayer {
-			return false
-		}
-		if kc.blocks.Has(curPb.GetID()) {
+	if pb.GetLayer() < kc.miniLayer {
+		return false
+	}
+	if kc.blocks.Has(pb.GetID()) {
+		return true
+	}
+	for id := range kc.blocks.GetMap() {
+		if ph.bd.reachability.isTreeAncestor(id, pb.GetID()) {
 			return true
 		}
-		if curPb.mainParent == MaxId {
-			break
-		}
-		curPb = ph.getBlock(curPb.mainParent)
 	}
 	return false
 }
@@ -289,7 +298,20 @@ func (ph *Phantom) updateMainChain(buestTip *PhantomBlock, pb *PhantomBlock) (*P
 		return buestTip, nil
 	}
 
-	intersection, path := ph.getIntersectionPathWithMainChain(buestTip)
+	intersectionBlock, oldOrders := ph.switchMainChain(buestTip)
+	changeOrder := intersectionBlock.GetOrder() + 1
+
+	coPB, ok := ph.bd.getBlockByOrder(changeOrder).(*PhantomBlock)
+	if !ok {
+		return nil, nil
+	}
+	return coPB, oldOrders
+}
+
+// Move the main chain to the tip, and return the intersection with the old
+// main chain and the old orders after the intersection.
+func (ph *Phantom) switchMainChain(tip *PhantomBlock) (IBlock, *list.List) {
+	intersection, path := ph.getIntersectionPathWithMainChain(tip)
 	intersectionBlock := ph.bd.getBlockById(intersection)
 	if intersectionBlock == nil {
 		panic("DAG can't find intersection!")
@@ -309,17 +331,36 @@ func (ph *Phantom) updateMainChain(buestTip *PhantomBlock, pb *PhantomBlock) (*P
 	ph.rollBackMainChain(intersection)
 
 	ph.updateMainOrder(path, intersection)
-	ph.mainChain.tip = buestTip.GetID()
+	ph.mainChain.tip = tip.GetID()
 
 	ph.diffAnticone = ph.bd.getAnticone(ph.bd.getBlockById(ph.mainChain.tip), nil)
 
-	changeOrder := intersectionBlock.GetOrder() + 1
+	return intersectionBlock, oldOrders
+}
 
-	coPB, ok := ph.bd.getBlockByOrder(changeOrder).(*PhantomBlock)
-	if !ok {
-		return nil, nil
+// Reselect the main chain after some blocks were invalidated or reconsidered
+// by operator, the tip is the bluest block which is not invalidated. The blocks
+// that leave the past of new tip are no longer ordered. It returns the old
+// orders after the intersection, or nil if the tip is not changed.
+func (ph *Phantom) reselectMainChain() *list.List {
+	tip := ph.getBluest(ph.bd.getNotInvalidatedTips())
+	if tip == nil || tip.GetID() == ph.mainChain.tip {
+		return nil
 	}
-	return coPB, oldOrders
+	_, oldOrders := ph.switchMainChain(tip)
+	for e := oldOrders.Front(); e != nil; e = e.Next() {
+		ib := e.Value.(*BlockOrderHelp).Block
+		id, ok := ph.bd.commitOrder[ib.GetOrder()]
+		if ib.GetOrder() <= tip.GetOrder() && ok && id == ib.GetID() {
+			continue
+		}
+		ib.SetOrder(MaxBlockOrder)
+		ph.bd.commitBlock.AddPair(ib.GetID(), ib)
+		ph.diffAnticone.AddPair(ib.GetID(), ib)
+	}
+	ph.virtualBlock.SetOrder(MaxBlockOrder)
+	ph.preUpdateVirtualBlock()
+	return oldOrders
 }
 
 func (ph *Phantom) isMaxMainTip(pb *PhantomBlock) bool {
@@ -406,7 +447,7 @@ func (ph *Phantom) UpdateVirtualBlockOrder() *PhantomBlock {
 	var maxLayer uint = 0
 	for k := range ph.bd.tips.GetMap() {
 		parent := ph.bd.getBlockById(k)
-		ph.virtualBlock.parents.AddPair(k, parent)
+		ph.virtualBlock.parents.Add(k)
 
 		if maxLayer == 0 || maxLayer < parent.GetLayer() {
 			maxLayer = parent.GetLayer()
@@ -598,6 +639,8 @@ func (ph *Phantom) Load(dbTx database.Tx) error {
 	ph.mainChain.genesis = 0
 	ph.mainChain.tip = tips[0]
 
+	// Only the blocks that are not ordered and tips will stay in memory,
+	// others will be loaded on demand.
 	for i := uint(0); i < ph.bd.blockTotal; i++ {
 		block := Block{id: i}
 		ib := ph.CreateBlock(&block)
@@ -611,21 +654,13 @@ func (ph *Phantom) Load(dbTx database.Tx) error {
 		if i == 0 && !ib.GetHash().IsEqual(ph.bd.GetGenesisHash()) {
 			return fmt.Errorf("genesis data mismatch")
 		}
-		// Make up for missing
-		if ib.HasParents() {
-			parentsSet := NewIdSet()
-			for k := range ib.GetParents().GetMap() {
-				parent := ph.bd.getBlockById(k)
-				parentsSet.AddPair(k, parent)
-				parent.AddChild(ib)
-			}
-			ib.GetParents().Clean()
-			ib.GetParents().AddSet(parentsSet)
-		}
-		ph.bd.blocks[ib.GetID()] = ib
-
 		//
 		if !ib.IsOrdered() {
+			ib, err = ph.bd.loadBlock(dbTx, i)
+			if err != nil {
+				return err
+			}
+			ph.bd.blocks.add(ib)
 			ph.diffAnticone.AddPair(ib.GetID(), ib)
 		} else {
 			// check order index
@@ -637,13 +672,17 @@ func (ph *Phantom) Load(dbTx database.Tx) error {
 				return fmt.Errorf("The order(%d) of %s is inconsistent: Order Index (%d)\n", ib.GetOrder(), ib.GetHash(), id)
 			}
 		}
-		block.data = ph.bd.getBlockData(ib.GetHash())
 	}
 	// load tips
 	for _, v := range tips {
-		tip := ph.getBlock(v)
+		tip := ph.bd.blocks.get(v)
 		if tip == nil {
-			return fmt.Errorf("Can't find tip:%d\n", v)
+			ib, err := ph.bd.loadBlock(dbTx, v)
+			if err != nil {
+				return fmt.Errorf("Can't find tip:%d\n", v)
+			}
+			ph.bd.blocks.add(ib)
+			tip = ib
 		}
 		ph.bd.updateTips(tip)
 	}
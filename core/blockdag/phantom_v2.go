@@ -249,22 +249,21 @@ func (ph *Phantom_v2) colorBlock(kc *KChain, pb *PhantomBlock, blueOrder *HashSe
 	}
 }
 
+// The same rule as Phantom, the ancestry of k-chain is answered by the
+// reachability index.
 func (ph *Phantom_v2) coloringRule2(kc *KChain, pb *PhantomBlock) bool {
-	return false
-	/*	curPb := pb
-		for {
-			if curPb.GetLayer() < kc.miniLayer {
-				return false
-			}
-			if kc.blocks.Has(curPb.GetHash()) {
-				return true
-			}
-			if curPb.mainParent == nil {
-				break
-			}
-			curPb = ph.blocks[*curPb.mainParent]
+	if kc == nil || pb.GetLayer() < kc.miniLayer {
+		return false
+	}
+	if kc.blocks.Has(pb.GetID()) {
+		return true
+	}
+	for id := range kc.blocks.GetMap() {
+		if ph.bd.reachability.isTreeAncestor(id, pb.GetID()) {
+			return true
 		}
-		return false*/
+	}
+	return false
 }
 
 func (ph *Phantom_v2) updateMaxColoring(pb *PhantomBlock) {
@@ -0,0 +1,639 @@
+/*
+ * Copyright (c) 2017-2020 The qitmeer developers
+ */
+
+package blockdag
+
+import (
+	"bytes"
+	"container/list"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/core/dbnamespace"
+	s "github.com/Qitmeer/qitmeer/core/serialization"
+	"github.com/Qitmeer/qitmeer/database"
+	"io"
+	"math"
+	"sort"
+)
+
+// The interval space that a new tree child keeps for its future siblings
+const reachabilityReserve = uint64(1) << 16
+
+// The maximum number of reachability nodes that are kept in memory
+const reachabilityCacheSize = 100000
+
+// The interval of genesis, it includes all the other intervals.
+var reachabilityRootInterval = reachabilityInterval{1, math.MaxUint64 - 1}
+
+// The closed interval [start,end] of the reachability tree
+type reachabilityInterval struct {
+	start uint64
+	end   uint64
+}
+
+func (ri *reachabilityInterval) size() uint64 {
+	return ri.end - ri.start + 1
+}
+
+func (ri *reachabilityInterval) contains(other *reachabilityInterval) bool {
+	return ri.start <= other.start && other.end <= ri.end
+}
+
+// The reachability data of one block. The tree is built by the main parent of
+// block, and the interval of every tree node contains the intervals of its
+// tree children. The blocks that are in the DAG future but not in the tree
+// future are covered by the future covering set.
+type reachabilityNode struct {
+	id       uint
+	parent   uint
+	children []uint
+	interval reachabilityInterval
+
+	// The minimal set of blocks whose merge set contains this block
+	futureCoveringSet []uint
+}
+
+func (rn *reachabilityNode) clone() *reachabilityNode {
+	result := *rn
+	result.children = append([]uint{}, rn.children...)
+	result.futureCoveringSet = append([]uint{}, rn.futureCoveringSet...)
+	return &result
+}
+
+// encode
+func (rn *reachabilityNode) Encode(w io.Writer) error {
+	err := s.WriteElements(w, uint32(rn.parent), rn.interval.start, rn.interval.end)
+	if err != nil {
+		return err
+	}
+	for _, ids := range [][]uint{rn.children, rn.futureCoveringSet} {
+		err = s.WriteElements(w, uint32(len(ids)))
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			err = s.WriteElements(w, uint32(id))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// decode
+func (rn *reachabilityNode) Decode(r io.Reader) error {
+	var parent uint32
+	err := s.ReadElements(r, &parent, &rn.interval.start, &rn.interval.end)
+	if err != nil {
+		return err
+	}
+	rn.parent = uint(parent)
+
+	readIds := func() ([]uint, error) {
+		var size uint32
+		err := s.ReadElements(r, &size)
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]uint, size)
+		for i := uint32(0); i < size; i++ {
+			var id uint32
+			err := s.ReadElements(r, &id)
+			if err != nil {
+				return nil, err
+			}
+			ids[i] = uint(id)
+		}
+		return ids, nil
+	}
+	rn.children, err = readIds()
+	if err != nil {
+		return err
+	}
+	rn.futureCoveringSet, err = readIds()
+	return err
+}
+
+// The reachability index answers whether a block is in the past of another
+// block without walking the DAG.
+type reachability struct {
+	bd *BlockDAG
+
+	// The LRU nodes in the database
+	cache    map[uint]*list.Element
+	cacheLRU *list.List
+
+	// The nodes that were changed by the last added block and not committed
+	staging map[uint]*reachabilityNode
+
+	// It is used to read the nodes which are not committed in the building.
+	dbTx database.Tx
+}
+
+func newReachability(bd *BlockDAG) *reachability {
+	return &reachability{
+		bd:       bd,
+		cache:    map[uint]*list.Element{},
+		cacheLRU: list.New(),
+		staging:  map[uint]*reachabilityNode{},
+	}
+}
+
+func (r *reachability) getCached(id uint) *reachabilityNode {
+	e, ok := r.cache[id]
+	if !ok {
+		return nil
+	}
+	r.cacheLRU.MoveToFront(e)
+	return e.Value.(*reachabilityNode)
+}
+
+// Add or replace the node, and evict the least recently used one if the cache
+// is full.
+func (r *reachability) putCached(node *reachabilityNode) {
+	if e, ok := r.cache[node.id]; ok {
+		e.Value = node
+		r.cacheLRU.MoveToFront(e)
+		return
+	}
+	r.cache[node.id] = r.cacheLRU.PushFront(node)
+	if r.cacheLRU.Len() > reachabilityCacheSize {
+		e := r.cacheLRU.Back()
+		r.cacheLRU.Remove(e)
+		delete(r.cache, e.Value.(*reachabilityNode).id)
+	}
+}
+
+func (r *reachability) removeCached(id uint) {
+	if e, ok := r.cache[id]; ok {
+		r.cacheLRU.Remove(e)
+		delete(r.cache, id)
+	}
+}
+
+func (r *reachability) getNode(id uint) *reachabilityNode {
+	if node, ok := r.staging[id]; ok {
+		return node
+	}
+	if node := r.getCached(id); node != nil {
+		return node
+	}
+	if id == MaxId {
+		return nil
+	}
+	var node *reachabilityNode
+	load := func(dbTx database.Tx) error {
+		var err error
+		node, err = dbGetReachabilityNode(dbTx, id)
+		return err
+	}
+	var err error
+	if r.dbTx != nil {
+		err = load(r.dbTx)
+	} else {
+		err = r.bd.db.View(load)
+	}
+	if err != nil {
+		if de, ok := err.(*DAGError); !ok || !de.IsEmpty() {
+			log.Error(fmt.Sprintf("Load reachability(%d) error:%s", id, err.Error()))
+		}
+		return nil
+	}
+	r.putCached(node)
+	return node
+}
+
+// Return the node that can be modified, it will be committed with the block.
+func (r *reachability) getNodeForUpdate(id uint) *reachabilityNode {
+	if node, ok := r.staging[id]; ok {
+		return node
+	}
+	node := r.getNode(id)
+	if node == nil {
+		return nil
+	}
+	node = node.clone()
+	r.staging[id] = node
+	return node
+}
+
+func (r *reachability) hasNode(id uint) bool {
+	return r.getNode(id) != nil
+}
+
+// Add the block into the index, the main parent and all parents must have been
+// added already.
+func (r *reachability) addBlock(ib IBlock, mainParent uint) error {
+	return r.add(ib.GetID(), mainParent, ib.GetParents(), func(id uint) *IdSet {
+		block := r.bd.getBlockById(id)
+		if block == nil {
+			return nil
+		}
+		return block.GetParents()
+	})
+}
+
+func (r *reachability) add(id uint, mainParent uint, parents *IdSet, getParents func(id uint) *IdSet) error {
+	node := &reachabilityNode{id: id, parent: mainParent}
+	if mainParent == MaxId {
+		node.interval = reachabilityRootInterval
+		r.staging[id] = node
+		return nil
+	}
+	parent := r.getNodeForUpdate(mainParent)
+	if parent == nil {
+		return fmt.Errorf("No reachability data of main parent:%d", mainParent)
+	}
+	r.staging[id] = node
+	err := r.addTreeChild(parent, node)
+	if err != nil {
+		return err
+	}
+	mergeset, err := r.getMergeset(mainParent, parents, getParents)
+	if err != nil {
+		return err
+	}
+	for _, m := range mergeset {
+		mn := r.getNodeForUpdate(m)
+		if mn == nil {
+			return fmt.Errorf("No reachability data of block:%d", m)
+		}
+		r.insertFutureCovering(mn, node)
+	}
+	return nil
+}
+
+// The blocks in the past of block but not in the past of its main parent
+func (r *reachability) getMergeset(mainParent uint, parents *IdSet, getParents func(id uint) *IdSet) ([]uint, error) {
+	result := []uint{}
+	if parents == nil {
+		return result, nil
+	}
+	visited := NewIdSet()
+	queue := []uint{}
+	for k := range parents.GetMap() {
+		if k != mainParent {
+			queue = append(queue, k)
+		}
+	}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+
+		if visited.Has(cur) {
+			continue
+		}
+		visited.Add(cur)
+		if cur == mainParent || r.isDAGAncestor(cur, mainParent) {
+			continue
+		}
+		result = append(result, cur)
+		curParents := getParents(cur)
+		if curParents == nil {
+			return nil, fmt.Errorf("No parents of block:%d", cur)
+		}
+		for k := range curParents.GetMap() {
+			if !visited.Has(k) {
+				queue = append(queue, k)
+			}
+		}
+	}
+	return result, nil
+}
+
+// Allocate the interval for new tree child from the free space of parent. The
+// last slot of parent is never allocated, so the child is always a proper
+// subset of its parent.
+func (r *reachability) addTreeChild(parent *reachabilityNode, child *reachabilityNode) error {
+	start := parent.interval.start
+	if len(parent.children) > 0 {
+		last := r.getNode(parent.children[len(parent.children)-1])
+		if last == nil {
+			return fmt.Errorf("No reachability data of block:%d", parent.children[len(parent.children)-1])
+		}
+		start = last.interval.end + 1
+	}
+	parent.children = append(parent.children, child.id)
+
+	end := parent.interval.end - 1
+	if start <= end {
+		remaining := end - start + 1
+		reserve := remaining / 2
+		if reserve > reachabilityReserve {
+			reserve = reachabilityReserve
+		}
+		child.interval = reachabilityInterval{start, start + remaining - reserve - 1}
+		return nil
+	}
+	return r.reindex(child)
+}
+
+// Reallocate the intervals for the subtree of the nearest ancestor that has
+// enough space. The path to the new block will get most of the free space,
+// because the following blocks are most likely to be added there.
+func (r *reachability) reindex(leaf *reachabilityNode) error {
+	sizes := map[uint]uint64{}
+	path := NewIdSet()
+	path.Add(leaf.id)
+
+	root := r.getNode(leaf.parent)
+	path.Add(root.id)
+	err := r.countSubtree(root, sizes)
+	if err != nil {
+		return err
+	}
+	for root.interval.size()/(4*reachabilityReserve) < sizes[root.id] {
+		if root.parent == MaxId {
+			return fmt.Errorf("Reachability interval is exhausted")
+		}
+		parent := r.getNode(root.parent)
+		if parent == nil {
+			return fmt.Errorf("No reachability data of block:%d", root.parent)
+		}
+		size := uint64(1)
+		for _, c := range parent.children {
+			if c != root.id {
+				child := r.getNode(c)
+				if child == nil {
+					return fmt.Errorf("No reachability data of block:%d", c)
+				}
+				err := r.countSubtree(child, sizes)
+				if err != nil {
+					return err
+				}
+			}
+			size += sizes[c]
+		}
+		sizes[parent.id] = size
+		root = parent
+		path.Add(root.id)
+	}
+	log.Trace(fmt.Sprintf("Reachability reindex:%d (size=%d)", root.id, sizes[root.id]))
+	return r.allocate(root, sizes, path)
+}
+
+// Count the subtree size of all the nodes in the subtree
+func (r *reachability) countSubtree(root *reachabilityNode, sizes map[uint]uint64) error {
+	nodes := []*reachabilityNode{root}
+	for i := 0; i < len(nodes); i++ {
+		for _, c := range nodes[i].children {
+			child := r.getNode(c)
+			if child == nil {
+				return fmt.Errorf("No reachability data of block:%d", c)
+			}
+			nodes = append(nodes, child)
+		}
+	}
+	for i := len(nodes) - 1; i >= 0; i-- {
+		size := uint64(1)
+		for _, c := range nodes[i].children {
+			size += sizes[c]
+		}
+		sizes[nodes[i].id] = size
+	}
+	return nil
+}
+
+func (r *reachability) allocate(root *reachabilityNode, sizes map[uint]uint64, path *IdSet) error {
+	queue := []*reachabilityNode{root}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		if len(cur.children) == 0 {
+			continue
+		}
+		required := uint64(0)
+		var heavy uint = MaxId
+		for _, c := range cur.children {
+			required += sizes[c]
+			if path.Has(c) {
+				heavy = c
+			}
+		}
+		if heavy == MaxId {
+			for _, c := range cur.children {
+				if heavy == MaxId || sizes[c] >= sizes[heavy] {
+					heavy = c
+				}
+			}
+		}
+		capacity := cur.interval.size() - 1
+		if capacity < required {
+			return fmt.Errorf("Reachability interval of %d is not enough", cur.id)
+		}
+		spare := capacity - required
+		reserve := spare / 2
+		if reserve > reachabilityReserve {
+			reserve = reachabilityReserve
+		}
+		rest := spare - reserve
+		extra := map[uint]uint64{}
+		for _, c := range cur.children {
+			if c == heavy {
+				continue
+			}
+			e := rest / uint64(2*len(cur.children))
+			if e > reachabilityReserve {
+				e = reachabilityReserve
+			}
+			extra[c] = e
+			rest -= e
+		}
+		extra[heavy] = rest
+
+		start := cur.interval.start
+		for _, c := range cur.children {
+			child := r.getNodeForUpdate(c)
+			if child == nil {
+				return fmt.Errorf("No reachability data of block:%d", c)
+			}
+			size := sizes[c] + extra[c]
+			child.interval = reachabilityInterval{start, start + size - 1}
+			start += size
+			queue = append(queue, child)
+		}
+	}
+	return nil
+}
+
+// Put the block into future covering set of node, unless it is already
+// covered by one of the set.
+func (r *reachability) insertFutureCovering(node *reachabilityNode, future *reachabilityNode) {
+	for _, c := range node.futureCoveringSet {
+		if c == future.id {
+			return
+		}
+		cn := r.getNode(c)
+		if cn != nil && cn.interval.contains(&future.interval) {
+			return
+		}
+	}
+	node.futureCoveringSet = append(node.futureCoveringSet, future.id)
+}
+
+// Whether a is the ancestor of b in the tree of main parent
+func (r *reachability) isTreeAncestor(a uint, b uint) bool {
+	if a == b {
+		return false
+	}
+	an := r.getNode(a)
+	bn := r.getNode(b)
+	if an == nil || bn == nil {
+		return false
+	}
+	return an.interval.contains(&bn.interval)
+}
+
+// Whether a is in the past of b
+func (r *reachability) isDAGAncestor(a uint, b uint) bool {
+	if a == b {
+		return false
+	}
+	an := r.getNode(a)
+	bn := r.getNode(b)
+	if an == nil || bn == nil {
+		return false
+	}
+	if an.interval.contains(&bn.interval) {
+		return true
+	}
+	for _, c := range an.futureCoveringSet {
+		if c == b {
+			return true
+		}
+		cn := r.getNode(c)
+		if cn != nil && cn.interval.contains(&bn.interval) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *reachability) commit(dbTx database.Tx) error {
+	for id, node := range r.staging {
+		err := dbPutReachabilityNode(dbTx, id, node)
+		if err != nil {
+			return err
+		}
+		r.putCached(node)
+	}
+	r.staging = map[uint]*reachabilityNode{}
+	return nil
+}
+
+func (r *reachability) rollback() {
+	r.staging = map[uint]*reachabilityNode{}
+}
+
+// Remove the discarded tip
+func (r *reachability) removeBlock(dbTx database.Tx, id uint) error {
+	node := r.getNode(id)
+	if node == nil {
+		return nil
+	}
+	r.removeCached(id)
+	delete(r.staging, id)
+	parent := r.getNode(node.parent)
+	if parent != nil {
+		parent = parent.clone()
+		for i, c := range parent.children {
+			if c == id {
+				parent.children = append(parent.children[:i], parent.children[i+1:]...)
+				break
+			}
+		}
+		err := dbPutReachabilityNode(dbTx, parent.id, parent)
+		if err != nil {
+			return err
+		}
+		r.putCached(parent)
+	}
+	return dbDelReachabilityNode(dbTx, id)
+}
+
+// Build the index for the blocks that were stored before it existed
+func (r *reachability) build(dbTx database.Tx) error {
+	bucket := dbTx.Metadata().Bucket(dbnamespace.BlockIndexBucketName)
+	if bucket == nil {
+		return nil
+	}
+	ids := []uint{}
+	cursor := bucket.Cursor()
+	for cok := cursor.First(); cok; cok = cursor.Next() {
+		ids = append(ids, uint(dbnamespace.ByteOrder.Uint32(cursor.Key())))
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+	r.dbTx = dbTx
+	defer func() {
+		r.dbTx = nil
+	}()
+
+	getParents := func(id uint) *IdSet {
+		block := &Block{id: id}
+		err := DBGetDAGBlock(dbTx, block)
+		if err != nil {
+			return nil
+		}
+		return block.GetParents()
+	}
+	log.Info(fmt.Sprintf("Start building MeerDAG reachability index🛠:%d blocks", len(ids)))
+	for i, id := range ids {
+		block := &Block{id: id}
+		err := DBGetDAGBlock(dbTx, block)
+		if err != nil {
+			return err
+		}
+		err = r.add(id, block.GetMainParent(), block.GetParents(), getParents)
+		if err != nil {
+			return err
+		}
+		if (i+1)%1000 == 0 {
+			err = r.commit(dbTx)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return r.commit(dbTx)
+}
+
+func dbReachabilityKey(id uint) []byte {
+	var serializedID [4]byte
+	dbnamespace.ByteOrder.PutUint32(serializedID[:], uint32(id))
+	return serializedID[:]
+}
+
+func dbPutReachabilityNode(dbTx database.Tx, id uint, node *reachabilityNode) error {
+	var buff bytes.Buffer
+	err := node.Encode(&buff)
+	if err != nil {
+		return err
+	}
+	bucket := dbTx.Metadata().Bucket(dbnamespace.ReachabilityBucketName)
+	return bucket.Put(dbReachabilityKey(id), buff.Bytes())
+}
+
+func dbGetReachabilityNode(dbTx database.Tx, id uint) (*reachabilityNode, error) {
+	bucket := dbTx.Metadata().Bucket(dbnamespace.ReachabilityBucketName)
+	data := bucket.Get(dbReachabilityKey(id))
+	if data == nil {
+		return nil, &DAGError{DAGErrorEmpty}
+	}
+	node := &reachabilityNode{id: id}
+	err := node.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, NewDAGError(err)
+	}
+	return node, nil
+}
+
+func dbDelReachabilityNode(dbTx database.Tx, id uint) error {
+	bucket := dbTx.Metadata().Bucket(dbnamespace.ReachabilityBucketName)
+	return bucket.Delete(dbReachabilityKey(id))
+}
@@ -0,0 +1,121 @@
+package blockdag
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func Test_ReachabilityFig(t *testing.T) {
+	graphs := []string{"PH_fig2-blocks", "PH_fig4-blocks"}
+	for _, graph := range graphs {
+		ibd := InitBlockDAG(phantom, graph)
+		if ibd == nil {
+			t.FailNow()
+		}
+		for ak, a := range tbMap {
+			fs := NewIdSet()
+			bd.getFutureSet(fs, a)
+			for bk, b := range tbMap {
+				if fs.Has(b.GetID()) != bd.IsAncestor(a.GetID(), b.GetID()) {
+					t.Fatalf("%s: IsAncestor(%s,%s) error", graph, ak, bk)
+				}
+			}
+		}
+	}
+}
+
+func Test_ReachabilityReindex(t *testing.T) {
+	r := newReachability(&BlockDAG{blocks: newBlockCache(DefaultBlockCacheSize)})
+	parents := map[uint]*IdSet{}
+	pasts := map[uint]*IdSet{}
+	getParents := func(id uint) *IdSet {
+		return parents[id]
+	}
+	rnd := rand.New(rand.NewSource(1))
+	total := uint(2000)
+	for id := uint(0); id < total; id++ {
+		ps := NewIdSet()
+		past := NewIdSet()
+		mainParent := MaxId
+		if id > 0 {
+			// The long side chains will use up the reserved intervals.
+			mainParent = id - 1
+			if rnd.Intn(20) == 0 {
+				mainParent = uint(rnd.Intn(int(id)))
+			}
+			ps.Add(mainParent)
+			if rnd.Intn(3) == 0 {
+				ps.Add(uint(rnd.Intn(int(id))))
+			}
+			for k := range ps.GetMap() {
+				past.Add(k)
+				past.AddSet(pasts[k])
+			}
+		}
+		parents[id] = ps
+		pasts[id] = past
+		err := r.add(id, mainParent, ps, getParents)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 20000; i++ {
+		a := uint(rnd.Intn(int(total)))
+		b := uint(rnd.Intn(int(total)))
+		if pasts[b].Has(a) != r.isDAGAncestor(a, b) {
+			t.Fatalf("isDAGAncestor(%d,%d) error", a, b)
+		}
+	}
+}
+
+func Test_ReachabilityColoringRule(t *testing.T) {
+	graphs := []string{"PH_fig2-blocks", "PH_fig4-blocks"}
+	for _, graph := range graphs {
+		ibd := InitBlockDAG(phantom, graph)
+		if ibd == nil {
+			t.FailNow()
+		}
+		ph := ibd.(*Phantom)
+		for kk, k := range tbMap {
+			kc := ph.getKChain(k.(*PhantomBlock))
+			for bk, b := range tbMap {
+				// Walk the main chain as the reference
+				expect := false
+				for cur := b.(*PhantomBlock); cur.GetLayer() >= kc.miniLayer; cur = ph.getBlock(cur.mainParent) {
+					if kc.blocks.Has(cur.GetID()) {
+						expect = true
+						break
+					}
+					if cur.mainParent == MaxId {
+						break
+					}
+				}
+				if ph.coloringRule(kc, b.(*PhantomBlock)) != expect {
+					t.Fatalf("%s: coloringRule(%s,%s) error", graph, kk, bk)
+				}
+			}
+		}
+	}
+}
+
+func Test_ReachabilityCache(t *testing.T) {
+	r := newReachability(&BlockDAG{blocks: newBlockCache(1)})
+	for id := uint(0); id < reachabilityCacheSize; id++ {
+		r.putCached(&reachabilityNode{id: id})
+	}
+	// The genesis is used recently, so the next one is evicted.
+	if r.getCached(0) == nil {
+		t.Fatal("The genesis is not in the cache")
+	}
+	r.putCached(&reachabilityNode{id: reachabilityCacheSize})
+	if r.cacheLRU.Len() != reachabilityCacheSize || len(r.cache) != reachabilityCacheSize {
+		t.Fatalf("The cache size is %d, expect %d", r.cacheLRU.Len(), reachabilityCacheSize)
+	}
+	if r.getCached(0) == nil || r.getCached(1) != nil || r.getCached(reachabilityCacheSize) == nil {
+		t.Fatal("The least recently used node is not evicted")
+	}
+	r.removeCached(0)
+	if r.getCached(0) != nil || r.cacheLRU.Len() != reachabilityCacheSize-1 {
+		t.Fatal("The node is not removed")
+	}
+}
@@ -0,0 +1,61 @@
+package sim
+
+import (
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/blockdag"
+)
+
+// Block is the simulated block data, it implements blockdag.IBlockData like
+// the TestBlock of blockdag tests.
+type Block struct {
+	hash    hash.Hash
+	parents []*hash.Hash
+	layer   uint
+	miner   int
+	created float64
+}
+
+// The seed decides the hash of block, so it must be unique in one round.
+func newBlock(seed string, miner int, parents []*Block, created float64) *Block {
+	b := &Block{
+		hash:    hash.DoubleHashH([]byte(seed)),
+		parents: make([]*hash.Hash, 0, len(parents)),
+		miner:   miner,
+		created: created,
+	}
+	for _, p := range parents {
+		b.parents = append(b.parents, &p.hash)
+		if p.layer+1 > b.layer {
+			b.layer = p.layer + 1
+		}
+	}
+	return b
+}
+
+// Return the hash
+func (b *Block) GetHash() *hash.Hash {
+	return &b.hash
+}
+
+// Get all parents set,the dag block has more than one parent
+func (b *Block) GetParents() []*hash.Hash {
+	return b.parents
+}
+
+func (b *Block) GetTimestamp() int64 {
+	return int64(b.created)
+}
+
+func (b *Block) GetPriority() int {
+	return blockdag.MaxPriority
+}
+
+// The index of miner, it is -1 for genesis and replayed blocks
+func (b *Block) Miner() int {
+	return b.miner
+}
+
+// The simulated time when the block was created, unit is second
+func (b *Block) Created() float64 {
+	return b.created
+}
@@ -0,0 +1,111 @@
+package sim
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/core/blockdag"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/database"
+)
+
+const (
+	// The confirmations which are used to measure the confirmation time
+	DefaultConfirmations = blockdag.StableConfirmations
+
+	// The number of private blocks that attacker waits for before release
+	DefaultAttackBlocks = 6
+)
+
+// Miner describes one miner of the simulated network.
+type Miner struct {
+	// The share of the hash power of whole network
+	HashShare float64
+
+	// The propagation delay of the blocks from this miner, unit is second
+	Delay float64
+
+	// The attacker mines blocks privately from AttackStart, and it releases
+	// them after it has mined AttackBlocks blocks. Only one attacker is
+	// supported.
+	Attacker bool
+}
+
+// Config is the parameters of simulator.
+type Config struct {
+	// DAG type {phantom,ghostdag,spectre}
+	DAGType string
+
+	Miners []Miner
+
+	// Blocks per second of whole network
+	BlockRate float64
+
+	// The number of blocks which are mined in each round
+	Blocks int
+
+	// The number of rounds, every round uses a new DAG
+	Rounds int
+
+	// The seed of random, the same seed always gets the same result
+	Seed int64
+
+	Confirmations uint
+
+	// The simulated time when attacker starts to mine privately, unit is second
+	AttackStart float64
+
+	AttackBlocks int
+
+	MaxParents int
+
+	// It opens an empty database for the DAG of each round, the simulator
+	// will close it at the end of round.
+	OpenDB func() (database.DB, error)
+}
+
+func (c *Config) check() error {
+	if len(c.Miners) == 0 {
+		return fmt.Errorf("No miners")
+	}
+	totalShare := 0.0
+	attackers := 0
+	for i, m := range c.Miners {
+		if m.HashShare < 0 || m.Delay < 0 {
+			return fmt.Errorf("Miner %d: hash share and delay can't be negative", i)
+		}
+		totalShare += m.HashShare
+		if m.Attacker {
+			attackers++
+		}
+	}
+	if totalShare <= 0 {
+		return fmt.Errorf("The total hash share must be positive")
+	}
+	if attackers > 1 {
+		return fmt.Errorf("Only one attacker is supported")
+	}
+	if c.BlockRate <= 0 {
+		return fmt.Errorf("The block rate must be positive")
+	}
+	if c.Blocks <= 0 {
+		return fmt.Errorf("The number of blocks must be positive")
+	}
+	if c.OpenDB == nil {
+		return fmt.Errorf("No database")
+	}
+	if blockdag.NewBlockDAG(c.DAGType) == nil {
+		return fmt.Errorf("Unknown DAG type:%s", c.DAGType)
+	}
+	if c.Rounds <= 0 {
+		c.Rounds = 1
+	}
+	if c.Confirmations == 0 {
+		c.Confirmations = DefaultConfirmations
+	}
+	if c.AttackBlocks <= 0 {
+		c.AttackBlocks = DefaultAttackBlocks
+	}
+	if c.MaxParents <= 0 || c.MaxParents > types.MaxParentsPerBlock {
+		c.MaxParents = types.MaxParentsPerBlock
+	}
+	return nil
+}
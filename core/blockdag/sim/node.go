@@ -0,0 +1,93 @@
+package sim
+
+import (
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/blockdag"
+	"sort"
+)
+
+// node is the local view of one participant, it only knows the blocks which
+// have arrived and whose parents are known.
+type node struct {
+	known   map[hash.Hash]*Block
+	tips    map[hash.Hash]*Block
+	waiting map[hash.Hash][]*Block
+
+	// The blocks from others which are ignored during private mining
+	ignored []*Block
+}
+
+func newNode(genesis *Block) *node {
+	n := &node{
+		known:   map[hash.Hash]*Block{},
+		tips:    map[hash.Hash]*Block{},
+		waiting: map[hash.Hash][]*Block{},
+	}
+	n.known[genesis.hash] = genesis
+	n.tips[genesis.hash] = genesis
+	return n
+}
+
+func (n *node) missingParent(b *Block) *hash.Hash {
+	for _, p := range b.parents {
+		if _, ok := n.known[*p]; !ok {
+			return p
+		}
+	}
+	return nil
+}
+
+// Receive one block, it returns the blocks which become known in order. The
+// block waits for its parents if they have not arrived.
+func (n *node) receive(b *Block) []*Block {
+	var accepted []*Block
+	queue := []*Block{b}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		if _, ok := n.known[cur.hash]; ok {
+			continue
+		}
+		missing := n.missingParent(cur)
+		if missing != nil {
+			n.waiting[*missing] = append(n.waiting[*missing], cur)
+			continue
+		}
+		n.known[cur.hash] = cur
+		for _, p := range cur.parents {
+			delete(n.tips, *p)
+		}
+		n.tips[cur.hash] = cur
+		accepted = append(accepted, cur)
+
+		queue = append(queue, n.waiting[cur.hash]...)
+		delete(n.waiting, cur.hash)
+	}
+	return accepted
+}
+
+// Select the parents from local tips like the miner, the highest layers are
+// preferred and the layer gap is limited by blockdag.MaxTipLayerGap.
+func (n *node) selectParents(max int) []*Block {
+	tips := make([]*Block, 0, len(n.tips))
+	for _, b := range n.tips {
+		tips = append(tips, b)
+	}
+	sort.Slice(tips, func(i, j int) bool {
+		if tips[i].layer != tips[j].layer {
+			return tips[i].layer > tips[j].layer
+		}
+		return tips[i].hash.String() < tips[j].hash.String()
+	})
+	result := []*Block{}
+	for _, b := range tips {
+		if tips[0].layer-b.layer > blockdag.MaxTipLayerGap {
+			break
+		}
+		result = append(result, b)
+		if len(result) >= max {
+			break
+		}
+	}
+	return result
+}
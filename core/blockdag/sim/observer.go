@@ -0,0 +1,136 @@
+package sim
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/core/blockdag"
+	"github.com/Qitmeer/qitmeer/database"
+	"math"
+)
+
+type pendingBlock struct {
+	id      uint
+	created float64
+}
+
+// observer drives the DAG instance with the blocks in its arrival order, and
+// it collects the metrics.
+type observer struct {
+	bd            *blockdag.BlockDAG
+	confirmations uint
+
+	added     int
+	rejected  int
+	reorders  int
+	reorderer int
+	maxDepth  uint
+
+	pending      []pendingBlock
+	confirmTimes []float64
+}
+
+func calcWeight(ib blockdag.IBlock, bi *blockdag.BlueInfo) int64 {
+	return 1
+}
+
+func newObserver(cfg *Config, db database.DB, genesis *Block) (*observer, error) {
+	bd := &blockdag.BlockDAG{}
+	if bd.Init(cfg.DAGType, calcWeight, cfg.BlockRate, db, nil) == nil {
+		return nil, fmt.Errorf("Failed to initialize DAG:%s", cfg.DAGType)
+	}
+	// Keep all the blocks, the late blocks may refer to the old tips.
+	bd.SetTipsDisLimit(math.MaxInt32)
+
+	_, _, _, _, err := bd.AddBlock(genesis)
+	if err != nil {
+		return nil, err
+	}
+	err = bd.Commit()
+	if err != nil {
+		return nil, err
+	}
+	return &observer{bd: bd, confirmations: cfg.Confirmations}, nil
+}
+
+// Add the block which arrived at now, only the confirmation time of honest
+// blocks is measured.
+func (o *observer) add(b *Block, now float64, honest bool) error {
+	mainOrder := o.bd.GetMainChainTip().GetOrder()
+	_, olds, ib, _, err := o.bd.AddBlock(b)
+	if err != nil {
+		log.Debug(fmt.Sprintf("Reject block %s:%s", b.GetHash(), err))
+		o.rejected++
+		return nil
+	}
+	err = o.bd.Commit()
+	if err != nil {
+		return err
+	}
+	o.added++
+
+	minOld := blockdag.MaxBlockOrder
+	for e := olds.Front(); e != nil; e = e.Next() {
+		boh, ok := e.Value.(*blockdag.BlockOrderHelp)
+		if !ok || boh.OldOrder == blockdag.MaxBlockOrder {
+			continue
+		}
+		o.reorders++
+		if boh.OldOrder < minOld {
+			minOld = boh.OldOrder
+		}
+	}
+	if minOld != blockdag.MaxBlockOrder {
+		o.reorderer++
+		if mainOrder+1-minOld > o.maxDepth {
+			o.maxDepth = mainOrder + 1 - minOld
+		}
+	}
+
+	if honest {
+		o.pending = append(o.pending, pendingBlock{id: ib.GetID(), created: b.created})
+	}
+	o.updateConfirmations(now)
+	return nil
+}
+
+func (o *observer) updateConfirmations(now float64) {
+	remain := o.pending[:0]
+	for _, p := range o.pending {
+		if o.bd.GetConfirmations(p.id) >= o.confirmations {
+			o.confirmTimes = append(o.confirmTimes, now-p.created)
+			continue
+		}
+		remain = append(remain, p)
+	}
+	o.pending = remain
+}
+
+func (o *observer) result() *Result {
+	r := &Result{
+		Blocks:          o.added,
+		Rejected:        o.rejected,
+		Reorders:        o.reorders,
+		MaxReorderDepth: o.maxDepth,
+		Confirmed:       len(o.confirmTimes),
+	}
+	total := o.bd.GetBlockTotal()
+	blue := 0
+	for id := uint(0); id < total; id++ {
+		if o.bd.IsBlue(id) {
+			blue++
+		}
+	}
+	if total > 0 {
+		r.BlueRatio = float64(blue) / float64(total)
+	}
+	if o.added > 0 {
+		r.OrderStability = 1 - float64(o.reorderer)/float64(o.added)
+	}
+	if len(o.confirmTimes) > 0 {
+		sum := 0.0
+		for _, t := range o.confirmTimes {
+			sum += t
+		}
+		r.ConfirmationTime = sum / float64(len(o.confirmTimes))
+	}
+	return r
+}
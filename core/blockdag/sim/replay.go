@@ -0,0 +1,101 @@
+package sim
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/core/blockdag"
+	"os"
+)
+
+// GraphBlock is one block of the recorded graph, it is the same structure as
+// the blocks in testData.json of blockdag.
+type GraphBlock struct {
+	Tag     string   `json:"tag"`
+	Parents []string `json:"parents"`
+}
+
+// LoadGraph loads the graph by name from the recorded json file,
+// e.g. "PH_fig2-blocks" of testData.json.
+func LoadGraph(fileName string, name string) ([]GraphBlock, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data := map[string]json.RawMessage{}
+	err = json.NewDecoder(f).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	raw, ok := data[name]
+	if !ok {
+		return nil, fmt.Errorf("No graph:%s", name)
+	}
+	var graph []GraphBlock
+	err = json.Unmarshal(raw, &graph)
+	if err != nil {
+		return nil, err
+	}
+	return graph, nil
+}
+
+// Replay adds the recorded graph to a new DAG in order, the blocks are created
+// one second after another. Only the DAGType, Confirmations and OpenDB of
+// config are used, it returns the metrics and the DAG blocks by tag.
+func Replay(cfg *Config, graph []GraphBlock) (*Result, map[string]blockdag.IBlock, error) {
+	if len(graph) == 0 || len(graph[0].Parents) != 0 {
+		return nil, nil, fmt.Errorf("The first block of graph must be genesis")
+	}
+	if blockdag.NewBlockDAG(cfg.DAGType) == nil {
+		return nil, nil, fmt.Errorf("Unknown DAG type:%s", cfg.DAGType)
+	}
+	if cfg.OpenDB == nil {
+		return nil, nil, fmt.Errorf("No database")
+	}
+	if cfg.Confirmations == 0 {
+		cfg.Confirmations = DefaultConfirmations
+	}
+	db, err := cfg.OpenDB()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer db.Close()
+
+	blocks := map[string]*Block{}
+	genesis := newBlock(graph[0].Tag, -1, nil, 0)
+	blocks[graph[0].Tag] = genesis
+	obs, err := newObserver(cfg, db, genesis)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := 1; i < len(graph); i++ {
+		gb := graph[i]
+		if _, ok := blocks[gb.Tag]; ok {
+			return nil, nil, fmt.Errorf("Duplicate block:%s", gb.Tag)
+		}
+		parents := []*Block{}
+		for _, tag := range gb.Parents {
+			p, ok := blocks[tag]
+			if !ok {
+				return nil, nil, fmt.Errorf("No parent %s of block %s", tag, gb.Tag)
+			}
+			parents = append(parents, p)
+		}
+		b := newBlock(gb.Tag, -1, parents, float64(i))
+		blocks[gb.Tag] = b
+		err := obs.add(b, float64(i), true)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	result := map[string]blockdag.IBlock{}
+	for tag, b := range blocks {
+		ib := obs.bd.GetBlock(b.GetHash())
+		if ib != nil {
+			result[tag] = ib
+		}
+	}
+	return obs.result(), result, nil
+}
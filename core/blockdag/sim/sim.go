@@ -0,0 +1,313 @@
+/*
+ * Copyright (c) 2017-2020 The qitmeer developers
+ */
+
+// Package sim is a deterministic network simulator for the block DAG. The
+// miners mine blocks on their local views, the blocks are propagated with
+// delays, and one observer drives the DAG instance to collect the metrics.
+package sim
+
+import (
+	"container/heap"
+	"fmt"
+	"math/rand"
+)
+
+// Result is the metrics of one round.
+type Result struct {
+	// The blocks added to the DAG, genesis is excluded
+	Blocks   int `json:"blocks"`
+	Rejected int `json:"rejected"`
+
+	BlueRatio float64 `json:"blueratio"`
+
+	// The number of order changes of the past blocks
+	Reorders        int  `json:"reorders"`
+	MaxReorderDepth uint `json:"maxreorderdepth"`
+
+	// The ratio of blocks that didn't change the past orders
+	OrderStability float64 `json:"orderstability"`
+
+	// The average time for the honest blocks to reach the confirmations
+	ConfirmationTime float64 `json:"confirmationtime"`
+	Confirmed        int     `json:"confirmed"`
+
+	Attacked      bool `json:"attacked"`
+	AttackSuccess bool `json:"attacksuccess"`
+}
+
+// Report is the summary of all rounds.
+type Report struct {
+	Rounds []*Result `json:"rounds"`
+
+	BlueRatio         float64 `json:"blueratio"`
+	OrderStability    float64 `json:"orderstability"`
+	ConfirmationTime  float64 `json:"confirmationtime"`
+	AttackSuccessRate float64 `json:"attacksuccessrate"`
+}
+
+type Simulator struct {
+	cfg *Config
+}
+
+func New(cfg *Config) (*Simulator, error) {
+	err := cfg.check()
+	if err != nil {
+		return nil, err
+	}
+	return &Simulator{cfg: cfg}, nil
+}
+
+// Run all the rounds, the seed of each round is derived from Config.Seed.
+func (s *Simulator) Run() (*Report, error) {
+	report := &Report{}
+	attacks := 0
+	successes := 0
+	confirmed := 0
+	for i := 0; i < s.cfg.Rounds; i++ {
+		r := newRound(s.cfg, i)
+		result, err := r.run()
+		if err != nil {
+			return nil, fmt.Errorf("Round %d:%s", i, err)
+		}
+		report.Rounds = append(report.Rounds, result)
+		report.BlueRatio += result.BlueRatio
+		report.OrderStability += result.OrderStability
+		if result.Confirmed > 0 {
+			report.ConfirmationTime += result.ConfirmationTime
+			confirmed++
+		}
+		if result.Attacked {
+			attacks++
+			if result.AttackSuccess {
+				successes++
+			}
+		}
+	}
+	rounds := float64(len(report.Rounds))
+	report.BlueRatio /= rounds
+	report.OrderStability /= rounds
+	if confirmed > 0 {
+		report.ConfirmationTime /= float64(confirmed)
+	}
+	if attacks > 0 {
+		report.AttackSuccessRate = float64(successes) / float64(attacks)
+	}
+	return report, nil
+}
+
+// The node of mining event
+const mineEvent = -1
+
+type event struct {
+	time  float64
+	seq   uint64
+	node  int
+	block *Block
+}
+
+// The events are ordered by time, and the same time is ordered by sequence.
+type eventQueue []*event
+
+func (q eventQueue) Len() int { return len(q) }
+
+func (q eventQueue) Less(i, j int) bool {
+	if q[i].time != q[j].time {
+		return q[i].time < q[j].time
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q eventQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *eventQueue) Push(x interface{}) { *q = append(*q, x.(*event)) }
+
+func (q *eventQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	e := old[n-1]
+	*q = old[:n-1]
+	return e
+}
+
+type round struct {
+	cfg   *Config
+	index int
+	rand  *rand.Rand
+	queue eventQueue
+	seq   uint64
+	now   float64
+	mined int
+
+	// The miners are followed by the observer
+	nodes []*node
+	obs   *observer
+
+	attacker   int
+	private    bool
+	attackDone bool
+	privates   []*Block
+	conflict   *Block
+	target     *Block
+}
+
+func newRound(cfg *Config, index int) *round {
+	r := &round{
+		cfg:      cfg,
+		index:    index,
+		rand:     rand.New(rand.NewSource(cfg.Seed + int64(index))),
+		attacker: -1,
+	}
+	for i, m := range cfg.Miners {
+		if m.Attacker {
+			r.attacker = i
+		}
+	}
+	return r
+}
+
+func (r *round) observerIndex() int {
+	return len(r.cfg.Miners)
+}
+
+func (r *round) run() (*Result, error) {
+	db, err := r.cfg.OpenDB()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	genesis := newBlock(fmt.Sprintf("%d:genesis", r.index), -1, nil, 0)
+	for i := 0; i <= len(r.cfg.Miners); i++ {
+		r.nodes = append(r.nodes, newNode(genesis))
+	}
+	r.obs, err = newObserver(r.cfg, db, genesis)
+	if err != nil {
+		return nil, err
+	}
+
+	r.schedule(r.rand.ExpFloat64()/r.cfg.BlockRate, mineEvent, nil)
+	for r.queue.Len() > 0 {
+		ev := heap.Pop(&r.queue).(*event)
+		r.now = ev.time
+		if ev.node == mineEvent {
+			r.mine()
+			if r.mined < r.cfg.Blocks {
+				r.schedule(r.now+r.rand.ExpFloat64()/r.cfg.BlockRate, mineEvent, nil)
+			} else if r.private {
+				r.release()
+			}
+			continue
+		}
+		err = r.deliver(ev.node, ev.block)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return r.result(), nil
+}
+
+func (r *round) schedule(t float64, node int, b *Block) {
+	r.seq++
+	heap.Push(&r.queue, &event{time: t, seq: r.seq, node: node, block: b})
+}
+
+func (r *round) pickMiner() int {
+	total := 0.0
+	for _, m := range r.cfg.Miners {
+		total += m.HashShare
+	}
+	x := r.rand.Float64() * total
+	for i, m := range r.cfg.Miners {
+		if x < m.HashShare {
+			return i
+		}
+		x -= m.HashShare
+	}
+	return len(r.cfg.Miners) - 1
+}
+
+func (r *round) mine() {
+	m := r.pickMiner()
+	isAttacker := m == r.attacker
+	if isAttacker && !r.private && !r.attackDone && r.now >= r.cfg.AttackStart {
+		r.private = true
+	}
+	nd := r.nodes[m]
+	r.mined++
+	b := newBlock(fmt.Sprintf("%d:%d:%d", r.index, m, r.mined), m, nd.selectParents(r.cfg.MaxParents), r.now)
+	nd.receive(b)
+
+	if r.private {
+		if isAttacker {
+			if r.conflict == nil {
+				r.conflict = b
+			}
+			r.privates = append(r.privates, b)
+			if r.target != nil && len(r.privates) >= r.cfg.AttackBlocks {
+				r.release()
+			}
+			return
+		}
+		if r.target == nil {
+			r.target = b
+		}
+	}
+	r.broadcast(m, b)
+}
+
+// The attacker publishes the private blocks and then it mines honestly.
+func (r *round) release() {
+	r.private = false
+	r.attackDone = true
+	for _, b := range r.privates {
+		r.broadcast(r.attacker, b)
+	}
+	nd := r.nodes[r.attacker]
+	for _, b := range nd.ignored {
+		nd.receive(b)
+	}
+	nd.ignored = nil
+}
+
+func (r *round) broadcast(m int, b *Block) {
+	for i := range r.nodes {
+		if i == m {
+			continue
+		}
+		r.schedule(r.now+r.cfg.Miners[m].Delay, i, b)
+	}
+}
+
+func (r *round) deliver(i int, b *Block) error {
+	nd := r.nodes[i]
+	if r.private && i == r.attacker {
+		nd.ignored = append(nd.ignored, b)
+		return nil
+	}
+	accepted := nd.receive(b)
+	if i != r.observerIndex() {
+		return nil
+	}
+	for _, ab := range accepted {
+		err := r.obs.add(ab, r.now, ab.miner != r.attacker)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *round) result() *Result {
+	result := r.obs.result()
+	if r.conflict == nil || r.target == nil {
+		return result
+	}
+	result.Attacked = true
+	conflict := r.obs.bd.GetBlock(r.conflict.GetHash())
+	target := r.obs.bd.GetBlock(r.target.GetHash())
+	if conflict != nil && target != nil && conflict.IsOrdered() && target.IsOrdered() {
+		result.AttackSuccess = conflict.GetOrder() < target.GetOrder()
+	}
+	return result
+}
@@ -0,0 +1,145 @@
+package sim
+
+import (
+	"github.com/Qitmeer/qitmeer/database"
+	_ "github.com/Qitmeer/qitmeer/database/ffldb"
+	"github.com/Qitmeer/qitmeer/params"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+type testDB struct {
+	dirs []string
+}
+
+func (tdb *testDB) open() (database.DB, error) {
+	dir, err := ioutil.TempDir(os.TempDir(), "dagsim")
+	if err != nil {
+		return nil, err
+	}
+	tdb.dirs = append(tdb.dirs, dir)
+	return database.Create("ffldb", filepath.Join(dir, "blocks_ffldb"), params.PrivNetParam.Net)
+}
+
+func (tdb *testDB) clean() {
+	for _, dir := range tdb.dirs {
+		os.RemoveAll(dir)
+	}
+}
+
+func testConfig(tdb *testDB) *Config {
+	return &Config{
+		DAGType: "phantom",
+		Miners: []Miner{
+			{HashShare: 0.5, Delay: 2},
+			{HashShare: 0.3, Delay: 5},
+			{HashShare: 0.2, Delay: 1},
+		},
+		BlockRate:     0.2,
+		Blocks:        60,
+		Rounds:        2,
+		Seed:          1,
+		Confirmations: 3,
+		OpenDB:        tdb.open,
+	}
+}
+
+func TestDeterministic(t *testing.T) {
+	tdb := &testDB{}
+	defer tdb.clean()
+
+	run := func() *Report {
+		s, err := New(testConfig(tdb))
+		if err != nil {
+			t.Fatal(err)
+		}
+		report, err := s.Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return report
+	}
+	first := run()
+	second := run()
+	if !reflect.DeepEqual(first, second) {
+		t.Fatalf("The same seed should get the same report")
+	}
+	for i, r := range first.Rounds {
+		if r.Blocks+r.Rejected != 60 {
+			t.Fatalf("Round %d: %d blocks arrived, expect 60", i, r.Blocks+r.Rejected)
+		}
+		if r.BlueRatio <= 0 || r.BlueRatio > 1 {
+			t.Fatalf("Round %d: blue ratio %f is out of range", i, r.BlueRatio)
+		}
+		if r.Attacked {
+			t.Fatalf("Round %d: no attacker", i)
+		}
+	}
+}
+
+func TestAttack(t *testing.T) {
+	tdb := &testDB{}
+	defer tdb.clean()
+
+	cfg := testConfig(tdb)
+	cfg.Miners = append(cfg.Miners, Miner{HashShare: 0.4, Delay: 1, Attacker: true})
+	cfg.AttackStart = 20
+	cfg.AttackBlocks = 3
+	cfg.Rounds = 3
+	s, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := s.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	attacked := false
+	for _, r := range report.Rounds {
+		attacked = attacked || r.Attacked
+	}
+	if !attacked {
+		t.Fatalf("The attacker should attack")
+	}
+	if report.AttackSuccessRate < 0 || report.AttackSuccessRate > 1 {
+		t.Fatalf("Attack success rate %f is out of range", report.AttackSuccessRate)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	tdb := &testDB{}
+	defer tdb.clean()
+
+	graph, err := LoadGraph("../testData.json", "PH_fig2-blocks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, blocks, err := Replay(testConfig(tdb), graph)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Blocks != len(graph)-1 || len(blocks) != len(graph) {
+		t.Fatalf("Replay %d blocks, expect %d", result.Blocks, len(graph)-1)
+	}
+	// The anticone of main chain tip is not ordered until the next block.
+	orders := map[uint]string{}
+	for _, gb := range graph {
+		ib, ok := blocks[gb.Tag]
+		if !ok {
+			t.Fatalf("%s is not in DAG", gb.Tag)
+		}
+		if !ib.IsOrdered() {
+			continue
+		}
+		if tag, ok := orders[ib.GetOrder()]; ok {
+			t.Fatalf("%s and %s have the same order %d", tag, gb.Tag, ib.GetOrder())
+		}
+		orders[ib.GetOrder()] = gb.Tag
+	}
+	if len(orders) == 0 || orders[0] != graph[0].Tag {
+		t.Fatalf("The genesis is not the first block")
+	}
+}
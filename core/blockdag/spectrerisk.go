@@ -2,6 +2,7 @@ package blockdag
 
 import (
 	"fmt"
+	"github.com/Qitmeer/qitmeer/common/roughtime"
 	"gonum.org/v1/gonum/floats"
 	"gonum.org/v1/gonum/mat"
 	"gonum.org/v1/gonum/stat/distuv"
@@ -98,3 +99,96 @@ func GetRisk(N int, alpha float64, lambda float64, delay float64, waitingTime ui
 	}
 	return riskHidden
 }
+
+const (
+	// The size of markov chain that is used by GetRisk for the confirmation risk
+	riskChainSize = 100
+
+	// Stop counting the future of block at the size, the risk will be close to
+	// zero long before it.
+	maxRiskAntiPast = 1000
+)
+
+// Return the reversal risk of the block and the antiPast that is used by GetRisk.
+// alpha: the attacker's relative computational power.
+// delay: the upper bound on the recent delay diameter in the network (seconds).
+// It uses the block rate of DAG and the elapsed time since the block timestamp.
+func (bd *BlockDAG) GetConfirmationRisk(id uint, alpha float64, delay float64) (float64, int, error) {
+	if alpha <= 0 || alpha >= 0.5 {
+		return 0, 0, fmt.Errorf("The attacker hash share(%f) must be in (0,0.5)", alpha)
+	}
+	if delay < 0 {
+		return 0, 0, fmt.Errorf("The delay(%f) is negative", delay)
+	}
+	bd.stateLock.Lock()
+	ib := bd.getBlockById(id)
+	if ib == nil {
+		bd.stateLock.Unlock()
+		return 0, 0, fmt.Errorf("No block:%d", id)
+	}
+	waitingTime := uint(0)
+	if ib.GetData() != nil {
+		elapsed := roughtime.Now().Unix() - ib.GetData().GetTimestamp()
+		if elapsed > 0 {
+			waitingTime = uint(elapsed)
+		}
+	}
+	antiPast := bd.getAntiPast(ib, maxRiskAntiPast)
+	bd.stateLock.Unlock()
+
+	if antiPast == 0 {
+		return 1, 0, nil
+	}
+	risk := GetRisk(riskChainSize, alpha, bd.blockRate, delay, waitingTime, antiPast)
+	// The rounding error may make it a little greater than 1 after a long time
+	if risk > 1 {
+		risk = 1
+	}
+	return risk, antiPast, nil
+}
+
+// min(|future(x')|), where x' is ib or any block in anticone(ib).
+// It stops counting at limit.
+func (bd *BlockDAG) getAntiPast(ib IBlock, limit int) int {
+	result := bd.getFutureSize(ib, limit)
+	for k := range bd.getAnticone(ib, nil).GetMap() {
+		if result == 0 {
+			break
+		}
+		size := bd.getFutureSize(bd.getBlockById(k), result)
+		if size < result {
+			result = size
+		}
+	}
+	return result
+}
+
+// The size of future set of block, it stops counting at limit.
+func (bd *BlockDAG) getFutureSize(ib IBlock, limit int) int {
+	if ib == nil {
+		return 0
+	}
+	visited := NewIdSet()
+	queue := []IBlock{ib}
+	for len(queue) > 0 && visited.Size() < limit {
+		cur := queue[0]
+		queue = queue[1:]
+		if !cur.HasChildren() {
+			continue
+		}
+		for k := range cur.GetChildren().GetMap() {
+			if visited.Has(k) {
+				continue
+			}
+			visited.Add(k)
+			child := bd.getBlockById(k)
+			if child != nil {
+				queue = append(queue, child)
+			}
+		}
+	}
+	if visited.Size() > limit {
+		return limit
+	}
+	return visited.Size()
+}
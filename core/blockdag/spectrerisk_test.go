@@ -10,3 +10,36 @@ func TestOnlineRiskInSpectre(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+func TestConfirmationRisk(t *testing.T) {
+	ibd := InitBlockDAG(phantom, "PH_fig2-blocks")
+	if ibd == nil {
+		t.FailNow()
+	}
+	for k, ib := range tbMap {
+		fs := NewIdSet()
+		bd.getFutureSet(fs, ib)
+		if bd.getFutureSize(ib, maxRiskAntiPast) != fs.Size() {
+			t.Fatalf("future size of %s error", k)
+		}
+	}
+	genesis := bd.getGenesis()
+	risk, antiPast, err := bd.GetConfirmationRisk(genesis.GetID(), 0.1, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if antiPast != int(bd.GetBlockTotal())-1 || risk < 0 || risk > 1 {
+		t.Fatalf("genesis risk:%f antiPast:%d", risk, antiPast)
+	}
+	tip := bd.GetMainChainTip()
+	risk, antiPast, err = bd.GetConfirmationRisk(tip.GetID(), 0.1, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if antiPast != 0 || risk != 1 {
+		t.Fatalf("tip risk:%f antiPast:%d", risk, antiPast)
+	}
+	if _, _, err := bd.GetConfirmationRisk(tip.GetID(), 0.5, 5); err == nil {
+		t.Fatalf("attacker hash share must be less than 0.5")
+	}
+}
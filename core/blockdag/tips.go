@@ -88,6 +88,10 @@ func (bd *BlockDAG) getValidTips(limit bool) []IBlock {
 		if math.Abs(float64(block.GetLayer())-float64(mainParent.GetLayer())) > MaxTipLayerGap {
 			continue
 		}
+		// Don't build on the blocks which were invalidated by operator
+		if block.GetStatus().IsInvalidated() {
+			continue
+		}
 		tips = append(tips, block)
 		if limit && len(tips) >= bd.getMaxParents() {
 			break
@@ -96,6 +100,32 @@ func (bd *BlockDAG) getValidTips(limit bool) []IBlock {
 	return tips
 }
 
+// Return the blocks which were not invalidated by operator and are reached from
+// the tips only through the invalidated blocks, the bluest of them is the bluest
+// block which was not invalidated. They are the tips if nothing was invalidated.
+func (bd *BlockDAG) getNotInvalidatedTips() *IdSet {
+	result := NewIdSet()
+	visited := NewIdSet()
+	queue := bd.tips.List()
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if visited.Has(id) {
+			continue
+		}
+		visited.Add(id)
+		block := bd.getBlockById(id)
+		if !block.GetStatus().IsInvalidated() {
+			result.AddPair(id, block)
+			continue
+		}
+		if block.HasParents() {
+			queue = append(queue, block.GetParents().List()...)
+		}
+	}
+	return result
+}
+
 // build merkle tree form current DAG tips
 func (bd *BlockDAG) BuildMerkleTreeStoreFromTips() []*hash.Hash {
 	parents := bd.GetTips().SortList(false)
@@ -142,6 +172,7 @@ func (bd *BlockDAG) optimizeTips(dbTx database.Tx) {
 
 func (bd *BlockDAG) removeTip(dbTx database.Tx, b IBlock) error {
 	bd.tips.Remove(b.GetID())
+	bd.blocks.remove(b.GetID())
 	err := DBDelDAGBlock(dbTx, b.GetID())
 	if err != nil {
 		return err
@@ -154,9 +185,20 @@ func (bd *BlockDAG) removeTip(dbTx database.Tx, b IBlock) error {
 	if err != nil {
 		return err
 	}
+	err = bd.reachability.removeBlock(dbTx, b.GetID())
+	if err != nil {
+		return err
+	}
 
-	for _, v := range b.GetParents().GetMap() {
-		block := v.(IBlock)
+	for k := range b.GetParents().GetMap() {
+		err := DBDelDAGBlockChild(dbTx, k, b.GetID())
+		if err != nil {
+			return err
+		}
+		block := bd.getBlockById(k)
+		if block == nil {
+			continue
+		}
 		block.RemoveChild(b.GetID())
 		if !block.HasChildren() {
 			bd.tips.AddPair(block.GetID(), block)
@@ -167,7 +209,7 @@ func (bd *BlockDAG) removeTip(dbTx database.Tx, b IBlock) error {
 		}
 	}
 
-	ph, ok := bd.instance.(*Phantom)
+	ph, ok := bd.getPhantom()
 	if !ok {
 		return fmt.Errorf("MeerDAG instance error")
 	}
@@ -1,6 +1,7 @@
 package blockdag
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/Qitmeer/qitmeer/common/hash"
 	"github.com/Qitmeer/qitmeer/core/dbnamespace"
@@ -91,3 +92,34 @@ func (bd *BlockDAG) UpgradeDB(dbTx database.Tx, mainTip *hash.Hash, total uint64
 	return nil
 }
 
+// Build the children index of all blocks, so that the block can be loaded without
+// its children in memory.
+func (bd *BlockDAG) buildChildrenIndex(dbTx database.Tx) error {
+	bucket := dbTx.Metadata().Bucket(dbnamespace.BlockIndexBucketName)
+	if bucket == nil {
+		return nil
+	}
+	count := 0
+	cursor := bucket.Cursor()
+	for cok := cursor.First(); cok; cok = cursor.Next() {
+		block := &Block{}
+		err := block.Decode(bytes.NewReader(cursor.Value()))
+		if err != nil {
+			return err
+		}
+		if !block.HasParents() {
+			continue
+		}
+		for k := range block.GetParents().GetMap() {
+			err := DBPutDAGBlockChild(dbTx, k, block.GetID())
+			if err != nil {
+				return err
+			}
+		}
+		count++
+	}
+	if count > 0 {
+		log.Info(fmt.Sprintf("Build MeerDAG children index🛠:%d blocks", count))
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+/*
+ * Copyright (c) 2017-2020 The qitmeer developers
+ */
+
+package blockdag
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/database"
+	"sort"
+)
+
+// The kinds of divergence between the persisted DAG and the rebuilt one
+const (
+	DivergenceBlock = "block"
+	DivergenceOrder = "order"
+	DivergenceTip   = "tip"
+)
+
+// DAGDivergence describes the first difference which is found by Verify.
+type DAGDivergence struct {
+	Kind      string `json:"kind"`
+	Hash      string `json:"hash,omitempty"`
+	ID        uint   `json:"id"`
+	Order     uint   `json:"order"`
+	Field     string `json:"field"`
+	Persisted string `json:"persisted"`
+	Rebuilt   string `json:"rebuilt"`
+}
+
+// DAGVerifyReport is the machine-readable result of Verify.
+type DAGVerifyReport struct {
+	DAGType    string         `json:"dagtype"`
+	BlockTotal uint           `json:"blocktotal"`
+	Rebuilt    uint           `json:"rebuilt"`
+	Skipped    uint           `json:"skipped"`
+	Consistent bool           `json:"consistent"`
+	Divergence *DAGDivergence `json:"divergence,omitempty"`
+}
+
+// Verify rebuilds the DAG from the stored blocks in a scratch instance which
+// uses scratchDB, then it diffs the blue sets, orders, layers, main chain and
+// tips against the persisted data. The scratchDB must be empty.
+// The discarded tips are skipped because they had been removed from database.
+func (bd *BlockDAG) Verify(scratchDB database.DB) (*DAGVerifyReport, error) {
+	bd.stateLock.Lock()
+	defer bd.stateLock.Unlock()
+
+	dagType := bd.instance.GetName()
+	scratch := &BlockDAG{}
+	if scratch.Init(dagType, bd.calcWeight, bd.blockRate, scratchDB, bd.getBlockData) == nil {
+		return nil, fmt.Errorf("Failed to initialize the scratch DAG")
+	}
+	scratch.tipsDisLimit = bd.tipsDisLimit
+
+	report := &DAGVerifyReport{DAGType: dagType, BlockTotal: bd.blockTotal}
+	for id := uint(0); id < bd.blockTotal; id++ {
+		ib := bd.getBlockById(id)
+		if ib == nil {
+			report.Skipped++
+			continue
+		}
+		data := ib.GetData()
+		if data == nil {
+			return nil, fmt.Errorf("No block data:%d(%s)", id, ib.GetHash())
+		}
+		_, _, _, _, err := scratch.AddBlock(data)
+		if err != nil {
+			report.Divergence = &DAGDivergence{Kind: DivergenceBlock, Hash: ib.GetHash().String(), ID: id,
+				Order: ib.GetOrder(), Field: "add", Persisted: "added", Rebuilt: err.Error()}
+			return report, nil
+		}
+		err = scratch.Commit()
+		if err != nil {
+			return nil, err
+		}
+		report.Rebuilt++
+		if report.Rebuilt%DefaultBlockCacheSize == 0 {
+			bd.shrinkCache()
+		}
+	}
+
+	err := bd.db.View(func(dbTx database.Tx) error {
+		var er error
+		report.Divergence, er = bd.diff(dbTx, scratch)
+		return er
+	})
+	if err != nil {
+		return nil, err
+	}
+	report.Consistent = report.Divergence == nil
+	return report, nil
+}
+
+// Load the block from database without the cache
+func (bd *BlockDAG) dbGetBlock(dbTx database.Tx, id uint) IBlock {
+	block := Block{id: id}
+	ib := bd.instance.CreateBlock(&block)
+	if DBGetDAGBlock(dbTx, ib) != nil {
+		return nil
+	}
+	return ib
+}
+
+func (bd *BlockDAG) diff(dbTx database.Tx, scratch *BlockDAG) (*DAGDivergence, error) {
+	hashStr := func(d *BlockDAG, id uint) string {
+		if id == MaxId {
+			return ""
+		}
+		ib := d.getBlockById(id)
+		if ib == nil {
+			return fmt.Sprintf("unknown(%d)", id)
+		}
+		return ib.GetHash().String()
+	}
+	// blocks
+	for id := uint(0); id < bd.blockTotal; id++ {
+		pib := bd.dbGetBlock(dbTx, id)
+		if pib == nil {
+			continue
+		}
+		dv := &DAGDivergence{Kind: DivergenceBlock, Hash: pib.GetHash().String(), ID: id, Order: pib.GetOrder()}
+		sib := scratch.getBlock(pib.GetHash())
+		if sib == nil {
+			dv.Field, dv.Persisted, dv.Rebuilt = "exist", "true", "false"
+			return dv, nil
+		}
+		if pib.GetOrder() != sib.GetOrder() {
+			dv.Field, dv.Persisted, dv.Rebuilt = "order", GetOrderLogStr(pib.GetOrder()), GetOrderLogStr(sib.GetOrder())
+			return dv, nil
+		}
+		if pib.GetLayer() != sib.GetLayer() {
+			dv.Field, dv.Persisted, dv.Rebuilt = "layer", fmt.Sprintf("%d", pib.GetLayer()), fmt.Sprintf("%d", sib.GetLayer())
+			return dv, nil
+		}
+		if pib.GetHeight() != sib.GetHeight() {
+			dv.Field, dv.Persisted, dv.Rebuilt = "height", fmt.Sprintf("%d", pib.GetHeight()), fmt.Sprintf("%d", sib.GetHeight())
+			return dv, nil
+		}
+		pmp := hashStr(bd, pib.GetMainParent())
+		smp := hashStr(scratch, sib.GetMainParent())
+		if pmp != smp {
+			dv.Field, dv.Persisted, dv.Rebuilt = "mainparent", pmp, smp
+			return dv, nil
+		}
+		pBlue := bd.instance.IsBlue(id)
+		sBlue := scratch.instance.IsBlue(sib.GetID())
+		if pBlue != sBlue {
+			dv.Field, dv.Persisted, dv.Rebuilt = "blue", fmt.Sprintf("%v", pBlue), fmt.Sprintf("%v", sBlue)
+			return dv, nil
+		}
+		pMain := DBHasMainChainBlock(dbTx, id)
+		sMain := scratch.isOnMainChain(sib.GetID())
+		if pMain != sMain {
+			dv.Field, dv.Persisted, dv.Rebuilt = "mainchain", fmt.Sprintf("%v", pMain), fmt.Sprintf("%v", sMain)
+			return dv, nil
+		}
+	}
+	// orders
+	maxOrder := bd.getMainChainTip().GetOrder()
+	if scratch.getMainChainTip().GetOrder() > maxOrder {
+		maxOrder = scratch.getMainChainTip().GetOrder()
+	}
+	for order := uint(0); order <= maxOrder; order++ {
+		pHash := ""
+		pid := MaxId
+		id, err := DBGetBlockIdByOrder(dbTx, order)
+		if err == nil {
+			pid = uint(id)
+			pHash = hashStr(bd, pid)
+		}
+		sHash := ""
+		sib := scratch.getBlockByOrder(order)
+		if sib != nil {
+			sHash = sib.GetHash().String()
+		}
+		if pHash != sHash {
+			return &DAGDivergence{Kind: DivergenceOrder, Hash: pHash, ID: pid, Order: order,
+				Field: "hash", Persisted: pHash, Rebuilt: sHash}, nil
+		}
+	}
+	// tips
+	tips, err := DBGetDAGTips(dbTx)
+	if err != nil {
+		return nil, err
+	}
+	pTips := map[string]uint{}
+	for _, id := range tips {
+		pTips[hashStr(bd, id)] = id
+	}
+	sTips := map[string]uint{}
+	for id := range scratch.tips.GetMap() {
+		sTips[hashStr(scratch, id)] = id
+	}
+	for _, h := range sortedKeys(pTips) {
+		if _, ok := sTips[h]; !ok {
+			return &DAGDivergence{Kind: DivergenceTip, Hash: h, ID: pTips[h], Order: MaxBlockOrder,
+				Field: "tip", Persisted: "true", Rebuilt: "false"}, nil
+		}
+	}
+	for _, h := range sortedKeys(sTips) {
+		if _, ok := pTips[h]; !ok {
+			return &DAGDivergence{Kind: DivergenceTip, Hash: h, ID: MaxId, Order: MaxBlockOrder,
+				Field: "tip", Persisted: "false", Rebuilt: "true"}, nil
+		}
+	}
+	return nil, nil
+}
+
+func sortedKeys(m map[string]uint) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,62 @@
+package blockdag
+
+import (
+	"github.com/Qitmeer/qitmeer/config"
+	"github.com/Qitmeer/qitmeer/database"
+	"os"
+	"testing"
+)
+
+func Test_Verify(t *testing.T) {
+	ibd := InitBlockDAG(phantom, "PH_fig2-blocks")
+	if ibd == nil {
+		t.FailNow()
+	}
+	verify := func() *DAGVerifyReport {
+		dataDir := "./verify"
+		err := os.MkdirAll(dataDir, 0700)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scratchDB, err := loadBlockDB(&config.Config{DbType: "ffldb", DataDir: dataDir})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			scratchDB.Close()
+			os.RemoveAll(dataDir)
+		}()
+		report, err := bd.Verify(scratchDB)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return report
+	}
+
+	report := verify()
+	if !report.Consistent || report.Rebuilt != bd.GetBlockTotal() {
+		t.Fatalf("The DAG should be consistent:%v", report.Divergence)
+	}
+
+	// Corrupt the order index
+	first := bd.GetBlockByOrder(1)
+	second := bd.GetBlockByOrder(2)
+	err := bd.db.Update(func(dbTx database.Tx) error {
+		err := DBPutBlockIdByOrder(dbTx, 1, second.GetID())
+		if err != nil {
+			return err
+		}
+		return DBPutBlockIdByOrder(dbTx, 2, first.GetID())
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	report = verify()
+	if report.Consistent || report.Divergence == nil {
+		t.Fatalf("The divergence should be found")
+	}
+	dv := report.Divergence
+	if dv.Kind != DivergenceOrder || dv.Order != 1 || dv.Rebuilt != first.GetHash().String() {
+		t.Fatalf("The first divergence is wrong:%v", dv)
+	}
+}
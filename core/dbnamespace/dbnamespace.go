@@ -81,4 +81,41 @@ var (
 	// DAGTipsBucketName is the name of the db bucket used to house to
 	// the block id -> is main chain
 	DAGTipsBucketName = []byte("dagtips")
+
+	// DAGChildrenBucketName is the name of the db bucket used to house to
+	// the block id + child id -> nil
+	DAGChildrenBucketName = []byte("dagchildren")
+
+	// ReachabilityBucketName is the name of the db bucket used to house to
+	// the block id -> reachability tree interval and future covering set
+	ReachabilityBucketName = []byte("reachability")
+
+	// ReorgHistoryBucketName is the name of the db bucket used to house to
+	// the reorganization id -> reorganization record
+	ReorgHistoryBucketName = []byte("reorghistory")
+
+	// UtxoSnapshotKeyName is the name of the db key used to store the
+	// state of the loaded utxo set snapshot.
+	UtxoSnapshotKeyName = []byte("utxosnapshot")
+
+	// UtxoCommitmentKeyName is the name of the db key used to store the
+	// rolling hash of the current utxo set.
+	UtxoCommitmentKeyName = []byte("utxocommitment")
+
+	// UtxoCommitmentBucketName is the name of the db bucket used to house to
+	// the block hash -> utxo set commitment after the block is connected
+	UtxoCommitmentBucketName = []byte("utxocommitments")
+
+	// PrunedBlockBucketName is the name of the db bucket used to house to
+	// the block hash -> block without transactions after it was pruned
+	PrunedBlockBucketName = []byte("prunedblock")
+
+	// UtxoDiffBucketName is the name of the db bucket used to house to
+	// the diff id -> utxo entries changed by a block which are not flushed
+	// from the utxo cache
+	UtxoDiffBucketName = []byte("utxodiff")
+
+	// TicketBucketName is the name of the db bucket used to house to
+	// the outpoint key of the tickets in the utxo set
+	TicketBucketName = []byte("tickets")
 )
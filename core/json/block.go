@@ -61,6 +61,109 @@ type BlockResult struct {
 	Children      []string  `json:"children"`
 }
 
+// FinalityPointResult models the data from the getFinalityPoint command.
+type FinalityPointResult struct {
+	Hash  string `json:"hash"`
+	Order uint64 `json:"order"`
+	Depth uint64 `json:"depth"`
+}
+
+// ReorgRecordResult models the data from the getReorgHistory command.
+type ReorgRecordResult struct {
+	ID             uint64   `json:"id"`
+	Time           string   `json:"time"`
+	NewBlock       string   `json:"newblock"`
+	OldMainTip     string   `json:"oldmaintip"`
+	NewMainTip     string   `json:"newmaintip"`
+	DetachedOrders []uint64 `json:"detachedorders"`
+	AttachedOrders []uint64 `json:"attachedorders"`
+	DetachedTxs    uint64   `json:"detachedtxs"`
+	AttachedTxs    uint64   `json:"attachedtxs"`
+}
+
+// UtxoCommitmentResult models the data from the getUtxoCommitment command.
+type UtxoCommitmentResult struct {
+	Hash       string `json:"hash"`
+	Order      uint64 `json:"order"`
+	Commitment string `json:"commitment"`
+}
+
+// CoinSupplyResult models the supply of a coin in the getTxOutSetInfo command.
+type CoinSupplyResult struct {
+	CoinId   uint16 `json:"coinid"`
+	CoinName string `json:"coinname"`
+	Amount   int64  `json:"amount"`
+	Expected int64  `json:"expected"`
+}
+
+// TxOutSetInfoResult models the data from the getTxOutSetInfo command.
+type TxOutSetInfoResult struct {
+	Hash           string             `json:"hash"`
+	Order          uint64             `json:"order"`
+	Utxos          uint64             `json:"utxos"`
+	SerializedSize uint64             `json:"serializedsize"`
+	UtxoSetHash    string             `json:"utxosethash"`
+	Commitment     string             `json:"commitment,omitempty"`
+	Supply         []CoinSupplyResult `json:"supply"`
+	Valid          bool               `json:"valid"`
+}
+
+// PowSignallingResult models the signalling blocks of a pow type in the
+// getDeploymentInfo command.
+type PowSignallingResult struct {
+	PowName string `json:"powname"`
+	Blocks  uint32 `json:"blocks"`
+	Count   uint32 `json:"count"`
+}
+
+// DeploymentInfoResult models the data from the getDeploymentInfo command.
+type DeploymentInfoResult struct {
+	Id               uint32                `json:"id"`
+	Name             string                `json:"name"`
+	Bit              uint8                 `json:"bit"`
+	Status           string                `json:"status"`
+	StartTime        uint64                `json:"starttime"`
+	ExpireTime       uint64                `json:"expiretime"`
+	PerformTime      uint64                `json:"performtime,omitempty"`
+	TimeMode         bool                  `json:"timemode"`
+	Window           uint32                `json:"window"`
+	Threshold        uint32                `json:"threshold"`
+	Elapsed          uint32                `json:"elapsed"`
+	Count            uint32                `json:"count"`
+	PowCounts        []PowSignallingResult `json:"powcounts"`
+	ActivationHeight uint64                `json:"activationheight,omitempty"`
+	ActivationOrder  uint64                `json:"activationorder,omitempty"`
+}
+
+// StakeResult models a ticket in the getStakes command.
+type StakeResult struct {
+	TxId      string `json:"txid"`
+	Vout      uint32 `json:"vout"`
+	Amount    int64  `json:"amount"`
+	Address   string `json:"address"`
+	BlockHash string `json:"blockhash"`
+	Mature    bool   `json:"mature"`
+}
+
+// LockedBalanceResult models the data from the getLockedBalance command.
+type LockedBalanceResult struct {
+	MainHeight uint64              `json:"mainheight"`
+	Locked     int64               `json:"locked"`
+	Releasable int64               `json:"releasable"`
+	Schedule   []GenesisLockResult `json:"schedule"`
+}
+
+// GenesisLockResult models a locked genesis output in the getLockedBalance
+// command.
+type GenesisLockResult struct {
+	TxId          string `json:"txid"`
+	Vout          uint32 `json:"vout"`
+	Amount        int64  `json:"amount"`
+	Address       string `json:"address"`
+	ReleaseHeight int64  `json:"releaseheight"`
+	Released      bool   `json:"released"`
+}
+
 // GetBlockHeaderVerboseResult models the data from the getblockheader command when
 // the verbose flag is set.  When the verbose flag is not set, getblockheader
 // returns a hex-encoded string.
@@ -85,4 +188,15 @@ type TokenState struct {
 	Enable     bool   `json:"enable,omitempty"`
 	Balance    int64  `json:"balance,omitempty"`
 	LockedMeer int64  `json:"lockedMEER,omitempty"`
+	Revoked    bool   `json:"revoked,omitempty"`
+
+	Metadata *TokenMetadata `json:"metadata,omitempty"`
+}
+
+// TokenMetadata models the extended metadata of token.
+type TokenMetadata struct {
+	Decimals    uint8  `json:"decimals"`
+	LongName    string `json:"longname,omitempty"`
+	Description string `json:"description,omitempty"`
+	Issuer      string `json:"issuer,omitempty"`
 }
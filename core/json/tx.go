@@ -140,6 +140,32 @@ type GetRawTransactionsResult struct {
 	Blocktime     int64        `json:"blocktime,omitempty"`
 }
 
+// TxConfirmationRiskResult models the data from the getTxConfirmationRisk
+// command.
+type TxConfirmationRiskResult struct {
+	TxId          string  `json:"txid"`
+	BlockHash     string  `json:"blockhash,omitempty"`
+	Confirmations uint64  `json:"confirmations"`
+	AntiPast      int     `json:"antipast"`
+	Risk          float64 `json:"risk"`
+}
+
+// TxInclusionResult models a block which includes the transaction.
+type TxInclusionResult struct {
+	BlockHash     string `json:"blockhash"`
+	Order         uint64 `json:"order"`
+	Height        uint64 `json:"height"`
+	Confirmations uint64 `json:"confirmations"`
+	Effective     bool   `json:"effective"`
+	Reason        string `json:"reason,omitempty"`
+}
+
+// TxInclusionsResult models the data from the getTxInclusions command.
+type TxInclusionsResult struct {
+	TxId       string              `json:"txid"`
+	Inclusions []TxInclusionResult `json:"inclusions"`
+}
+
 type VinPrevOut struct {
 	Coinbase  string     `json:"coinbase"`
 	Txid      string     `json:"txid"`
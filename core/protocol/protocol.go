@@ -16,7 +16,11 @@ const (
 	InitialProcotolVersion uint32 = 36
 
 	// ProtocolVersion is the latest protocol version this package supports.
-	ProtocolVersion uint32 = 36
+	ProtocolVersion uint32 = 37
+
+	// HeadersVersion is the protocol version which added the getheaders
+	// request, the headers of blocks are checked before downloading them.
+	HeadersVersion uint32 = 37
 )
 
 // Network represents which qitmeer network a message belongs to.
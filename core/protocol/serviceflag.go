@@ -13,6 +13,7 @@ var sfStrings = map[ServiceFlag]string{
 	Light:    "Light",
 	Relay:    "Relay",
 	Observer: "Observer",
+	Prune:    "Prune",
 	Unknown:  "Unknown",
 }
 
@@ -25,6 +26,7 @@ var orderedSFStrings = []ServiceFlag{
 	Light,
 	Relay,
 	Observer,
+	Prune,
 	Unknown,
 }
 
@@ -52,6 +54,9 @@ const (
 
 	// None
 	Unknown
+
+	// a peer is pruned, it can't serve the old blocks.
+	Prune
 )
 
 // String returns the ServiceFlag in human-readable form.
@@ -108,6 +108,12 @@ const (
 	// TokenInSequence is the maximum tx type the sequence field
 	// of a transaction input can be.
 	TxTypeInSequence uint32 = 0x400
+
+	// TxTypeSequenceTag is set in the sequence of the first input of the
+	// transactions which are tagged by their sequences, the transaction
+	// type is in the low bits. It has the SequenceLockTimeDisabled flag,
+	// so the tag is never a relative lock time.
+	TxTypeSequenceTag uint32 = SequenceLockTimeDisabled | 0x7f000000
 )
 
 // TxIndexUnknown is the value returned for a transaction index that is unknown.
@@ -28,7 +28,7 @@ const (
 	TxTypeTokenRenew      TxType = 0x82 // update owners, up-limits etc. can't change coin-id. renew works only when the token is disabled.
 	TxTypeTokenValidate   TxType = 0x83 // enable the token.
 	TxTypeTokenInvalidate TxType = 0x84 // disable the token.
-	TxTypeTokenRevoke     TxType = 0x8f // revoke the coin-id. token-revoke is reserved, not used at current stage.
+	TxTypeTokenRevoke     TxType = 0x8f // revoke the coin-id permanently, the locked MEER is released. (must have no token amount)
 
 	TxTypeTokenbase   TxType = 0x90 // token-base is reserved, not used at current stage.
 	TxTypeTokenMint   TxType = 0x91 // token owner mint token amount by locking MEER. (must validated token)
@@ -81,6 +81,15 @@ func DetermineTxType(tx *Transaction) TxType {
 	if IsGenesisLockTx(tx) {
 		return TxTypeGenesisLock
 	}
+	if IsStakePurchaseTx(tx) {
+		return TxTypeStakePurchase
+	}
+	if IsStakebaseTx(tx) {
+		return TxTypeStakebase
+	}
+	if IsStakeDisposeTx(tx) {
+		return TxTypeStakeDispose
+	}
 	if IsTokenNewTx(tx) {
 		return TxTypeTokenNew
 	}
@@ -93,6 +102,9 @@ func DetermineTxType(tx *Transaction) TxType {
 	if IsTokenInvalidateTx(tx) {
 		return TxTypeTokenInvalidate
 	}
+	if IsTokenRevokeTx(tx) {
+		return TxTypeTokenRevoke
+	}
 	if IsTokenMintTx(tx) {
 		return TxTypeTokenMint
 	}
@@ -125,37 +137,63 @@ func IsCoinBaseTx(tx *Transaction) bool {
 	return prevOut.OutIndex == math.MaxUint32
 }
 
+// TaggedSequence returns the sequence of the first input which tags the
+// transaction as the type tt.
+func TaggedSequence(tt TxType) uint32 {
+	return TxTypeSequenceTag | uint32(tt)
+}
+
+// isSequenceTaggedTx returns whether the first input of the transaction spends
+// a regular output and its sequence is tagged as the transaction type.
+func isSequenceTaggedTx(tx *Transaction, tt TxType) bool {
+	if len(tx.TxIn) < 1 || len(tx.TxOut) < 1 {
+		return false
+	}
+	outIndex := tx.TxIn[0].PreviousOut.OutIndex
+	if outIndex == TokenPrevOutIndex || outIndex == MaxPrevOutIndex {
+		return false
+	}
+	return tx.TxIn[0].Sequence == TaggedSequence(tt)
+}
+
 // --------------------------------------------------------------------------------
 // The Genesis_XXX transactions
 //
 //    the transactions related to the genesis UTXOs
 //
-//  - genesis_lock   how lock the genesis output to the stake pool
+//  - genesis_lock   release the locked genesis outputs by the schedule
 // --------------------------------------------------------------------------------
 
 // CheckGenesisLock returns an error if a transaction is not a genesis_lock transaction.
-// It makes sure the number of inputs/outputs, and related scripts are valid.
+// It makes sure the number of inputs/outputs and the tag are valid, the locked
+// genesis outputs it spends are checked by the blockchain.
 //
 // genesis_lock transactions are specified as below:
 //
 // 1.) Inputs:
-// genesis output 1 [index 0]
-// genesis output 2 [index 1]
+// locked genesis output 1 [index 0], its sequence is TaggedSequence(TxTypeGenesisLock)
+// locked genesis output 2 [index 1]
 // ...
 //
 // 2.) Outputs:
-// OP_STAKE_LOCK tagged output [index 0]
-// OP_RETURN push of input 1's stake reward receiving address [index 1]
-// OP_STAKE_LOCK tagged output [index 2]
-// OP_RETURN push of input 2's stake reward receiving address [index 3]
+// the released MEER outputs [index 0]
 // ...
-// Note: The output OP_RETURN pushes should be of size 20 bytes (standard address).
 //
-// 3.) NO changes are allowed for the tx && NO fee are allowed for this tx.
-// inputs amount == output amount
+// 3.) The lock time is a DAG main height, which must be no less than the
+// release heights of all inputs. (<release height> OP_MEER_LOCK of the
+// genesis output script)
 //
 func CheckGenesisLock(tx *Transaction) error {
-	return fmt.Errorf("CheckGenesisLock is not supported yet")
+	if !isSequenceTaggedTx(tx, TxTypeGenesisLock) {
+		return fmt.Errorf("The first input is not tagged as genesis lock")
+	}
+	for i, txOut := range tx.TxOut {
+		if txOut.Amount.Id != MEERID {
+			return fmt.Errorf("The output %d of genesis lock %s must be %s, but it is %s",
+				i, tx.TxHash(), MEERID.Name(), txOut.Amount.Id.Name())
+		}
+	}
+	return nil
 }
 
 // IsGenesisLockTx returns whether or not a transaction is a genesis_lock transaction.
@@ -165,8 +203,45 @@ func IsGenesisLockTx(tx *Transaction) bool {
 
 // --------------------------------------------------------------------------------
 // Stake_XXX Transaction
+//
+//    the transactions tagged by the sequence of the first input, the details
+//    of them are checked by the stake package.
+//
+//  - stake_purchase   lock value into the stake pool as a ticket
+//  - stakebase        reward the ticket holder from the stake_reserve outputs
+//  - stake_dispose    release the value of a mature ticket
 // --------------------------------------------------------------------------------
 
+// IsStakePurchaseTx returns whether the transaction buys a ticket, the first
+// output is the ticket and the others are the changes.
+func IsStakePurchaseTx(tx *Transaction) bool {
+	return isSequenceTaggedTx(tx, TxTypeStakePurchase)
+}
+
+// IsStakebaseTx returns whether the transaction spends a ticket and a
+// stake_reserve output to reward the ticket holder.
+func IsStakebaseTx(tx *Transaction) bool {
+	if len(tx.TxIn) != 2 || len(tx.TxOut) != 1 {
+		return false
+	}
+	return isSequenceTaggedTx(tx, TxTypeStakebase)
+}
+
+// IsStakeDisposeTx returns whether the transaction spends a ticket to release
+// its value.
+func IsStakeDisposeTx(tx *Transaction) bool {
+	if len(tx.TxIn) != 1 || len(tx.TxOut) != 1 {
+		return false
+	}
+	return isSequenceTaggedTx(tx, TxTypeStakeDispose)
+}
+
+func IsStakeTx(tx *Transaction) bool {
+	return IsStakePurchaseTx(tx) ||
+		IsStakebaseTx(tx) ||
+		IsStakeDisposeTx(tx)
+}
+
 // --------------------------------------------------------------------------------
 // Token_XXX Transaction
 //
@@ -185,8 +260,11 @@ func IsGenesisLockTx(tx *Transaction) bool {
 //   5. token operator do token_mint, the consensus-based token amount assessable. (on chain)
 // --------------------------------------------------------------------------------
 
+// IsTokenNewTx returns whether the transaction creates a token. The first
+// output is the token script, and the optional second output carries the
+// token metadata.
 func IsTokenNewTx(tx *Transaction) bool {
-	if len(tx.TxOut) != 1 || len(tx.TxIn) != 1 {
+	if len(tx.TxOut) < 1 || len(tx.TxOut) > 2 || len(tx.TxIn) != 1 {
 		return false
 	}
 	if tx.TxIn[0].PreviousOut.OutIndex != TokenPrevOutIndex {
@@ -195,8 +273,10 @@ func IsTokenNewTx(tx *Transaction) bool {
 	return TxType(tx.TxIn[0].Sequence) == TxTypeTokenNew
 }
 
+// IsTokenRenewTx returns whether the transaction renews a token, the outputs
+// are the same as token new. It is signed by the current token owners.
 func IsTokenRenewTx(tx *Transaction) bool {
-	if len(tx.TxOut) != 1 || len(tx.TxIn) != 1 {
+	if len(tx.TxOut) < 1 || len(tx.TxOut) > 2 || len(tx.TxIn) != 1 {
 		return false
 	}
 	if tx.TxIn[0].PreviousOut.OutIndex != TokenPrevOutIndex {
@@ -225,6 +305,19 @@ func IsTokenInvalidateTx(tx *Transaction) bool {
 	return TxType(tx.TxIn[0].Sequence) == TxTypeTokenInvalidate
 }
 
+// IsTokenRevokeTx returns whether the transaction revokes a token. The first
+// output is the token script of the coin id, and the optional second output
+// releases the locked MEER.
+func IsTokenRevokeTx(tx *Transaction) bool {
+	if len(tx.TxOut) < 1 || len(tx.TxOut) > 2 || len(tx.TxIn) != 1 {
+		return false
+	}
+	if tx.TxIn[0].PreviousOut.OutIndex != TokenPrevOutIndex {
+		return false
+	}
+	return TxType(tx.TxIn[0].Sequence) == TxTypeTokenRevoke
+}
+
 func IsTokenMintTx(tx *Transaction) bool {
 	if len(tx.TxOut) < 1 || len(tx.TxIn) <= 1 {
 		return false
@@ -250,6 +343,7 @@ func IsTokenTx(tx *Transaction) bool {
 		IsTokenRenewTx(tx) ||
 		IsTokenValidateTx(tx) ||
 		IsTokenInvalidateTx(tx) ||
+		IsTokenRevokeTx(tx) ||
 		IsTokenMintTx(tx) ||
 		IsTokenUnmintTx(tx)
 }
@@ -263,3 +357,20 @@ var NonStdTxs = []TxType{
 	TxTypeTokenValidate,
 	TxTypeTokenMint,
 }
+
+// Token transaction type after DeploymentTokenV2
+var TokenV2Txs = []TxType{
+	TxTypeTokenRevoke,
+}
+
+// Genesis transaction type after DeploymentGenesisLock
+var GenesisLockTxs = []TxType{
+	TxTypeGenesisLock,
+}
+
+// Stake transaction type
+var StakeTxs = []TxType{
+	TxTypeStakePurchase,
+	TxTypeStakebase,
+	TxTypeStakeDispose,
+}
@@ -311,6 +311,29 @@ func (s *blockStore) deleteFile(fileNum uint32) error {
 	return nil
 }
 
+// removeFile closes the block file for the passed flat file number if it's
+// open, and then deletes it.  It must not be the current write file.
+func (s *blockStore) removeFile(fileNum uint32) error {
+	s.obfMutex.Lock()
+	if blockFile, ok := s.openBlockFiles[fileNum]; ok {
+		s.lruMutex.Lock()
+		s.openBlocksLRU.Remove(s.fileNumToLRUElem[fileNum])
+		delete(s.fileNumToLRUElem, fileNum)
+		s.lruMutex.Unlock()
+
+		// Close the file under the write lock for the file in case
+		// any readers are currently reading from it.
+		blockFile.Lock()
+		_ = blockFile.file.Close()
+		blockFile.Unlock()
+
+		delete(s.openBlockFiles, fileNum)
+	}
+	s.obfMutex.Unlock()
+
+	return s.deleteFileFunc(fileNum)
+}
+
 // blockFile attempts to return an existing file handle for the passed flat file
 // number if it is already open as well as marking it as most recently used.  It
 // will also open the file when it's not already open subject to the rules
@@ -720,16 +743,24 @@ func (s *blockStore) handleRollback(oldBlockFileNum, oldBlockOffset uint32) {
 // current write cursor which is also stored in the metadata.  Thus, it is used
 // to detect unexpected shutdowns in the middle of writes so the block files
 // can be reconciled.
+//
+// The oldest files may be deleted by pruning, so all the files are scanned
+// rather than counting up from the first one.
 func scanBlockFiles(dbPath string) (int, uint32) {
 	lastFile := -1
 	fileLen := uint32(0)
-	for i := 0; ; i++ {
-		filePath := blockFilePath(dbPath, uint32(i))
+	filePaths, _ := filepath.Glob(filepath.Join(dbPath, "*.fdb"))
+	for _, filePath := range filePaths {
+		var fileNum uint32
+		_, err := fmt.Sscanf(filepath.Base(filePath), blockFilenameTemplate, &fileNum)
+		if err != nil || int(fileNum) <= lastFile {
+			continue
+		}
 		st, err := os.Stat(filePath)
 		if err != nil {
-			break
+			continue
 		}
-		lastFile = i
+		lastFile = int(fileNum)
 
 		fileLen = uint32(st.Size())
 	}
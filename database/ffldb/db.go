@@ -76,6 +76,10 @@ var (
 	// writeLocKeyName is the key used to store the current write file
 	// location.
 	writeLocKeyName = []byte("ffldb-writeloc")
+
+	// prunedKeyName is the key used to mark the block files were ever
+	// pruned.
+	prunedKeyName = []byte("ffldb-pruned")
 )
 
 // Common error strings.
@@ -968,6 +972,9 @@ type transaction struct {
 	pendingBlocks    map[hash.Hash]int
 	pendingBlockData []pendingBlock
 
+	// Block files that need to be deleted on commit.
+	pendingPruneFiles []uint32
+
 	// Keys that need to be stored or deleted on commit.
 	pendingKeys   *treap.Mutable
 	pendingRemove *treap.Mutable
@@ -1629,6 +1636,126 @@ func (tx *transaction) FetchBlockRegions(regions []database.BlockRegion) ([][]by
 	return blockRegions, nil
 }
 
+// PruneBlocks deletes the oldest block files until the total size of block
+// files is not more than targetSize.  A file is only deleted when canPrune
+// returns true for all its blocks, and the current write file is never
+// deleted.  The block index entries are removed in the transaction, the files
+// are deleted after it is committed.
+//
+// Returns the following errors as required by the interface contract:
+//   - ErrTxNotWritable if attempted against a read-only transaction
+//   - ErrTxClosed if the transaction has already been closed
+//
+// This function is part of the database.Tx interface implementation.
+func (tx *transaction) PruneBlocks(targetSize uint64, canPrune func(hash *hash.Hash) bool,
+	onPrune func(hash *hash.Hash, block []byte) error) (int, error) {
+	// Ensure transaction state is valid.
+	if err := tx.checkClosed(); err != nil {
+		return 0, err
+	}
+
+	// Ensure the transaction is writable.
+	if !tx.writable {
+		str := "prune blocks requires a writable database transaction"
+		return 0, makeDbErr(database.ErrTxNotWritable, str, nil)
+	}
+
+	store := tx.db.store
+	wc := store.writeCursor
+	wc.RLock()
+	curFileNum := wc.curFileNum
+	totalSize := uint64(wc.curOffset)
+	wc.RUnlock()
+
+	fileSizes := make(map[uint32]uint64)
+	for fileNum := uint32(0); fileNum < curFileNum; fileNum++ {
+		st, err := os.Stat(blockFilePath(store.basePath, fileNum))
+		if err != nil {
+			// It was already pruned.
+			continue
+		}
+		fileSizes[fileNum] = uint64(st.Size())
+		totalSize += uint64(st.Size())
+	}
+	if totalSize <= targetSize {
+		return 0, nil
+	}
+
+	// Group all the blocks by the file which houses them.
+	fileBlocks := make(map[uint32][]hash.Hash)
+	cursor := tx.blockIdxBucket.Cursor()
+	for ok := cursor.First(); ok; ok = cursor.Next() {
+		loc := deserializeBlockLoc(cursor.Value())
+		if loc.blockFileNum >= curFileNum {
+			continue
+		}
+		var h hash.Hash
+		copy(h[:], cursor.Key())
+		fileBlocks[loc.blockFileNum] = append(fileBlocks[loc.blockFileNum], h)
+	}
+
+	pruned := 0
+	for fileNum := uint32(0); fileNum < curFileNum && totalSize > targetSize; fileNum++ {
+		size, ok := fileSizes[fileNum]
+		if !ok {
+			continue
+		}
+		// The newer files are not checked once a file can't be
+		// pruned, since the blocks are stored in order.
+		blocks := fileBlocks[fileNum]
+		prunable := true
+		for i := range blocks {
+			if !canPrune(&blocks[i]) {
+				prunable = false
+				break
+			}
+		}
+		if !prunable {
+			break
+		}
+		for i := range blocks {
+			blockBytes, err := tx.FetchBlock(&blocks[i])
+			if err != nil {
+				return pruned, err
+			}
+			err = onPrune(&blocks[i], blockBytes)
+			if err != nil {
+				return pruned, err
+			}
+			err = tx.blockIdxBucket.Delete(blocks[i][:])
+			if err != nil {
+				return pruned, err
+			}
+		}
+		tx.pendingPruneFiles = append(tx.pendingPruneFiles, fileNum)
+		totalSize -= size
+		pruned += len(blocks)
+	}
+	if len(tx.pendingPruneFiles) > 0 {
+		err := tx.metaBucket.Put(prunedKeyName, []byte{1})
+		if err != nil {
+			return pruned, err
+		}
+	}
+	return pruned, nil
+}
+
+// BeenPruned returns whether or not any block file was ever deleted by
+// PruneBlocks.
+//
+// Returns the following errors as required by the interface contract:
+//   - ErrTxClosed if the transaction has already been closed
+//
+// This function is part of the database.Tx interface implementation.
+func (tx *transaction) BeenPruned() (bool, error) {
+	// Ensure transaction state is valid.
+	if err := tx.checkClosed(); err != nil {
+		return false, err
+	}
+
+	return tx.metaBucket.Get(prunedKeyName) != nil, nil
+}
+
 // close marks the transaction closed then releases any pending data, the
 // underlying snapshot, the transaction read lock, and the write lock when the
 // transaction is writable.
@@ -1638,6 +1765,7 @@ func (tx *transaction) close() {
 	// Clear pending blocks that would have been written on commit.
 	tx.pendingBlocks = nil
 	tx.pendingBlockData = nil
+	tx.pendingPruneFiles = nil
 
 	// Clear pending keys that would have been written or deleted on commit.
 	tx.pendingKeys = nil
@@ -1726,7 +1854,19 @@ func (tx *transaction) writePendingAndCommit() error {
 
 	// Atomically update the database cache.  The cache automatically
 	// handles flushing to the underlying persistent storage database.
-	return tx.db.cache.commitTx(tx)
+	if err := tx.db.cache.commitTx(tx); err != nil {
+		return err
+	}
+
+	// Delete the pruned block files now that nothing refers to them.  The
+	// failure is only logged, the file is deleted by the next pruning
+	// since it has no blocks any more.
+	for _, fileNum := range tx.pendingPruneFiles {
+		if err := tx.db.store.removeFile(fileNum); err != nil {
+			dblog.Warn("Failed to delete pruned block file", "fileNum", fileNum, "error", err)
+		}
+	}
+	return nil
 }
 
 // Commit commits all changes that have been made to the root metadata bucket
@@ -0,0 +1,244 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+
+package ffldb
+
+import (
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/protocol"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/core/types/pow"
+	"github.com/Qitmeer/qitmeer/database"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+// testPruneBlock returns a small block which is distinct by i.
+func testPruneBlock(i int) *types.SerializedBlock {
+	tx := types.NewTransaction()
+	tx.AddTxIn(types.NewTxInput(types.NewOutPoint(&hash.Hash{}, types.MaxPrevOutIndex), []byte{byte(i), byte(i >> 8)}))
+	tx.AddTxOut(types.NewTxOutput(types.Amount{Value: int64(i), Id: types.MEERID}, []byte{0x51}))
+	block := &types.Block{
+		Header: types.BlockHeader{
+			Timestamp: time.Unix(int64(1577836800+i), 0),
+			Pow:       pow.GetInstance(pow.BLAKE2BD, 0, []byte{}),
+		},
+	}
+	block.AddTransaction(tx)
+	return types.NewBlock(block)
+}
+
+// TestPruneBlocks ensures the oldest block files are deleted until the target
+// size is reached, the files which have any block that can't be pruned and the
+// current write file are kept, and the pruned state is kept after restart.
+func TestPruneBlocks(t *testing.T) {
+	dbPath, err := ioutil.TempDir("", "test_ffldb_prune")
+	if err != nil {
+		t.Fatalf("failed to create prune db : %v", err)
+	}
+	defer os.RemoveAll(dbPath)
+
+	network := protocol.PrivNet
+	pdb, err := openDB(dbPath, network, true)
+	if err != nil {
+		t.Fatalf("failed to create prune db : %v", err)
+	}
+	defer func() {
+		pdb.Close()
+	}()
+
+	// Every block file houses a few blocks.
+	blockSize := uint32(testPruneBlock(0).Block().SerializeSize())
+	store := pdb.(*db).store
+	store.maxBlockFileSize = (blockSize + 12) * 3
+	blocks := make([]*types.SerializedBlock, 0, 20)
+	for i := 0; i < 20; i++ {
+		blocks = append(blocks, testPruneBlock(i))
+	}
+	err = pdb.Update(func(dbTx database.Tx) error {
+		for _, block := range blocks {
+			err := dbTx.StoreBlock(block)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	curFileNum := store.writeCursor.curFileNum
+	if curFileNum < 5 {
+		t.Fatalf("the blocks are in %d files, the test needs more", curFileNum+1)
+	}
+	fileSize := func(fileNum uint32) uint64 {
+		st, err := os.Stat(blockFilePath(store.basePath, fileNum))
+		if err != nil {
+			return 0
+		}
+		return uint64(st.Size())
+	}
+	var totalSize uint64
+	for fileNum := uint32(0); fileNum <= curFileNum; fileNum++ {
+		totalSize += fileSize(fileNum)
+	}
+
+	// Pruning needs a writable transaction.
+	err = pdb.View(func(dbTx database.Tx) error {
+		_, err := dbTx.PruneBlocks(0, nil, nil)
+		return err
+	})
+	if !database.IsError(err, database.ErrTxNotWritable) {
+		t.Fatalf("expected ErrTxNotWritable, got %v", err)
+	}
+
+	// Nothing is deleted if the block files are under the target size.
+	prune := func(targetSize uint64, keep *hash.Hash) ([]*hash.Hash, int) {
+		var pruned []*hash.Hash
+		var n int
+		err := pdb.Update(func(dbTx database.Tx) error {
+			var err error
+			n, err = dbTx.PruneBlocks(targetSize, func(h *hash.Hash) bool {
+				return keep == nil || !h.IsEqual(keep)
+			}, func(h *hash.Hash, block []byte) error {
+				if len(block) == 0 {
+					t.Errorf("no block data of %s", h)
+				}
+				pruned = append(pruned, h)
+				return nil
+			})
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != len(pruned) {
+			t.Fatalf("%d blocks were pruned, but %d blocks were passed to onPrune", n, len(pruned))
+		}
+		return pruned, n
+	}
+	_, n := prune(totalSize, nil)
+	if n != 0 {
+		t.Fatalf("%d blocks were pruned under the target size", n)
+	}
+
+	// The pruning stops at the file which has the block can't be pruned.
+	first := blocks[0].Hash()
+	keep := blocks[len(blocks)/2].Hash()
+	pruned, _ := prune(0, keep)
+	if len(pruned) == 0 || !pruned[0].IsEqual(first) {
+		t.Fatalf("the oldest block %s was not pruned first", first)
+	}
+	if fileSize(0) != 0 {
+		t.Fatal("the oldest block file was not deleted")
+	}
+	if fileSize(curFileNum) == 0 {
+		t.Fatal("the current block file was deleted")
+	}
+	isPruned := make(map[hash.Hash]bool)
+	for _, h := range pruned {
+		isPruned[*h] = true
+	}
+	if isPruned[*keep] {
+		t.Fatalf("the block %s which can't be pruned was pruned", keep)
+	}
+	checkBlocks := func(db database.DB) {
+		err := db.View(func(dbTx database.Tx) error {
+			for _, block := range blocks {
+				has, err := dbTx.HasBlock(block.Hash())
+				if err != nil {
+					return err
+				}
+				_, err = dbTx.FetchBlock(block.Hash())
+				if isPruned[*block.Hash()] {
+					if has || !database.IsError(err, database.ErrBlockNotFound) {
+						t.Errorf("the pruned block %s is still there: %v", block.Hash(), err)
+					}
+					continue
+				}
+				if !has || err != nil {
+					t.Errorf("the block %s was lost: %v", block.Hash(), err)
+				}
+			}
+			beenPruned, err := dbTx.BeenPruned()
+			if err != nil {
+				return err
+			}
+			if !beenPruned {
+				t.Error("the database is not marked as pruned")
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkBlocks(pdb)
+
+	// The pruned state is kept after restart, and the new blocks are still
+	// stored.
+	pdb.Close()
+	pdb, err = openDB(dbPath, network, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkBlocks(pdb)
+	newBlock := testPruneBlock(len(blocks))
+	err = pdb.Update(func(dbTx database.Tx) error {
+		return dbTx.StoreBlock(newBlock)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocks = append(blocks, newBlock)
+	checkBlocks(pdb)
+}
+
+// TestBeenPruned ensures the database which was never pruned is not marked.
+func TestBeenPruned(t *testing.T) {
+	dbPath, err := ioutil.TempDir("", "test_ffldb_beenpruned")
+	if err != nil {
+		t.Fatalf("failed to create prune db : %v", err)
+	}
+	defer os.RemoveAll(dbPath)
+
+	pdb, err := openDB(dbPath, protocol.PrivNet, true)
+	if err != nil {
+		t.Fatalf("failed to create prune db : %v", err)
+	}
+	defer pdb.Close()
+
+	err = pdb.Update(func(dbTx database.Tx) error {
+		err := dbTx.StoreBlock(testPruneBlock(0))
+		if err != nil {
+			return err
+		}
+		// The current write file is never deleted.
+		n, err := dbTx.PruneBlocks(0, func(*hash.Hash) bool { return true },
+			func(*hash.Hash, []byte) error { return nil })
+		if err != nil {
+			return err
+		}
+		if n != 0 {
+			t.Errorf("%d blocks in the current file were pruned", n)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = pdb.View(func(dbTx database.Tx) error {
+		beenPruned, err := dbTx.BeenPruned()
+		if err != nil {
+			return err
+		}
+		if beenPruned {
+			t.Error("the database which was never pruned is marked as pruned")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
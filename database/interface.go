@@ -391,6 +391,31 @@ type Tx interface {
 	// implementations.
 	FetchBlockRegions(regions []BlockRegion) ([][]byte, error)
 
+	// PruneBlocks deletes the oldest blocks until the total size of block
+	// storage is not more than targetSize.  The blocks are deleted in the
+	// unit of implementation-specific storage, e.g. a flat file, it is only
+	// deleted when canPrune returns true for all its blocks.  onPrune is
+	// called with every block before it is deleted, so the caller can keep
+	// what it still needs.  The number of deleted blocks is returned.
+	//
+	// The interface contract guarantees at least the following errors will
+	// be returned (other implementation-specific errors are possible):
+	//   - ErrTxNotWritable if attempted against a read-only transaction
+	//   - ErrTxClosed if the transaction has already been closed
+	//
+	// NOTE: The block data passed to onPrune is only valid during the
+	// call.
+	PruneBlocks(targetSize uint64, canPrune func(hash *hash.Hash) bool,
+		onPrune func(hash *hash.Hash, block []byte) error) (int, error)
+
+	// BeenPruned returns whether or not any block was ever deleted by
+	// PruneBlocks.
+	//
+	// The interface contract guarantees at least the following errors will
+	// be returned (other implementation-specific errors are possible):
+	//   - ErrTxClosed if the transaction has already been closed
+	BeenPruned() (bool, error)
+
 	// ******************************************************************
 	// Methods related to both atomic metadata storage and block storage.
 	// ******************************************************************
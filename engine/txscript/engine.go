@@ -71,6 +71,11 @@ const (
 	// OP_UNKNOWN192) as the OP_SHA256 opcode which consumes the top item of
 	// the data stack and replaces it with the sha256 of it.
 	ScriptVerifySHA256
+
+	// ScriptVerifyMeerLock defines whether to treat opcode 195 (OP_MEER_LOCK)
+	// as the check of the release height of the locked genesis outputs,
+	// otherwise it is a NOP.
+	ScriptVerifyMeerLock
 )
 
 const (
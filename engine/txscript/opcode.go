@@ -536,7 +536,7 @@ var opcodeArray = [256]Opcode{
 	// Qitmeer Token opcode.
 	OP_TOKEN_MINT:    {OP_TOKEN_MINT, "OP_TOKEN_MINT", 1, opcodeNop},
 	OP_TOKEN_UNMINT:  {OP_TOKEN_UNMINT, "OP_TOKEN_UNMINT", 1, opcodeNop},
-	OP_MEER_LOCK:     {OP_MEER_LOCK, "OP_MEER_LOCK", 1, opcodeNop},
+	OP_MEER_LOCK:     {OP_MEER_LOCK, "OP_MEER_LOCK", 1, opcodeMeerLock},
 	OP_MEER_RELEASE:  {OP_MEER_RELEASE, "OP_MEER_RELEASE", 1, opcodeNop},
 	OP_TOKEN_DESTORY: {OP_TOKEN_DESTORY, "OP_TOKEN_DESTORY", 1, opcodeNop},
 	OP_TOKEN_RELEASE: {OP_TOKEN_RELEASE, "OP_TOKEN_RELEASE", 1, opcodeNop},
@@ -1113,6 +1113,45 @@ func opcodeCheckLockTimeVerify(op *ParsedOpcode, vm *Engine) error {
 	return nil
 }
 
+// opcodeMeerLock compares the top item on the data stack, which is the DAG main
+// height the locked genesis output is released at, to the LockTime field of
+// the transaction containing the script signature.  Unlike
+// OP_CHECKLOCKTIMEVERIFY, the lock time must be a main height, so the locked
+// genesis outputs can only be spent once the transaction is finalized after
+// the release height.  If flag ScriptVerifyMeerLock is not set, the code
+// continues as if OP_MEER_LOCK were executed as a NOP.
+func opcodeMeerLock(op *ParsedOpcode, vm *Engine) error {
+	if !vm.hasFlag(ScriptVerifyMeerLock) {
+		return opcodeNop(op, vm)
+	}
+
+	so, err := vm.dstack.PeekByteArray(0)
+	if err != nil {
+		return err
+	}
+	releaseHeight, err := makeScriptNum(so, vm.dstack.verifyMinimalData, 5)
+	if err != nil {
+		return err
+	}
+	if releaseHeight < 0 || int64(releaseHeight) >= LockTimeThreshold {
+		return fmt.Errorf("release height is not a main height: %d", releaseHeight)
+	}
+
+	err = verifyLockTime(int64(vm.tx.LockTime), LockTimeThreshold,
+		int64(releaseHeight))
+	if err != nil {
+		return err
+	}
+
+	// The same as OP_CHECKLOCKTIMEVERIFY, the input must not be finalized or
+	// the lock time would be bypassed.
+	if vm.tx.TxIn[vm.txIdx].Sequence == types.MaxTxInSequenceNum {
+		return errors.New("transaction input is finalized")
+	}
+
+	return nil
+}
+
 // opcodeCheckSequenceVerify compares the top item on the data stack to the
 // LockTime field of the transaction containing the script signature
 // validating if the transaction outputs are spendable yet.  If flag
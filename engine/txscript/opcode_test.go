@@ -0,0 +1,68 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+package txscript
+
+import (
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"testing"
+)
+
+func TestOpcodeMeerLock(t *testing.T) {
+	pkScript, err := PayToGenesisLockScript(make([]byte, 20), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if GetScriptClass(DefaultScriptVersion, pkScript) != GenesisLockTy {
+		t.Fatalf("The class of genesis lock script is %s", GetScriptClass(DefaultScriptVersion, pkScript))
+	}
+	releaseHeight, ok := ExtractGenesisLockHeight(pkScript)
+	if !ok || releaseHeight != 100 {
+		t.Fatalf("The release height is %d, %v", releaseHeight, ok)
+	}
+	if _, err := PayToGenesisLockScript(make([]byte, 20), LockTimeThreshold); err == nil {
+		t.Fatal("The release height which is a timestamp is accepted")
+	}
+
+	// <release height> OP_MEER_LOCK OP_DROP OP_TRUE
+	script, err := NewScriptBuilder().AddInt64(100).AddOp(OP_MEER_LOCK).AddOp(OP_DROP).AddOp(OP_TRUE).Script()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		lockTime uint32
+		sequence uint32
+		flags    ScriptFlags
+		valid    bool
+	}{
+		{"released", 100, types.TaggedSequence(types.TxTypeGenesisLock), ScriptVerifyMeerLock, true},
+		{"after released", 101, types.TaggedSequence(types.TxTypeGenesisLock), ScriptVerifyMeerLock, true},
+		{"locked", 99, types.TaggedSequence(types.TxTypeGenesisLock), ScriptVerifyMeerLock, false},
+		{"no lock time", 0, types.TaggedSequence(types.TxTypeGenesisLock), ScriptVerifyMeerLock, false},
+		{"timestamp", LockTimeThreshold + 100, types.TaggedSequence(types.TxTypeGenesisLock), ScriptVerifyMeerLock, false},
+		{"finalized input", 100, types.MaxTxInSequenceNum, ScriptVerifyMeerLock, false},
+		{"not enforced", 0, types.MaxTxInSequenceNum, 0, true},
+	}
+	for _, test := range tests {
+		tx := types.NewTransaction()
+		txIn := types.NewTxInput(types.NewOutPoint(&hash.Hash{1}, 0), nil)
+		txIn.Sequence = test.sequence
+		tx.AddTxIn(txIn)
+		tx.AddTxOut(types.NewTxOutput(types.Amount{Value: 1, Id: types.MEERID}, []byte{OP_TRUE}))
+		tx.LockTime = test.lockTime
+
+		vm, err := NewEngine(script, tx, 0, test.flags, DefaultScriptVersion, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		err = vm.Execute()
+		if test.valid && err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: the locked output is spent", test.name)
+		}
+	}
+}
@@ -321,7 +321,7 @@ func sign(chainParams *params.Params, tx *types.Transaction, idx int,
 
 		return script, class, addresses, nrequired, nil
 
-	case MultiSigTy:
+	case MultiSigTy, TokenMultiSigTy:
 		script, _ := signMultiSig(tx, idx, subScript, hashType,
 			addresses, nrequired, kdb)
 		return script, class, addresses, nrequired, nil
@@ -346,7 +346,7 @@ func sign(chainParams *params.Params, tx *types.Transaction, idx int,
 		return nil, class, nil, 0,
 			errors.New("can't sign NULLDATA transactions")
 
-	case CLTVPubKeyHashTy:
+	case CLTVPubKeyHashTy, GenesisLockTy:
 		key, compressed, err := kdb.GetKey(addresses[0])
 		if err != nil {
 			return nil, class, nil, 0, err
@@ -429,7 +429,7 @@ func mergeScripts(chainParams *params.Params, tx *types.Transaction, idx int,
 		builder.AddData(script)
 		finalScript, _ := builder.Script()
 		return finalScript
-	case MultiSigTy:
+	case MultiSigTy, TokenMultiSigTy:
 		return mergeMultiSig(tx, idx, addresses, nRequired, pkScript,
 			sigScript, prevScript)
 
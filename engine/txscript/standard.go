@@ -41,6 +41,8 @@ const (
 	PubkeyHashAltTy                      // Alternative signature pubkey hash.
 	CLTVPubKeyHashTy                     // Check Lock Time Verify Pay pubkey hash.
 	TokenPubKeyHashTy                    // Token Pay pubkey hash.
+	GenesisLockTy                        // Locked genesis output pay pubkey hash.
+	TokenMultiSigTy                      // Token M-of-N multi signature.
 )
 
 // Script Interface provide a abstract layer to support new Script parsing from opcode
@@ -130,6 +132,8 @@ var scriptClassToName = []string{
 	StakeSubChangeTy:  "sstxchange",
 	CLTVPubKeyHashTy:  "cltvpubkeyhash",
 	TokenPubKeyHashTy: "tokenpubkeyhash",
+	GenesisLockTy:     "genesislock",
+	TokenMultiSigTy:   "tokenmultisig",
 }
 
 // String implements the Stringer interface by returning the name of
@@ -423,6 +427,19 @@ func isCLTVPubkeyHash(pops []ParsedOpcode) bool {
 		pops[7].opcode.value == OP_CHECKSIG
 }
 
+// isGenesisLock returns true if the script passed is a locked genesis output
+// which pays to pubkey hash, false otherwise.
+func isGenesisLock(pops []ParsedOpcode) bool {
+	return len(pops) == 8 &&
+		pops[1].opcode.value == OP_MEER_LOCK &&
+		pops[2].opcode.value == OP_DROP &&
+		pops[3].opcode.value == OP_DUP &&
+		pops[4].opcode.value == OP_HASH160 &&
+		pops[5].opcode.value == OP_DATA_20 &&
+		pops[6].opcode.value == OP_EQUALVERIFY &&
+		pops[7].opcode.value == OP_CHECKSIG
+}
+
 // isTokenPubkeyHash returns true if the script passed is a pay-to-token-pubkey-hash
 // transaction, false otherwise.
 func isTokenPubkeyHash(pops []ParsedOpcode) bool {
@@ -437,6 +454,21 @@ func isTokenPubkeyHash(pops []ParsedOpcode) bool {
 		pops[11].opcode.value == OP_CHECKSIG
 }
 
+// isTokenMultiSig returns true if the script passed is a token script whose
+// owners are a standard M-of-N multi-signature, false otherwise. At least one
+// signature is required.
+func isTokenMultiSig(pops []ParsedOpcode) bool {
+	if len(pops) < 11 ||
+		pops[4].opcode.value != OP_TOKEN ||
+		pops[5].opcode.value != OP_2DROP ||
+		pops[6].opcode.value != OP_2DROP ||
+		!isMultiSig(pops[7:]) {
+		return false
+	}
+	numSigs := asSmallInt(pops[7].opcode)
+	return numSigs >= 1 && numSigs <= asSmallInt(pops[len(pops)-2].opcode)
+}
+
 // scriptType returns the type of the script being inspected from the known
 // standard types.
 func typeOfScript(pops []ParsedOpcode) ScriptClass {
@@ -466,6 +498,10 @@ func typeOfScript(pops []ParsedOpcode) ScriptClass {
 		return CLTVPubKeyHashTy
 	} else if isTokenPubkeyHash(pops) {
 		return TokenPubKeyHashTy
+	} else if isGenesisLock(pops) {
+		return GenesisLockTy
+	} else if isTokenMultiSig(pops) {
+		return TokenMultiSigTy
 	}
 
 	return NonStandardTy
@@ -1027,6 +1063,73 @@ func PayToCLTVPubKeyHashScript(pubKeyHash []byte, lockTime int64) ([]byte, error
 		AddData(pubKeyHash).AddOp(OP_EQUALVERIFY).AddOp(OP_CHECKSIG).Script()
 }
 
+// PayToTokenMultiSigScript returns a token script whose owners are the M-of-N
+// multi-signature of pubkeys, nrequired signatures are required to sign for
+// the token.
+func PayToTokenMultiSigScript(pubkeys []*address.SecpPubKeyAddress, nrequired int, coinId types.CoinID, upLimit uint64, name string, feeCfg int64) ([]byte, error) {
+	if nrequired < 1 || len(pubkeys) < nrequired {
+		return nil, ErrBadNumRequired
+	}
+	multiSig, err := MultiSigScript(pubkeys, nrequired)
+	if err != nil {
+		return nil, err
+	}
+	builder := NewScriptBuilder().AddInt64(int64(coinId)).AddInt64(int64(upLimit)).AddData([]byte(name)).AddInt64(feeCfg).AddOp(OP_TOKEN).AddOp(OP_2DROP).AddOp(OP_2DROP)
+	builder.AddOps(multiSig)
+	return builder.Script()
+}
+
+// PayToTokenOwnersScript returns a token script which has the same owners as
+// the token script of owners, with the coin id, up limit, name and fee
+// replaced.
+func PayToTokenOwnersScript(owners []byte, coinId types.CoinID, upLimit uint64, name string, feeCfg int64) ([]byte, error) {
+	pops, err := parseScript(owners)
+	if err != nil {
+		return nil, err
+	}
+	if !isTokenPubkeyHash(pops) && !isTokenMultiSig(pops) {
+		return nil, ErrUnsupportedScriptType
+	}
+	ownersScript, err := unparseScript(pops[7:])
+	if err != nil {
+		return nil, err
+	}
+	builder := NewScriptBuilder().AddInt64(int64(coinId)).AddInt64(int64(upLimit)).AddData([]byte(name)).AddInt64(feeCfg).AddOp(OP_TOKEN).AddOp(OP_2DROP).AddOp(OP_2DROP)
+	builder.AddOps(ownersScript)
+	return builder.Script()
+}
+
+// PayToGenesisLockScript creates a new script to pay a locked genesis output
+// to a 20-byte pubkey hash, which can't be spent before the DAG main height
+// releaseHeight.
+func PayToGenesisLockScript(pubKeyHash []byte, releaseHeight int64) ([]byte, error) {
+	if releaseHeight < 1 || releaseHeight >= LockTimeThreshold {
+		return nil, fmt.Errorf("Release height out of range:%d", releaseHeight)
+	}
+	return NewScriptBuilder().AddInt64(releaseHeight).AddOp(OP_MEER_LOCK).AddOp(OP_DROP).AddOp(OP_DUP).AddOp(OP_HASH160).
+		AddData(pubKeyHash).AddOp(OP_EQUALVERIFY).AddOp(OP_CHECKSIG).Script()
+}
+
+// ExtractGenesisLockHeight returns the release height of a locked genesis
+// output script, ok is false if the script is not a locked genesis output.
+func ExtractGenesisLockHeight(pkScript []byte) (releaseHeight int64, ok bool) {
+	pops, err := parseScript(pkScript)
+	if err != nil || !isGenesisLock(pops) {
+		return 0, false
+	}
+	if pops[0].data != nil {
+		height, err := makeScriptNum(pops[0].data, true, 5)
+		if err != nil {
+			return 0, false
+		}
+		return int64(height), true
+	}
+	if isSmallInt(pops[0].opcode) {
+		return int64(asSmallInt(pops[0].opcode)), true
+	}
+	return 0, false
+}
+
 func PayToTokenPubKeyHashScript(pubKeyHash []byte, coinId types.CoinID, upLimit uint64, name string, feeCfg int64) ([]byte, error) {
 	return NewScriptBuilder().AddInt64(int64(coinId)).AddInt64(int64(upLimit)).AddData([]byte(name)).AddInt64(feeCfg).AddOp(OP_TOKEN).AddOp(OP_2DROP).AddOp(OP_2DROP).AddOp(OP_DUP).AddOp(OP_HASH160).
 		AddData(pubKeyHash).AddOp(OP_EQUALVERIFY).AddOp(OP_CHECKSIG).Script()
@@ -1371,20 +1474,7 @@ func ExtractPkScriptAddrs(pkScript []byte,
 		// on the stack and the number of public keys is the 2nd to last
 		// item on the stack.
 		requiredSigs = asSmallInt(pops[0].opcode)
-		numPubKeys := asSmallInt(pops[len(pops)-2].opcode)
-
-		// Extract the public keys while skipping any that are invalid.
-		addrs = make([]types.Address, 0, numPubKeys)
-		for i := 0; i < numPubKeys; i++ {
-			pubkey, err := ecc.Secp256k1.ParsePubKey(pops[i+1].data)
-			if err == nil {
-				addr, err := address.NewSecpPubKeyCompressedAddress(pubkey,
-					chainParams)
-				if err == nil {
-					addrs = append(addrs, addr)
-				}
-			}
-		}
+		addrs = extractMultiSigAddrs(pops[1:len(pops)-2], chainParams)
 
 	case NullDataTy:
 		// Null data transactions have no addresses or required
@@ -1413,11 +1503,45 @@ func ExtractPkScriptAddrs(pkScript []byte,
 		if err == nil {
 			addrs = append(addrs, addr)
 		}
+
+	case GenesisLockTy:
+		// A locked genesis output script is of the form:
+		//  <release height> OP_MEER_LOCK OP_DROP OP_DUP OP_HASH160 <hash> OP_EQUALVERIFY OP_CHECKSIG
+		requiredSigs = 1
+		addr, err := address.NewPubKeyHashAddress(pops[5].data,
+			chainParams, ecc.ECDSA_Secp256k1)
+		if err == nil {
+			addrs = append(addrs, addr)
+		}
+
+	case TokenMultiSigTy:
+		// A token multi-signature script is of the form:
+		//  <coin id> <up limit> <name> <fee> OP_TOKEN OP_2DROP OP_2DROP
+		//  <numsigs> <pubkey> <pubkey> <pubkey>... <numpubkeys> OP_CHECKMULTISIG
+		requiredSigs = asSmallInt(pops[7].opcode)
+		addrs = extractMultiSigAddrs(pops[8:len(pops)-2], chainParams)
 	}
 
 	return scriptClass, addrs, requiredSigs, nil
 }
 
+// extractMultiSigAddrs returns the addresses of the public keys pushed by a
+// multi-signature script while skipping any that are invalid.
+func extractMultiSigAddrs(pubKeyPops []ParsedOpcode, chainParams *params.Params) []types.Address {
+	addrs := make([]types.Address, 0, len(pubKeyPops))
+	for _, pop := range pubKeyPops {
+		pubkey, err := ecc.Secp256k1.ParsePubKey(pop.data)
+		if err == nil {
+			addr, err := address.NewSecpPubKeyCompressedAddress(pubkey,
+				chainParams)
+			if err == nil {
+				addrs = append(addrs, addr)
+			}
+		}
+	}
+	return addrs
+}
+
 // extractOneBytePush returns the value of a one byte push.
 func extractOneBytePush(po ParsedOpcode) int {
 	if !isOneByteMaxDataPush(po) {
@@ -12,15 +12,20 @@ type TokenScript struct {
 }
 
 func (s *TokenScript) Name() string {
-	return scriptClassToName[TokenPubKeyHashTy]
+	return scriptClassToName[s.GetClass()]
 }
 
+// GetClass returns TokenMultiSigTy when the token owners are an M-of-N
+// multi-signature, or TokenPubKeyHashTy otherwise.
 func (s *TokenScript) GetClass() ScriptClass {
+	if isTokenMultiSig(s.pops) {
+		return TokenMultiSigTy
+	}
 	return TokenPubKeyHashTy
 }
 
 func (s *TokenScript) Match(pops []ParsedOpcode) bool {
-	return isTokenPubkeyHash(pops)
+	return isTokenPubkeyHash(pops) || isTokenMultiSig(pops)
 }
 
 func (s *TokenScript) SetOpcode(pops []ParsedOpcode) error {
@@ -29,8 +34,11 @@ func (s *TokenScript) SetOpcode(pops []ParsedOpcode) error {
 }
 
 func (s *TokenScript) GetAddresses() []types.Address {
+	if isTokenMultiSig(s.pops) {
+		return extractMultiSigAddrs(s.pops[8:len(s.pops)-2], params.ActiveNetParams.Params)
+	}
 	var addrs []types.Address
-	addr, err := address.NewPubKeyHashAddress(s.pops[8].data, params.ActiveNetParams.Params, ecc.ECDSA_Secp256k1)
+	addr, err := address.NewPubKeyHashAddress(s.pops[9].data, params.ActiveNetParams.Params, ecc.ECDSA_Secp256k1)
 	if err == nil {
 		addrs = append(addrs, addr)
 	} else {
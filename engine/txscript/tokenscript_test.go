@@ -0,0 +1,125 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+package txscript
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/address"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/crypto/ecc"
+	"github.com/Qitmeer/qitmeer/params"
+	"testing"
+)
+
+func TestTokenMultiSig(t *testing.T) {
+	param := params.ActiveNetParams.Params
+	privKeys := make([]ecc.PrivateKey, 3)
+	pubKeys := make([]*address.SecpPubKeyAddress, 3)
+	for i := range privKeys {
+		privKey, pubKey := ecc.Secp256k1.PrivKeyFromBytes(bytes.Repeat([]byte{byte(i + 1)}, 32))
+		addr, err := address.NewSecpPubKeyAddress(pubKey.SerializeCompressed(), param)
+		if err != nil {
+			t.Fatal(err)
+		}
+		privKeys[i] = privKey
+		pubKeys[i] = addr
+	}
+	coinId := types.CoinID(1000)
+
+	// 2-of-3 token owners
+	pkScript, err := PayToTokenMultiSigScript(pubKeys, 2, coinId, 100, "TEST", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	class, addrs, requiredSigs, err := ExtractPkScriptAddrs(pkScript, param)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if class != TokenMultiSigTy || len(addrs) != 3 || requiredSigs != 2 {
+		t.Fatalf("The token owners are %s %d-of-%d", class, requiredSigs, len(addrs))
+	}
+	script, err := ParsePkScript(pkScript)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tnScript, ok := script.(*TokenScript)
+	if !ok {
+		t.Fatal("The token multi-signature script is not a token script")
+	}
+	if tnScript.GetClass() != TokenMultiSigTy || tnScript.GetCoinId() != coinId ||
+		tnScript.GetUpLimit() != 100 || tnScript.GetName() != "TEST" || len(tnScript.GetAddresses()) != 3 {
+		t.Fatalf("The token script is %s %d %d %s", tnScript.GetClass(), tnScript.GetCoinId(), tnScript.GetUpLimit(), tnScript.GetName())
+	}
+	if _, err := PayToTokenMultiSigScript(pubKeys, 0, coinId, 100, "TEST", 0); err == nil {
+		t.Fatal("The token owners require no signature")
+	}
+
+	// The token type updates keep the owners.
+	updateScript, err := PayToTokenOwnersScript(pkScript, coinId, 0, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	class, updateAddrs, requiredSigs, err := ExtractPkScriptAddrs(updateScript, param)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if class != TokenMultiSigTy || len(updateAddrs) != 3 || requiredSigs != 2 {
+		t.Fatalf("The owners of token type update are %s %d-of-%d", class, requiredSigs, len(updateAddrs))
+	}
+	for i, addr := range updateAddrs {
+		if addr.Encode() != pubKeys[i].Encode() {
+			t.Fatalf("The owner %d of token type update is %s", i, addr.Encode())
+		}
+	}
+
+	tx := types.NewTransaction()
+	tx.AddTxIn(types.NewTxInput(types.NewOutPoint(&hash.ZeroHash, types.TokenPrevOutIndex), nil))
+	tx.AddTxOut(types.NewTxOutput(types.Amount{Value: 1, Id: coinId}, []byte{OP_TRUE}))
+	verify := func(sigScript []byte) error {
+		tx.TxIn[0].SignScript = sigScript
+		vm, err := NewEngine(pkScript, tx, 0, 0, DefaultScriptVersion, nil)
+		if err != nil {
+			return err
+		}
+		return vm.Execute()
+	}
+	signBy := func(i int, previousScript []byte) []byte {
+		var kdb KeyClosure = func(addr types.Address) (ecc.PrivateKey, bool, error) {
+			if addr.Encode() != pubKeys[i].Encode() {
+				return nil, false, fmt.Errorf("No private key of %s", addr.Encode())
+			}
+			return privKeys[i], true, nil
+		}
+		sigScript, err := SignTxOutput(param, tx, 0, pkScript, SigHashAll, kdb, nil, previousScript, ecc.ECDSA_Secp256k1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sigScript
+	}
+
+	// The owners sign one by one.
+	partial := signBy(2, nil)
+	if err := verify(partial); err == nil {
+		t.Fatal("The token is signed by one of 2-of-3 owners")
+	}
+	mergedPops, err := parseScript(signBy(2, partial))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sigs := 0
+	for _, pop := range mergedPops {
+		if len(pop.data) != 0 {
+			sigs++
+		}
+	}
+	if sigs != 1 {
+		t.Fatal("The signature of the same owner is merged twice")
+	}
+	full := signBy(0, partial)
+	if err := verify(full); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -7,6 +7,7 @@ package node
 
 import (
 	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
 	"github.com/Qitmeer/qitmeer/common/math"
 	"github.com/Qitmeer/qitmeer/common/roughtime"
 	"github.com/Qitmeer/qitmeer/core/blockchain"
@@ -90,15 +91,8 @@ func (api *PublicBlockChainAPI) GetNodeInfo() (interface{}, error) {
 	for deployment, deploymentDetails := range params.ActiveNetParams.Deployments {
 		// Map the integer deployment ID into a human readable
 		// fork-name.
-		var forkName string
-		switch deployment {
-		case params.DeploymentTestDummy:
-			forkName = "dummy"
-
-		case params.DeploymentToken:
-			forkName = "token"
-
-		default:
+		forkName := params.DeploymentName(deployment)
+		if len(forkName) == 0 {
 			return nil, fmt.Errorf("Unknown deployment %v detected\n", deployment)
 		}
 
@@ -428,6 +422,25 @@ func (api *PrivateBlockChainAPI) SetRpcMaxClients(max int) (interface{}, error)
 	return api.node.node.Config.RPCMaxClients, nil
 }
 
+// Invalidate the block and its future set, their transactions are removed from
+// the utxo set until the block is reconsidered.
+func (api *PrivateBlockChainAPI) InvalidateBlock(h hash.Hash) (interface{}, error) {
+	err := api.node.blockManager.GetChain().InvalidateBlock(&h)
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(), fmt.Sprintf("Invalidate block:%s", h))
+	}
+	return true, nil
+}
+
+// Reconsider the block which was invalidated by InvalidateBlock
+func (api *PrivateBlockChainAPI) ReconsiderBlock(h hash.Hash) (interface{}, error) {
+	err := api.node.blockManager.GetChain().ReconsiderBlock(&h)
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(), fmt.Sprintf("Reconsider block:%s", h))
+	}
+	return true, nil
+}
+
 type PrivateLogAPI struct {
 	node *QitmeerFull
 }
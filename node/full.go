@@ -98,6 +98,7 @@ func newQitmeerFullNode(node *Node) (*QitmeerFull, error) {
 
 	var txIndex *index.TxIndex
 	var addrIndex *index.AddrIndex
+	var txInclusionIndex *index.TxInclusionIndex
 	log.Info("Transaction index is enabled")
 	txIndex = index.NewTxIndex(qm.db)
 	indexes = append(indexes, txIndex)
@@ -106,6 +107,11 @@ func newQitmeerFullNode(node *Node) (*QitmeerFull, error) {
 		addrIndex = index.NewAddrIndex(qm.db, node.Params)
 		indexes = append(indexes, addrIndex)
 	}
+	if cfg.TxInclusionIndex {
+		log.Info("Transaction inclusion index is enabled")
+		txInclusionIndex = index.NewTxInclusionIndex(qm.db)
+		indexes = append(indexes, txInclusionIndex)
+	}
 	// index-manager
 	var indexManager blockchain.IndexManager
 	if len(indexes) > 0 {
@@ -123,7 +129,7 @@ func newQitmeerFullNode(node *Node) (*QitmeerFull, error) {
 	qm.blockManager = bm
 
 	// txmanager
-	tm, err := tx.NewTxManager(bm, txIndex, addrIndex, cfg, qm.nfManager, qm.sigCache, node.DB, &node.events)
+	tm, err := tx.NewTxManager(bm, txIndex, addrIndex, txInclusionIndex, cfg, qm.nfManager, qm.sigCache, node.DB, &node.events)
 	if err != nil {
 		return nil, err
 	}
@@ -151,7 +157,7 @@ func newQitmeerFullNode(node *Node) (*QitmeerFull, error) {
 		BlockPrioritySize: cfg.BlockPrioritySize,
 		TxMinFreeFee:      cfg.MinTxFee, //TODO, duplicated config item with mem-pool
 		StandardVerifyFlags: func() (txscript.ScriptFlags, error) {
-			return common.StandardScriptVerifyFlags()
+			return common.StandardScriptVerifyFlags(qm.blockManager.GetChain())
 		}, //TODO, duplicated config item with mem-pool
 		CoinbaseGenerator: coinbase.NewCoinbaseGenerator(node.Params, qm.node.peerServer.PeerID().String()),
 	}
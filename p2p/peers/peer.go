@@ -355,6 +355,13 @@ func (p *Peer) SetQNR(record *qnr.Record) {
 	p.qnr = record
 }
 
+func (p *Peer) ProtocolVersion() uint32 {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.protocolVersion()
+}
+
 func (p *Peer) protocolVersion() uint32 {
 	if p.chainState == nil {
 		return 0
@@ -598,6 +598,10 @@ func NewService(cfg *config.Config, events *event.Feed, param *params.Params) (*
 	if cfg.MaxBadResp > 0 {
 		peers.MaxBadResponses = cfg.MaxBadResp
 	}
+	services := defaultServices
+	if cfg.Prune > 0 {
+		services |= pv.Prune
+	}
 	s := &Service{
 		cfg: &common.Config{
 			NoDiscovery:          cfg.NoDiscovery,
@@ -613,7 +617,7 @@ func NewService(cfg *config.Config, events *event.Feed, param *params.Params) (*
 			UDPPort:              uint(cfg.P2PUDPPort),
 			Encoding:             "ssz-snappy",
 			ProtocolVersion:      pv.ProtocolVersion,
-			Services:             defaultServices,
+			Services:             services,
 			UserAgent:            BuildUserAgent("Qitmeer"),
 			DisableRelayTx:       cfg.BlocksOnly,
 			MaxOrphanTxs:         cfg.MaxOrphanTxs,
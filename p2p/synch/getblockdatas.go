@@ -11,18 +11,30 @@ import (
 	"github.com/Qitmeer/qitmeer/common/bloom"
 	"github.com/Qitmeer/qitmeer/common/hash"
 	"github.com/Qitmeer/qitmeer/core/blockchain"
+	"github.com/Qitmeer/qitmeer/core/protocol"
 	"github.com/Qitmeer/qitmeer/core/types"
 	"github.com/Qitmeer/qitmeer/p2p/common"
 	"github.com/Qitmeer/qitmeer/p2p/peers"
 	pb "github.com/Qitmeer/qitmeer/p2p/proto/v1"
 	libp2pcore "github.com/libp2p/go-libp2p-core"
 	"github.com/libp2p/go-libp2p-core/peer"
+	"sync"
 	"sync/atomic"
 	"time"
 )
 
 const BLOCKDATA_SSZ_HEAD_SIZE = 4
 
+const (
+	// MaxBlockDataPeers is the maximum number of peers which the bodies of
+	// blocks are downloaded from in parallel.
+	MaxBlockDataPeers = 4
+
+	// MinBlockDatasPerPeer is the minimum number of blocks requested from
+	// one peer at a time.
+	MinBlockDatasPerPeer = 16
+)
+
 func (s *Sync) sendGetBlockDataRequest(ctx context.Context, id peer.ID, locator *pb.GetBlockDatas) (*pb.BlockDatas, error) {
 	ctx, cancel := context.WithTimeout(ctx, ReqTimeout)
 	defer cancel()
@@ -235,22 +247,25 @@ func (ps *PeerSync) processGetBlockDatas(pe *peers.Peer, blocks []*hash.Hash) er
 		}
 	}
 	if len(blocksReady) > 0 {
-		log.Trace(fmt.Sprintf("processGetBlockDatas sendGetBlockDataRequest peer=%v, blocks=%v ", pe.GetID(), blocksReady))
-		bd, err := ps.sy.sendGetBlockDataRequest(ps.sy.p2p.Context(), pe.GetID(), &pb.GetBlockDatas{Locator: changeHashsToPBHashs(blocksReady)})
+		// Only the blocks whose headers are valid are downloaded.
+		checked, err := ps.checkBlockHeaders(pe, blocksReady)
+		if err != nil && len(checked) <= 0 {
+			log.Warn(fmt.Sprintf("getHeaders send:%v", err))
+			updateSyncPoint()
+			ps.updateSyncPeer(true)
+			return err
+		}
+		log.Trace(fmt.Sprintf("processGetBlockDatas fetchBlockDatas peer=%v, blocks=%v ", pe.GetID(), checked))
+		received, err := ps.fetchBlockDatas(pe, checked)
 		if err != nil {
 			log.Warn(fmt.Sprintf("getBlocks send:%v", err))
 			updateSyncPoint()
 			ps.updateSyncPeer(true)
 			return err
 		}
-		log.Trace(fmt.Sprintf("Received:Locator=%d", len(bd.Locator)))
-		for _, b := range bd.Locator {
-			block, err := types.NewBlockFromBytes(b.BlockBytes)
-			if err != nil {
-				log.Warn(fmt.Sprintf("getBlocks from:%v", err))
-				break
-			}
-			bd, ok := blockDataM[*block.Hash()]
+		log.Trace(fmt.Sprintf("Received:Locator=%d", len(received)))
+		for h, block := range received {
+			bd, ok := blockDataM[h]
 			if ok {
 				bd.Block = block
 			}
@@ -275,6 +290,9 @@ func (ps *PeerSync) processGetBlockDatas(pe *peers.Peer, blocks []*hash.Hash) er
 		isOrphan, err := ps.sy.p2p.BlockChain().ProcessBlock(block, behaviorFlags)
 		if err != nil {
 			log.Error("Failed to process block", "hash", block.Hash(), "error", err)
+			if rerr, ok := err.(blockchain.RuleError); ok && rerr.ErrorCode == blockchain.ErrFinalityViolation {
+				ps.sy.Peers().IncrementBadResponses(pe.GetID(), "finality violation")
+			}
 			break
 		}
 		if isOrphan {
@@ -312,6 +330,111 @@ func (ps *PeerSync) processGetBlockDatas(pe *peers.Peer, blocks []*hash.Hash) er
 	return err
 }
 
+// blockDataPeers returns the sync peer and the other peers which have its
+// blocks, the bodies of blocks are downloaded from them in parallel.
+func (ps *PeerSync) blockDataPeers(pe *peers.Peer) []*peers.Peer {
+	result := []*peers.Peer{pe}
+	gs := pe.GraphState()
+	if gs == nil {
+		return result
+	}
+	best := ps.Chain().BestSnapshot()
+	for _, sp := range ps.sy.peers.ConnectedPeers() {
+		if len(result) >= MaxBlockDataPeers {
+			break
+		}
+		if sp.GetID() == pe.GetID() {
+			continue
+		}
+		sgs := sp.GraphState()
+		if sgs == nil {
+			continue
+		}
+		if !sgs.IsExcellent(gs) && !sgs.IsEqual(gs) {
+			continue
+		}
+		if protocol.HasServices(sp.Services(), protocol.Prune) &&
+			sgs.GetMainOrder() > best.GraphState.GetMainOrder()+blockchain.PruneDepth {
+			continue
+		}
+		result = append(result, sp)
+	}
+	return result
+}
+
+// fetchBlockDatas downloads the bodies of blocks from the sync peer and the
+// other peers in parallel, the blocks which were not received from the other
+// peers are requested from the sync peer again.
+func (ps *PeerSync) fetchBlockDatas(pe *peers.Peer, blocks []*hash.Hash) (map[hash.Hash]*types.SerializedBlock, error) {
+	received := map[hash.Hash]*types.SerializedBlock{}
+	if len(blocks) <= 0 {
+		return received, nil
+	}
+	requested := map[hash.Hash]struct{}{}
+	for _, h := range blocks {
+		requested[*h] = struct{}{}
+	}
+	var lock sync.Mutex
+	download := func(sp *peers.Peer, hs []*hash.Hash) error {
+		bd, err := ps.sy.sendGetBlockDataRequest(ps.sy.p2p.Context(), sp.GetID(), &pb.GetBlockDatas{Locator: changeHashsToPBHashs(hs)})
+		if err != nil {
+			return err
+		}
+		lock.Lock()
+		defer lock.Unlock()
+		for _, b := range bd.Locator {
+			block, err := types.NewBlockFromBytes(b.BlockBytes)
+			if err != nil {
+				return err
+			}
+			if _, ok := requested[*block.Hash()]; ok {
+				received[*block.Hash()] = block
+			}
+		}
+		return nil
+	}
+
+	pes := ps.blockDataPeers(pe)
+	size := (len(blocks) + len(pes) - 1) / len(pes)
+	if size < MinBlockDatasPerPeer {
+		size = MinBlockDatasPerPeer
+	}
+	var wg sync.WaitGroup
+	for i, sp := range pes {
+		start := i * size
+		if start >= len(blocks) {
+			break
+		}
+		end := start + size
+		if end > len(blocks) {
+			end = len(blocks)
+		}
+		wg.Add(1)
+		go func(sp *peers.Peer, hs []*hash.Hash) {
+			defer wg.Done()
+			err := download(sp, hs)
+			if err != nil {
+				log.Debug(fmt.Sprintf("getBlocks from peer %v:%v", sp.GetID(), err))
+			}
+		}(sp, blocks[start:end])
+	}
+	wg.Wait()
+
+	missing := []*hash.Hash{}
+	for _, h := range blocks {
+		if _, ok := received[*h]; !ok {
+			missing = append(missing, h)
+		}
+	}
+	if len(missing) > 0 {
+		err := download(pe, missing)
+		if err != nil && len(received) <= 0 {
+			return nil, err
+		}
+	}
+	return received, nil
+}
+
 func (ps *PeerSync) processGetMerkleBlockDatas(pe *peers.Peer, blocks []*hash.Hash) error {
 	if !ps.isSyncPeer(pe) || !pe.IsConnected() {
 		err := fmt.Errorf("no sync peer")
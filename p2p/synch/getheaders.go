@@ -0,0 +1,141 @@
+/*
+ * Copyright (c) 2017-2020 The qitmeer developers
+ */
+
+package synch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/blockchain"
+	"github.com/Qitmeer/qitmeer/core/protocol"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/p2p/common"
+	"github.com/Qitmeer/qitmeer/p2p/peers"
+	pb "github.com/Qitmeer/qitmeer/p2p/proto/v1"
+	libp2pcore "github.com/libp2p/go-libp2p-core"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// The headers are sent as the blocks without transactions, so the parents of
+// them can be checked.
+func (s *Sync) sendGetHeadersRequest(ctx context.Context, id peer.ID, locator *pb.GetBlockDatas) (*pb.BlockDatas, error) {
+	ctx, cancel := context.WithTimeout(ctx, ReqTimeout)
+	defer cancel()
+
+	stream, err := s.Send(ctx, locator, RPCGetHeaders, id)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err := stream.Reset()
+		if err != nil {
+			log.Error(fmt.Sprintf("Failed to close stream with protocol %s,%v", stream.Protocol(), err))
+		}
+	}()
+
+	code, errMsg, err := ReadRspCode(stream, s.Encoding())
+	if err != nil {
+		return nil, err
+	}
+
+	if !code.IsSuccess() {
+		s.Peers().IncrementBadResponses(stream.Conn().RemotePeer(), "get headers request rsp")
+		return nil, errors.New(errMsg)
+	}
+
+	msg := &pb.BlockDatas{}
+	if err := s.Encoding().DecodeWithMaxLength(stream, msg); err != nil {
+		return nil, err
+	}
+	return msg, err
+}
+
+func (s *Sync) getHeadersHandler(ctx context.Context, msg interface{}, stream libp2pcore.Stream) *common.Error {
+	ctx, cancel := context.WithTimeout(ctx, HandleTimeout)
+	var err error
+	defer func() {
+		cancel()
+	}()
+
+	m, ok := msg.(*pb.GetBlockDatas)
+	if !ok {
+		err = fmt.Errorf("message is not type *pb.GetBlockDatas")
+		return ErrMessage(err)
+	}
+	chain := s.p2p.BlockChain()
+	bd := &pb.BlockDatas{Locator: []*pb.BlockData{}}
+	for _, bdh := range m.Locator {
+		blockHash, err := hash.NewHash(bdh.Hash)
+		if err != nil {
+			err = fmt.Errorf("invalid block hash")
+			return ErrMessage(err)
+		}
+		bn := chain.GetBlockNode(chain.BlockDAG().GetBlock(blockHash))
+		if bn == nil {
+			return ErrMessage(fmt.Errorf("No block:%s", blockHash))
+		}
+		header := types.Block{Header: *bn.GetHeader(), Parents: bn.GetParents()}
+		var buff bytes.Buffer
+		err = header.Serialize(&buff)
+		if err != nil {
+			return ErrMessage(err)
+		}
+		pbbd := pb.BlockData{BlockBytes: buff.Bytes()}
+		if uint64(bd.SizeSSZ()+pbbd.SizeSSZ()+BLOCKDATA_SSZ_HEAD_SIZE) >= s.p2p.Encoding().GetMaxChunkSize() {
+			break
+		}
+		bd.Locator = append(bd.Locator, &pbbd)
+	}
+	e := s.EncodeResponseMsg(stream, bd)
+	if e != nil {
+		err = e.Error
+		return e
+	}
+	return nil
+}
+
+// checkBlockHeaders fetches the headers of the blocks to download from the
+// sync peer and checks them in order before downloading the bodies. It
+// returns the blocks whose headers passed the checks, the blocks after the
+// first one without a valid header are not downloaded.
+func (ps *PeerSync) checkBlockHeaders(pe *peers.Peer, blocksReady []*hash.Hash) ([]*hash.Hash, error) {
+	if pe.ProtocolVersion() < protocol.HeadersVersion {
+		return blocksReady, nil
+	}
+	rsp, err := ps.sy.sendGetHeadersRequest(ps.sy.p2p.Context(), pe.GetID(), &pb.GetBlockDatas{Locator: changeHashsToPBHashs(blocksReady)})
+	if err != nil {
+		return nil, err
+	}
+	received := map[hash.Hash]*types.Block{}
+	for _, b := range rsp.Locator {
+		header := &types.Block{}
+		err := header.Deserialize(bytes.NewReader(b.BlockBytes))
+		if err != nil {
+			return nil, err
+		}
+		received[header.BlockHash()] = header
+	}
+
+	headers := []*types.Block{}
+	for _, h := range blocksReady {
+		header, ok := received[*h]
+		if !ok {
+			break
+		}
+		headers = append(headers, header)
+	}
+	n, err := ps.sy.p2p.BlockChain().ProcessBlockHeaders(headers)
+	if err != nil {
+		log.Warn(fmt.Sprintf("Invalid block header from peer %v:%v", pe.GetID(), err))
+		// The unknown parents may be sent by the other peers, but
+		// the headers which broke the rules can't be valid.
+		if rerr, ok := err.(blockchain.RuleError); ok && rerr.ErrorCode != blockchain.ErrMissingParent {
+			ps.sy.Peers().IncrementBadResponses(pe.GetID(), "invalid block header")
+		}
+	}
+	return blocksReady[:n], err
+}
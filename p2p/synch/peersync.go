@@ -329,6 +329,12 @@ func (ps *PeerSync) getBestPeer() *peers.Peer {
 		if !gs.IsExcellent(best.GraphState) {
 			continue
 		}
+		// The pruned peer can't serve the blocks which are deeper than
+		// its prune depth.
+		if protocol.HasServices(sp.Services(), protocol.Prune) &&
+			gs.GetMainOrder() > best.GraphState.GetMainOrder()+blockchain.PruneDepth {
+			continue
+		}
 		// the best sync candidate is the most updated peer
 		if bestPeer == nil {
 			bestPeer = sp
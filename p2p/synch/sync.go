@@ -57,6 +57,8 @@ const (
 	RPCMemPool = "/qitmeer/req/mempool/1"
 	// RPCMemPool defines the topic for the getdata rpc method.
 	RPCGetData = "/qitmeer/req/getdata/1"
+	// RPCGetHeaders defines the topic for the get headers rpc method.
+	RPCGetHeaders = "/qitmeer/req/getheaders/1"
 )
 
 // Time to first byte timeout. The maximum time to wait for first byte of
@@ -219,6 +221,12 @@ func (s *Sync) registerRPCHandlers() {
 		&pb.Inventory{},
 		s.GetDataHandler,
 	)
+
+	s.registerRPC(
+		RPCGetHeaders,
+		&pb.GetBlockDatas{},
+		s.getHeadersHandler,
+	)
 }
 
 // registerRPC for a given topic with an expected protobuf message type.
@@ -51,6 +51,15 @@ type Checkpoint struct {
 	Hash  *hash.Hash
 }
 
+// AssumeUTXO identifies a known good utxo set snapshot, the snapshot is
+// exported when the main chain tip is the block of Hash. A snapshot can be
+// loaded only if its utxo set hash is the same as UtxoSetHash.
+type AssumeUTXO struct {
+	Order       uint64
+	Hash        *hash.Hash
+	UtxoSetHash *hash.Hash
+}
+
 const (
 	CoinbaseVersionV1 = "0.10.4"
 )
@@ -97,6 +106,23 @@ const (
 	// soft-fork package.
 	DeploymentToken
 
+	// DeploymentUtxoCommitment defines the rule change deployment ID for
+	// committing the utxo set in the state root of block header.
+	DeploymentUtxoCommitment
+
+	// DeploymentTokenV2 defines the rule change deployment ID for the
+	// token rules after DeploymentToken, which allow the token revoke, the
+	// token metadata and the multi-signature token owners.
+	DeploymentTokenV2
+
+	// DeploymentStake defines the rule change deployment ID for the
+	// staking subsystem.
+	DeploymentStake
+
+	// DeploymentGenesisLock defines the rule change deployment ID for
+	// releasing the locked genesis outputs by genesis_lock.
+	DeploymentGenesisLock
+
 	// NOTE: DefinedDeployments must always come last since it is used to
 	// determine how many defined deployments there currently are.
 
@@ -104,6 +130,26 @@ const (
 	DefinedDeployments
 )
 
+// DeploymentName returns the human readable fork-name of the deployment ID,
+// it is empty if the deployment is unknown.
+func DeploymentName(id int) string {
+	switch id {
+	case DeploymentTestDummy:
+		return "dummy"
+	case DeploymentToken:
+		return "token"
+	case DeploymentUtxoCommitment:
+		return "utxocommitment"
+	case DeploymentTokenV2:
+		return "tokenv2"
+	case DeploymentStake:
+		return "stake"
+	case DeploymentGenesisLock:
+		return "genesislock"
+	}
+	return ""
+}
+
 // Params defines a qitmeer network by its parameters.  These parameters may be
 // used by qitmeer applications to differentiate networks as well as addresses
 // and keys for one network from those intended for use on another network.
@@ -233,6 +279,13 @@ type Params struct {
 	// Checkpoints ordered from oldest to newest.
 	Checkpoints []Checkpoint
 
+	// The known good utxo set snapshots ordered from oldest to newest.
+	AssumeUTXO []AssumeUTXO
+
+	// AssumeValid is the known good block, the scripts of blocks in its
+	// past set are not executed. It can be nil.
+	AssumeValid *hash.Hash
+
 	// These fields are related to voting on consensus rule changes as
 	// defined by BIP0009.
 	//
@@ -284,6 +337,18 @@ type Params struct {
 	// It should ideally be a P2SH multisignature address.
 	TokenAdminPkScript []byte
 
+	// StakeReservePkScript is the output script for the subsidy reserved
+	// for the stake holders in the coinbase, the stakebase transactions
+	// spend it to reward the tickets.
+	StakeReservePkScript []byte
+
+	// StakeMinAmount is the minimum amount of a ticket.
+	StakeMinAmount int64
+
+	// StakeMaturity is the number of blocks required before a ticket can be
+	// rewarded or disposed.
+	StakeMaturity uint16
+
 	// the output script for guard lock address
 	GuardAddrPkScript []byte
 	// the output script for honor lock address
@@ -294,6 +359,14 @@ type Params struct {
 	BlockRate     float64
 	SecurityLevel float64
 
+	// GHOSTDAGK is the maximum anticone size of the blue blocks in GHOSTDAG,
+	// it is the k parameter of the k-cluster.
+	GHOSTDAGK int
+
+	// FinalityDepth is the number of orders under the main order, the blocks
+	// below it can't be reordered any more. Zero disables the finality.
+	FinalityDepth uint
+
 	LedgerParams ledger.LedgerParams
 
 	CoinbaseConfig CoinbaseConfigs
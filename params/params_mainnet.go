@@ -85,6 +85,10 @@ var MainNetParams = Params{
 	TargetTimespan:           time.Second * mainTargetTimePerBlock * mainWorkDiffWindowSize, // TimePerBlock * WindowSize
 	RetargetAdjustmentFactor: 2,
 
+	// DAG parameters.
+	GHOSTDAGK:     3,
+	FinalityDepth: 0, // disabled until the finality is deployed
+
 	// Subsidy parameters.
 	BaseSubsidy:              10 * 1e8, // POW daily supply is almost 24*60*(60/30)*10 = 28880, ignore the DAG concurrent increment.
 	MulSubsidy:               0,        // subsidy reduce to zero after 7986093 block created
@@ -98,6 +102,12 @@ var MainNetParams = Params{
 	// Checkpoints ordered from oldest to newest.
 	Checkpoints: []Checkpoint{},
 
+	// The known good utxo set snapshots ordered from oldest to newest.
+	AssumeUTXO: []AssumeUTXO{},
+
+	// The known good block whose past set skips the script checks.
+	AssumeValid: nil,
+
 	// Consensus rule change deployments.
 	//
 	// The miner confirmation window is defined as:
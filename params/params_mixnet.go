@@ -92,6 +92,10 @@ var MixNetParams = Params{
 	TargetTimespan:           time.Second * mixTargetTimePerBlock * mixWorkDiffWindowSize, // TimePerBlock * WindowSize
 	RetargetAdjustmentFactor: 2,
 
+	// DAG parameters.
+	GHOSTDAGK:     3,
+	FinalityDepth: 0, // disabled until the finality is deployed
+
 	// Subsidy parameters.
 	BaseSubsidy:              10 * 1e8, // 10 Coin, stay same with testnet
 	MulSubsidy:               100,
@@ -104,6 +108,12 @@ var MixNetParams = Params{
 	// Checkpoints ordered from oldest to newest.
 	Checkpoints: []Checkpoint{},
 
+	// The known good utxo set snapshots ordered from oldest to newest.
+	AssumeUTXO: []AssumeUTXO{},
+
+	// The known good block whose past set skips the script checks.
+	AssumeValid: nil,
+
 	// Consensus rule change deployments.
 	//
 	// The miner confirmation window is defined as:
@@ -124,18 +124,60 @@ var PrivNetParams = Params{
 	TargetTimespan:           time.Second * privTargetTimePerBlock * 16, // TimePerBlock * WindowSize
 	RetargetAdjustmentFactor: 2,
 
+	// DAG parameters.
+	GHOSTDAGK:     3,
+	FinalityDepth: 100,
+
 	// Subsidy parameters.
 	BaseSubsidy:              50000000000,
 	MulSubsidy:               100,
 	DivSubsidy:               101,
 	SubsidyReductionInterval: 128,
 	WorkRewardProportion:     10,
-	StakeRewardProportion:    0,
+	StakeRewardProportion:    3,
 	BlockTaxProportion:       0,
 
 	// Checkpoints ordered from oldest to newest.
 	Checkpoints: nil,
 
+	// The known good utxo set snapshots ordered from oldest to newest.
+	AssumeUTXO: nil,
+
+	// The known good block whose past set skips the script checks.
+	AssumeValid: nil,
+
+	// Consensus rule change deployments.
+	//
+	// The miner confirmation window is defined as:
+	//   target proof of work timespan / target proof of work spacing
+	RuleChangeActivationThreshold: 12, // 75% of MinerConfirmationWindow
+	MinerConfirmationWindow:       16,
+	Deployments: []ConsensusDeployment{
+		DeploymentTestDummy: {
+			BitNumber: 28,
+		},
+		DeploymentToken: {
+			BitNumber:  0,
+			StartTime:  0,
+			ExpireTime: 16 * 100,
+		},
+		DeploymentTokenV2: {
+			BitNumber:  3,
+			StartTime:  0,
+			ExpireTime: 16 * 100,
+		},
+		DeploymentStake: {
+			BitNumber:  2,
+			StartTime:  0,
+			ExpireTime: 16 * 100,
+		},
+		DeploymentGenesisLock: {
+			BitNumber:  4,
+			StartTime:  0,
+			ExpireTime: 16 * 100,
+		},
+	},
+
 	// Address encoding magics
 	NetworkAddressPrefix: "R",
 	PubKeyAddrID:         [2]byte{0x25, 0xe5}, // starts with Rk
@@ -160,4 +202,10 @@ var PrivNetParams = Params{
 	TokenAdminPkScript: hexMustDecode("00000000c96d6d76a914785bfbf4ecad8b72f2582be83616c5d364a3244288ac"),
 
 	CoinbaseMaturity: 16,
+
+	// The stake reserve outputs are spent by the stakebase, which is
+	// checked by the consensus rules.
+	StakeReservePkScript: hexMustDecode("bb51"), // OP_SSGEN OP_TRUE
+	StakeMinAmount:       100 * 1e8,
+	StakeMaturity:        16,
 }
@@ -92,6 +92,10 @@ var TestNetParams = Params{
 	TargetTimespan:           time.Second * testTargetTimePerBlock * testWorkDiffWindowSize, // TimePerBlock * WindowSize
 	RetargetAdjustmentFactor: 2,                                                             // equal to 2 hour vs. 4
 
+	// DAG parameters.
+	GHOSTDAGK:     3,
+	FinalityDepth: 0, // disabled until the finality is deployed
+
 	// Subsidy parameters.
 	BaseSubsidy:              12000000000, // 120 Coin , daily supply is 120*2*60*24 = 345600 ~ 345600 * 2 (DAG factor)
 	MulSubsidy:               100,
@@ -107,6 +111,12 @@ var TestNetParams = Params{
 	// Checkpoints ordered from oldest to newest.
 	Checkpoints: []Checkpoint{},
 
+	// The known good utxo set snapshots ordered from oldest to newest.
+	AssumeUTXO: []AssumeUTXO{},
+
+	// The known good block whose past set skips the script checks.
+	AssumeValid: nil,
+
 	// Address encoding magics
 	NetworkAddressPrefix: "T",
 	PubKeyAddrID:         [2]byte{0x28, 0xf5}, // starts with Tk
@@ -723,3 +723,196 @@ func (c *Client) GetFeesAsync(h string) FutureGetFeesResult {
 func (c *Client) GetFees(h string) (int64, error) {
 	return c.GetFeesAsync(h).Receive()
 }
+
+type FutureExportDAGResult chan *response
+
+// Receive returns the exported text, the json format is returned as it is.
+func (r FutureExportDAGResult) Receive() (string, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return "", err
+	}
+	var text string
+	err = json.Unmarshal(res, &text)
+	if err != nil {
+		return string(res), nil
+	}
+	return text, nil
+}
+
+func (c *Client) ExportDAGAsync(startOrder int64, endOrder int64, format string) FutureExportDAGResult {
+	cmd := cmds.NewExportDAGCmd(startOrder, endOrder, &format)
+	return c.sendCmd(cmd)
+}
+
+func (c *Client) ExportDAG(startOrder int64, endOrder int64, format string) (string, error) {
+	return c.ExportDAGAsync(startOrder, endOrder, format).Receive()
+}
+
+type FutureGetFinalityPointResult chan *response
+
+func (r FutureGetFinalityPointResult) Receive() (*j.FinalityPointResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+	var fp j.FinalityPointResult
+	err = json.Unmarshal(res, &fp)
+	if err != nil {
+		return nil, err
+	}
+	return &fp, nil
+}
+
+func (c *Client) GetFinalityPointAsync() FutureGetFinalityPointResult {
+	cmd := cmds.NewGetFinalityPointCmd()
+	return c.sendCmd(cmd)
+}
+
+func (c *Client) GetFinalityPoint() (*j.FinalityPointResult, error) {
+	return c.GetFinalityPointAsync().Receive()
+}
+
+type FutureGetReorgHistoryResult chan *response
+
+func (r FutureGetReorgHistoryResult) Receive() ([]j.ReorgRecordResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+	var records []j.ReorgRecordResult
+	err = json.Unmarshal(res, &records)
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func (c *Client) GetReorgHistoryAsync(count int) FutureGetReorgHistoryResult {
+	cmd := cmds.NewGetReorgHistoryCmd(count)
+	return c.sendCmd(cmd)
+}
+
+func (c *Client) GetReorgHistory(count int) ([]j.ReorgRecordResult, error) {
+	return c.GetReorgHistoryAsync(count).Receive()
+}
+
+type FutureGetUtxoCommitmentResult chan *response
+
+func (r FutureGetUtxoCommitmentResult) Receive() (*j.UtxoCommitmentResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+	var result j.UtxoCommitmentResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) GetUtxoCommitmentAsync(h *string, verify *bool) FutureGetUtxoCommitmentResult {
+	cmd := cmds.NewGetUtxoCommitmentCmd(h, verify)
+	return c.sendCmd(cmd)
+}
+
+func (c *Client) GetUtxoCommitment(h *string, verify *bool) (*j.UtxoCommitmentResult, error) {
+	return c.GetUtxoCommitmentAsync(h, verify).Receive()
+}
+
+type FutureGetTxOutSetInfoResult chan *response
+
+func (r FutureGetTxOutSetInfoResult) Receive() (*j.TxOutSetInfoResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+	var result j.TxOutSetInfoResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) GetTxOutSetInfoAsync() FutureGetTxOutSetInfoResult {
+	cmd := cmds.NewGetTxOutSetInfoCmd()
+	return c.sendCmd(cmd)
+}
+
+func (c *Client) GetTxOutSetInfo() (*j.TxOutSetInfoResult, error) {
+	return c.GetTxOutSetInfoAsync().Receive()
+}
+
+type FutureGetDeploymentInfoResult chan *response
+
+func (r FutureGetDeploymentInfoResult) Receive() ([]j.DeploymentInfoResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+	var result []j.DeploymentInfoResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) GetDeploymentInfoAsync() FutureGetDeploymentInfoResult {
+	cmd := cmds.NewGetDeploymentInfoCmd()
+	return c.sendCmd(cmd)
+}
+
+func (c *Client) GetDeploymentInfo() ([]j.DeploymentInfoResult, error) {
+	return c.GetDeploymentInfoAsync().Receive()
+}
+
+type FutureGetStakesResult chan *response
+
+func (r FutureGetStakesResult) Receive() ([]j.StakeResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+	var result []j.StakeResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) GetStakesAsync(addr *string) FutureGetStakesResult {
+	cmd := cmds.NewGetStakesCmd(addr)
+	return c.sendCmd(cmd)
+}
+
+func (c *Client) GetStakes(addr *string) ([]j.StakeResult, error) {
+	return c.GetStakesAsync(addr).Receive()
+}
+
+type FutureGetLockedBalanceResult chan *response
+
+func (r FutureGetLockedBalanceResult) Receive() (*j.LockedBalanceResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+	var result j.LockedBalanceResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) GetLockedBalanceAsync(addr *string) FutureGetLockedBalanceResult {
+	cmd := cmds.NewGetLockedBalanceCmd(addr)
+	return c.sendCmd(cmd)
+}
+
+func (c *Client) GetLockedBalance(addr *string) (*j.LockedBalanceResult, error) {
+	return c.GetLockedBalanceAsync(addr).Receive()
+}
@@ -329,6 +329,22 @@ func (c *Client) handleNotification(ntfn *rawNotification) {
 
 		c.ntfnHandlers.OnReorganization(blockHash, blockOrder, olds)
 
+	case cmds.ReorgRecordedNtfnMethod:
+		// Ignore the notification if the client is not interested in
+		// it.
+		if c.ntfnHandlers.OnReorgRecorded == nil {
+			return
+		}
+
+		record, err := parseReorgRecordedNtfnParams(ntfn.Params)
+		if err != nil {
+			log.Warn(fmt.Sprintf("Received invalid reorganization recorded "+
+				"notification: %v", err))
+			return
+		}
+
+		c.ntfnHandlers.OnReorgRecorded(record)
+
 		// OnTxAccepted
 	case cmds.TxAcceptedNtfnMethod:
 		// Ignore the notification if the client is not interested in
@@ -244,6 +244,83 @@ func NewGetFeesCmd(h string) *GetFeesCmd {
 	}
 }
 
+type ExportDAGCmd struct {
+	StartOrder int64
+	EndOrder   int64
+	Format     *string
+}
+
+func NewExportDAGCmd(startOrder int64, endOrder int64, format *string) *ExportDAGCmd {
+	return &ExportDAGCmd{
+		StartOrder: startOrder,
+		EndOrder:   endOrder,
+		Format:     format,
+	}
+}
+
+type GetFinalityPointCmd struct {
+}
+
+func NewGetFinalityPointCmd() *GetFinalityPointCmd {
+	return &GetFinalityPointCmd{}
+}
+
+type GetReorgHistoryCmd struct {
+	Count int
+}
+
+func NewGetReorgHistoryCmd(count int) *GetReorgHistoryCmd {
+	return &GetReorgHistoryCmd{
+		Count: count,
+	}
+}
+
+type GetUtxoCommitmentCmd struct {
+	H      *string
+	Verify *bool
+}
+
+func NewGetUtxoCommitmentCmd(h *string, verify *bool) *GetUtxoCommitmentCmd {
+	return &GetUtxoCommitmentCmd{
+		H:      h,
+		Verify: verify,
+	}
+}
+
+type GetTxOutSetInfoCmd struct {
+}
+
+func NewGetTxOutSetInfoCmd() *GetTxOutSetInfoCmd {
+	return &GetTxOutSetInfoCmd{}
+}
+
+type GetDeploymentInfoCmd struct {
+}
+
+func NewGetDeploymentInfoCmd() *GetDeploymentInfoCmd {
+	return &GetDeploymentInfoCmd{}
+}
+
+type GetStakesCmd struct {
+	Addr *string
+}
+
+func NewGetStakesCmd(addr *string) *GetStakesCmd {
+	return &GetStakesCmd{
+		Addr: addr,
+	}
+}
+
+type GetLockedBalanceCmd struct {
+	Addr *string
+}
+
+func NewGetLockedBalanceCmd(addr *string) *GetLockedBalanceCmd {
+	return &GetLockedBalanceCmd{
+		Addr: addr,
+	}
+}
+
 func init() {
 	flags := UsageFlag(0)
 
@@ -266,4 +343,12 @@ func init() {
 	MustRegisterCmd("tips", (*TipsCmd)(nil), flags, DefaultServiceNameSpace)
 	MustRegisterCmd("getCoinbase", (*GetCoinbaseCmd)(nil), flags, DefaultServiceNameSpace)
 	MustRegisterCmd("getFees", (*GetFeesCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("exportDAG", (*ExportDAGCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("getFinalityPoint", (*GetFinalityPointCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("getReorgHistory", (*GetReorgHistoryCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("getUtxoCommitment", (*GetUtxoCommitmentCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("getTxOutSetInfo", (*GetTxOutSetInfoCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("getDeploymentInfo", (*GetDeploymentInfoCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("getStakes", (*GetStakesCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("getLockedBalance", (*GetLockedBalanceCmd)(nil), flags, DefaultServiceNameSpace)
 }
@@ -14,6 +14,8 @@ import (
 type GetBlockTemplateCmd struct {
 	Capabilities []string
 	PowType      byte
+	Mode         *string
+	Data         *string
 }
 
 func NewGetBlockTemplateCmd(capabilities []string, powType byte) *GetBlockTemplateCmd {
@@ -23,6 +25,17 @@ func NewGetBlockTemplateCmd(capabilities []string, powType byte) *GetBlockTempla
 	}
 }
 
+// NewGetBlockTemplateProposalCmd returns a getBlockTemplate command in the
+// proposal mode, the block is checked without the proof of work.
+func NewGetBlockTemplateProposalCmd(hexBlock string) *GetBlockTemplateCmd {
+	mode := "proposal"
+	return &GetBlockTemplateCmd{
+		Capabilities: []string{"proposal"},
+		Mode:         &mode,
+		Data:         &hexBlock,
+	}
+}
+
 type SubmitBlockCmd struct {
 	HexBlock string
 }
@@ -56,6 +56,26 @@ func NewSetRpcMaxClientsCmd(max int) *SetRpcMaxClientsCmd {
 	}
 }
 
+type InvalidateBlockCmd struct {
+	H string
+}
+
+func NewInvalidateBlockCmd(h string) *InvalidateBlockCmd {
+	return &InvalidateBlockCmd{
+		H: h,
+	}
+}
+
+type ReconsiderBlockCmd struct {
+	H string
+}
+
+func NewReconsiderBlockCmd(h string) *ReconsiderBlockCmd {
+	return &ReconsiderBlockCmd{
+		H: h,
+	}
+}
+
 type CheckAddressCmd struct {
 	Address string
 	Network string
@@ -89,6 +109,8 @@ func init() {
 	MustRegisterCmd("banlist", (*BanlistCmd)(nil), flags, TestNameSpace)
 	MustRegisterCmd("removeBan", (*RemoveBanCmd)(nil), flags, TestNameSpace)
 	MustRegisterCmd("setRpcMaxClients", (*SetRpcMaxClientsCmd)(nil), flags, TestNameSpace)
+	MustRegisterCmd("invalidateBlock", (*InvalidateBlockCmd)(nil), flags, TestNameSpace)
+	MustRegisterCmd("reconsiderBlock", (*ReconsiderBlockCmd)(nil), flags, TestNameSpace)
 
 	MustRegisterCmd("checkAddress", (*CheckAddressCmd)(nil), flags, DefaultServiceNameSpace)
 
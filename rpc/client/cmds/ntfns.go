@@ -10,6 +10,7 @@ const (
 	BlockDisconnectedNtfnMethod = "blockDisconnected"
 	BlockAcceptedNtfnMethod     = "blockAccepted"
 	ReorganizationNtfnMethod    = "reorganization"
+	ReorgRecordedNtfnMethod     = "reorgRecorded"
 	TxAcceptedNtfnMethod        = "txaccepted"
 	TxAcceptedVerboseNtfnMethod = "txacceptedverbose"
 	TxConfirmNtfnMethod         = "txconfirm"
@@ -77,6 +78,16 @@ func NewReorganizationNtfn(hash string, order int64, olds []string) *Reorganizat
 	}
 }
 
+type ReorgRecordedNtfn struct {
+	Record json.ReorgRecordResult
+}
+
+func NewReorgRecordedNtfn(record json.ReorgRecordResult) *ReorgRecordedNtfn {
+	return &ReorgRecordedNtfn{
+		Record: record,
+	}
+}
+
 type TxAcceptedNtfn struct {
 	TxID    string
 	Amounts types.AmountGroup
@@ -121,6 +132,7 @@ func init() {
 	MustRegisterCmd(BlockDisconnectedNtfnMethod, (*BlockDisconnectedNtfn)(nil), flags, NotifyNameSpace)
 	MustRegisterCmd(BlockAcceptedNtfnMethod, (*BlockAcceptedNtfn)(nil), flags, NotifyNameSpace)
 	MustRegisterCmd(ReorganizationNtfnMethod, (*ReorganizationNtfn)(nil), flags, NotifyNameSpace)
+	MustRegisterCmd(ReorgRecordedNtfnMethod, (*ReorgRecordedNtfn)(nil), flags, NotifyNameSpace)
 	MustRegisterCmd(TxAcceptedNtfnMethod, (*TxAcceptedNtfn)(nil), flags, NotifyNameSpace)
 	MustRegisterCmd(TxAcceptedVerboseNtfnMethod, (*TxAcceptedVerboseNtfn)(nil), flags, NotifyNameSpace)
 	MustRegisterCmd(TxConfirmNtfnMethod, (*NotificationTxConfirmNtfn)(nil), flags, NotifyNameSpace)
@@ -66,6 +66,30 @@ func NewGetUtxoCmd(txHash string, vout uint32, includeMempool bool) *GetUtxoCmd
 	}
 }
 
+type GetTxConfirmationRiskCmd struct {
+	TxHash            string
+	AttackerHashShare float64
+	Delay             float64
+}
+
+func NewGetTxConfirmationRiskCmd(txHash string, attackerHashShare float64, delay float64) *GetTxConfirmationRiskCmd {
+	return &GetTxConfirmationRiskCmd{
+		TxHash:            txHash,
+		AttackerHashShare: attackerHashShare,
+		Delay:             delay,
+	}
+}
+
+type GetTxInclusionsCmd struct {
+	TxHash string
+}
+
+func NewGetTxInclusionsCmd(txHash string) *GetTxInclusionsCmd {
+	return &GetTxInclusionsCmd{
+		TxHash: txHash,
+	}
+}
+
 type GetRawTransactionsCmd struct {
 	Addre       string
 	Vinext      bool
@@ -206,6 +230,8 @@ func init() {
 	MustRegisterCmd("sendRawTransaction", (*SendRawTransactionCmd)(nil), flags, DefaultServiceNameSpace)
 	MustRegisterCmd("getRawTransaction", (*GetRawTransactionCmd)(nil), flags, DefaultServiceNameSpace)
 	MustRegisterCmd("getUtxo", (*GetUtxoCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("getTxConfirmationRisk", (*GetTxConfirmationRiskCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("getTxInclusions", (*GetTxInclusionsCmd)(nil), flags, DefaultServiceNameSpace)
 	MustRegisterCmd("getRawTransactions", (*GetRawTransactionsCmd)(nil), flags, DefaultServiceNameSpace)
 	MustRegisterCmd("txSign", (*TxSignCmd)(nil), flags, TestNameSpace)
 
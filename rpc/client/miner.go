@@ -35,6 +35,35 @@ func (c *Client) GetBlockTemplate(capabilities []string, powType byte) (*j.GetBl
 	return c.GetBlockTemplateAsync(capabilities, powType).Receive()
 }
 
+type FutureGetBlockTemplateProposalResult chan *response
+
+// Receive returns the reason of rejection, it is empty if the proposed block
+// is acceptable.
+func (r FutureGetBlockTemplateProposalResult) Receive() (string, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return "", err
+	}
+	var result *string
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return "", err
+	}
+	if result == nil {
+		return "", nil
+	}
+	return *result, nil
+}
+
+func (c *Client) GetBlockTemplateProposalAsync(hexBlock string) FutureGetBlockTemplateProposalResult {
+	cmd := cmds.NewGetBlockTemplateProposalCmd(hexBlock)
+	return c.sendCmd(cmd)
+}
+
+func (c *Client) GetBlockTemplateProposal(hexBlock string) (string, error) {
+	return c.GetBlockTemplateProposalAsync(hexBlock).Receive()
+}
+
 type FutureSubmitBlockResult chan *response
 
 func (r FutureSubmitBlockResult) Receive() (string, error) {
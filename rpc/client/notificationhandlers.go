@@ -21,6 +21,7 @@ type NotificationHandlers struct {
 	OnBlockDisconnected func(hash *hash.Hash, height, order int64, t time.Time, txs []*types.Transaction)
 	OnBlockAccepted     func(hash *hash.Hash, height, order int64, t time.Time, txs []*types.Transaction)
 	OnReorganization    func(hash *hash.Hash, order int64, olds []*hash.Hash)
+	OnReorgRecorded     func(record *j.ReorgRecordResult)
 	OnTxAccepted        func(hash *hash.Hash, amounts types.AmountGroup)
 	OnTxAcceptedVerbose func(c *Client, tx *j.DecodeRawTransactionResult)
 	OnTxConfirm         func(txConfirm *cmds.TxConfirmResult)
@@ -137,6 +138,19 @@ func parseReorganizationNtfnParams(params []json.RawMessage) (*hash.Hash, int64,
 	return blockHash, blockOrder, olds, nil
 }
 
+func parseReorgRecordedNtfnParams(params []json.RawMessage) (*j.ReorgRecordResult, error) {
+	if len(params) != 1 {
+		return nil, wrongNumParams(len(params))
+	}
+
+	var record j.ReorgRecordResult
+	err := json.Unmarshal(params[0], &record)
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
 func parseTxAcceptedNtfnParams(params []json.RawMessage) (*hash.Hash,
 	types.AmountGroup, error) {
 
@@ -156,6 +156,30 @@ func (c *Client) GetUtxo(txHash string, vout uint32, includeMempool bool) (*j.Ge
 	return c.GetUtxoAsync(txHash, vout, includeMempool).Receive()
 }
 
+type FutureGetTxInclusionsResult chan *response
+
+func (r FutureGetTxInclusionsResult) Receive() (*j.TxInclusionsResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+	var result j.TxInclusionsResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) GetTxInclusionsAsync(txHash string) FutureGetTxInclusionsResult {
+	cmd := cmds.NewGetTxInclusionsCmd(txHash)
+	return c.sendCmd(cmd)
+}
+
+func (c *Client) GetTxInclusions(txHash string) (*j.TxInclusionsResult, error) {
+	return c.GetTxInclusionsAsync(txHash).Receive()
+}
+
 type FutureGetRawTransactionsResult chan *response
 
 func (r FutureGetRawTransactionsResult) Receive(verbose bool) (interface{}, error) {
@@ -82,6 +82,15 @@ func (s *RpcServer) handleNotifyMsg(notification *blockchain.Notification) {
 			break
 		}
 		s.ntfnMgr.NotifyReorganization(rnd)
+
+	case blockchain.ReorganizationRecorded:
+		r, ok := notification.Data.(*blockchain.ReorgRecord)
+		if !ok {
+			log.Warn("Chain reorganization recorded notification is not " +
+				"ReorgRecord.")
+			break
+		}
+		s.ntfnMgr.NotifyReorgRecorded(r)
 	}
 }
 
@@ -26,6 +26,8 @@ type notificationReorganization struct {
 	NewOrder  uint64
 }
 
+type notificationReorgRecorded blockchain.ReorgRecord
+
 type notificationTxAcceptedByMempool struct {
 	isNew bool
 	tx    *types.Tx
@@ -128,6 +130,11 @@ out:
 					m.notifyReorganization(blockNotifications, n)
 				}
 
+			case *notificationReorgRecorded:
+				if len(blockNotifications) != 0 {
+					m.notifyReorgRecorded(blockNotifications, (*blockchain.ReorgRecord)(n))
+				}
+
 			case *notificationTxAcceptedByMempool:
 
 				if n.isNew && len(txNotifications) != 0 {
@@ -283,6 +290,26 @@ func (m *wsNotificationManager) notifyReorganization(clients map[chan struct{}]*
 	}
 }
 
+func (m *wsNotificationManager) NotifyReorgRecorded(r *blockchain.ReorgRecord) {
+	select {
+	case m.queueNotification <- (*notificationReorgRecorded)(r):
+	case <-m.quit:
+	}
+}
+
+func (m *wsNotificationManager) notifyReorgRecorded(clients map[chan struct{}]*wsClient, r *blockchain.ReorgRecord) {
+	ntfn := cmds.NewReorgRecordedNtfn(*r.Result())
+	marshalledJSON, err := cmds.MarshalCmd(nil, ntfn)
+	if err != nil {
+		log.Error(fmt.Sprintf("Failed to marshal reorganization recorded notification: "+
+			"%v", err))
+		return
+	}
+	for _, wsc := range clients {
+		wsc.QueueNotification(marshalledJSON)
+	}
+}
+
 func (m *wsNotificationManager) NumClients() (n int) {
 	select {
 	case n = <-m.numClients:
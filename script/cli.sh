@@ -213,6 +213,28 @@ function get_utxo() {
   get_result "$data"
 }
 
+# return the reversal risk of the block which includes the tx
+function get_tx_confirmation_risk() {
+  local tx_hash=$1
+  local share=$2
+  local delay=$3
+  if [ "$share" == "" ]; then
+    share=0.1
+  fi
+  if [ "$delay" == "" ]; then
+    delay=15
+  fi
+  local data='{"jsonrpc":"2.0","method":"getTxConfirmationRisk","params":["'$tx_hash'",'$share','$delay'],"id":1}'
+  get_result "$data"
+}
+
+# return every block which includes the tx and why the others are void
+function get_tx_inclusions() {
+  local tx_hash=$1
+  local data='{"jsonrpc":"2.0","method":"getTxInclusions","params":["'$tx_hash'"],"id":1}'
+  get_result "$data"
+}
+
 function tx_sign(){
    local private_key=$1
    local raw_tx=$2
@@ -234,6 +256,21 @@ function create_raw_txv2() {
   get_result "$data"
 }
 
+function create_stake_raw_tx(){
+  local txtype=$1
+  local inputs=$2
+  local amount=$3
+  local addr=$4
+  local changes=$5
+
+  if [ "$changes" == "" ]; then
+    changes='{}'
+  fi
+
+  local data='{"jsonrpc":"2.0","method":"createStakeRawTransaction","params":["'$txtype'",'$inputs','$amount',"'$addr'",'$changes'],"id":1}'
+  get_result "$data"
+}
+
 function create_token_raw_tx(){
   local txtype=$1
   local coinId=$2
@@ -245,6 +282,7 @@ function create_token_raw_tx(){
   local amounts=$7
   local feeType=$8
   local feeValue=$9
+  local metadata=${10}
 
   if [ "$coinName" == "" ]; then
     coinName=""
@@ -272,8 +310,11 @@ function create_token_raw_tx(){
   if [ "$feeValue" == "" ]; then
     feeValue=0
   fi
+  if [ "$metadata" == "" ]; then
+    metadata=null
+  fi
 
-  local data='{"jsonrpc":"2.0","method":"createTokenRawTransaction","params":["'$txtype'",'$coinId',"'$coinName'","'$owners'",'$uplimit','$inputs','$amounts','$feeType','$feeValue'],"id":1}'
+  local data='{"jsonrpc":"2.0","method":"createTokenRawTransaction","params":["'$txtype'",'$coinId',"'$coinName'","'$owners'",'$uplimit','$inputs','$amounts','$feeType','$feeValue','$metadata'],"id":1}'
   get_result "$data"
 }
 
@@ -399,6 +440,55 @@ function tips(){
   get_result "$data"
 }
 
+function get_finality_point(){
+  local data='{"jsonrpc":"2.0","method":"getFinalityPoint","params":[],"id":null}'
+  get_result "$data"
+}
+
+function get_reorg_history(){
+  local count=$1
+  if [ "$count" == "" ]; then
+    count=10
+  fi
+  local data='{"jsonrpc":"2.0","method":"getReorgHistory","params":['$count'],"id":null}'
+  get_result "$data"
+}
+
+function get_utxo_commitment(){
+  local block_hash=$1
+  local verify=$2
+  if [ "$block_hash" == "current" ]; then
+    block_hash=""
+  fi
+  if [ "$verify" == "" ]; then
+    verify="false"
+  fi
+  local data='{"jsonrpc":"2.0","method":"getUtxoCommitment","params":["'$block_hash'",'$verify'],"id":null}'
+  get_result "$data"
+}
+
+function get_txout_set_info(){
+  local data='{"jsonrpc":"2.0","method":"getTxOutSetInfo","params":[],"id":null}'
+  get_result "$data"
+}
+
+function get_deployment_info(){
+  local data='{"jsonrpc":"2.0","method":"getDeploymentInfo","params":[],"id":null}'
+  get_result "$data"
+}
+
+function get_stakes(){
+  local addr=$1
+  local data='{"jsonrpc":"2.0","method":"getStakes","params":["'$addr'"],"id":null}'
+  get_result "$data"
+}
+
+function get_locked_balance(){
+  local addr=$1
+  local data='{"jsonrpc":"2.0","method":"getLockedBalance","params":["'$addr'"],"id":null}'
+  get_result "$data"
+}
+
 function get_coinbase(){
   local block_hash=$1
   local verbose=$2
@@ -420,6 +510,18 @@ function remove_ban(){
   get_result "$data"
 }
 
+function invalidate_block(){
+  local block_hash=$1
+  local data='{"jsonrpc":"2.0","method":"test_invalidateBlock","params":["'$block_hash'"],"id":1}'
+  get_result "$data"
+}
+
+function reconsider_block(){
+  local block_hash=$1
+  local data='{"jsonrpc":"2.0","method":"test_reconsiderBlock","params":["'$block_hash'"],"id":1}'
+  get_result "$data"
+}
+
 function set_rpc_maxclients(){
   local max=$1
   local data='{"jsonrpc":"2.0","method":"test_setRpcMaxClients","params":['$max'],"id":null}'
@@ -459,6 +561,20 @@ function is_blue(){
   get_result "$data"
 }
 
+function export_dag(){
+  local start=$1
+  local end=$2
+  local format=$3
+  if [ "$end" == "" ]; then
+    end=-1
+  fi
+  if [ "$format" == "" ]; then
+    format=json
+  fi
+  local data='{"jsonrpc":"2.0","method":"exportDAG","params":['$start','$end',"'$format'"],"id":1}'
+  get_result "$data"
+}
+
 function get_fees(){
   local block_hash=$1
   local data='{"jsonrpc":"2.0","method":"getFees","params":["'$block_hash'"],"id":1}'
@@ -482,6 +598,12 @@ function submit_block() {
   get_result "$data"
 }
 
+function propose_block() {
+  local input=$1
+  local data='{"jsonrpc":"2.0","method":"getBlockTemplate","params":[["proposal"],0,"proposal","'$input'"],"id":1}'
+  get_result "$data"
+}
+
 function get_remote_gbt() {
   local powtype=$1
     if [ "$powtype" == "" ]; then
@@ -572,6 +694,8 @@ function usage(){
   echo "  stop"
   echo "  banlist"
   echo "  removeban"
+  echo "  invalidateblock <hash>"
+  echo "  reconsiderblock <hash>"
   echo "  loglevel [trace, debug, info, warn, error, critical]"
   echo "  timeinfo"
   echo "  subsidy"
@@ -591,6 +715,14 @@ function usage(){
   echo "  coinbase <hash>"
   echo "  fees <hash>"
   echo "  tokeninfo"
+  echo "  exportdag <start> [end] [dot|graphml|json]"
+  echo "  finalitypoint"
+  echo "  reorghistory [count]"
+  echo "  utxocommitment [hash|current] [verify]"
+  echo "  txoutsetinfo"
+  echo "  deploymentinfo"
+  echo "  stakes [address]"
+  echo "  lockedbalance [address]"
   echo "tx     :"
   echo "  tx <id>"
   echo "  txv2 <id>"
@@ -598,9 +730,12 @@ function usage(){
   echo "  createRawTx"
   echo "  createRawTxV2"
   echo "  createTokenRawTx"
+  echo "  createStakeRawTx <purchase|stakebase|dispose> <inputs> <amount> [address] [changes]"
   echo "  txSign <rawTx>"
   echo "  sendRawTx <signedRawTx>"
   echo "  getrawtxs <address>"
+  echo "  txrisk <hash> <attacker_hash_share,default=0.1> <delay,default=15>"
+  echo "  txinclusions <hash>"
   echo "utxo   :"
   echo "  getutxo <tx_id> <index> <include_mempool,default=true>"
   echo "miner  :"
@@ -611,6 +746,7 @@ function usage(){
   echo "  savemempool"
   echo "  minerinfo"
   echo "  submitblock"
+  echo "  proposeblock <hex_block>"
   echo "  submitblockheader"
   echo "  remotegbt"
 }
@@ -900,6 +1036,38 @@ elif [ "$1" == "isblue" ]; then
   shift
   is_blue $@
 
+elif [ "$1" == "exportdag" ]; then
+  shift
+  export_dag $@
+
+elif [ "$1" == "finalitypoint" ]; then
+  shift
+  get_finality_point $@
+
+elif [ "$1" == "reorghistory" ]; then
+  shift
+  get_reorg_history $@
+
+elif [ "$1" == "utxocommitment" ]; then
+  shift
+  get_utxo_commitment $@
+
+elif [ "$1" == "txoutsetinfo" ]; then
+  shift
+  get_txout_set_info $@
+
+elif [ "$1" == "deploymentinfo" ]; then
+  shift
+  get_deployment_info $@
+
+elif [ "$1" == "stakes" ]; then
+  shift
+  get_stakes $@
+
+elif [ "$1" == "lockedbalance" ]; then
+  shift
+  get_locked_balance $@
+
 elif [ "$1" == "fees" ]; then
   shift
   get_fees $@
@@ -962,6 +1130,14 @@ elif [ "$1" == "removeban" ]; then
   shift
   remove_ban $@
 
+elif [ "$1" == "invalidateblock" ]; then
+  shift
+  invalidate_block $@
+
+elif [ "$1" == "reconsiderblock" ]; then
+  shift
+  reconsider_block $@
+
 ## Tx
 elif [ "$1" == "tx" ]; then
   shift
@@ -982,6 +1158,10 @@ elif [ "$1" == "createTokenRawTx" ]; then
   shift
   create_token_raw_tx $@
 
+elif [ "$1" == "createStakeRawTx" ]; then
+  shift
+  create_stake_raw_tx $@
+
 elif [ "$1" == "decodeRawTx" ]; then
   shift
   decode_raw_tx $@
@@ -1020,6 +1200,14 @@ elif [ "$1" == "txSign" ]; then
   shift
   tx_sign $@
 
+elif [ "$1" == "txrisk" ]; then
+  shift
+  get_tx_confirmation_risk $@
+
+elif [ "$1" == "txinclusions" ]; then
+  shift
+  get_tx_inclusions $@
+
 ## UTXO
 elif [ "$1" == "getutxo" ]; then
   shift
@@ -1167,6 +1355,10 @@ elif [ "$1" == "submitblock" ]; then
   shift
   submit_block $@
 
+elif [ "$1" == "proposeblock" ]; then
+  shift
+  propose_block $@
+
 elif [ "$1" == "submitblockheader" ]; then
   shift
   submit_block_header $@
@@ -12,9 +12,11 @@ import (
 	"github.com/Qitmeer/qitmeer/core/blockdag"
 	"github.com/Qitmeer/qitmeer/core/json"
 	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/core/types/pow"
 	"github.com/Qitmeer/qitmeer/engine/txscript"
 	"github.com/Qitmeer/qitmeer/rpc"
 	"github.com/Qitmeer/qitmeer/rpc/client/cmds"
+	"sort"
 	"strconv"
 )
 
@@ -168,8 +170,8 @@ func (api *PublicBlockAPI) GetBlock(h hash.Hash, verbose *bool, inclTx *bool, fu
 	cs := ib.GetChildren()
 	children := []*hash.Hash{}
 	if cs != nil && !cs.IsEmpty() {
-		for _, v := range cs.GetMap() {
-			children = append(children, v.(blockdag.IBlock).GetHash())
+		for k := range cs.GetMap() {
+			children = append(children, api.bm.chain.BlockDAG().GetBlockHash(k))
 		}
 	}
 	api.bm.chain.CalculateDAGDuplicateTxs(blk)
@@ -237,8 +239,8 @@ func (api *PublicBlockAPI) GetBlockV2(h hash.Hash, verbose *bool, inclTx *bool,
 	cs := ib.GetChildren()
 	children := []*hash.Hash{}
 	if cs != nil && !cs.IsEmpty() {
-		for _, v := range cs.GetMap() {
-			children = append(children, v.(blockdag.IBlock).GetHash())
+		for k := range cs.GetMap() {
+			children = append(children, api.bm.chain.BlockDAG().GetBlockHash(k))
 		}
 	}
 	api.bm.chain.CalculateDAGDuplicateTxs(blk)
@@ -439,6 +441,250 @@ func (api *PublicBlockAPI) GetFees(h hash.Hash) (interface{}, error) {
 	return api.bm.chain.GetFees(&h), nil
 }
 
+// Export the sub-DAG from startOrder to endOrder (include self) for debugging.
+// The format is one of {dot,graphml,json}, the default is json.
+// If endOrder is -1, it will export to the last order. At most
+// blockdag.MaxExportBlocks blocks are exported in one call, the rest can be
+// exported from the next order of the end order in result.
+func (api *PublicBlockAPI) ExportDAG(startOrder int64, endOrder int64, format *string) (interface{}, error) {
+	if startOrder < 0 {
+		return nil, fmt.Errorf("startOrder(%d) is negative", startOrder)
+	}
+	if endOrder == LatestBlockOrder {
+		endOrder = int64(api.bm.chain.BestSnapshot().GraphState.GetMainOrder())
+	} else if endOrder < 0 {
+		return nil, fmt.Errorf("endOrder(%d) is negative", endOrder)
+	}
+	fm := blockdag.ExportFormatJSON
+	if format != nil && len(*format) > 0 {
+		fm = *format
+	}
+	de, err := api.bm.chain.BlockDAG().Export(uint(startOrder), uint(endOrder))
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(), fmt.Sprintf("Export DAG:%d-%d", startOrder, endOrder))
+	}
+	if fm == blockdag.ExportFormatJSON {
+		return de, nil
+	}
+	var buf bytes.Buffer
+	err = de.Write(&buf, fm)
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(), fmt.Sprintf("Export DAG:%d-%d", startOrder, endOrder))
+	}
+	return buf.String(), nil
+}
+
+// Return the finality point, the order of it and the blocks before it can't be
+// changed by new blocks.
+func (api *PublicBlockAPI) GetFinalityPoint() (interface{}, error) {
+	bd := api.bm.chain.BlockDAG()
+	fp := bd.GetFinalityPoint()
+	if fp == nil {
+		return nil, rpc.RpcInternalError("The finality is disabled", "Finality point")
+	}
+	return json.FinalityPointResult{
+		Hash:  fp.GetHash().String(),
+		Order: uint64(fp.GetOrder()),
+		Depth: uint64(bd.GetFinalityDepth()),
+	}, nil
+}
+
+// Return the latest count reorganizations of the DAG, the newest is first.
+func (api *PublicBlockAPI) GetReorgHistory(count int) (interface{}, error) {
+	records, err := api.bm.chain.GetReorgHistory(count)
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(), "Reorganization history")
+	}
+	result := []*json.ReorgRecordResult{}
+	for _, r := range records {
+		result = append(result, r.Result())
+	}
+	return result, nil
+}
+
+// Return the commitment of utxo set after the block was connected, it is the
+// current utxo set if the hash is empty. The current utxo set is recalculated
+// to compare with the commitment when verify is true.
+func (api *PublicBlockAPI) GetUtxoCommitment(h *string, verify *bool) (interface{}, error) {
+	chain := api.bm.chain
+	if h == nil || len(*h) == 0 {
+		if verify != nil && *verify {
+			err := chain.VerifyUtxoCommitment()
+			if err != nil {
+				return nil, rpc.RpcInternalError(err.Error(), "Verify utxo commitment")
+			}
+		}
+		mainTip := chain.BlockDAG().GetMainChainTip()
+		commitment, err := chain.UtxoCommitment()
+		if err != nil {
+			return nil, rpc.RpcInternalError(err.Error(), "Utxo commitment")
+		}
+		return json.UtxoCommitmentResult{
+			Hash:       mainTip.GetHash().String(),
+			Order:      uint64(mainTip.GetOrder()),
+			Commitment: commitment.String(),
+		}, nil
+	}
+	blockHash, err := hash.NewHashFromStr(*h)
+	if err != nil {
+		return nil, rpc.RpcInvalidError("Invalid block hash:%s", *h)
+	}
+	ib := chain.BlockDAG().GetBlock(blockHash)
+	if ib == nil {
+		return nil, rpc.RpcInternalError(fmt.Sprintf("No block:%s", blockHash), "Utxo commitment")
+	}
+	commitment, err := chain.BlockUtxoCommitment(blockHash)
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(), "Utxo commitment")
+	}
+	return json.UtxoCommitmentResult{
+		Hash:       blockHash.String(),
+		Order:      uint64(ib.GetOrder()),
+		Commitment: commitment.String(),
+	}, nil
+}
+
+// GetTxOutSetInfo returns the statistics of the utxo set at the main chain
+// tip, the supply of every coin is checked against its issued amount.
+func (api *PublicBlockAPI) GetTxOutSetInfo() (interface{}, error) {
+	info, err := api.bm.chain.FetchTxOutSetInfo()
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(), "Tx out set info")
+	}
+	result := json.TxOutSetInfoResult{
+		Hash:           info.Hash.String(),
+		Order:          info.Order,
+		Utxos:          info.Utxos,
+		SerializedSize: info.SerializedSize,
+		UtxoSetHash:    info.UtxoSetHash.String(),
+		Supply:         []json.CoinSupplyResult{},
+		Valid:          info.Valid(),
+	}
+	if info.Commitment != nil {
+		result.Commitment = info.Commitment.String()
+	}
+	for _, s := range info.Supply {
+		result.Supply = append(result.Supply, json.CoinSupplyResult{
+			CoinId:   uint16(s.Id),
+			CoinName: s.Name,
+			Amount:   s.Amount,
+			Expected: s.Expected,
+		})
+	}
+	return result, nil
+}
+
+// GetDeploymentInfo returns the status of every soft-fork deployment for the
+// block after the main chain tip, and the signalling blocks of every pow type
+// in the current window.
+func (api *PublicBlockAPI) GetDeploymentInfo() (interface{}, error) {
+	infos, err := api.bm.chain.FetchDeploymentInfo()
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(), "Deployment info")
+	}
+	result := []json.DeploymentInfoResult{}
+	for _, info := range infos {
+		r := json.DeploymentInfoResult{
+			Id:               info.Id,
+			Name:             info.Name,
+			Bit:              info.Bit,
+			Status:           info.State.HumanString(),
+			StartTime:        info.StartTime,
+			ExpireTime:       info.ExpireTime,
+			PerformTime:      info.PerformTime,
+			TimeMode:         info.TimeMode,
+			Window:           info.Window,
+			Threshold:        info.Threshold,
+			Elapsed:          info.Elapsed,
+			Count:            info.Count,
+			PowCounts:        []json.PowSignallingResult{},
+			ActivationHeight: info.ActivationHeight,
+			ActivationOrder:  info.ActivationOrder,
+		}
+		for _, ps := range info.PowCounts {
+			r.PowCounts = append(r.PowCounts, json.PowSignallingResult{
+				PowName: pow.GetPowName(ps.PowType),
+				Blocks:  ps.Blocks,
+				Count:   ps.Count,
+			})
+		}
+		result = append(result, r)
+	}
+	return result, nil
+}
+
+// GetStakes returns the tickets in the utxo set at the main chain tip, they are
+// filtered by the address of stake holder if it is given.
+func (api *PublicBlockAPI) GetStakes(addr *string) (interface{}, error) {
+	tickets, err := api.bm.chain.FetchTickets()
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(), "Stakes")
+	}
+	result := []json.StakeResult{}
+	for _, ticket := range tickets {
+		_, addrs, _, err := txscript.ExtractPkScriptAddrs(ticket.PkScript, api.bm.params)
+		if err != nil || len(addrs) == 0 {
+			continue
+		}
+		encoded := addrs[0].Encode()
+		if addr != nil && len(*addr) > 0 && *addr != encoded {
+			continue
+		}
+		result = append(result, json.StakeResult{
+			TxId:      ticket.OutPoint.Hash.String(),
+			Vout:      ticket.OutPoint.OutIndex,
+			Amount:    ticket.Amount,
+			Address:   encoded,
+			BlockHash: ticket.BlockHash.String(),
+			Mature:    ticket.Mature,
+		})
+	}
+	return result, nil
+}
+
+// GetLockedBalance returns the release schedule of the locked genesis outputs
+// which are not spent at the main chain tip, they are filtered by the address
+// if it is given. The releasable amount can be spent by genesis lock at the
+// next block.
+func (api *PublicBlockAPI) GetLockedBalance(addr *string) (interface{}, error) {
+	locks, mainHeight, err := api.bm.chain.FetchGenesisLocks()
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(), "Locked balance")
+	}
+	result := json.LockedBalanceResult{
+		MainHeight: uint64(mainHeight),
+		Schedule:   []json.GenesisLockResult{},
+	}
+	for _, lock := range locks {
+		_, addrs, _, err := txscript.ExtractPkScriptAddrs(lock.PkScript, api.bm.params)
+		if err != nil || len(addrs) == 0 {
+			continue
+		}
+		encoded := addrs[0].Encode()
+		if addr != nil && len(*addr) > 0 && *addr != encoded {
+			continue
+		}
+		released := lock.ReleaseHeight <= int64(mainHeight)
+		if released {
+			result.Releasable += lock.Amount
+		} else {
+			result.Locked += lock.Amount
+		}
+		result.Schedule = append(result.Schedule, json.GenesisLockResult{
+			TxId:          lock.OutPoint.Hash.String(),
+			Vout:          lock.OutPoint.OutIndex,
+			Amount:        lock.Amount,
+			Address:       encoded,
+			ReleaseHeight: lock.ReleaseHeight,
+			Released:      released,
+		})
+	}
+	sort.Slice(result.Schedule, func(i, j int) bool {
+		return result.Schedule[i].ReleaseHeight < result.Schedule[j].ReleaseHeight
+	})
+	return result, nil
+}
+
 func (api *PublicBlockAPI) GetTokenInfo() (interface{}, error) {
 	state := api.bm.chain.GetCurTokenState()
 	if state == nil {
@@ -454,6 +700,15 @@ func (api *PublicBlockAPI) GetTokenInfo() (interface{}, error) {
 		if v.Id != types.MEERID {
 			ts.UpLimit = v.UpLimit
 			ts.Enable = v.Enable
+			ts.Revoked = v.Revoked
+			if v.Metadata != nil {
+				ts.Metadata = &json.TokenMetadata{
+					Decimals:    v.Metadata.Decimals,
+					LongName:    v.Metadata.LongName,
+					Description: v.Metadata.Description,
+					Issuer:      v.Metadata.Issuer,
+				}
+			}
 			for k, vb := range state.Balances {
 				if k == v.Id {
 					ts.Balance = vb.Balance
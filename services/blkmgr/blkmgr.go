@@ -4,6 +4,7 @@ package blkmgr
 
 import (
 	"container/list"
+	"encoding/json"
 	"fmt"
 	"github.com/Qitmeer/qitmeer/common/hash"
 	"github.com/Qitmeer/qitmeer/common/roughtime"
@@ -19,6 +20,8 @@ import (
 	"github.com/Qitmeer/qitmeer/params"
 	"github.com/Qitmeer/qitmeer/services/common/progresslog"
 	"github.com/Qitmeer/qitmeer/services/zmq"
+	"os"
+	"path/filepath"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -99,18 +102,35 @@ func NewBlockManager(ntmgr notify.Notify, indexManager blockchain.IndexManager,
 		peerServer:     peerServer,
 	}
 
+	// The assumed valid block of network can be replaced or disabled.
+	assumeValid := par.AssumeValid
+	if len(cfg.AssumeValid) > 0 {
+		assumeValid = nil
+		if cfg.AssumeValid != "0" {
+			h, err := hash.NewHashFromStr(cfg.AssumeValid)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid assumevalid block hash:%s", cfg.AssumeValid)
+			}
+			assumeValid = h
+		}
+	}
+
 	// Create a new block chain instance with the appropriate configuration.
 	var err error
 	bm.chain, err = blockchain.New(&blockchain.Config{
-		DB:             db,
-		Interrupt:      interrupt,
-		ChainParams:    par,
-		TimeSource:     timeSource,
-		Events:         events,
-		SigCache:       sigCache,
-		IndexManager:   indexManager,
-		DAGType:        cfg.DAGType,
-		CacheInvalidTx: cfg.CacheInvalidTx,
+		DB:               db,
+		Interrupt:        interrupt,
+		ChainParams:      par,
+		TimeSource:       timeSource,
+		Events:           events,
+		SigCache:         sigCache,
+		IndexManager:     indexManager,
+		DAGType:          cfg.DAGType,
+		DAGCacheSize:     cfg.DAGCacheSize,
+		CacheInvalidTx:   cfg.CacheInvalidTx,
+		Prune:            cfg.Prune,
+		UtxoCacheMaxSize: cfg.UtxoCacheMaxSize,
+		AssumeValid:      assumeValid,
 	})
 	if err != nil {
 		return nil, err
@@ -264,6 +284,23 @@ func (b *BlockManager) handleNotifyMsg(notification *blockchain.Notification) {
 			// will be no longer valid.
 			b.cachedCurrentTemplate = nil
 		*/
+
+	// The reorganization was finished and recorded.
+	case blockchain.ReorganizationRecorded:
+		r, ok := notification.Data.(*blockchain.ReorgRecord)
+		if !ok {
+			log.Warn("Chain reorganization recorded notification is malformed")
+			break
+		}
+		if !b.zmqNotify.IsEnable() {
+			break
+		}
+		data, err := json.Marshal(r.Result())
+		if err != nil {
+			log.Error(fmt.Sprintf("Failed to marshal reorganization record:%v", err))
+			break
+		}
+		b.zmqNotify.Reorganization(data)
 	}
 }
 
@@ -281,6 +318,39 @@ func (b *BlockManager) Start() {
 	log.Trace("Starting block manager")
 	b.wg.Add(1)
 	go b.blockHandler()
+
+	us, err := b.chain.FetchUtxoSnapshot()
+	if err != nil {
+		log.Error(fmt.Sprintf("Failed to fetch utxo set snapshot:%v", err))
+	} else if us != nil && !us.Validated {
+		b.wg.Add(1)
+		go b.validateUtxoSnapshot()
+	}
+}
+
+// validateUtxoSnapshot replays the history of the loaded utxo set snapshot in
+// a scratch database, it is removed after validating.
+func (b *BlockManager) validateUtxoSnapshot() {
+	defer b.wg.Done()
+
+	scratchPath := filepath.Join(b.config.DataDir, "snapshotvalidation")
+	err := os.RemoveAll(scratchPath)
+	if err != nil {
+		log.Error(err.Error())
+		return
+	}
+	defer os.RemoveAll(scratchPath)
+	scratchDB, err := database.Create(b.config.DbType, scratchPath, b.params.Net)
+	if err != nil {
+		log.Error(fmt.Sprintf("Failed to create the database of snapshot validation:%v", err))
+		return
+	}
+	defer scratchDB.Close()
+
+	err = b.chain.ValidateUtxoSnapshot(scratchDB, b.quit)
+	if err != nil {
+		log.Error(err.Error())
+	}
 }
 
 func (b *BlockManager) Stop() error {
@@ -300,6 +370,10 @@ func (b *BlockManager) Stop() error {
 func (b *BlockManager) WaitForStop() {
 	log.Info("Wait For Block manager stop ...")
 	b.wg.Wait()
+	err := b.chain.FlushUtxoCache()
+	if err != nil {
+		log.Error(fmt.Sprintf("Failed to flush utxo cache:%v", err))
+	}
 	log.Info("Block manager stopped")
 }
 
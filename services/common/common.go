@@ -1,6 +1,7 @@
 package common
 
 import (
+	"github.com/Qitmeer/qitmeer/core/blockchain"
 	"github.com/Qitmeer/qitmeer/engine/txscript"
 	"github.com/Qitmeer/qitmeer/services/mempool"
 )
@@ -9,7 +10,17 @@ import (
 // executing transaction scripts to enforce additional checks which are required
 // for the script to be considered standard.  Note these flags are different
 // than what is required for the consensus rules in that they are more strict.
-func StandardScriptVerifyFlags() (txscript.ScriptFlags, error) {
+func StandardScriptVerifyFlags(chain *blockchain.BlockChain) (txscript.ScriptFlags, error) {
 	scriptFlags := mempool.BaseStandardVerifyFlags
+
+	// Enable the release height check of the locked genesis outputs once
+	// the genesis lock is active.
+	active, err := chain.IsGenesisLockActive(nil)
+	if err != nil {
+		return 0, err
+	}
+	if active {
+		scriptFlags |= txscript.ScriptVerifyMeerLock
+	}
 	return scriptFlags, nil
 }
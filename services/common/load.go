@@ -10,6 +10,8 @@ import (
 	"github.com/Qitmeer/qitmeer/common/util"
 	"github.com/Qitmeer/qitmeer/config"
 	"github.com/Qitmeer/qitmeer/core/address"
+	"github.com/Qitmeer/qitmeer/core/blockchain"
+	"github.com/Qitmeer/qitmeer/core/blockdag"
 	"github.com/Qitmeer/qitmeer/core/types"
 	"github.com/Qitmeer/qitmeer/log"
 	"github.com/Qitmeer/qitmeer/params"
@@ -46,7 +48,9 @@ const (
 	defaultMempoolExpiry          = int64(time.Hour)
 )
 const (
-	defaultSigCacheMaxSize = 100000
+	defaultSigCacheMaxSize  = 100000
+	defaultDAGCacheSize     = blockdag.DefaultBlockCacheSize
+	defaultUtxoCacheMaxSize = blockchain.DefaultUtxoCacheMaxSize
 )
 const (
 	defaultMaxOrphanTxSize = 5000
@@ -89,6 +93,8 @@ func LoadConfig() (*config.Config, []string, error) {
 		SigCacheMaxSize:      defaultSigCacheMaxSize,
 		MiningStateSync:      defaultMiningStateSync,
 		DAGType:              defaultDAGType,
+		DAGCacheSize:         defaultDAGCacheSize,
+		UtxoCacheMaxSize:     defaultUtxoCacheMaxSize,
 		Banning:              true,
 		MaxInbound:           defaultMaxInboundPeersPerHost,
 		CacheInvalidTx:       defaultCacheInvalidTx,
@@ -375,6 +381,40 @@ func LoadConfig() (*config.Config, []string, error) {
 		return nil, nil, err
 	}
 
+	// --txinclusionindex and --droptxinclusionindex do not mix.
+	if cfg.TxInclusionIndex && cfg.DropInclusionIndex {
+		err := fmt.Errorf("%s: the --txinclusionindex and "+
+			"--droptxinclusionindex options may not be activated at "+
+			"the same time",
+			funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// --txinclusionindex and --droptxindex do not mix.
+	if cfg.TxInclusionIndex && cfg.DropTxIndex {
+		err := fmt.Errorf("%s: the --txinclusionindex and --droptxindex "+
+			"options may not be activated at the same time "+
+			"because the transaction inclusion index relies on the "+
+			"transaction index",
+			funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// --prune doesn't work with the indexes which need the old blocks.
+	if cfg.Prune > 0 && (cfg.AddrIndex || cfg.CacheInvalidTx || cfg.TxInclusionIndex) {
+		err := fmt.Errorf("%s: the --prune option may not be activated "+
+			"with --addrindex, --cacheinvalidtx or --txinclusionindex "+
+			"because they rely on the old blocks",
+			funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// Check mining addresses are valid and saved parsed versions.
 	for _, strAddr := range cfg.MiningAddrs {
 		addr, err := address.DecodeAddress(strAddr)
@@ -100,6 +100,9 @@ func (m *Manager) Init(chain *blockchain.BlockChain, interrupt <-chan struct{})
 			}
 
 		}
+		if indexer.Name() == txInclusionIndexName {
+			indexer.(*TxInclusionIndex).chain = chain
+		}
 	}
 
 	bestOrder := uint32(chain.BestSnapshot().GraphState.GetMainOrder())
@@ -0,0 +1,212 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+
+package index
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/blockchain"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/database"
+)
+
+const (
+	// txInclusionIndexName is the human-readable name for the index.
+	txInclusionIndexName = "transaction inclusion index"
+)
+
+var (
+	// txInclusionIndexKey is the key of the transaction inclusion index and
+	// the db bucket used to house it.
+	txInclusionIndexKey = []byte("txinclusionidx")
+)
+
+// TxVoidReason is the reason why the inclusion of a transaction in a block
+// takes no effect.
+type TxVoidReason byte
+
+const (
+	// TxVoidDuplicate indicates the transaction is duplicate, it was
+	// included by a block earlier in order.
+	TxVoidDuplicate TxVoidReason = iota + 1
+
+	// TxVoidInvalidBlock indicates the block which includes the transaction
+	// is invalid.
+	TxVoidInvalidBlock
+)
+
+// String returns the TxVoidReason as a human-readable string.
+func (r TxVoidReason) String() string {
+	switch r {
+	case TxVoidDuplicate:
+		return "duplicate"
+	case TxVoidInvalidBlock:
+		return "invalid block"
+	}
+	return fmt.Sprintf("unknown(%d)", r)
+}
+
+// The serialized key of the index entry is the transaction id followed by the
+// hash of the block which includes it, and the value is the void reason:
+//
+//	<txid><block hash> = <reason>
+//
+//	Field           Type             Size
+//	txid            hash.Hash        32
+//	block hash      hash.Hash        32
+//	reason          byte             1
+//
+// Only the void inclusions are kept, the effective one is in the transaction
+// index.
+func txInclusionKey(txid *hash.Hash, blockHash *hash.Hash) []byte {
+	key := make([]byte, hash.HashSize*2)
+	copy(key, txid[:])
+	copy(key[hash.HashSize:], blockHash[:])
+	return key
+}
+
+// dbFetchTxVoidInclusions returns the void inclusions of transaction which
+// are keyed by the hash of block.
+func dbFetchTxVoidInclusions(dbTx database.Tx, txid *hash.Hash) (map[hash.Hash]TxVoidReason, error) {
+	result := map[hash.Hash]TxVoidReason{}
+	cursor := dbTx.Metadata().Bucket(txInclusionIndexKey).Cursor()
+	for ok := cursor.Seek(txid[:]); ok; ok = cursor.Next() {
+		key := cursor.Key()
+		if len(key) != hash.HashSize*2 || !bytes.Equal(key[:hash.HashSize], txid[:]) {
+			break
+		}
+		value := cursor.Value()
+		if len(value) != 1 {
+			return nil, database.Error{
+				ErrorCode: database.ErrCorruption,
+				Description: fmt.Sprintf("corrupt transaction inclusion "+
+					"index entry for %s", txid),
+			}
+		}
+		var blockHash hash.Hash
+		copy(blockHash[:], key[hash.HashSize:])
+		result[blockHash] = TxVoidReason(value[0])
+	}
+	return result, nil
+}
+
+// TxInclusionIndex implements an index of the transactions which are included
+// by several blocks or by invalid blocks, so the reason why each inclusion
+// takes no effect can be queried.
+type TxInclusionIndex struct {
+	db    database.DB
+	chain *blockchain.BlockChain
+}
+
+// Ensure the TxInclusionIndex type implements the Indexer interface.
+var _ Indexer = (*TxInclusionIndex)(nil)
+
+// Init is only provided to satisfy the Indexer interface as there is nothing to
+// initialize for this index.
+//
+// This is part of the Indexer interface.
+func (idx *TxInclusionIndex) Init() error {
+	// Nothing to do.
+	return nil
+}
+
+// Key returns the database key to use for the index as a byte slice.
+//
+// This is part of the Indexer interface.
+func (idx *TxInclusionIndex) Key() []byte {
+	return txInclusionIndexKey
+}
+
+// Name returns the human-readable name of the index.
+//
+// This is part of the Indexer interface.
+func (idx *TxInclusionIndex) Name() string {
+	return txInclusionIndexName
+}
+
+// Create is invoked when the indexer manager determines the index needs
+// to be created for the first time.  It creates the bucket for the transaction
+// inclusion index.
+//
+// This is part of the Indexer interface.
+func (idx *TxInclusionIndex) Create(dbTx database.Tx) error {
+	_, err := dbTx.Metadata().CreateBucket(txInclusionIndexKey)
+	return err
+}
+
+// ConnectBlock is invoked by the index manager when a new block has been
+// connected to the main chain.  This indexer adds an entry for every
+// transaction of the block which is duplicate, or for all of them if the
+// block is invalid.
+//
+// This is part of the Indexer interface.
+func (idx *TxInclusionIndex) ConnectBlock(dbTx database.Tx, block *types.SerializedBlock, stxos []blockchain.SpentTxOut) error {
+	node := idx.chain.BlockDAG().GetBlock(block.Hash())
+	if node == nil {
+		return fmt.Errorf("no node %s", block.Hash())
+	}
+	invalid := node.GetStatus().KnownInvalid()
+	bucket := dbTx.Metadata().Bucket(txInclusionIndexKey)
+	for _, tx := range block.Transactions() {
+		var reason TxVoidReason
+		if invalid {
+			reason = TxVoidInvalidBlock
+		} else if tx.IsDuplicate {
+			reason = TxVoidDuplicate
+		} else {
+			continue
+		}
+		err := bucket.Put(txInclusionKey(tx.Hash(), block.Hash()), []byte{byte(reason)})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DisconnectBlock is invoked by the index manager when a block has been
+// disconnected from the main chain.  This indexer removes the entries of all
+// transactions in the block.
+//
+// This is part of the Indexer interface.
+func (idx *TxInclusionIndex) DisconnectBlock(dbTx database.Tx, block *types.SerializedBlock, stxos []blockchain.SpentTxOut) error {
+	bucket := dbTx.Metadata().Bucket(txInclusionIndexKey)
+	for _, tx := range block.Transactions() {
+		err := bucket.Delete(txInclusionKey(tx.Hash(), block.Hash()))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TxVoidInclusions returns the blocks which include the transaction without
+// effect and the reason of each.
+//
+// This function is safe for concurrent access.
+func (idx *TxInclusionIndex) TxVoidInclusions(txid *hash.Hash) (map[hash.Hash]TxVoidReason, error) {
+	var result map[hash.Hash]TxVoidReason
+	err := idx.db.View(func(dbTx database.Tx) error {
+		var err error
+		result, err = dbFetchTxVoidInclusions(dbTx, txid)
+		return err
+	})
+	return result, err
+}
+
+// NewTxInclusionIndex returns a new instance of an indexer that is used to
+// keep the void inclusions of transactions.
+//
+// It implements the Indexer interface which plugs into the IndexManager that in
+// turn is used by the blockchain package.  This allows the index to be
+// seamlessly maintained along with the chain.
+func NewTxInclusionIndex(db database.DB) *TxInclusionIndex {
+	return &TxInclusionIndex{db: db}
+}
+
+// DropTxInclusionIndex drops the transaction inclusion index from the provided
+// database if it exists.
+func DropTxInclusionIndex(db database.DB, interrupt <-chan struct{}) error {
+	return dropIndex(db, txInclusionIndexKey, txInclusionIndexName, interrupt)
+}
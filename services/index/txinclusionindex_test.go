@@ -0,0 +1,182 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+
+package index
+
+import (
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/blockchain"
+	"github.com/Qitmeer/qitmeer/core/blockchain/opreturn"
+	"github.com/Qitmeer/qitmeer/core/blockdag"
+	"github.com/Qitmeer/qitmeer/core/merkle"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/core/types/pow"
+	"github.com/Qitmeer/qitmeer/database"
+	_ "github.com/Qitmeer/qitmeer/database/ffldb"
+	"github.com/Qitmeer/qitmeer/engine/txscript"
+	"github.com/Qitmeer/qitmeer/params"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// newTestBlock returns the block on the mining tips with the transactions and
+// a coinbase paying the subsidy to OP_TRUE, the blocks of the same tips are
+// distinct by extra.
+func newTestBlock(t *testing.T, chain *blockchain.BlockChain, par *params.Params, extra string, txs ...*types.Tx) *types.SerializedBlock {
+	bd := chain.BlockDAG()
+	mainParent, parents := bd.GetMainParentAndList(chain.GetMiningTips(blockdag.MaxPriority))
+	height := int64(mainParent.GetHeight() + 1)
+
+	coinbaseScript, err := txscript.NewScriptBuilder().AddInt64(height).
+		AddInt64(int64(bd.GetBlockTotal())).
+		AddData([]byte("/test/" + par.CoinbaseConfig.GetCurrentVersion(height) + "/" + extra)).Script()
+	if err != nil {
+		t.Fatal(err)
+	}
+	subsidy := chain.GetSubsidyCache().CalcBlockSubsidy(bd.GetBlueInfo(mainParent))
+	coinbase := types.NewTransaction()
+	coinbase.AddTxIn(&types.TxInput{
+		PreviousOut: *types.NewOutPoint(&hash.Hash{}, types.MaxPrevOutIndex),
+		Sequence:    types.MaxTxInSequenceNum,
+		SignScript:  coinbaseScript,
+	})
+	coinbase.AddTxOut(types.NewTxOutput(types.Amount{Value: subsidy, Id: types.MEERID}, []byte{txscript.OP_TRUE}))
+	coinbase.AddTxOut(opreturn.GetOPReturnTxOutput(opreturn.NewShowAmount(subsidy)))
+	blockTxs := append([]*types.Tx{types.NewTx(coinbase)}, txs...)
+	merkles := merkle.BuildMerkleTreeStore(blockTxs, true)
+	witnessPreimage := append(merkles[len(merkles)-1].Bytes(), coinbaseScript...)
+	coinbase.TxIn[0].PreviousOut.Hash = hash.DoubleHashH(witnessPreimage)
+	blockTxs[0] = types.NewTx(coinbase)
+
+	timestamp := chain.GetBlockNode(mainParent).Timestamp().Add(par.TargetTimePerBlock)
+	instance := pow.GetInstance(pow.MEERXKECCAKV1, 0, []byte{})
+	instance.SetParams(par.PowConfig)
+	instance.SetMainHeight(pow.MainHeight(height))
+	difficulty, err := chain.CalcNextRequiredDifficulty(timestamp, instance.GetPowType())
+	if err != nil {
+		t.Fatal(err)
+	}
+	version, err := chain.CalcNextBlockVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	merkles = merkle.BuildMerkleTreeStore(blockTxs, false)
+	paMerkles := merkle.BuildParentsMerkleTreeStore(parents)
+	block := &types.Block{
+		Header: types.BlockHeader{
+			Version:    version,
+			ParentRoot: *paMerkles[len(paMerkles)-1],
+			TxRoot:     *merkles[len(merkles)-1],
+			StateRoot:  chain.CalculateTokenStateRoot(blockTxs, parents),
+			Timestamp:  timestamp,
+			Difficulty: difficulty,
+			Pow:        instance,
+		},
+	}
+	for _, parent := range parents {
+		block.AddParent(parent)
+	}
+	for _, tx := range blockTxs {
+		block.AddTransaction(tx.Tx)
+	}
+	for nonce := uint64(0); ; nonce++ {
+		block.Header.Pow.SetNonce(nonce)
+		err := block.Header.Pow.Verify(block.Header.BlockData(), block.Header.BlockHash(), difficulty)
+		if err == nil {
+			break
+		}
+	}
+	return types.NewBlock(block)
+}
+
+// TestTxInclusionIndex ensures the inclusions of transaction are void in the
+// blocks which include it again or are invalid.
+func TestTxInclusionIndex(t *testing.T) {
+	par := *params.PrivNetParam.Params
+	dbPath, err := ioutil.TempDir("", "test_txinclusionindex")
+	if err != nil {
+		t.Fatalf("failed to create txinclusionindex db : %v", err)
+	}
+	defer os.RemoveAll(dbPath)
+	db, err := database.Create("ffldb", dbPath, par.Net)
+	if err != nil {
+		t.Fatalf("failed to create txinclusionindex db : %v", err)
+	}
+	defer db.Close()
+
+	idx := NewTxInclusionIndex(db)
+	chain, err := blockchain.New(&blockchain.Config{
+		DB:           db,
+		ChainParams:  &par,
+		TimeSource:   blockchain.NewMedianTime(),
+		DAGType:      "phantom",
+		IndexManager: NewManager(db, []Indexer{NewTxIndex(db), idx}, &par),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	process := func(block *types.SerializedBlock) {
+		isOrphan, err := chain.ProcessBlock(block, blockchain.BFNone)
+		if err != nil || isOrphan {
+			t.Fatalf("failed to process block %s : %v", block.Hash(), err)
+		}
+	}
+	var blocks []*types.SerializedBlock
+	for i := 0; i <= int(par.CoinbaseMaturity); i++ {
+		block := newTestBlock(t, chain, &par, "")
+		process(block)
+		blocks = append(blocks, block)
+	}
+	spend := func(block *types.SerializedBlock, pkScript []byte) *types.Tx {
+		coinbase := block.Transactions()[0]
+		tx := types.NewTransaction()
+		tx.AddTxIn(types.NewTxInput(types.NewOutPoint(coinbase.Hash(), 0), nil))
+		tx.AddTxOut(types.NewTxOutput(coinbase.Tx.TxOut[0].Amount, pkScript))
+		return types.NewTx(tx)
+	}
+	checkVoid := func(tx *types.Tx, expected map[hash.Hash]TxVoidReason) {
+		void, err := idx.TxVoidInclusions(tx.Hash())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(void) != len(expected) {
+			t.Fatalf("the void inclusions of %s are %v, but %v is expected", tx.Hash(), void, expected)
+		}
+		for h, reason := range expected {
+			if void[h] != reason {
+				t.Fatalf("the void inclusions of %s are %v, but %v is expected", tx.Hash(), void, expected)
+			}
+		}
+	}
+
+	// The parallel blocks include the same transaction, it takes effect in
+	// the one earlier in order once they are merged.
+	tx := spend(blocks[0], []byte{txscript.OP_TRUE})
+	checkVoid(tx, nil)
+	first := newTestBlock(t, chain, &par, "a", tx)
+	second := newTestBlock(t, chain, &par, "b", tx)
+	process(first)
+	process(second)
+	process(newTestBlock(t, chain, &par, ""))
+	later := second
+	if chain.BlockDAG().GetBlock(first.Hash()).GetOrder() > chain.BlockDAG().GetBlock(second.Hash()).GetOrder() {
+		later = first
+	}
+	checkVoid(tx, map[hash.Hash]TxVoidReason{*later.Hash(): TxVoidDuplicate})
+
+	// All transactions of the invalid block are void.
+	falseTx := spend(blocks[1], []byte{txscript.OP_FALSE})
+	process(newTestBlock(t, chain, &par, "", falseTx))
+	falseSpend := types.NewTransaction()
+	falseSpend.AddTxIn(types.NewTxInput(types.NewOutPoint(falseTx.Hash(), 0), nil))
+	falseSpend.AddTxOut(types.NewTxOutput(falseTx.Tx.TxOut[0].Amount, []byte{txscript.OP_TRUE}))
+	badTx := types.NewTx(falseSpend)
+	invalid := newTestBlock(t, chain, &par, "", badTx)
+	process(invalid)
+	if !chain.BlockDAG().GetBlock(invalid.Hash()).GetStatus().KnownInvalid() {
+		t.Fatalf("block %s whose script fails is valid", invalid.Hash())
+	}
+	checkVoid(badTx, map[hash.Hash]TxVoidReason{*invalid.Hash(): TxVoidInvalidBlock})
+	checkVoid(invalid.Transactions()[0], map[hash.Hash]TxVoidReason{*invalid.Hash(): TxVoidInvalidBlock})
+	checkVoid(falseTx, nil)
+}
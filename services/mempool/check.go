@@ -188,6 +188,11 @@ func checkPkScriptStandard(pkScript []byte,
 	case txscript.NonStandardTy:
 		return txRuleError(message.RejectNonstandard,
 			"non-standard script form")
+
+	// Only the genesis ledger locks the outputs by the release schedule.
+	case txscript.GenesisLockTy:
+		return txRuleError(message.RejectNonstandard,
+			"genesis lock script form")
 	}
 
 	return nil
@@ -315,6 +320,11 @@ func checkInputsStandard(tx *types.Tx, utxoView *blockchain.UtxoViewpoint) error
 		prevOut := txIn.PreviousOut
 		entry := utxoView.LookupEntry(prevOut)
 		originPkScript := entry.PkScript()
+		// The stake reserve output of stakebase needs no signature,
+		// it is checked by the stake rules.
+		if i == 1 && types.IsStakebaseTx(tx.Tx) {
+			continue
+		}
 		switch txscript.GetScriptClass(txscript.DefaultScriptVersion, originPkScript) {
 		case txscript.ScriptHashTy:
 			numSigOps := txscript.GetPreciseSigOpCount(
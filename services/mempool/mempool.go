@@ -11,6 +11,7 @@ import (
 	"github.com/Qitmeer/qitmeer/common/hash"
 	"github.com/Qitmeer/qitmeer/common/roughtime"
 	"github.com/Qitmeer/qitmeer/core/blockchain"
+	"github.com/Qitmeer/qitmeer/core/blockchain/token"
 	"github.com/Qitmeer/qitmeer/core/event"
 	"github.com/Qitmeer/qitmeer/core/message"
 	"github.com/Qitmeer/qitmeer/core/types"
@@ -289,6 +290,15 @@ func (mp *TxPool) maybeAcceptTransaction(tx *types.Tx, isNew, rateLimit, allowHi
 	}
 
 	if types.IsTokenTx(tx.Tx) {
+		tokenV2Active, err := mp.cfg.BC.IsTokenV2Active(nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		err = mp.cfg.BC.CheckTokenTypeUpdate(tx, tokenV2Active)
+		if err != nil {
+			return nil, nil, err
+		}
+
 		// Verify crypto signatures for each input and reject the transaction if
 		// any don't verify.
 		flags, err := mp.cfg.Policy.StandardVerifyFlags()
@@ -312,6 +322,27 @@ func (mp *TxPool) maybeAcceptTransaction(tx *types.Tx, isNew, rateLimit, allowHi
 			if err != nil {
 				return nil, nil, err
 			}
+		} else if types.IsTokenRevokeTx(tx.Tx) || (tokenV2Active && types.IsTokenRenewTx(tx.Tx)) {
+			update, err := token.NewTypeUpdateFromTx(tx.Tx)
+			if err != nil {
+				return nil, nil, err
+			}
+			pkscript, err := mp.cfg.BC.GetCurTokenOwners(update.Tt.Id)
+			if err != nil {
+				return nil, nil, err
+			}
+			utxoView.AddTokenTxOut(tx.Tx.TxIn[0].PreviousOut, pkscript)
+
+			if types.IsTokenRevokeTx(tx.Tx) {
+				state := mp.cfg.BC.GetCurTokenState()
+				if state == nil {
+					return nil, nil, fmt.Errorf("Token state error")
+				}
+				err = mp.cfg.BC.CheckTokenRevoke(tx, state)
+				if err != nil {
+					return nil, nil, err
+				}
+			}
 		} else {
 			utxoView.AddTokenTxOut(tx.Tx.TxIn[0].PreviousOut, nil)
 		}
@@ -406,6 +437,22 @@ func (mp *TxPool) maybeAcceptTransaction(tx *types.Tx, isNew, rateLimit, allowHi
 		return nil, nil, err
 	}
 
+	// The tickets and the stake reserve outputs can only be spent by the
+	// stake transactions once the stake is active.
+	stakeActive, err := mp.cfg.BC.IsStakeActive(nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if stakeActive {
+		err = mp.cfg.BC.CheckStakeTransaction(tx, utxoView)
+		if err != nil {
+			if cerr, ok := err.(blockchain.RuleError); ok {
+				return nil, nil, chainRuleError(cerr)
+			}
+			return nil, nil, err
+		}
+	}
+
 	// Don't allow transactions with non-standard inputs if the mempool config
 	// forbids their acceptance and relaying.
 	if !mp.cfg.Policy.AcceptNonStd {
@@ -468,7 +515,10 @@ func (mp *TxPool) maybeAcceptTransaction(tx *types.Tx, isNew, rateLimit, allowHi
 		txFee.Value = txFees[txFee.Id]
 	}
 
-	if txFee.Value < minFee {
+	// The stakebase has no fee, since its output is fixed by the stake
+	// rules, so it is not limited by the fee.
+	isLowFee := txFee.Value < minFee && !types.IsStakebaseTx(msgTx)
+	if isLowFee {
 		str := fmt.Sprintf("transaction %v has %v fees which "+
 			"is under the required amount of %v, tx size is %v bytes, policy-rate is %v/byte.", txHash,
 			txFee, minFee, serializedSize, mp.cfg.Policy.MinRelayTxFee.Value/1000)
@@ -481,7 +531,7 @@ func (mp *TxPool) maybeAcceptTransaction(tx *types.Tx, isNew, rateLimit, allowHi
 	// are exempted.
 	//
 	// This applies to non-stake transactions only.
-	if isNew && !mp.cfg.Policy.DisableRelayPriority && txFee.Value < minFee {
+	if isNew && !mp.cfg.Policy.DisableRelayPriority && isLowFee {
 
 		currentPriority := CalcPriority(msgTx, utxoView,
 			nextBlockHeight, mp.cfg.BD)
@@ -497,7 +547,7 @@ func (mp *TxPool) maybeAcceptTransaction(tx *types.Tx, isNew, rateLimit, allowHi
 	// Free-to-relay transactions are rate limited here to prevent
 	// penny-flooding with tiny transactions as a form of attack.
 	// This applies to non-stake transactions only.
-	if rateLimit && txFee.Value < minFee {
+	if rateLimit && isLowFee {
 		nowUnix := roughtime.Now().Unix()
 		// Decay passed data with an exponentially decaying ~10 minute
 		// window.
@@ -40,16 +40,20 @@ func NewPublicMinerAPI(m *Miner) *PublicMinerAPI {
 }
 
 //func (api *PublicMinerAPI) GetBlockTemplate(request *mining.TemplateRequest) (interface{}, error){
-func (api *PublicMinerAPI) GetBlockTemplate(capabilities []string, powType byte) (interface{}, error) {
+func (api *PublicMinerAPI) GetBlockTemplate(capabilities []string, powType byte, mode *string, data *string) (interface{}, error) {
 	// Set the default mode and override it if supplied.
-	mode := "template"
-	request := json.TemplateRequest{Mode: mode, Capabilities: capabilities, PowType: powType}
-	switch mode {
+	request := json.TemplateRequest{Mode: "template", Capabilities: capabilities, PowType: powType}
+	if mode != nil && *mode != "" {
+		request.Mode = *mode
+	}
+	if data != nil {
+		request.Data = *data
+	}
+	switch request.Mode {
 	case "template":
 		return handleGetBlockTemplateRequest(api, &request)
 	case "proposal":
-		//TODO LL, will be added
-		//return handleGetBlockTemplateProposal(s, request)
+		return handleGetBlockTemplateProposal(api, &request)
 	}
 	return nil, rpc.RpcInvalidError("Invalid mode")
 }
@@ -71,6 +75,173 @@ func handleGetBlockTemplateRequest(api *PublicMinerAPI, request *json.TemplateRe
 	return resp.result, resp.err
 }
 
+// handleGetBlockTemplateProposal is a helper for GetBlockTemplate which deals
+// with block proposals as defined in BIP 0023. The proposed block is fully
+// validated aside from the proof of work, nil is returned if it's acceptable,
+// otherwise the reason of rejection is returned as defined in BIP 0022.
+func handleGetBlockTemplateProposal(api *PublicMinerAPI, request *json.TemplateRequest) (interface{}, error) {
+	hexData := request.Data
+	if hexData == "" {
+		return nil, rpc.RpcInvalidError("Data must contain the hex-encoded serialized block that is being proposed")
+	}
+
+	// Ensure the provided data is sane and deserialize the proposed block.
+	if len(hexData)%2 != 0 {
+		hexData = "0" + hexData
+	}
+	dataBytes, err := hex.DecodeString(hexData)
+	if err != nil {
+		return nil, rpc.RpcDecodeHexError(hexData)
+	}
+	block, err := types.NewBlockFromBytes(dataBytes)
+	if err != nil {
+		return nil, rpc.RpcDeserializationError("Block decode failed: %s", err.Error())
+	}
+
+	chain := api.miner.blockManager.GetChain()
+	if chain.BlockDAG().HasBlock(block.Hash()) {
+		return "duplicate", nil
+	}
+	// The proposed block must build on the known blocks.
+	for _, parent := range block.Block().Parents {
+		if !chain.BlockDAG().HasBlock(parent) {
+			return "bad-prevblk", nil
+		}
+	}
+	if len(block.Block().Transactions) <= 0 {
+		return "bad-txns-none", nil
+	}
+	height, err := blockchain.ExtractCoinbaseHeight(block.Block().Transactions[0])
+	if err != nil {
+		return chainErrToGBTErrString(err), nil
+	}
+	block.SetHeight(uint(height))
+	block.SetOrder(uint64(chain.BestSnapshot().GraphState.GetTotal()))
+
+	err = chain.CheckConnectBlockTemplate(block)
+	if err != nil {
+		if _, ok := err.(blockchain.RuleError); !ok {
+			return nil, rpc.RpcInternalError(err.Error(), "Block proposal")
+		}
+		log.Debug(fmt.Sprintf("Rejected block proposal %s: %v", block.Hash(), err))
+		return chainErrToGBTErrString(err), nil
+	}
+	return nil, nil
+}
+
+// chainErrToGBTErrString converts an error returned from the chain to a string
+// which matches the reasons and format described in BIP 0022 for rejection
+// reasons.
+func chainErrToGBTErrString(err error) string {
+	// When the passed error is not a RuleError, just return a generic
+	// rejected string with the error text.
+	rErr, ok := err.(blockchain.RuleError)
+	if !ok {
+		return "rejected: " + err.Error()
+	}
+
+	switch rErr.ErrorCode {
+	case blockchain.ErrDuplicateBlock:
+		return "duplicate"
+	case blockchain.ErrMissingParent, blockchain.ErrParentsBlockUnknown:
+		return "bad-prevblk"
+	case blockchain.ErrBlockTooBig:
+		return "bad-blk-length"
+	case blockchain.ErrWrongBlockSize:
+		return "bad-blk-size"
+	case blockchain.ErrBlockVersionTooOld:
+		return "bad-version"
+	case blockchain.ErrInvalidTime:
+		return "bad-time"
+	case blockchain.ErrTimeTooOld:
+		return "time-too-old"
+	case blockchain.ErrTimeTooNew:
+		return "time-too-new"
+	case blockchain.ErrDifficultyTooLow:
+		return "bad-diffbits"
+	case blockchain.ErrUnexpectedDifficulty:
+		return "bad-diffbits"
+	case blockchain.ErrHighHash:
+		return "high-hash"
+	case blockchain.ErrBadMerkleRoot:
+		return "bad-txnmrklroot"
+	case blockchain.ErrBadParentsMerkleRoot:
+		return "bad-parentsmrklroot"
+	case blockchain.ErrBadCheckpoint:
+		return "bad-checkpoint"
+	case blockchain.ErrForkTooOld:
+		return "fork-too-old"
+	case blockchain.ErrCheckpointTimeTooOld:
+		return "checkpoint-time-too-old"
+	case blockchain.ErrNoTransactions:
+		return "bad-txns-none"
+	case blockchain.ErrNoParents:
+		return "bad-parents-none"
+	case blockchain.ErrDuplicateParent:
+		return "bad-parents-duplicate"
+	case blockchain.ErrTooManyTransactions:
+		return "bad-txns-toomany"
+	case blockchain.ErrNoTxInputs:
+		return "bad-txns-noinputs"
+	case blockchain.ErrNoTxOutputs:
+		return "bad-txns-nooutputs"
+	case blockchain.ErrTxTooBig:
+		return "bad-txns-size"
+	case blockchain.ErrInvalidTxOutValue:
+		return "bad-txns-outputvalue"
+	case blockchain.ErrDuplicateTxInputs:
+		return "bad-txns-dupinputs"
+	case blockchain.ErrInvalidTxInput:
+		return "bad-txns-badinput"
+	case blockchain.ErrMissingTxOut:
+		return "bad-txns-missinginput"
+	case blockchain.ErrUnfinalizedTx:
+		return "bad-txns-unfinalizedtx"
+	case blockchain.ErrDuplicateTx:
+		return "bad-txns-duplicate"
+	case blockchain.ErrOverwriteTx:
+		return "bad-txns-overwrite"
+	case blockchain.ErrImmatureSpend:
+		return "bad-txns-maturity"
+	case blockchain.ErrSpendTooHigh:
+		return "bad-txns-highspend"
+	case blockchain.ErrBadFees:
+		return "bad-txns-fees"
+	case blockchain.ErrTooManySigOps:
+		return "high-sigops"
+	case blockchain.ErrFirstTxNotCoinbase:
+		return "bad-txns-nocoinbase"
+	case blockchain.ErrMultipleCoinbases:
+		return "bad-txns-multicoinbase"
+	case blockchain.ErrCoinbaseHeight, blockchain.ErrMissingCoinbaseHeight:
+		return "bad-cb-height"
+	case blockchain.ErrBadCoinbaseScriptLen:
+		return "bad-cb-length"
+	case blockchain.ErrBadCoinbaseValue:
+		return "bad-cb-value"
+	case blockchain.ErrScriptMalformed:
+		return "bad-script-malformed"
+	case blockchain.ErrScriptValidation:
+		return "bad-script-validate"
+	case blockchain.ErrExpiredTx:
+		return "bad-txns-expired"
+	case blockchain.ErrInvalidAncestorBlock:
+		return "bad-prevblk-invalid"
+	case blockchain.ErrPrevBlockNotBest:
+		return "inconclusive-not-best-prvblk"
+	case blockchain.ErrInValidPowType:
+		return "bad-pow-type"
+	case blockchain.ErrNoBlueCoinbase:
+		return "bad-cb-blue"
+	case blockchain.ErrFinalityViolation:
+		return "bad-finality"
+	case blockchain.ErrorCoinbaseBlockVersion:
+		return "bad-cb-version"
+	}
+
+	return "rejected: " + err.Error()
+}
+
 //LL
 //Attempts to submit new block to network.
 //See https://en.bitcoin.it/wiki/BIP_0022 for full specification
@@ -0,0 +1,57 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+
+package miner
+
+import (
+	"errors"
+	"github.com/Qitmeer/qitmeer/core/blockchain"
+	"strings"
+	"testing"
+)
+
+// TestBlockProposalData ensures the proposal is rejected before the chain is
+// used if its data is not a serialized block.
+func TestBlockProposalData(t *testing.T) {
+	api := NewPublicMinerAPI(nil)
+	mode := "proposal"
+	tests := []struct {
+		name string
+		mode string
+		data string
+		err  string
+	}{
+		{"unknown mode", "unknown", "00", "Invalid mode"},
+		{"no data", mode, "", "Data must contain"},
+		{"not hex", mode, "zz", "hexadecimal"},
+		{"not block", mode, "0102", "Block decode failed"},
+	}
+	for _, test := range tests {
+		m, data := test.mode, test.data
+		result, err := api.GetBlockTemplate(nil, 0, &m, &data)
+		if result != nil || err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: expected the error %q, got %v %v", test.name, test.err, result, err)
+		}
+	}
+}
+
+// TestChainErrToGBTErrString ensures the rule errors are converted to the
+// rejection reasons of BIP 0022.
+func TestChainErrToGBTErrString(t *testing.T) {
+	tests := []struct {
+		err    error
+		reason string
+	}{
+		{blockchain.RuleError{ErrorCode: blockchain.ErrMissingParent}, "bad-prevblk"},
+		{blockchain.RuleError{ErrorCode: blockchain.ErrBadMerkleRoot}, "bad-txnmrklroot"},
+		{blockchain.RuleError{ErrorCode: blockchain.ErrBadCoinbaseValue}, "bad-cb-value"},
+		{blockchain.RuleError{ErrorCode: blockchain.ErrScriptValidation}, "bad-script-validate"},
+		{blockchain.RuleError{ErrorCode: blockchain.ErrFinalityViolation}, "bad-finality"},
+		{errors.New("disk failure"), "rejected: disk failure"},
+	}
+	for _, test := range tests {
+		reason := chainErrToGBTErrString(test.err)
+		if reason != test.reason {
+			t.Errorf("%v: expected %q, got %q", test.err, test.reason, reason)
+		}
+	}
+}
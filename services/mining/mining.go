@@ -104,7 +104,10 @@ func standardCoinbaseOpReturn(enData []byte) ([]byte, error) {
 //
 // See the comment for NewBlockTemplate for more information about why the nil
 // address handling is useful.
-func createCoinbaseTx(subsidyCache *blockchain.SubsidyCache, coinbaseScript []byte, bi *blockdag.BlueInfo, addr types.Address, params *params.Params, opReturnPkScript []byte) (*types.Tx, *types.TxOutput, *types.TxOutput, error) {
+//
+// The stake share of subsidy is reserved for the stake holders once the stake
+// is active, otherwise it is paid to the miner.
+func createCoinbaseTx(subsidyCache *blockchain.SubsidyCache, coinbaseScript []byte, bi *blockdag.BlueInfo, addr types.Address, params *params.Params, opReturnPkScript []byte, stakeActive bool) (*types.Tx, *types.TxOutput, *types.TxOutput, *types.TxOutput, error) {
 	tx := types.NewTransaction()
 	tx.AddTxIn(&types.TxInput{
 		// Coinbase transactions have no inputs, so previous outpoint is
@@ -117,9 +120,9 @@ func createCoinbaseTx(subsidyCache *blockchain.SubsidyCache, coinbaseScript []by
 
 	// Create a coinbase with correct block subsidy and extranonce.
 	subsidy := blockchain.CalcBlockWorkSubsidy(subsidyCache,
-		bi, params)
+		bi, params, stakeActive)
 	tax := blockchain.CalcBlockTaxSubsidy(subsidyCache,
-		bi, params)
+		bi, params, stakeActive)
 
 	// output
 	// Create the script to pay to the provided payment address if one was
@@ -130,15 +133,20 @@ func createCoinbaseTx(subsidyCache *blockchain.SubsidyCache, coinbaseScript []by
 	if addr != nil {
 		pksSubsidy, err = txscript.PayToAddrScript(addr)
 		if err != nil {
-			return nil, nil, nil, err
+			return nil, nil, nil, nil, err
 		}
 	} else {
 		scriptBuilder := txscript.NewScriptBuilder()
 		pksSubsidy, err = scriptBuilder.AddOp(txscript.OP_TRUE).Script()
 		if err != nil {
-			return nil, nil, nil, err
+			return nil, nil, nil, nil, err
 		}
 	}
+	var stake uint64
+	if stakeActive {
+		stake = blockchain.CalcBlockStakeSubsidy(subsidyCache,
+			bi, params)
+	}
 	if !params.HasTax() {
 		subsidy += uint64(tax)
 		tax = 0
@@ -149,6 +157,15 @@ func createCoinbaseTx(subsidyCache *blockchain.SubsidyCache, coinbaseScript []by
 		PkScript: pksSubsidy,
 	})
 
+	// Stake reserve output.
+	var stakeOutput *types.TxOutput
+	if stake > 0 {
+		stakeOutput = &types.TxOutput{
+			Amount:   types.Amount{Value: int64(stake), Id: types.MEERID},
+			PkScript: params.StakeReservePkScript,
+		}
+	}
+
 	// Tax output.
 	var taxOutput *types.TxOutput
 	if params.HasTax() {
@@ -168,7 +185,7 @@ func createCoinbaseTx(subsidyCache *blockchain.SubsidyCache, coinbaseScript []by
 		opReturnOutput = opreturn.GetOPReturnTxOutput(opreturn.NewShowAmount(int64(subsidy)))
 	}
 
-	return types.NewTx(tx), taxOutput, opReturnOutput, nil
+	return types.NewTx(tx), stakeOutput, taxOutput, opReturnOutput, nil
 }
 
 func fillWitnessToCoinBase(blockTxns []*types.Tx) error {
@@ -181,7 +198,7 @@ func fillWitnessToCoinBase(blockTxns []*types.Tx) error {
 	return nil
 }
 
-func fillOutputsToCoinBase(coinbaseTx *types.Tx, blockFeesMap types.AmountMap, taxOutput *types.TxOutput, oprOutput *types.TxOutput) error {
+func fillOutputsToCoinBase(coinbaseTx *types.Tx, blockFeesMap types.AmountMap, stakeOutput *types.TxOutput, taxOutput *types.TxOutput, oprOutput *types.TxOutput) error {
 	if len(coinbaseTx.Tx.TxOut) != blockchain.CoinbaseOutput_subsidy+1 {
 		return fmt.Errorf("coinbase output error")
 	}
@@ -194,6 +211,9 @@ func fillOutputsToCoinBase(coinbaseTx *types.Tx, blockFeesMap types.AmountMap, t
 			PkScript: coinbaseTx.Tx.TxOut[0].GetPkScript(),
 		})
 	}
+	if stakeOutput != nil {
+		coinbaseTx.Tx.AddTxOut(stakeOutput)
+	}
 	if taxOutput != nil {
 		coinbaseTx.Tx.AddTxOut(taxOutput)
 	}
@@ -122,13 +122,18 @@ func NewBlockTemplate(policy *Policy, params *params.Params,
 		return nil, err
 	}
 
+	stakeActive, err := blockManager.GetChain().IsStakeActive(mainp)
+	if err != nil {
+		return nil, err
+	}
 	blues := int64(bd.GetBluesByBlock(mainp))
-	coinbaseTx, taxOutput, oprOutput, err := createCoinbaseTx(subsidyCache,
+	coinbaseTx, stakeOutput, taxOutput, oprOutput, err := createCoinbaseTx(subsidyCache,
 		coinbaseScript,
 		bd.GetBlueInfo(mainp),
 		payToAddress,
 		params,
-		nil)
+		nil,
+		stakeActive)
 	if err != nil {
 		return nil, err
 	}
@@ -325,8 +330,8 @@ mempool:
 		}
 
 		// Skip free transactions once the block is larger than the
-		// minimum block size.
-		if sortedByFee &&
+		// minimum block size, except the stakebase which never pays fee.
+		if sortedByFee && !types.IsStakebaseTx(tx.Tx) &&
 			weirandItem.feePerKB < int64(policy.TxMinFreeFee) &&
 			(blockPlusTxSize >= policy.BlockMinSize) {
 			log.Trace(fmt.Sprintf("Skipping tx %s with feePerKB %.2d "+
@@ -347,6 +352,15 @@ mempool:
 			logSkippedDeps(tx, deps)
 			continue
 		}
+		if stakeActive {
+			err = blockManager.GetChain().CheckStakeTransaction(tx, blockUtxos)
+			if err != nil {
+				log.Trace(fmt.Sprintf("Skipping tx %s due to error in "+
+					"CheckStakeTransaction: %v", tx.Hash(), err))
+				logSkippedDeps(tx, deps)
+				continue
+			}
+		}
 		err = blockchain.ValidateTransactionScripts(tx, blockUtxos,
 			scriptFlags, sigCache)
 		if err != nil {
@@ -400,7 +414,7 @@ mempool:
 		}
 	}
 	// Fill outputs
-	err = fillOutputsToCoinBase(coinbaseTx, blockFeesMap, taxOutput, oprOutput)
+	err = fillOutputsToCoinBase(coinbaseTx, blockFeesMap, stakeOutput, taxOutput, oprOutput)
 	if err != nil {
 		return nil, miningRuleError(ErrCreatingCoinbase, err.Error())
 	}
@@ -432,13 +446,17 @@ mempool:
 		parents = blockManager.GetChain().GetMiningTips(len(blockTxns))
 	}
 
+	stateRoot, err := blockManager.GetChain().CalculateStateRoot(blockTxns, parents)
+	if err != nil {
+		return nil, err
+	}
 	paMerkles := merkle.BuildParentsMerkleTreeStore(parents)
 	var block types.Block
 	block.Header = types.BlockHeader{
 		Version:    blockVersion,
 		ParentRoot: *paMerkles[len(paMerkles)-1],
 		TxRoot:     *merkles[len(merkles)-1],
-		StateRoot:  blockManager.GetChain().CalculateTokenStateRoot(blockTxns, parents),
+		StateRoot:  stateRoot,
 		Timestamp:  ts,
 		Difficulty: reqCompactDifficulty,
 		Pow:        pow.GetInstance(powType, 0, []byte{}),
@@ -9,6 +9,7 @@ import (
 	"github.com/Qitmeer/qitmeer/common/marshal"
 	"github.com/Qitmeer/qitmeer/common/math"
 	"github.com/Qitmeer/qitmeer/core/address"
+	"github.com/Qitmeer/qitmeer/core/blockchain/stake"
 	"github.com/Qitmeer/qitmeer/core/blockchain/token"
 	"github.com/Qitmeer/qitmeer/core/dbnamespace"
 	"github.com/Qitmeer/qitmeer/core/json"
@@ -20,7 +21,9 @@ import (
 	"github.com/Qitmeer/qitmeer/params"
 	"github.com/Qitmeer/qitmeer/rpc"
 	"github.com/Qitmeer/qitmeer/rpc/client/cmds"
+	"github.com/Qitmeer/qitmeer/services/index"
 	"github.com/Qitmeer/qitmeer/services/mempool"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -86,6 +89,20 @@ func (api *PublicTxAPI) CreateRawTransactionV2(inputs []json.TransactionInput,
 		mtx.AddTxIn(txIn)
 	}
 
+	// The locked genesis outputs can only be spent by genesis lock, which is
+	// tagged by the sequence of the first input.
+	if len(mtx.TxIn) > 0 {
+		entry, err := api.txManager.bm.GetChain().FetchUtxoEntry(mtx.TxIn[0].PreviousOut)
+		if err == nil && entry != nil {
+			if _, ok := txscript.ExtractGenesisLockHeight(entry.PkScript()); ok {
+				if lockTime == nil || *lockTime == 0 {
+					return nil, rpc.RpcInvalidError("The locked genesis output needs the lock time")
+				}
+				mtx.TxIn[0].Sequence = types.TaggedSequence(types.TxTypeGenesisLock)
+			}
+		}
+	}
+
 	// Add all transaction outputs to the transaction after performing
 	// some validity checks.
 	for encodedAddr, amount := range amounts {
@@ -476,6 +493,101 @@ func (api *PublicTxAPI) GetUtxo(txHash hash.Hash, vout uint32, includeMempool *b
 	return txOutReply, nil
 }
 
+// Return the probability that the block of transaction is reversed by an attacker.
+// 1. txid              (string, required)   The hash of the transaction
+// 2. attackerHashShare (float, required)    The attacker's relative computational power, (0,0.5)
+// 3. delay             (float, required)    The upper bound of network delay in seconds
+func (api *PublicTxAPI) GetTxConfirmationRisk(txHash hash.Hash, attackerHashShare float64, delay float64) (interface{}, error) {
+	result := &json.TxConfirmationRiskResult{TxId: txHash.String(), Risk: 1}
+	tx, _ := api.txManager.txMemPool.FetchTransaction(&txHash)
+	if tx != nil {
+		return result, nil
+	}
+	txIndex := api.txManager.txIndex
+	if txIndex == nil {
+		return nil, fmt.Errorf("the transaction index " +
+			"must be enabled to query the blockchain (specify --txindex in configuration)")
+	}
+	blockRegion, err := txIndex.TxBlockRegion(txHash)
+	if err != nil {
+		return nil, errors.New("Failed to retrieve transaction location")
+	}
+	if blockRegion == nil {
+		return nil, rpc.RpcNoTxInfoError(&txHash)
+	}
+	bd := api.txManager.bm.GetChain().BlockDAG()
+	ib := bd.GetBlock(blockRegion.Hash)
+	if ib == nil {
+		return nil, rpc.RpcNoTxInfoError(&txHash)
+	}
+	risk, antiPast, err := bd.GetConfirmationRisk(ib.GetID(), attackerHashShare, delay)
+	if err != nil {
+		return nil, rpc.RpcInvalidError(err.Error())
+	}
+	result.BlockHash = blockRegion.Hash.String()
+	result.Confirmations = uint64(bd.GetConfirmations(ib.GetID()))
+	result.AntiPast = antiPast
+	result.Risk = risk
+	return result, nil
+}
+
+// GetTxInclusions returns every block which includes the transaction in order,
+// only one of them is effective and the reason is given for the others.
+func (api *PublicTxAPI) GetTxInclusions(txHash hash.Hash) (interface{}, error) {
+	txIndex := api.txManager.txIndex
+	if txIndex == nil {
+		return nil, fmt.Errorf("the transaction index " +
+			"must be enabled to query the blockchain (specify --txindex in configuration)")
+	}
+	txInclusionIndex := api.txManager.txInclusionIndex
+	if txInclusionIndex == nil {
+		return nil, fmt.Errorf("Transaction inclusion index must be enabled (--txinclusionindex)")
+	}
+	blockRegion, err := txIndex.TxBlockRegion(txHash)
+	if err != nil {
+		return nil, errors.New("Failed to retrieve transaction location")
+	}
+	voids, err := txInclusionIndex.TxVoidInclusions(&txHash)
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(), "Failed to retrieve transaction inclusions")
+	}
+	if blockRegion == nil && len(voids) == 0 {
+		return nil, rpc.RpcNoTxInfoError(&txHash)
+	}
+
+	bd := api.txManager.bm.GetChain().BlockDAG()
+	result := &json.TxInclusionsResult{TxId: txHash.String(), Inclusions: []json.TxInclusionResult{}}
+	addInclusion := func(blockHash *hash.Hash, reason string) {
+		ib := bd.GetBlock(blockHash)
+		if ib == nil {
+			return
+		}
+		result.Inclusions = append(result.Inclusions, json.TxInclusionResult{
+			BlockHash:     blockHash.String(),
+			Order:         uint64(ib.GetOrder()),
+			Height:        uint64(ib.GetHeight()),
+			Confirmations: uint64(bd.GetConfirmations(ib.GetID())),
+			Effective:     len(reason) == 0,
+			Reason:        reason,
+		})
+	}
+	if blockRegion != nil {
+		addInclusion(blockRegion.Hash, "")
+	}
+	for blockHash, reason := range voids {
+		h := blockHash
+		str := reason.String()
+		if reason == index.TxVoidDuplicate && blockRegion != nil {
+			str = fmt.Sprintf("duplicate of the effective inclusion in block %s", blockRegion.Hash)
+		}
+		addInclusion(&h, str)
+	}
+	sort.Slice(result.Inclusions, func(i, j int) bool {
+		return result.Inclusions[i].Order < result.Inclusions[j].Order
+	})
+	return result, nil
+}
+
 // handleSearchRawTransactions implements the searchrawtransactions command.
 func (api *PublicTxAPI) GetRawTransactions(addre string, vinext *bool, count *uint, skip *uint, revers *bool, verbose *bool, filterAddrs *[]string) (interface{}, error) {
 	addrIndex := api.txManager.addrIndex
@@ -961,9 +1073,18 @@ func (api *PrivateTxAPI) TxSign(privkeyStr string, rawTxStr string, tokenPrivkey
 	var kdb txscript.KeyClosure = func(types.Address) (ecc.PrivateKey, bool, error) {
 		return privateKey, true, nil // compressed is true
 	}
+	// The token renew is signed by the token admin before DeploymentTokenV2,
+	// and by the current token owners after it.
+	isOwnersRenew := false
+	if types.IsTokenRenewTx(&redeemTx) {
+		isOwnersRenew, err = api.txManager.bm.GetChain().IsTokenV2Active(nil)
+		if err != nil {
+			return nil, err
+		}
+	}
 	//
 	if types.IsTokenNewTx(&redeemTx) ||
-		types.IsTokenRenewTx(&redeemTx) ||
+		(types.IsTokenRenewTx(&redeemTx) && !isOwnersRenew) ||
 		types.IsTokenValidateTx(&redeemTx) ||
 		types.IsTokenInvalidateTx(&redeemTx) {
 		if len(param.TokenAdminPkScript) <= 0 {
@@ -982,8 +1103,22 @@ func (api *PrivateTxAPI) TxSign(privkeyStr string, rawTxStr string, tokenPrivkey
 		}
 		var tokenPkScript []byte
 		var tokenPrivkey ecc.PrivateKey
-		if types.IsTokenMintTx(&redeemTx) {
-			tokenPkScript, err = api.txManager.bm.GetChain().GetCurTokenOwners(redeemTx.TxOut[0].Amount.Id)
+		var tokenAddr types.Address
+		// The token mint, revoke and the renew after DeploymentTokenV2 are
+		// signed by the current token owners. The owners of M-of-N
+		// multi-signature sign one by one, the signature of the token
+		// private key is merged into the previous signatures of the first
+		// input.
+		if types.IsTokenMintTx(&redeemTx) || types.IsTokenRevokeTx(&redeemTx) || isOwnersRenew {
+			coinId := redeemTx.TxOut[0].Amount.Id
+			if types.IsTokenRevokeTx(&redeemTx) || isOwnersRenew {
+				update, err := token.NewTypeUpdateFromTx(&redeemTx)
+				if err != nil {
+					return nil, err
+				}
+				coinId = update.Tt.Id
+			}
+			tokenPkScript, err = api.txManager.bm.GetChain().GetCurTokenOwners(coinId)
 			if err != nil {
 				return nil, err
 			}
@@ -997,14 +1132,40 @@ func (api *PrivateTxAPI) TxSign(privkeyStr string, rawTxStr string, tokenPrivkey
 			if len(tprivkeyByte) != 32 {
 				return nil, fmt.Errorf("error:%d", len(tprivkeyByte))
 			}
-			tokenPrivkey, _ = ecc.Secp256k1.PrivKeyFromBytes(tprivkeyByte)
+			var tokenPubKey ecc.PublicKey
+			tokenPrivkey, tokenPubKey = ecc.Secp256k1.PrivKeyFromBytes(tprivkeyByte)
+			tokenAddr, err = address.NewPubKeyHashAddress(hash.Hash160(tokenPubKey.SerializeCompressed()), param, ecc.ECDSA_Secp256k1)
+			if err != nil {
+				return nil, err
+			}
+			_, owners, _, err := txscript.ExtractPkScriptAddrs(tokenPkScript, param)
+			if err != nil {
+				return nil, err
+			}
+			isOwner := false
+			for _, owner := range owners {
+				if owner.Encode() == tokenAddr.Encode() {
+					isOwner = true
+					break
+				}
+			}
+			if !isOwner {
+				return nil, fmt.Errorf("The token private key is not one of the token owners.")
+			}
 		}
 		for i := 0; i < len(redeemTx.TxIn); i++ {
+			// The stake reserve output of stakebase needs no signature.
+			if i == 1 && types.IsStakebaseTx(&redeemTx) {
+				continue
+			}
 			if i == 0 && len(tokenPkScript) > 0 {
-				var tkdb txscript.KeyClosure = func(types.Address) (ecc.PrivateKey, bool, error) {
+				var tkdb txscript.KeyClosure = func(addr types.Address) (ecc.PrivateKey, bool, error) {
+					if addr.Encode() != tokenAddr.Encode() {
+						return nil, false, fmt.Errorf("No private key of %s", addr.Encode())
+					}
 					return tokenPrivkey, true, nil // compressed is true
 				}
-				sigScript, err := txscript.SignTxOutput(param, &redeemTx, 0, tokenPkScript, txscript.SigHashAll, tkdb, nil, nil, ecc.ECDSA_Secp256k1)
+				sigScript, err := txscript.SignTxOutput(param, &redeemTx, 0, tokenPkScript, txscript.SigHashAll, tkdb, nil, redeemTx.TxIn[0].SignScript, ecc.ECDSA_Secp256k1)
 				if err != nil {
 					return nil, err
 				}
@@ -1053,8 +1214,11 @@ func (api *PrivateTxAPI) TxSign(privkeyStr string, rawTxStr string, tokenPrivkey
 			}
 
 			pks := pkScript
-			if redeemTx.LockTime != 0 {
-				pks = prevTx.TxOut[redeemTx.TxIn[i].PreviousOut.OutIndex].PkScript
+			prevPkScript := prevTx.TxOut[redeemTx.TxIn[i].PreviousOut.OutIndex].PkScript
+			// The tickets and the outputs of stakebase are tagged by
+			// the stake opcodes.
+			if redeemTx.LockTime != 0 || txscript.IsStakeOutput(prevPkScript) {
+				pks = prevPkScript
 			}
 			sigScript, err := txscript.SignTxOutput(param, &redeemTx, i, pks, txscript.SigHashAll, kdb, nil, nil, ecc.ECDSA_Secp256k1)
 			if err != nil {
@@ -1073,7 +1237,11 @@ func (api *PrivateTxAPI) TxSign(privkeyStr string, rawTxStr string, tokenPrivkey
 
 // token
 
-func (api *PublicTxAPI) CreateTokenRawTransaction(txtype string, coinId uint16, coinName *string, owners *string, uplimit *uint64, inputs []json.TransactionInput, amounts json.Amounts, feeType uint16, feeValue int64) (interface{}, error) {
+// CreateTokenRawTransaction creates the raw transaction of token. The owners of
+// token new and renew is an address, or the M-of-N multi-signature owners in
+// the form "M:<pubkey>,<pubkey>,..." which require M of the owners to sign
+// the token mint, renew and revoke.
+func (api *PublicTxAPI) CreateTokenRawTransaction(txtype string, coinId uint16, coinName *string, owners *string, uplimit *uint64, inputs []json.TransactionInput, amounts json.Amounts, feeType uint16, feeValue int64, metadata *json.TokenMetadata) (interface{}, error) {
 	txt := types.TxTypeTokenRegulation
 	if !strings.HasPrefix(txtype, "0x") {
 		switch txtype {
@@ -1087,6 +1255,8 @@ func (api *PublicTxAPI) CreateTokenRawTransaction(txtype string, coinId uint16,
 			txt = types.TxTypeTokenInvalidate
 		case "mint":
 			txt = types.TxTypeTokenMint
+		case "revoke":
+			txt = types.TxTypeTokenRevoke
 		default:
 			return nil, fmt.Errorf("No support %s\n", txtype)
 		}
@@ -1201,48 +1371,255 @@ func (api *PublicTxAPI) CreateTokenRawTransaction(txtype string, coinId uint16,
 			if owners == nil {
 				return nil, fmt.Errorf("No owners address\n")
 			}
-			addr, err := address.DecodeAddress(*owners)
-			if err != nil {
-				return nil, rpc.RpcAddressKeyError("Could not decode address: %v", err)
-			}
-			if !address.IsForNetwork(addr, params.ActiveNetParams.Params) {
-				return nil, rpc.RpcAddressKeyError("Wrong network: %v", addr)
-			}
-
 			if coinName == nil {
 				return nil, fmt.Errorf("No coin name\n")
 			}
 			fcfg := &token.TokenFeeConfig{Type: types.FeeType(feeType), Value: feeValue}
-			pkScript, err := txscript.PayToTokenPubKeyHashScript(addr.Script(), types.CoinID(coinId), upLi, *coinName, fcfg.GetData())
+			pkScript, err := tokenOwnersScript(*owners, types.CoinID(coinId), upLi, *coinName, fcfg.GetData())
 			if err != nil {
 				return nil, err
 			}
 			mtx.AddTxOut(&types.TxOutput{PkScript: pkScript})
+
+			// The optional metadata is carried by the second output.
+			if metadata != nil {
+				tm := &token.TokenMetadata{
+					Decimals:    metadata.Decimals,
+					LongName:    metadata.LongName,
+					Description: metadata.Description,
+					Issuer:      metadata.Issuer,
+				}
+				err = tm.CheckSanity()
+				if err != nil {
+					return nil, rpc.RpcInvalidError(err.Error())
+				}
+				metadataScript, err := tm.Script()
+				if err != nil {
+					return nil, err
+				}
+				mtx.AddTxOut(&types.TxOutput{PkScript: metadataScript})
+			}
 		} else {
 			state := api.txManager.bm.GetChain().GetCurTokenState()
 			if state == nil {
 				return nil, fmt.Errorf("Token state error\n")
 			}
-			tt, ok := state.Types[types.CoinID(coinId)]
-			if !ok {
-				return nil, fmt.Errorf("It doesn't exist: Coin id (%d)\n", coinId)
+			err := addTokenUpdateTxOuts(mtx, txt, types.CoinID(coinId), state, owners)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	mtxHex, err := marshal.MessageToHex(mtx)
+	if err != nil {
+		return nil, err
+	}
+	return mtxHex, nil
+}
+
+// addTokenUpdateTxOuts adds the outputs of token validate, invalidate and
+// revoke for the token of coinId in state. The revoke releases the locked MEER
+// to the owners address, or to the token owners by default.
+func addTokenUpdateTxOuts(mtx *types.Transaction, txt types.TxType, coinId types.CoinID, state *token.TokenState, owners *string) error {
+	tt, ok := state.Types[coinId]
+	if !ok {
+		return fmt.Errorf("It doesn't exist: Coin id (%d)\n", coinId)
+	}
+	if tt.Enable && txt == types.TxTypeTokenValidate {
+		return fmt.Errorf("Validate is allowed only when disable: Coin id (%d)\n", coinId)
+	}
+	if !tt.Enable && txt == types.TxTypeTokenInvalidate {
+		return fmt.Errorf("Invalidate is allowed only when enable: Coin id (%d)\n", coinId)
+	}
+	if tt.Revoked {
+		return fmt.Errorf("It was revoked: Coin id (%d)\n", coinId)
+	}
+	pkScript, err := txscript.PayToTokenOwnersScript(tt.Owners, coinId, 0, "", 0)
+	if err != nil {
+		return fmt.Errorf("Token owners is error: %v\n", err)
+	}
+	mtx.AddTxOut(&types.TxOutput{PkScript: pkScript})
+
+	if txt == types.TxTypeTokenRevoke {
+		tb := state.Balances[coinId]
+		if tb.Balance != 0 {
+			return fmt.Errorf("Revoke is allowed only when no token amount: Coin id (%d) balance (%d)\n", coinId, tb.Balance)
+		}
+		// The locked MEER is released to the owners address,
+		// or to the token owners by default.
+		if tb.LockedMeer > 0 {
+			releaseAddr := tt.GetAddress()
+			if owners != nil {
+				releaseAddr, err = address.DecodeAddress(*owners)
+				if err != nil {
+					return rpc.RpcAddressKeyError("Could not decode address: %v", err)
+				}
+				if !address.IsForNetwork(releaseAddr, params.ActiveNetParams.Params) {
+					return rpc.RpcAddressKeyError("Wrong network: %v", releaseAddr)
+				}
 			}
-			if tt.Enable && txt == types.TxTypeTokenValidate {
-				return nil, fmt.Errorf("Validate is allowed only when disable: Coin id (%d)\n", coinId)
+			if releaseAddr == nil {
+				return fmt.Errorf("The owners address must be provided for the multi-signature token owners\n")
 			}
-			if !tt.Enable && txt == types.TxTypeTokenInvalidate {
-				return nil, fmt.Errorf("Invalidate is allowed only when enable: Coin id (%d)\n", coinId)
+			releaseScript, err := txscript.PayToAddrScript(releaseAddr)
+			if err != nil {
+				return rpc.RpcInternalError(err.Error(), "Pay to address script")
 			}
-			addr := tt.GetAddress()
-			if addr == nil {
-				return nil, fmt.Errorf("Token owners is error\n")
+			mtx.AddTxOut(types.NewTxOutput(types.Amount{Value: tb.LockedMeer, Id: types.MEERID}, releaseScript))
+		}
+	}
+	return nil
+}
+
+// tokenOwnersScript returns the token script of owners, which is an address or
+// the M-of-N multi-signature in the form "M:<pubkey>,<pubkey>,...".
+func tokenOwnersScript(owners string, coinId types.CoinID, upLimit uint64, name string, feeCfg int64) ([]byte, error) {
+	param := params.ActiveNetParams.Params
+	sep := strings.Index(owners, ":")
+	if sep < 0 {
+		addr, err := address.DecodeAddress(owners)
+		if err != nil {
+			return nil, rpc.RpcAddressKeyError("Could not decode address: %v", err)
+		}
+		if !address.IsForNetwork(addr, param) {
+			return nil, rpc.RpcAddressKeyError("Wrong network: %v", addr)
+		}
+		return txscript.PayToTokenPubKeyHashScript(addr.Script(), coinId, upLimit, name, feeCfg)
+	}
+
+	nrequired, err := strconv.Atoi(owners[:sep])
+	if err != nil {
+		return nil, rpc.RpcInvalidError("Invalid number of required signatures: %v", err)
+	}
+	pubKeyStrs := strings.Split(owners[sep+1:], ",")
+	if nrequired < 1 || nrequired > len(pubKeyStrs) {
+		return nil, rpc.RpcInvalidError("Invalid number of required signatures: %d of %d", nrequired, len(pubKeyStrs))
+	}
+	pubKeys := make([]*address.SecpPubKeyAddress, 0, len(pubKeyStrs))
+	for _, pubKeyStr := range pubKeyStrs {
+		pubKeyBytes, err := hex.DecodeString(pubKeyStr)
+		if err != nil {
+			return nil, rpc.RpcDecodeHexError(pubKeyStr)
+		}
+		pubKey, err := address.NewSecpPubKeyAddress(pubKeyBytes, param)
+		if err != nil {
+			return nil, rpc.RpcAddressKeyError("Invalid public key %s: %v", pubKeyStr, err)
+		}
+		pubKeys = append(pubKeys, pubKey)
+	}
+	if len(pubKeys) > token.MaxTokenOwners {
+		return nil, rpc.RpcInvalidError("Too many token owners: %d (max:%d)", len(pubKeys), token.MaxTokenOwners)
+	}
+	return txscript.PayToTokenMultiSigScript(pubKeys, nrequired, coinId, upLimit, name, feeCfg)
+}
+
+// CreateStakeRawTransaction creates the raw transaction of the stake type which
+// is "purchase", "stakebase" or "dispose", the first input is tagged by the
+// type. The stake purchase locks amount into the ticket of the stake holder
+// address and the changes are paid to the other outputs. The stakebase spends
+// the ticket and the stake reserve output, and the stake dispose spends the
+// ticket, both of them pay amount to the stake holder of the ticket. The amount
+// of stakebase must be the value of ticket and its reward.
+func (api *PublicTxAPI) CreateStakeRawTransaction(txtype string, inputs []json.TransactionInput, amount int64, addr *string, changes *json.Amounts) (interface{}, error) {
+	var txt types.TxType
+	switch txtype {
+	case "purchase":
+		txt = types.TxTypeStakePurchase
+	case "stakebase":
+		txt = types.TxTypeStakebase
+	case "dispose":
+		txt = types.TxTypeStakeDispose
+	default:
+		return nil, fmt.Errorf("No support %s\n", txtype)
+	}
+	if len(inputs) <= 0 {
+		return nil, fmt.Errorf("Tx inputs cannot be empty\n")
+	}
+	if amount <= 0 || amount > types.MaxAmount {
+		return nil, rpc.RpcInvalidError("Invalid amount: 0 >= %v "+
+			"> %v", amount, types.MaxAmount)
+	}
+
+	mtx := types.NewTransaction()
+	for _, input := range inputs {
+		txid, err := hash.NewHashFromStr(input.Txid)
+		if err != nil {
+			return nil, rpc.RpcDecodeHexError(input.Txid)
+		}
+		mtx.AddTxIn(types.NewTxInput(types.NewOutPoint(txid, input.Vout), []byte{}))
+	}
+	mtx.TxIn[0].Sequence = types.TaggedSequence(txt)
+
+	if txt == types.TxTypeStakePurchase {
+		if addr == nil {
+			return nil, fmt.Errorf("No stake holder address\n")
+		}
+		holder, err := address.DecodeAddress(*addr)
+		if err != nil {
+			return nil, rpc.RpcAddressKeyError("Could not decode address: %v", err)
+		}
+		if !address.IsForNetwork(holder, params.ActiveNetParams.Params) {
+			return nil, rpc.RpcAddressKeyError("Wrong network: %v", holder)
+		}
+		pkScript, err := txscript.PayToSStx(holder)
+		if err != nil {
+			return nil, rpc.RpcInternalError(err.Error(), "Pay to stake submission script")
+		}
+		mtx.AddTxOut(types.NewTxOutput(types.Amount{Value: amount, Id: types.MEERID}, pkScript))
+		if changes != nil {
+			for encodedAddr, change := range *changes {
+				if change <= 0 || change > types.MaxAmount {
+					return nil, rpc.RpcInvalidError("Invalid amount: 0 >= %v "+
+						"> %v", change, types.MaxAmount)
+				}
+				changeAddr, err := address.DecodeAddress(encodedAddr)
+				if err != nil {
+					return nil, rpc.RpcAddressKeyError("Could not decode address: %v", err)
+				}
+				if !address.IsForNetwork(changeAddr, params.ActiveNetParams.Params) {
+					return nil, rpc.RpcAddressKeyError("Wrong network: %v", changeAddr)
+				}
+				changeScript, err := txscript.PayToAddrScript(changeAddr)
+				if err != nil {
+					return nil, rpc.RpcInternalError(err.Error(), "Pay to address script")
+				}
+				mtx.AddTxOut(types.NewTxOutput(types.Amount{Value: int64(change), Id: types.MEERID}, changeScript))
+			}
+		}
+	} else {
+		entry, err := api.txManager.bm.GetChain().FetchUtxoEntry(mtx.TxIn[0].PreviousOut)
+		if err != nil {
+			return nil, rpc.RpcNoTxInfoError(&mtx.TxIn[0].PreviousOut.Hash)
+		}
+		if entry == nil || entry.IsSpent() {
+			return nil, fmt.Errorf("Input(%s %d) is invalid\n", mtx.TxIn[0].PreviousOut.Hash, mtx.TxIn[0].PreviousOut.OutIndex)
+		}
+		owner := stake.TicketOwnerScript(entry.PkScript())
+		if owner == nil {
+			return nil, fmt.Errorf("Input(%s %d) is not a ticket\n", mtx.TxIn[0].PreviousOut.Hash, mtx.TxIn[0].PreviousOut.OutIndex)
+		}
+		pkScript := owner
+		if txt == types.TxTypeStakebase {
+			pkScript = append([]byte{txscript.OP_SSGEN}, owner...)
+			if len(mtx.TxIn) != 2 {
+				return nil, fmt.Errorf("The stakebase spends a ticket and a stake reserve output\n")
 			}
-			pkScript, err := txscript.PayToTokenPubKeyHashScript(addr.Script(), types.CoinID(coinId), 0, "", 0)
+			reserve, err := api.txManager.bm.GetChain().FetchUtxoEntry(mtx.TxIn[1].PreviousOut)
 			if err != nil {
-				return nil, err
+				return nil, rpc.RpcNoTxInfoError(&mtx.TxIn[1].PreviousOut.Hash)
+			}
+			if reserve == nil || reserve.IsSpent() {
+				return nil, fmt.Errorf("Input(%s %d) is invalid\n", mtx.TxIn[1].PreviousOut.Hash, mtx.TxIn[1].PreviousOut.OutIndex)
+			}
+			// The stakebase pays exactly the ticket and its reward.
+			reward := stake.TicketReward(types.NewTxOutput(reserve.Amount(), reserve.PkScript()))
+			if amount != entry.Amount().Value+reward {
+				return nil, rpc.RpcInvalidError("The stakebase must pay %d, but the amount is %d",
+					entry.Amount().Value+reward, amount)
 			}
-			mtx.AddTxOut(&types.TxOutput{PkScript: pkScript})
 		}
+		mtx.AddTxOut(types.NewTxOutput(types.Amount{Value: amount, Id: types.MEERID}, pkScript))
 	}
 
 	mtxHex, err := marshal.MessageToHex(mtx)
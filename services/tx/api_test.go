@@ -0,0 +1,96 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+package tx
+
+import (
+	"bytes"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/address"
+	"github.com/Qitmeer/qitmeer/core/blockchain/token"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/crypto/ecc"
+	"github.com/Qitmeer/qitmeer/engine/txscript"
+	"github.com/Qitmeer/qitmeer/params"
+	"testing"
+)
+
+func TestAddTokenRevokeTxOuts(t *testing.T) {
+	param := params.ActiveNetParams.Params
+	coinId := types.CoinID(1000)
+	ownersAddr, err := address.NewPubKeyHashAddress(bytes.Repeat([]byte{1}, 20), param, ecc.ECDSA_Secp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	releaseAddr, err := address.NewPubKeyHashAddress(bytes.Repeat([]byte{2}, 20), param, ecc.ECDSA_Secp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ownersScript, err := txscript.PayToTokenPubKeyHashScript(ownersAddr.Script(), coinId, 100, "TEST", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newState := func(balance int64) *token.TokenState {
+		return &token.TokenState{
+			Types: token.TokenTypesMap{
+				coinId: token.TokenType{Id: coinId, Owners: ownersScript, UpLimit: 100, Name: "TEST"},
+			},
+			Balances: token.TokenBalancesMap{
+				coinId: token.TokenBalance{Balance: balance, LockedMeer: 50},
+			},
+		}
+	}
+	newRevokeTx := func() *types.Transaction {
+		mtx := types.NewTransaction()
+		mtx.AddTxIn(&types.TxInput{
+			PreviousOut: *types.NewOutPoint(&hash.ZeroHash, types.TokenPrevOutIndex),
+			Sequence:    uint32(types.TxTypeTokenRevoke),
+		})
+		return mtx
+	}
+	releaseStr := releaseAddr.Encode()
+	tests := []struct {
+		name    string
+		balance int64
+		owners  *string
+		release types.Address
+	}{
+		{"to the token owners", 0, nil, ownersAddr},
+		{"to the owners address", 0, &releaseStr, releaseAddr},
+		{"with token amount", 1, nil, nil},
+	}
+	for _, test := range tests {
+		mtx := newRevokeTx()
+		err := addTokenUpdateTxOuts(mtx, types.TxTypeTokenRevoke, coinId, newState(test.balance), test.owners)
+		if test.release == nil {
+			if err == nil {
+				t.Errorf("%s: the token is revoked", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if !types.IsTokenRevokeTx(mtx) {
+			t.Errorf("%s: it is not a token revoke", test.name)
+			continue
+		}
+		update, err := token.NewTypeUpdateFromTx(mtx)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if update.Tt.Id != coinId {
+			t.Errorf("%s: revoke coin id (%d)", test.name, update.Tt.Id)
+		}
+		releaseScript, err := txscript.PayToAddrScript(test.release)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mtx.TxOut[1].Amount.Value != 50 || mtx.TxOut[1].Amount.Id != types.MEERID ||
+			!bytes.Equal(mtx.TxOut[1].PkScript, releaseScript) {
+			t.Errorf("%s: the release output is %v", test.name, mtx.TxOut[1])
+		}
+	}
+}
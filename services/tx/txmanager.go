@@ -25,6 +25,9 @@ type TxManager struct {
 
 	// addr index
 	addrIndex *index.AddrIndex
+
+	// tx inclusion index
+	txInclusionIndex *index.TxInclusionIndex
 	// mempool hold tx that need to be mined into blocks and relayed to other peers.
 	txMemPool *mempool.TxPool
 
@@ -67,7 +70,7 @@ func (tm *TxManager) MemPool() blkmgr.TxPool {
 }
 
 func NewTxManager(bm *blkmgr.BlockManager, txIndex *index.TxIndex,
-	addrIndex *index.AddrIndex, cfg *config.Config, ntmgr notify.Notify,
+	addrIndex *index.AddrIndex, txInclusionIndex *index.TxInclusionIndex, cfg *config.Config, ntmgr notify.Notify,
 	sigCache *txscript.SigCache, db database.DB, events *event.Feed) (*TxManager, error) {
 	// mem-pool
 	amt, _ := types.NewMeer(uint64(cfg.MinTxFee))
@@ -83,7 +86,7 @@ func NewTxManager(bm *blkmgr.BlockManager, txIndex *index.TxIndex,
 			MaxTxSize:            int64(cfg.BlockMaxSize - types.MaxBlockHeaderPayload),
 			MinRelayTxFee:        *amt,
 			StandardVerifyFlags: func() (txscript.ScriptFlags, error) {
-				return common.StandardScriptVerifyFlags()
+				return common.StandardScriptVerifyFlags(bm.GetChain())
 			},
 		},
 		ChainParams:      bm.ChainParams(),
@@ -106,5 +109,5 @@ func NewTxManager(bm *blkmgr.BlockManager, txIndex *index.TxIndex,
 	}
 	txMemPool := mempool.New(&txC)
 	invalidTx := make(map[hash.Hash]*blockdag.HashSet)
-	return &TxManager{bm, txIndex, addrIndex, txMemPool, ntmgr, db, invalidTx}, nil
+	return &TxManager{bm, txIndex, addrIndex, txInclusionIndex, txMemPool, ntmgr, db, invalidTx}, nil
 }
@@ -32,6 +32,10 @@ func (zp *ZMQBlockHashPublishNotifier) NotifyTransaction(txs []*types.Tx) error
 	return nil
 }
 
+func (zp *ZMQBlockHashPublishNotifier) NotifyReorg(data []byte) error {
+	return nil
+}
+
 func (zp *ZMQBlockHashPublishNotifier) Shutdown() {
 	zp.shutdown()
 }
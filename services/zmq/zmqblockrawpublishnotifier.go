@@ -35,6 +35,10 @@ func (zp *ZMQBlockRawPublishNotifier) NotifyTransaction(txs []*types.Tx) error {
 	return nil
 }
 
+func (zp *ZMQBlockRawPublishNotifier) NotifyReorg(data []byte) error {
+	return nil
+}
+
 func (zp *ZMQBlockRawPublishNotifier) Shutdown() {
 	zp.shutdown()
 }
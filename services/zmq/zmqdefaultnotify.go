@@ -36,6 +36,11 @@ func (zn *ZMQNotification) BlockDisconnected(block *types.SerializedBlock) {
 
 }
 
+// reorganization recorded
+func (zn *ZMQNotification) Reorganization(data []byte) {
+
+}
+
 // Shutdown
 func (zn *ZMQNotification) Shutdown() {
 
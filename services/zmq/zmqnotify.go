@@ -21,7 +21,7 @@ func (zn *ZMQNotification) Init(cfg *config.Config) {
 	zn.cfg = cfg
 
 	zn.publishNotifiers = []IZMQPublishNotifier{}
-	notiTypeArr := []string{BlockHash, BlockRaw, TxHash, TxRaw}
+	notiTypeArr := []string{BlockHash, BlockRaw, TxHash, TxRaw, ReorgRaw}
 	for _, notiType := range notiTypeArr {
 		publishNotifier := NewZMQPublishNotifier(cfg, notiType)
 		if publishNotifier != nil {
@@ -78,6 +78,20 @@ func (zn *ZMQNotification) BlockDisconnected(block *types.SerializedBlock) {
 	}
 }
 
+// reorganization recorded
+func (zn *ZMQNotification) Reorganization(data []byte) {
+	log.Debug("Reorganization recorded")
+	for i := 0; i < len(zn.publishNotifiers); {
+		err := zn.publishNotifiers[i].NotifyReorg(data)
+		if err != nil {
+			zn.publishNotifiers[i].Shutdown()
+			zn.publishNotifiers = append(zn.publishNotifiers[:i], zn.publishNotifiers[i+1:]...)
+		} else {
+			i++
+		}
+	}
+}
+
 // Shutdown
 func (zn *ZMQNotification) Shutdown() {
 	log.Info("ZMQ: Shutdown...")
@@ -23,6 +23,9 @@ type IZMQNotification interface {
 	// block connected
 	BlockDisconnected(block *types.SerializedBlock)
 
+	// reorganization recorded, the data is the record of json
+	Reorganization(data []byte)
+
 	// Shutdown
 	Shutdown()
 }
@@ -14,17 +14,20 @@ const (
 	BlockRaw  = "BlockRaw"
 	TxHash    = "TxHash"
 	TxRaw     = "TxRaw"
+	ReorgRaw  = "ReorgRaw"
 
 	defaultBlockHashEndpoint = "tcp://*:8230"
 	defaultBlockRawEndpoint  = "tcp://*:8231"
 	defaultTxHashEndpoint    = "tcp://*:8232"
 	defaultTxRawEndpoint     = "tcp://*:8233"
+	defaultReorgRawEndpoint  = "tcp://*:8234"
 )
 
 type IZMQPublishNotifier interface {
 	Init(cfg *config.Config) error
 	NotifyBlock(block *types.SerializedBlock) error
 	NotifyTransaction(transaction []*types.Tx) error
+	NotifyReorg(data []byte) error
 	Shutdown()
 }
 
@@ -83,6 +86,8 @@ func NewZMQPublishNotifier(cfg *config.Config, notifierType string) IZMQPublishN
 		zmq = &ZMQTxHashPublishNotifier{&ZMQPublishNotifier{name: notifierType}}
 	case TxRaw:
 		zmq = &ZMQTxRawPublishNotifier{&ZMQPublishNotifier{name: notifierType}}
+	case ReorgRaw:
+		zmq = &ZMQReorgRawPublishNotifier{&ZMQPublishNotifier{name: notifierType}}
 	}
 	if zmq == nil {
 		return nil
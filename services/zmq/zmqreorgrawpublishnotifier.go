@@ -0,0 +1,39 @@
+// +build zmq
+
+package zmq
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/config"
+	"github.com/Qitmeer/qitmeer/core/types"
+)
+
+type ZMQReorgRawPublishNotifier struct {
+	*ZMQPublishNotifier
+}
+
+func (zp *ZMQReorgRawPublishNotifier) Init(cfg *config.Config) error {
+	if len(cfg.Zmqpubreorg) <= 0 {
+		return fmt.Errorf("No config")
+	}
+	if cfg.Zmqpubreorg == "default" || cfg.Zmqpubreorg == "*" {
+		cfg.Zmqpubreorg = defaultReorgRawEndpoint
+	}
+	return zp.initialization(cfg.Zmqpubreorg)
+}
+
+func (zp *ZMQReorgRawPublishNotifier) NotifyBlock(block *types.SerializedBlock) error {
+	return nil
+}
+
+func (zp *ZMQReorgRawPublishNotifier) NotifyTransaction(txs []*types.Tx) error {
+	return nil
+}
+
+func (zp *ZMQReorgRawPublishNotifier) NotifyReorg(data []byte) error {
+	return zp.sendMessage(data, false)
+}
+
+func (zp *ZMQReorgRawPublishNotifier) Shutdown() {
+	zp.shutdown()
+}
@@ -40,6 +40,10 @@ func (zp *ZMQTxHashPublishNotifier) NotifyTransaction(txs []*types.Tx) error {
 	return nil
 }
 
+func (zp *ZMQTxHashPublishNotifier) NotifyReorg(data []byte) error {
+	return nil
+}
+
 func (zp *ZMQTxHashPublishNotifier) Shutdown() {
 	zp.shutdown()
 }
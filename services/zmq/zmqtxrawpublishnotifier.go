@@ -44,6 +44,10 @@ func (zp *ZMQTxRawPublishNotifier) NotifyTransaction(txs []*types.Tx) error {
 	return nil
 }
 
+func (zp *ZMQTxRawPublishNotifier) NotifyReorg(data []byte) error {
+	return nil
+}
+
 func (zp *ZMQTxRawPublishNotifier) Shutdown() {
 	zp.shutdown()
 }